@@ -54,6 +54,34 @@ type EnvironmentSpec struct {
 
 	// RemoteCluster flag indicates if the Environment is deployed in a separate cluster to the Development Environment
 	RemoteCluster bool `json:"remoteCluster,omitempty" protobuf:"bytes,12,opt,name=remoteCluster"`
+
+	// FreezeWindows are the periods during which promotions into the Environment are blocked
+	FreezeWindows []FreezeWindow `json:"freezeWindows,omitempty" protobuf:"bytes,13,rep,name=freezeWindows"`
+}
+
+// FreezeWindow is a period during which promotions into an Environment are blocked, such as the weekend, a holiday
+// code freeze or a recurring blackout. If none of the window, period or schedule is specified the freeze is in place
+// until it is removed.
+type FreezeWindow struct {
+	// the name of the freeze which is shown when a promotion is blocked
+	Name string `json:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
+	// why promotions are blocked
+	Reason string `json:"reason,omitempty" protobuf:"bytes,2,opt,name=reason"`
+	// the recurring window of time of the freeze, such as the whole of Saturday and Sunday
+	Window *TimeWindow `json:"window,omitempty" protobuf:"bytes,3,opt,name=window"`
+	// the start of a one-off freeze as a date such as '2019-12-20' or in RFC 3339 format
+	From string `json:"from,omitempty" protobuf:"bytes,4,opt,name=from"`
+	// the end of a one-off freeze as a date, which is inclusive, or in RFC 3339 format
+	To string `json:"to,omitempty" protobuf:"bytes,5,opt,name=to"`
+	// the cron expression for when a recurring freeze starts, such as '0 18 * * 5', which lasts for the duration
+	Schedule string `json:"schedule,omitempty" protobuf:"bytes,6,opt,name=schedule"`
+	// how long a freeze started by the schedule lasts, such as '62h'
+	Duration string `json:"duration,omitempty" protobuf:"bytes,7,opt,name=duration"`
+	// the IANA name of the time zone of the dates and schedule, such as 'Europe/London'. Defaults to UTC
+	TimeZone string `json:"timeZone,omitempty" protobuf:"bytes,8,opt,name=timeZone"`
+	// the Environments the freeze applies to when it is specified in the TeamSettings. If empty it applies to all
+	// permanent Environments
+	Environments []string `json:"environments,omitempty" protobuf:"bytes,9,opt,name=environments"`
 }
 
 // EnvironmentStatus is the status for an Environment resource
@@ -81,6 +109,9 @@ const (
 	PromotionStrategyTypeAutomatic PromotionStrategyType = "Auto"
 	// PromotionStrategyTypeNever specifies that promotion is disabled for this environment
 	PromotionStrategyTypeNever PromotionStrategyType = "Never"
+	// PromotionStrategyTypeCanary specifies that promotion happens automatically and only completes once the Flagger
+	// canary analysis of the new version succeeds
+	PromotionStrategyTypeCanary PromotionStrategyType = "Canary"
 )
 
 // EnvironmentKindType is the kind of an environment
@@ -166,11 +197,17 @@ func (e EnvironmentKindType) IsPermanent() bool {
 	}
 }
 
+// IsAutomatic returns true if the promotion strategy promotes new versions automatically
+func (p PromotionStrategyType) IsAutomatic() bool {
+	return p == PromotionStrategyTypeAutomatic || p == PromotionStrategyTypeCanary
+}
+
 // PromotionStrategyTypeValues is the list of all values
 var PromotionStrategyTypeValues = []string{
 	string(PromotionStrategyTypeAutomatic),
 	string(PromotionStrategyTypeManual),
 	string(PromotionStrategyTypeNever),
+	string(PromotionStrategyTypeCanary),
 }
 
 // EnvironmentRepositoryType is the repository type
@@ -239,6 +276,31 @@ type TeamSettings struct {
 
 	// BootRequirements is a marshaled string of the jx-requirements.yaml used in the most recent run for this cluster
 	BootRequirements string `json:"bootRequirements,omitempty" protobuf:"bytes,31,opt,name=bootRequirements"`
+
+	// FreezeWindows are the periods during which promotions into the permanent Environments of the team are blocked
+	FreezeWindows []FreezeWindow `json:"freezeWindows,omitempty" protobuf:"bytes,32,rep,name=freezeWindows"`
+
+	// PreviewGC is the policy for garbage collecting the Preview Environments of the team which are no longer used
+	PreviewGC *PreviewGCPolicy `json:"previewGC,omitempty" protobuf:"bytes,33,opt,name=previewGC"`
+}
+
+// PreviewGCPolicy is the policy used by 'jx gc previews' to reap Preview Environments which have not been deployed
+// for a while or which are idle, in addition to those whose Pull Request has been closed
+type PreviewGCPolicy struct {
+	// TTL is how long after it was last deployed a Preview Environment is reaped, such as '72h'
+	TTL string `json:"ttl,omitempty" protobuf:"bytes,1,opt,name=ttl"`
+	// IdleTimeout is how long a Preview Environment can receive no requests via its ingress before it is reaped,
+	// such as '24h'. Requires the MetricsURL
+	IdleTimeout string `json:"idleTimeout,omitempty" protobuf:"bytes,2,opt,name=idleTimeout"`
+	// MetricsURL is the URL of the Prometheus server which scrapes the metrics of the nginx ingress controller
+	MetricsURL string `json:"metricsUrl,omitempty" protobuf:"bytes,3,opt,name=metricsUrl"`
+	// ScaleToZero scales the deployments of a reaped Preview Environment down to zero replicas rather than deleting
+	// it. A Preview Environment whose Pull Request has been closed is always deleted
+	ScaleToZero bool `json:"scaleToZero,omitempty" protobuf:"bytes,4,opt,name=scaleToZero"`
+	// WakeupService is the host name of the 'jx controller wakeup' service, such as
+	// 'jx-preview-wakeup.jx.svc.cluster.local', which the ingresses of a Preview Environment scaled to zero are routed
+	// to so that it is scaled back up on its first request
+	WakeupService string `json:"wakeupService,omitempty" protobuf:"bytes,5,opt,name=wakeupService"`
 }
 
 // StorageLocation
@@ -62,6 +62,7 @@ type PipelineActivitySpec struct {
 	BatchPipelineActivity BatchPipelineActivity  `json:"batchPipelineActivity,omitempty" protobuf:"bytes,25,opt,name=batchPipelineActivity"`
 	Context               string                 `json:"context,omitempty" protobuf:"bytes,26,opt,name=context"`
 	BaseSHA               string                 `json:"baseSHA,omitempty" protobuf:"bytes,27,opt,name=baseSHA"`
+	WorkflowSteps         []WorkflowStepStatus   `json:"workflowSteps,omitempty" protobuf:"bytes,28,opt,name=workflowSteps"`
 }
 
 // BatchPipelineActivity contains information about a batch build, used by both the batch build and its comprising PRs for linking them together
@@ -92,6 +93,8 @@ type CoreActivityStep struct {
 	Status             ActivityStatusType `json:"status,omitempty" protobuf:"bytes,3,opt,name=status"`
 	StartedTimestamp   *metav1.Time       `json:"startedTimestamp,omitempty" protobuf:"bytes,4,opt,name=startedTimestamp"`
 	CompletedTimestamp *metav1.Time       `json:"completedTimestamp,omitempty" protobuf:"bytes,5,opt,name=completedTimestamp"`
+	// Attempts is the number of times the step was run when it has been retried
+	Attempts int32 `json:"attempts,omitempty" protobuf:"varint,6,opt,name=attempts"`
 }
 
 // StageActivityStep represents a stage of zero to more sub steps in a jenkins pipeline
@@ -99,6 +102,9 @@ type StageActivityStep struct {
 	CoreActivityStep `json:",inline"`
 
 	Steps []CoreActivityStep `json:"steps,omitempty" protobuf:"bytes,1,opt,name=steps"`
+
+	// RetryAttempts records the earlier, failed attempts at running the stage when it has been retried
+	RetryAttempts []CoreActivityStep `json:"retryAttempts,omitempty" protobuf:"bytes,2,opt,name=retryAttempts"`
 }
 
 // PreviewActivityStep is the step of creating a preview environment as part of a Pull Request pipeline
@@ -118,6 +124,8 @@ type PromoteActivityStep struct {
 	PullRequest    *PromotePullRequestStep `json:"pullRequest,omitempty" protobuf:"bytes,2,opt,name=pullRequest"`
 	Update         *PromoteUpdateStep      `json:"update,omitempty" protobuf:"bytes,3,opt,name=update"`
 	ApplicationURL string                  `json:"applicationURL,omitempty" protobuf:"bytes,4,opt,name=environment"`
+	Canary         *PromoteCanaryStep      `json:"canary,omitempty" protobuf:"bytes,5,opt,name=canary"`
+	Freeze         *PromoteFreezeStep      `json:"freeze,omitempty" protobuf:"bytes,6,opt,name=freeze"`
 }
 
 // GitStatus the status of a git commit in terms of CI/CD
@@ -142,6 +150,35 @@ type PromoteUpdateStep struct {
 	Statuses []GitStatus `json:"statuses,omitempty" protobuf:"bytes,1,opt,name=statuses"`
 }
 
+// PromoteCanaryStep is the step for waiting for the Flagger canary analysis of the promoted version to complete
+type PromoteCanaryStep struct {
+	CoreActivityStep `json:",inline"`
+
+	Phase        string                    `json:"phase,omitempty" protobuf:"bytes,1,opt,name=phase"`
+	Weight       int                       `json:"weight,omitempty" protobuf:"varint,2,opt,name=weight"`
+	FailedChecks int                       `json:"failedChecks,omitempty" protobuf:"varint,3,opt,name=failedChecks"`
+	Iterations   []CanaryAnalysisIteration `json:"iterations,omitempty" protobuf:"bytes,4,rep,name=iterations"`
+}
+
+// PromoteFreezeStep records a promotion which was blocked by a freeze window of the environment until the window
+// closed, or which overrode the freeze
+type PromoteFreezeStep struct {
+	CoreActivityStep `json:",inline"`
+
+	Window         string `json:"window,omitempty" protobuf:"bytes,1,opt,name=window"`
+	Reason         string `json:"reason,omitempty" protobuf:"bytes,2,opt,name=reason"`
+	OverriddenBy   string `json:"overriddenBy,omitempty" protobuf:"bytes,3,opt,name=overriddenBy"`
+	OverrideReason string `json:"overrideReason,omitempty" protobuf:"bytes,4,opt,name=overrideReason"`
+}
+
+// CanaryAnalysisIteration records the state of the canary at one iteration of the Flagger canary analysis
+type CanaryAnalysisIteration struct {
+	Phase        string      `json:"phase,omitempty" protobuf:"bytes,1,opt,name=phase"`
+	Weight       int         `json:"weight,omitempty" protobuf:"varint,2,opt,name=weight"`
+	FailedChecks int         `json:"failedChecks,omitempty" protobuf:"varint,3,opt,name=failedChecks"`
+	Timestamp    metav1.Time `json:"timestamp,omitempty" protobuf:"bytes,4,opt,name=timestamp"`
+}
+
 // PipelineActivityStatus is the status for an Environment resource
 type PipelineActivityStatus struct {
 	Version string `json:"version,omitempty"  protobuf:"bytes,1,opt,name=version"`
@@ -193,6 +230,8 @@ const (
 	ActivityStatusTypeAborted ActivityStatusType = "Aborted"
 	// ActivityStatusTypeNotExecuted if the workflow was not executed
 	ActivityStatusTypeNotExecuted ActivityStatusType = "NotExecuted"
+	// ActivityStatusTypeSkipped if a stage was skipped because its when conditions were not met
+	ActivityStatusTypeSkipped ActivityStatusType = "Skipped"
 )
 
 type Attachment struct {
@@ -202,7 +241,7 @@ type Attachment struct {
 
 // IsTerminated returns true if this activity has stopped executing
 func (s ActivityStatusType) IsTerminated() bool {
-	return s == ActivityStatusTypeSucceeded || s == ActivityStatusTypeFailed || s == ActivityStatusTypeError || s == ActivityStatusTypeAborted
+	return s == ActivityStatusTypeSucceeded || s == ActivityStatusTypeFailed || s == ActivityStatusTypeError || s == ActivityStatusTypeAborted || s == ActivityStatusTypeSkipped
 }
 
 func (s ActivityStatusType) String() string {
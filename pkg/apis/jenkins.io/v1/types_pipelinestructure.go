@@ -51,6 +51,16 @@ type PipelineStructureStage struct {
 	Previous *string `json:"previous,omitempty" protobuf:"bytes,8,opt,name=previous"`
 	// +optional
 	Next *string `json:"next,omitempty" protobuf:"bytes,9,opt,name=next"`
+	// Skipped is true if the stage is not run because its when conditions were not met
+	// +optional
+	Skipped bool `json:"skipped,omitempty" protobuf:"bytes,10,opt,name=skipped"`
+	// MatrixAxes holds the names of the axes of the matrix this stage was expanded from, if any. The stages for the
+	// cells of the matrix are the parallel stages nested within it.
+	// +optional
+	MatrixAxes []string `json:"matrixAxes,omitempty" protobuf:"bytes,11,rep,name=matrixAxes"`
+	// MatrixValues holds the value of each axis for the cell of a matrix this stage runs, if any.
+	// +optional
+	MatrixValues map[string]string `json:"matrixValues,omitempty" protobuf:"bytes,12,rep,name=matrixValues"`
 }
 
 // GetStage will get the PipelineStructureStage with the given name, if it exists.
@@ -119,7 +129,19 @@ func (ps *PipelineStructure) GetAllStagesWithSteps() []PipelineStructureStage {
 	var stages []PipelineStructureStage
 
 	for _, s := range ps.Stages {
-		if len(s.Stages) == 0 && len(s.Parallel) == 0 {
+		if len(s.Stages) == 0 && len(s.Parallel) == 0 && !s.Skipped {
+			stages = append(stages, s)
+		}
+	}
+	return stages
+}
+
+// GetSkippedStages gets all stages in this pipeline that are not run because their when conditions were not met.
+func (ps *PipelineStructure) GetSkippedStages() []PipelineStructureStage {
+	var stages []PipelineStructureStage
+
+	for _, s := range ps.Stages {
+		if s.Skipped {
 			stages = append(stages, s)
 		}
 	}
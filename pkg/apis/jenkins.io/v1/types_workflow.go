@@ -34,6 +34,10 @@ type WorkflowStep struct {
 	Description   string                `json:"description,omitempty" protobuf:"bytes,2,opt,name=description"`
 	Preconditions WorkflowPreconditions `json:"trigger,omitempty" protobuf:"bytes,3,opt,name=trigger"`
 	Promote       *PromoteWorkflowStep  `json:"promote,omitempty" protobuf:"bytes,4,opt,name=promote"`
+	Approve       *ApproveWorkflowStep  `json:"approve,omitempty" protobuf:"bytes,5,opt,name=approve"`
+	Wait          *WaitWorkflowStep     `json:"wait,omitempty" protobuf:"bytes,6,opt,name=wait"`
+	Verify        *VerifyWorkflowStep   `json:"verify,omitempty" protobuf:"bytes,7,opt,name=verify"`
+	Parallel      *ParallelWorkflowStep `json:"parallel,omitempty" protobuf:"bytes,8,opt,name=parallel"`
 }
 
 // PromoteWorkflowStep is the step of promoting a version of an application to an environment
@@ -41,10 +45,67 @@ type PromoteWorkflowStep struct {
 	Environment string `json:"environment,omitempty" protobuf:"bytes,1,opt,name=environment"`
 }
 
+// ApproveWorkflowStep is the step of waiting for someone to approve the workflow via 'jx approve workflow'
+type ApproveWorkflowStep struct {
+	// the message shown to the approvers
+	Message string `json:"message,omitempty" protobuf:"bytes,1,opt,name=message"`
+	// the users who can approve the step. If empty anyone can approve it
+	Approvers []string `json:"approvers,omitempty" protobuf:"bytes,2,opt,name=approvers"`
+}
+
+// WaitWorkflowStep is the step of waiting for a duration and/or until a time window is open
+type WaitWorkflowStep struct {
+	// how long to wait once the step is triggered, such as '30m'
+	Duration string `json:"duration,omitempty" protobuf:"bytes,1,opt,name=duration"`
+	// the time window in which the step can complete
+	Window *TimeWindow `json:"window,omitempty" protobuf:"bytes,2,opt,name=window"`
+}
+
+// TimeWindow is a window of time which is open on some or all days of the week
+type TimeWindow struct {
+	// the time the window opens in the form 'HH:MM'
+	Start string `json:"start,omitempty" protobuf:"bytes,1,opt,name=start"`
+	// the time the window closes in the form 'HH:MM'. If it is before the start the window is open over midnight
+	End string `json:"end,omitempty" protobuf:"bytes,2,opt,name=end"`
+	// the days of the week the window opens on, such as 'Mon'. If empty the window opens every day
+	Days []string `json:"days,omitempty" protobuf:"bytes,3,opt,name=days"`
+	// the IANA name of the time zone of the window, such as 'Europe/London'. Defaults to UTC
+	TimeZone string `json:"timeZone,omitempty" protobuf:"bytes,4,opt,name=timeZone"`
+}
+
+// VerifyWorkflowStep is the step of verifying the workflow by running a pipeline or a 'jx step verify' check, which
+// must succeed
+type VerifyWorkflowStep struct {
+	// the name of the pipeline to run in the form 'owner/repository/branch'
+	Pipeline string `json:"pipeline,omitempty" protobuf:"bytes,1,opt,name=pipeline"`
+	// the 'jx step verify' check to run and its arguments, such as 'url --endpoint https://myapp.example.com'
+	Check []string `json:"check,omitempty" protobuf:"bytes,2,opt,name=check"`
+	// the environments to roll back to the previous version of the application if the verification fails
+	Rollback []string `json:"rollback,omitempty" protobuf:"bytes,3,opt,name=rollback"`
+}
+
+// ParallelWorkflowStep is the step of promoting a version of an application to several environments at the same time
+type ParallelWorkflowStep struct {
+	Environments []string `json:"environments,omitempty" protobuf:"bytes,1,opt,name=environments"`
+}
+
 // WorkflowPreconditions is the trigger to start a step
 type WorkflowPreconditions struct {
 	// the names of the environments which need to have promoted before this step can be triggered
 	Environments []string `json:"environments,omitempty" protobuf:"bytes,1,opt,name=environments"`
+	// the names of the steps which need to have succeeded before this step can be triggered
+	Steps []string `json:"steps,omitempty" protobuf:"bytes,2,opt,name=steps"`
+}
+
+// WorkflowStepStatus is the status of a step of a Workflow, other than a promotion, for a particular run of a pipeline
+type WorkflowStepStatus struct {
+	Name               string               `json:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
+	Kind               WorkflowStepKindType `json:"kind,omitempty" protobuf:"bytes,2,opt,name=kind"`
+	Status             WorkflowStatusType   `json:"status,omitempty" protobuf:"bytes,3,opt,name=status"`
+	Message            string               `json:"message,omitempty" protobuf:"bytes,4,opt,name=message"`
+	ApprovedBy         string               `json:"approvedBy,omitempty" protobuf:"bytes,5,opt,name=approvedBy"`
+	StartedTimestamp   *metav1.Time         `json:"startedTimestamp,omitempty" protobuf:"bytes,6,opt,name=startedTimestamp"`
+	CompletedTimestamp *metav1.Time         `json:"completedTimestamp,omitempty" protobuf:"bytes,7,opt,name=completedTimestamp"`
 }
 
 // WorkflowStatus is the status for an Environment resource
@@ -70,6 +131,14 @@ const (
 	WorkflowStepKindTypeNone WorkflowStepKindType = ""
 	// WorkflowStepKindTypePromote a promote activity
 	WorkflowStepKindTypePromote WorkflowStepKindType = "Promote"
+	// WorkflowStepKindTypeApprove waits for a manual approval
+	WorkflowStepKindTypeApprove WorkflowStepKindType = "Approve"
+	// WorkflowStepKindTypeWait waits for a duration or time window
+	WorkflowStepKindTypeWait WorkflowStepKindType = "Wait"
+	// WorkflowStepKindTypeVerify runs a pipeline or check which must succeed
+	WorkflowStepKindTypeVerify WorkflowStepKindType = "Verify"
+	// WorkflowStepKindTypeParallel promotes to several environments at the same time
+	WorkflowStepKindTypeParallel WorkflowStepKindType = "Parallel"
 )
 
 // WorkflowStatusType is the status of an activity; usually succeeded or failed/error on completion
@@ -95,3 +164,8 @@ const (
 func (s WorkflowStatusType) String() string {
 	return string(s)
 }
+
+// IsTerminated returns true if this step has finished, successfully or not
+func (s WorkflowStatusType) IsTerminated() bool {
+	return s == WorkflowStatusTypeSucceeded || s == WorkflowStatusTypeFailed || s == WorkflowStatusTypeError
+}
@@ -184,6 +184,27 @@ func (in *Approve) DeepCopy() *Approve {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApproveWorkflowStep) DeepCopyInto(out *ApproveWorkflowStep) {
+	*out = *in
+	if in.Approvers != nil {
+		in, out := &in.Approvers, &out.Approvers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApproveWorkflowStep.
+func (in *ApproveWorkflowStep) DeepCopy() *ApproveWorkflowStep {
+	if in == nil {
+		return nil
+	}
+	out := new(ApproveWorkflowStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Attachment) DeepCopyInto(out *Attachment) {
 	*out = *in
@@ -393,6 +414,23 @@ func (in *BuildPackSpec) DeepCopy() *BuildPackSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryAnalysisIteration) DeepCopyInto(out *CanaryAnalysisIteration) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryAnalysisIteration.
+func (in *CanaryAnalysisIteration) DeepCopy() *CanaryAnalysisIteration {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryAnalysisIteration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChartRef) DeepCopyInto(out *ChartRef) {
 	*out = *in
@@ -1010,6 +1048,13 @@ func (in *EnvironmentSpec) DeepCopyInto(out *EnvironmentSpec) {
 	out.Source = in.Source
 	in.TeamSettings.DeepCopyInto(&out.TeamSettings)
 	out.PreviewGitSpec = in.PreviewGitSpec
+	if in.FreezeWindows != nil {
+		in, out := &in.FreezeWindows, &out.FreezeWindows
+		*out = make([]FreezeWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -1568,6 +1613,36 @@ func (in *FactStatus) DeepCopy() *FactStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FreezeWindow) DeepCopyInto(out *FreezeWindow) {
+	*out = *in
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(TimeWindow)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Environments != nil {
+		in, out := &in.Environments, &out.Environments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FreezeWindow.
+func (in *FreezeWindow) DeepCopy() *FreezeWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(FreezeWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitService) DeepCopyInto(out *GitService) {
 	*out = *in
@@ -2025,6 +2100,27 @@ func (in *Original) DeepCopy() *Original {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParallelWorkflowStep) DeepCopyInto(out *ParallelWorkflowStep) {
+	*out = *in
+	if in.Environments != nil {
+		in, out := &in.Environments, &out.Environments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParallelWorkflowStep.
+func (in *ParallelWorkflowStep) DeepCopy() *ParallelWorkflowStep {
+	if in == nil {
+		return nil
+	}
+	out := new(ParallelWorkflowStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Periodic) DeepCopyInto(out *Periodic) {
 	*out = *in
@@ -2207,6 +2303,13 @@ func (in *PipelineActivitySpec) DeepCopyInto(out *PipelineActivitySpec) {
 		}
 	}
 	in.BatchPipelineActivity.DeepCopyInto(&out.BatchPipelineActivity)
+	if in.WorkflowSteps != nil {
+		in, out := &in.WorkflowSteps, &out.WorkflowSteps
+		*out = make([]WorkflowStepStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -2473,6 +2576,18 @@ func (in *PipelineStructureStage) DeepCopyInto(out *PipelineStructureStage) {
 			**out = **in
 		}
 	}
+	if in.MatrixAxes != nil {
+		in, out := &in.MatrixAxes, &out.MatrixAxes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MatrixValues != nil {
+		in, out := &in.MatrixValues, &out.MatrixValues
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -2837,6 +2952,22 @@ func (in *PreviewActivityStep) DeepCopy() *PreviewActivityStep {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreviewGCPolicy) DeepCopyInto(out *PreviewGCPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreviewGCPolicy.
+func (in *PreviewGCPolicy) DeepCopy() *PreviewGCPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PreviewGCPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PreviewGitSpec) DeepCopyInto(out *PreviewGitSpec) {
 	*out = *in
@@ -2876,6 +3007,24 @@ func (in *PromoteActivityStep) DeepCopyInto(out *PromoteActivityStep) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(PromoteCanaryStep)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Freeze != nil {
+		in, out := &in.Freeze, &out.Freeze
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(PromoteFreezeStep)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -2889,6 +3038,47 @@ func (in *PromoteActivityStep) DeepCopy() *PromoteActivityStep {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromoteCanaryStep) DeepCopyInto(out *PromoteCanaryStep) {
+	*out = *in
+	in.CoreActivityStep.DeepCopyInto(&out.CoreActivityStep)
+	if in.Iterations != nil {
+		in, out := &in.Iterations, &out.Iterations
+		*out = make([]CanaryAnalysisIteration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromoteCanaryStep.
+func (in *PromoteCanaryStep) DeepCopy() *PromoteCanaryStep {
+	if in == nil {
+		return nil
+	}
+	out := new(PromoteCanaryStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromoteFreezeStep) DeepCopyInto(out *PromoteFreezeStep) {
+	*out = *in
+	in.CoreActivityStep.DeepCopyInto(&out.CoreActivityStep)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromoteFreezeStep.
+func (in *PromoteFreezeStep) DeepCopy() *PromoteFreezeStep {
+	if in == nil {
+		return nil
+	}
+	out := new(PromoteFreezeStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PromotePullRequestStep) DeepCopyInto(out *PromotePullRequestStep) {
 	*out = *in
@@ -3941,6 +4131,13 @@ func (in *StageActivityStep) DeepCopyInto(out *StageActivityStep) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RetryAttempts != nil {
+		in, out := &in.RetryAttempts, &out.RetryAttempts
+		*out = make([]CoreActivityStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -4080,6 +4277,22 @@ func (in *TeamSettings) DeepCopyInto(out *TeamSettings) {
 		copy(*out, *in)
 	}
 	out.DefaultScheduler = in.DefaultScheduler
+	if in.FreezeWindows != nil {
+		in, out := &in.FreezeWindows, &out.FreezeWindows
+		*out = make([]FreezeWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PreviewGC != nil {
+		in, out := &in.PreviewGC, &out.PreviewGC
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(PreviewGCPolicy)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -4130,6 +4343,27 @@ func (in *TeamStatus) DeepCopy() *TeamStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeWindow) DeepCopyInto(out *TimeWindow) {
+	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeWindow.
+func (in *TimeWindow) DeepCopy() *TimeWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Trigger) DeepCopyInto(out *Trigger) {
 	*out = *in
@@ -4288,6 +4522,57 @@ func (in *UserSpec) DeepCopy() *UserSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerifyWorkflowStep) DeepCopyInto(out *VerifyWorkflowStep) {
+	*out = *in
+	if in.Check != nil {
+		in, out := &in.Check, &out.Check
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rollback != nil {
+		in, out := &in.Rollback, &out.Rollback
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerifyWorkflowStep.
+func (in *VerifyWorkflowStep) DeepCopy() *VerifyWorkflowStep {
+	if in == nil {
+		return nil
+	}
+	out := new(VerifyWorkflowStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WaitWorkflowStep) DeepCopyInto(out *WaitWorkflowStep) {
+	*out = *in
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(TimeWindow)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WaitWorkflowStep.
+func (in *WaitWorkflowStep) DeepCopy() *WaitWorkflowStep {
+	if in == nil {
+		return nil
+	}
+	out := new(WaitWorkflowStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Welcome) DeepCopyInto(out *Welcome) {
 	*out = *in
@@ -4382,6 +4667,11 @@ func (in *WorkflowPreconditions) DeepCopyInto(out *WorkflowPreconditions) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -4447,6 +4737,42 @@ func (in *WorkflowStep) DeepCopyInto(out *WorkflowStep) {
 			**out = **in
 		}
 	}
+	if in.Approve != nil {
+		in, out := &in.Approve, &out.Approve
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ApproveWorkflowStep)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Wait != nil {
+		in, out := &in.Wait, &out.Wait
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(WaitWorkflowStep)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Verify != nil {
+		in, out := &in.Verify, &out.Verify
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(VerifyWorkflowStep)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Parallel != nil {
+		in, out := &in.Parallel, &out.Parallel
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ParallelWorkflowStep)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -4459,3 +4785,35 @@ func (in *WorkflowStep) DeepCopy() *WorkflowStep {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowStepStatus) DeepCopyInto(out *WorkflowStepStatus) {
+	*out = *in
+	if in.StartedTimestamp != nil {
+		in, out := &in.StartedTimestamp, &out.StartedTimestamp
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = (*in).DeepCopy()
+		}
+	}
+	if in.CompletedTimestamp != nil {
+		in, out := &in.CompletedTimestamp, &out.CompletedTimestamp
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = (*in).DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowStepStatus.
+func (in *WorkflowStepStatus) DeepCopy() *WorkflowStepStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowStepStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,478 @@
+package backup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/extensions"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Clients are the clients used to watch and restore the resources of a backup
+type Clients struct {
+	KubeClient kubernetes.Interface
+	JXClient   versioned.Interface
+}
+
+// Resource is a kind of resource which can be backed up to and restored from a git repository
+type Resource struct {
+	// Name is the singular lower case name of the resource. Its backups are stored in the '<name>s/<namespace>' directory
+	Name string
+	// ObjectType is an empty object of the type returned by the ListerWatcher
+	ObjectType runtime.Object
+	// ListWatch returns the ListerWatcher for the resources in the namespace
+	ListWatch func(c *Clients, ns string) cache.ListerWatcher
+	// Marshal returns the name of the backup file and its YAML for the object, defaulting to the object itself
+	Marshal func(obj interface{}) (string, []byte, error)
+	// Restore creates or updates the resource in the namespace from the YAML of its backup file
+	Restore func(c *Clients, ns string, data []byte) error
+}
+
+// Resources are all the resources which can be backed up in the order they are restored so that resources are
+// restored after the resources they refer to
+var Resources = []*Resource{
+	{
+		Name:       "team",
+		ObjectType: &v1.Team{},
+		ListWatch: func(c *Clients, ns string) cache.ListerWatcher {
+			return listWatch(func(lo metav1.ListOptions) (runtime.Object, error) {
+				return c.JXClient.JenkinsV1().Teams(ns).List(lo)
+			}, func(lo metav1.ListOptions) (watch.Interface, error) {
+				return c.JXClient.JenkinsV1().Teams(ns).Watch(lo)
+			})
+		},
+		Restore: func(c *Clients, ns string, data []byte) error {
+			resource := &v1.Team{}
+			api := c.JXClient.JenkinsV1().Teams(ns)
+			return createOrUpdate(data, resource, func(name string) (metav1.Object, error) {
+				return api.Get(name, metav1.GetOptions{})
+			}, func() error {
+				_, err := api.Create(resource)
+				return err
+			}, func() error {
+				_, err := api.Update(resource)
+				return err
+			})
+		},
+	},
+	{
+		Name:       "user",
+		ObjectType: &v1.User{},
+		ListWatch: func(c *Clients, ns string) cache.ListerWatcher {
+			return listWatch(func(lo metav1.ListOptions) (runtime.Object, error) {
+				return c.JXClient.JenkinsV1().Users(ns).List(lo)
+			}, func(lo metav1.ListOptions) (watch.Interface, error) {
+				return c.JXClient.JenkinsV1().Users(ns).Watch(lo)
+			})
+		},
+		Restore: func(c *Clients, ns string, data []byte) error {
+			resource := &v1.User{}
+			api := c.JXClient.JenkinsV1().Users(ns)
+			return createOrUpdate(data, resource, func(name string) (metav1.Object, error) {
+				return api.Get(name, metav1.GetOptions{})
+			}, func() error {
+				_, err := api.Create(resource)
+				return err
+			}, func() error {
+				_, err := api.Update(resource)
+				return err
+			})
+		},
+	},
+	{
+		Name:       "environment",
+		ObjectType: &v1.Environment{},
+		ListWatch: func(c *Clients, ns string) cache.ListerWatcher {
+			return listWatch(func(lo metav1.ListOptions) (runtime.Object, error) {
+				return c.JXClient.JenkinsV1().Environments(ns).List(lo)
+			}, func(lo metav1.ListOptions) (watch.Interface, error) {
+				return c.JXClient.JenkinsV1().Environments(ns).Watch(lo)
+			})
+		},
+		Restore: func(c *Clients, ns string, data []byte) error {
+			resource := &v1.Environment{}
+			api := c.JXClient.JenkinsV1().Environments(ns)
+			return createOrUpdate(data, resource, func(name string) (metav1.Object, error) {
+				return api.Get(name, metav1.GetOptions{})
+			}, func() error {
+				_, err := api.Create(resource)
+				return err
+			}, func() error {
+				_, err := api.Update(resource)
+				return err
+			})
+		},
+	},
+	{
+		Name:       "environmentrolebinding",
+		ObjectType: &v1.EnvironmentRoleBinding{},
+		ListWatch: func(c *Clients, ns string) cache.ListerWatcher {
+			return listWatch(func(lo metav1.ListOptions) (runtime.Object, error) {
+				return c.JXClient.JenkinsV1().EnvironmentRoleBindings(ns).List(lo)
+			}, func(lo metav1.ListOptions) (watch.Interface, error) {
+				return c.JXClient.JenkinsV1().EnvironmentRoleBindings(ns).Watch(lo)
+			})
+		},
+		Restore: func(c *Clients, ns string, data []byte) error {
+			resource := &v1.EnvironmentRoleBinding{}
+			api := c.JXClient.JenkinsV1().EnvironmentRoleBindings(ns)
+			return createOrUpdate(data, resource, func(name string) (metav1.Object, error) {
+				return api.Get(name, metav1.GetOptions{})
+			}, func() error {
+				_, err := api.Create(resource)
+				return err
+			}, func() error {
+				_, err := api.Update(resource)
+				return err
+			})
+		},
+	},
+	{
+		Name:       "scheduler",
+		ObjectType: &v1.Scheduler{},
+		ListWatch: func(c *Clients, ns string) cache.ListerWatcher {
+			return listWatch(func(lo metav1.ListOptions) (runtime.Object, error) {
+				return c.JXClient.JenkinsV1().Schedulers(ns).List(lo)
+			}, func(lo metav1.ListOptions) (watch.Interface, error) {
+				return c.JXClient.JenkinsV1().Schedulers(ns).Watch(lo)
+			})
+		},
+		Restore: func(c *Clients, ns string, data []byte) error {
+			resource := &v1.Scheduler{}
+			api := c.JXClient.JenkinsV1().Schedulers(ns)
+			return createOrUpdate(data, resource, func(name string) (metav1.Object, error) {
+				return api.Get(name, metav1.GetOptions{})
+			}, func() error {
+				_, err := api.Create(resource)
+				return err
+			}, func() error {
+				_, err := api.Update(resource)
+				return err
+			})
+		},
+	},
+	{
+		Name:       "sourcerepository",
+		ObjectType: &v1.SourceRepository{},
+		ListWatch: func(c *Clients, ns string) cache.ListerWatcher {
+			return listWatch(func(lo metav1.ListOptions) (runtime.Object, error) {
+				return c.JXClient.JenkinsV1().SourceRepositories(ns).List(lo)
+			}, func(lo metav1.ListOptions) (watch.Interface, error) {
+				return c.JXClient.JenkinsV1().SourceRepositories(ns).Watch(lo)
+			})
+		},
+		Restore: func(c *Clients, ns string, data []byte) error {
+			resource := &v1.SourceRepository{}
+			api := c.JXClient.JenkinsV1().SourceRepositories(ns)
+			return createOrUpdate(data, resource, func(name string) (metav1.Object, error) {
+				return api.Get(name, metav1.GetOptions{})
+			}, func() error {
+				_, err := api.Create(resource)
+				return err
+			}, func() error {
+				_, err := api.Update(resource)
+				return err
+			})
+		},
+	},
+	{
+		Name:       "app",
+		ObjectType: &v1.App{},
+		ListWatch: func(c *Clients, ns string) cache.ListerWatcher {
+			return listWatch(func(lo metav1.ListOptions) (runtime.Object, error) {
+				return c.JXClient.JenkinsV1().Apps(ns).List(lo)
+			}, func(lo metav1.ListOptions) (watch.Interface, error) {
+				return c.JXClient.JenkinsV1().Apps(ns).Watch(lo)
+			})
+		},
+		Restore: func(c *Clients, ns string, data []byte) error {
+			resource := &v1.App{}
+			api := c.JXClient.JenkinsV1().Apps(ns)
+			return createOrUpdate(data, resource, func(name string) (metav1.Object, error) {
+				return api.Get(name, metav1.GetOptions{})
+			}, func() error {
+				_, err := api.Create(resource)
+				return err
+			}, func() error {
+				_, err := api.Update(resource)
+				return err
+			})
+		},
+	},
+	{
+		// ExtensionConfigs are not a custom resource but are stored as a list in the extensions ConfigMap
+		Name:       "extensionconfig",
+		ObjectType: &corev1.ConfigMap{},
+		ListWatch: func(c *Clients, ns string) cache.ListerWatcher {
+			selector := fields.OneTermEqualSelector("metadata.name", extensions.ExtensionsConfigDefaultConfigMap).String()
+			return listWatch(func(lo metav1.ListOptions) (runtime.Object, error) {
+				lo.FieldSelector = selector
+				return c.KubeClient.CoreV1().ConfigMaps(ns).List(lo)
+			}, func(lo metav1.ListOptions) (watch.Interface, error) {
+				lo.FieldSelector = selector
+				return c.KubeClient.CoreV1().ConfigMaps(ns).Watch(lo)
+			})
+		},
+		Marshal: func(obj interface{}) (string, []byte, error) {
+			cm, ok := obj.(*corev1.ConfigMap)
+			if !ok {
+				return "", nil, fmt.Errorf("object is not a ConfigMap %#v", obj)
+			}
+			list := &v1.ExtensionConfigList{}
+			err := yaml.Unmarshal([]byte(cm.Data["extensions"]), &list.Extensions)
+			if err != nil {
+				return "", nil, errors.Wrapf(err, "failed to parse the extensions in ConfigMap %s", cm.Name)
+			}
+			data, err := yaml.Marshal(list)
+			return cm.Name, data, err
+		},
+		Restore: func(c *Clients, ns string, data []byte) error {
+			list := &v1.ExtensionConfigList{}
+			err := yaml.Unmarshal(data, list)
+			if err != nil {
+				return err
+			}
+			extensionsYaml, err := yaml.Marshal(list.Extensions)
+			if err != nil {
+				return err
+			}
+			cm, err := extensions.GetOrCreateExtensionsConfig(c.KubeClient, ns)
+			if err != nil {
+				return err
+			}
+			cm.Data["extensions"] = string(extensionsYaml)
+			_, err = c.KubeClient.CoreV1().ConfigMaps(ns).Update(cm)
+			return err
+		},
+	},
+}
+
+// DefaultResources are the names of the resources backed up by default
+var DefaultResources = []string{"environment", "team", "user"}
+
+// ResourceNames returns the names of all the resources which can be backed up
+func ResourceNames() []string {
+	names := []string{}
+	for _, r := range Resources {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// FindResources returns the resources with the given names in the order they are restored
+func FindResources(names []string) ([]*Resource, error) {
+	answer := []*Resource{}
+	for _, name := range names {
+		found := false
+		for _, r := range Resources {
+			if r.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown resource %s. Supported resources are: %s", name, strings.Join(ResourceNames(), ", "))
+		}
+	}
+	for _, r := range Resources {
+		for _, name := range names {
+			if r.Name == name {
+				answer = append(answer, r)
+				break
+			}
+		}
+	}
+	return answer, nil
+}
+
+// ResourceDir returns the directory in the backup repository containing the resources in the namespace
+func ResourceDir(dir string, resource *Resource, ns string) string {
+	return filepath.Join(dir, resource.Name+"s", ns)
+}
+
+// ResourceFile returns the path of the backup file of the named resource
+func ResourceFile(dir string, resource *Resource, ns string, name string) string {
+	return filepath.Join(ResourceDir(dir, resource, ns), name+".yaml")
+}
+
+// ObjectName returns the name of the object or of the last known state of an object deleted while it was not watched
+func ObjectName(obj interface{}) (string, error) {
+	if deleted, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = deleted.Obj
+		if obj == nil {
+			_, name, err := cache.SplitMetaNamespaceKey(deleted.Key)
+			return name, err
+		}
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", err
+	}
+	return accessor.GetName(), nil
+}
+
+// WriteResource writes the backup file of the object returning its file name
+func WriteResource(dir string, resource *Resource, ns string, obj interface{}) (string, error) {
+	var name string
+	var data []byte
+	var err error
+	if resource.Marshal != nil {
+		name, data, err = resource.Marshal(obj)
+	} else {
+		name, err = ObjectName(obj)
+		if err == nil {
+			data, err = yaml.Marshal(obj)
+		}
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal %s", resource.Name)
+	}
+	nsDir := ResourceDir(dir, resource, ns)
+	err = os.MkdirAll(nsDir, os.FileMode(0755))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create directory %s", nsDir)
+	}
+	fileName := ResourceFile(dir, resource, ns, name)
+	err = ioutil.WriteFile(fileName, data, 0644)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to write file %s", fileName)
+	}
+	return fileName, nil
+}
+
+// DeleteResource removes the backup file of the deleted object returning its file name if it existed
+func DeleteResource(dir string, resource *Resource, ns string, obj interface{}) (string, error) {
+	name, err := ObjectName(obj)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find the name of the deleted %s", resource.Name)
+	}
+	fileName := ResourceFile(dir, resource, ns, name)
+	err = os.Remove(fileName)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to remove file %s", fileName)
+	}
+	return fileName, nil
+}
+
+// PruneResources removes the backup files of the resources in the namespace which are not in the given names, such
+// as those deleted while the resources were not watched, returning the names of the removed files
+func PruneResources(dir string, resource *Resource, ns string, names []string) ([]string, error) {
+	pruned := []string{}
+	nsDir := ResourceDir(dir, resource, ns)
+	files, err := ioutil.ReadDir(nsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return pruned, nil
+		}
+		return pruned, errors.Wrapf(err, "failed to read directory %s", nsDir)
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".yaml") || util.StringArrayIndex(names, strings.TrimSuffix(f.Name(), ".yaml")) >= 0 {
+			continue
+		}
+		fileName := filepath.Join(nsDir, f.Name())
+		err = os.Remove(fileName)
+		if err != nil {
+			return pruned, errors.Wrapf(err, "failed to remove file %s", fileName)
+		}
+		pruned = append(pruned, fileName)
+	}
+	return pruned, nil
+}
+
+// RestoreResources restores the backups of the resources in the namespace of the backup repository into the target
+// namespace in the order of the resources, returning the names of the restored backup files
+func RestoreResources(c *Clients, dir string, resources []*Resource, backupNs string, ns string, dryRun bool) ([]string, error) {
+	restored := []string{}
+	for _, resource := range resources {
+		nsDir := ResourceDir(dir, resource, backupNs)
+		files, err := ioutil.ReadDir(nsDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return restored, errors.Wrapf(err, "failed to read directory %s", nsDir)
+		}
+		names := []string{}
+		for _, f := range files {
+			if !f.IsDir() && strings.HasSuffix(f.Name(), ".yaml") {
+				names = append(names, f.Name())
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fileName := filepath.Join(nsDir, name)
+			if !dryRun {
+				data, err := ioutil.ReadFile(fileName)
+				if err != nil {
+					return restored, errors.Wrapf(err, "failed to read file %s", fileName)
+				}
+				err = resource.Restore(c, ns, data)
+				if err != nil {
+					return restored, errors.Wrapf(err, "failed to restore %s from %s", resource.Name, fileName)
+				}
+			}
+			restored = append(restored, fileName)
+		}
+	}
+	return restored, nil
+}
+
+func listWatch(list cache.ListFunc, watch cache.WatchFunc) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc:  list,
+		WatchFunc: watch,
+	}
+}
+
+// createOrUpdate parses the backup of a resource into the empty resource and creates it, or updates it if the
+// resource already exists
+func createOrUpdate(data []byte, resource metav1.Object, get func(name string) (metav1.Object, error), create func() error, update func() error) error {
+	err := unmarshal(data, resource)
+	if err != nil {
+		return err
+	}
+	existing, err := get(resource.GetName())
+	if apierrors.IsNotFound(err) {
+		return create()
+	}
+	if err != nil {
+		return err
+	}
+	resource.SetResourceVersion(existing.GetResourceVersion())
+	return update()
+}
+
+// unmarshal parses the backup of a resource clearing the metadata which is specific to the cluster it was backed up from
+func unmarshal(data []byte, obj metav1.Object) error {
+	err := yaml.Unmarshal(data, obj)
+	if err != nil {
+		return err
+	}
+	obj.SetResourceVersion("")
+	obj.SetUID("")
+	obj.SetSelfLink("")
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetNamespace("")
+	return nil
+}
@@ -0,0 +1,183 @@
+package backup_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/backup"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/pkg/extensions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestFindResources(t *testing.T) {
+	t.Parallel()
+	resources, err := backup.FindResources([]string{"app", "environment", "team", "environmentrolebinding"})
+	require.NoError(t, err)
+	names := []string{}
+	for _, r := range resources {
+		names = append(names, r.Name)
+	}
+	assert.Equal(t, []string{"team", "environment", "environmentrolebinding", "app"}, names)
+
+	_, err = backup.FindResources([]string{"environment", "pod"})
+	assert.Error(t, err)
+}
+
+func TestWriteAndDeleteResource(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "test-backup-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	resources, err := backup.FindResources([]string{"environment"})
+	require.NoError(t, err)
+	resource := resources[0]
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "staging",
+			Namespace: "jx",
+		},
+		Spec: v1.EnvironmentSpec{
+			Namespace: "jx-staging",
+		},
+	}
+
+	fileName, err := backup.WriteResource(dir, resource, "jx", env)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "environments", "jx", "staging.yaml"), fileName)
+	assert.FileExists(t, fileName)
+
+	deleted, err := backup.DeleteResource(dir, resource, "jx", cache.DeletedFinalStateUnknown{Key: "jx/staging"})
+	require.NoError(t, err)
+	assert.Equal(t, fileName, deleted)
+	_, err = os.Stat(fileName)
+	assert.True(t, os.IsNotExist(err))
+
+	deleted, err = backup.DeleteResource(dir, resource, "jx", env)
+	require.NoError(t, err)
+	assert.Empty(t, deleted, "already deleted")
+}
+
+func TestPruneResources(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "test-backup-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	resources, err := backup.FindResources([]string{"environment"})
+	require.NoError(t, err)
+	resource := resources[0]
+	staging, err := backup.WriteResource(dir, resource, "jx", &v1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "staging"}})
+	require.NoError(t, err)
+	production, err := backup.WriteResource(dir, resource, "jx", &v1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "production"}})
+	require.NoError(t, err)
+
+	pruned, err := backup.PruneResources(dir, resource, "jx", []string{"staging", "dev"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{production}, pruned)
+	assert.FileExists(t, staging)
+	_, err = os.Stat(production)
+	assert.True(t, os.IsNotExist(err))
+
+	pruned, err = backup.PruneResources(dir, resource, "other", nil)
+	require.NoError(t, err)
+	assert.Empty(t, pruned, "no backups in the namespace")
+}
+
+func TestRestoreResources(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "test-backup-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	resources, err := backup.FindResources(backup.ResourceNames())
+	require.NoError(t, err)
+	resourceByName := map[string]*backup.Resource{}
+	for _, r := range resources {
+		resourceByName[r.Name] = r
+	}
+
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "staging",
+			Namespace:       "jx",
+			ResourceVersion: "42",
+			UID:             "1234",
+		},
+		Spec: v1.EnvironmentSpec{
+			Namespace: "jx-staging",
+		},
+	}
+	_, err = backup.WriteResource(dir, resourceByName["environment"], "jx", env)
+	require.NoError(t, err)
+	user := &v1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "jstrachan",
+		},
+		Spec: v1.UserDetails{
+			Login: "jstrachan",
+		},
+	}
+	_, err = backup.WriteResource(dir, resourceByName["user"], "jx", user)
+	require.NoError(t, err)
+	extensionsYaml, err := yaml.Marshal([]v1.ExtensionConfig{{Name: "my-extension", Namespace: "jenkins.io"}})
+	require.NoError(t, err)
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: extensions.ExtensionsConfigDefaultConfigMap,
+		},
+		Data: map[string]string{
+			"extensions": string(extensionsYaml),
+		},
+	}
+	_, err = backup.WriteResource(dir, resourceByName["extensionconfig"], "jx", cm)
+	require.NoError(t, err)
+
+	existing := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "staging",
+			Namespace: "jx",
+		},
+	}
+	clients := &backup.Clients{
+		KubeClient: kubefake.NewSimpleClientset(),
+		JXClient:   fake.NewSimpleClientset(existing),
+	}
+
+	restored, err := backup.RestoreResources(clients, dir, resources, "jx", "jx", true)
+	require.NoError(t, err)
+	assert.Len(t, restored, 3)
+	_, err = clients.JXClient.JenkinsV1().Users("jx").Get("jstrachan", metav1.GetOptions{})
+	assert.Error(t, err, "dry run should not restore the user")
+
+	restored, err = backup.RestoreResources(clients, dir, resources, "jx", "jx", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "users", "jx", "jstrachan.yaml"),
+		filepath.Join(dir, "environments", "jx", "staging.yaml"),
+		filepath.Join(dir, "extensionconfigs", "jx", extensions.ExtensionsConfigDefaultConfigMap+".yaml"),
+	}, restored)
+
+	restoredUser, err := clients.JXClient.JenkinsV1().Users("jx").Get("jstrachan", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "jstrachan", restoredUser.Spec.Login)
+
+	restoredEnv, err := clients.JXClient.JenkinsV1().Environments("jx").Get("staging", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "jx-staging", restoredEnv.Spec.Namespace)
+
+	extensionConfigs, err := (&v1.ExtensionConfigList{}).LoadFromConfigMap(extensions.ExtensionsConfigDefaultConfigMap, clients.KubeClient, "jx")
+	require.NoError(t, err)
+	require.Len(t, extensionConfigs.Extensions, 1)
+	assert.Equal(t, "my-extension", extensionConfigs.Extensions[0].Name)
+}
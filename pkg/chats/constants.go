@@ -1,10 +1,12 @@
 package chats
 
 const (
-	Slack = "slack"
-	Irc   = "irc"
+	Slack          = "slack"
+	Irc            = "irc"
+	Mattermost     = "mattermost"
+	MicrosoftTeams = "teams"
 )
 
 var (
-	ChatKinds = []string{Slack, Irc}
+	ChatKinds = []string{Slack, Irc, Mattermost, MicrosoftTeams}
 )
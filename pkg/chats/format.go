@@ -0,0 +1,13 @@
+package chats
+
+import (
+	"regexp"
+)
+
+// linkRegex matches the links of message text which use the Slack format <url|text>
+var linkRegex = regexp.MustCompile(`<(https?://[^|>\s]+)\|([^>]*)>`)
+
+// toMarkdown converts the links of the message text to markdown
+func toMarkdown(text string) string {
+	return linkRegex.ReplaceAllString(text, "[$2]($1)")
+}
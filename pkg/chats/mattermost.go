@@ -0,0 +1,238 @@
+package chats
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	mattermostAPI            = "api/v4"
+	mattermostMembersPerPage = 200
+)
+
+// MattermostChatProvider is a ChatProvider for Mattermost using its REST API v4 and a bot or personal access token
+type MattermostChatProvider struct {
+	Server     *auth.AuthServer
+	UserAuth   *auth.UserAuth
+	HTTPClient *http.Client
+
+	lock     sync.Mutex
+	channels map[string]*mattermostChannel
+}
+
+type mattermostUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type mattermostTeam struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type mattermostChannel struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	TeamID      string `json:"team_id"`
+
+	teamName string
+}
+
+type mattermostChannelStats struct {
+	MemberCount int `json:"member_count"`
+}
+
+type mattermostChannelMember struct {
+	UserID string `json:"user_id"`
+}
+
+type mattermostPost struct {
+	ID        string                 `json:"id,omitempty"`
+	ChannelID string                 `json:"channel_id,omitempty"`
+	RootID    string                 `json:"root_id,omitempty"`
+	Message   string                 `json:"message"`
+	Props     map[string]interface{} `json:"props,omitempty"`
+}
+
+// mattermostAttachment is a message attachment which Mattermost supports in the same format as Slack
+type mattermostAttachment struct {
+	Title     string                      `json:"title,omitempty"`
+	TitleLink string                      `json:"title_link,omitempty"`
+	Text      string                      `json:"text,omitempty"`
+	Color     string                      `json:"color,omitempty"`
+	Fields    []mattermostAttachmentField `json:"fields,omitempty"`
+	Footer    string                      `json:"footer,omitempty"`
+}
+
+type mattermostAttachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// CreateMattermostChatProvider creates a ChatProvider for the Mattermost server
+func CreateMattermostChatProvider(server *auth.AuthServer, userAuth *auth.UserAuth, batchMode bool) (ChatProvider, error) {
+	u := server.URL
+	if u == "" {
+		return nil, fmt.Errorf("No base URL for server!")
+	}
+	if userAuth == nil || userAuth.ApiToken == "" {
+		return nil, fmt.Errorf("No authentication found for Mattermost server %s", u)
+	}
+	return &MattermostChatProvider{
+		Server:     server,
+		UserAuth:   userAuth,
+		HTTPClient: http.DefaultClient,
+		channels:   map[string]*mattermostChannel{},
+	}, nil
+}
+
+// GetChannelMetrics returns the metrics of the channel which is either the name of a channel of the first team of the
+// user or 'team/channel'
+func (c *MattermostChatProvider) GetChannelMetrics(name string) (*ChannelMetrics, error) {
+	metrics := &ChannelMetrics{
+		Name: name,
+	}
+	channel, err := c.findChannel(name)
+	if err != nil {
+		return metrics, err
+	}
+	stats := &mattermostChannelStats{}
+	err = c.do(http.MethodGet, "channels/"+channel.ID+"/stats", nil, stats)
+	if err != nil {
+		return metrics, errors.Wrapf(err, "failed to get the stats of Mattermost channel %s", name)
+	}
+	members := []mattermostChannelMember{}
+	err = c.do(http.MethodGet, fmt.Sprintf("channels/%s/members?per_page=%d", channel.ID, mattermostMembersPerPage), nil, &members)
+	if err != nil {
+		return metrics, errors.Wrapf(err, "failed to get the members of Mattermost channel %s", name)
+	}
+	metrics.ID = channel.ID
+	metrics.Name = channel.Name
+	metrics.MemberCount = stats.MemberCount
+	for _, member := range members {
+		metrics.Members = append(metrics.Members, member.UserID)
+	}
+	metrics.URL = util.UrlJoin(c.Server.URL, channel.teamName, "channels", channel.Name)
+	return metrics, nil
+}
+
+// AuthenticatedUser returns the name of the user the Mattermost token belongs to
+func (c *MattermostChatProvider) AuthenticatedUser() (string, error) {
+	user := &mattermostUser{}
+	err := c.do(http.MethodGet, "users/me", nil, user)
+	if err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+// PostMessage posts the message to the Mattermost channel
+func (c *MattermostChatProvider) PostMessage(channel string, message *Message) (*MessageReference, error) {
+	ch, err := c.findChannel(channel)
+	if err != nil {
+		return nil, err
+	}
+	post := toMattermostPost(message)
+	post.ChannelID = ch.ID
+	post.RootID = message.ThreadID
+	result := &mattermostPost{}
+	err = c.do(http.MethodPost, "posts", post, result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to post message to Mattermost channel %s", channel)
+	}
+	return &MessageReference{
+		Channel: ch.ID,
+		ID:      result.ID,
+	}, nil
+}
+
+// UpdateMessage updates the Mattermost post
+func (c *MattermostChatProvider) UpdateMessage(ref *MessageReference, message *Message) (*MessageReference, error) {
+	result := &mattermostPost{}
+	err := c.do(http.MethodPut, "posts/"+ref.ID+"/patch", toMattermostPost(message), result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update Mattermost post %s", ref.ID)
+	}
+	return &MessageReference{
+		Channel: result.ChannelID,
+		ID:      result.ID,
+	}, nil
+}
+
+func (c *MattermostChatProvider) findChannel(name string) (*mattermostChannel, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	name = strings.TrimPrefix(name, "#")
+	if channel := c.channels[name]; channel != nil {
+		return channel, nil
+	}
+	teamName := ""
+	channelName := name
+	if i := strings.Index(name, "/"); i >= 0 {
+		teamName = name[:i]
+		channelName = name[i+1:]
+	}
+	if teamName == "" {
+		teams := []mattermostTeam{}
+		err := c.do(http.MethodGet, "users/me/teams", nil, &teams)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the Mattermost teams of the user")
+		}
+		if len(teams) == 0 {
+			return nil, fmt.Errorf("the Mattermost user is not a member of any team so the channel %s cannot be found", name)
+		}
+		teamName = teams[0].Name
+	}
+	channel := &mattermostChannel{}
+	err := c.do(http.MethodGet, fmt.Sprintf("teams/name/%s/channels/name/%s", url.PathEscape(teamName), url.PathEscape(channelName)), nil, channel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find Mattermost channel %s in team %s", channelName, teamName)
+	}
+	channel.teamName = teamName
+	c.channels[name] = channel
+	return channel, nil
+}
+
+func (c *MattermostChatProvider) do(method string, path string, body interface{}, result interface{}) error {
+	return util.CallJSON(c.HTTPClient, method, util.UrlJoin(c.Server.URL, mattermostAPI, path), util.BearerAuthorization(c.UserAuth.ApiToken), body, result)
+}
+
+func toMattermostPost(message *Message) *mattermostPost {
+	post := &mattermostPost{
+		Message: toMarkdown(message.Text),
+	}
+	if len(message.Attachments) > 0 {
+		attachments := []mattermostAttachment{}
+		for _, a := range message.Attachments {
+			attachment := mattermostAttachment{
+				Title:     a.Title,
+				TitleLink: a.TitleLink,
+				Text:      toMarkdown(a.Text),
+				Color:     a.Color,
+				Footer:    a.Footer,
+			}
+			for _, f := range a.Fields {
+				attachment.Fields = append(attachment.Fields, mattermostAttachmentField{
+					Title: f.Title,
+					Value: toMarkdown(f.Value),
+					Short: f.Short,
+				})
+			}
+			attachments = append(attachments, attachment)
+		}
+		post.Props = map[string]interface{}{
+			"attachments": attachments,
+		}
+	}
+	return post
+}
@@ -0,0 +1,94 @@
+package chats_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/chats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMattermostChatProvider(t *testing.T) {
+	t.Parallel()
+	var posts []map[string]interface{}
+	var patched map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer mytoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v4/users/me":
+			w.Write([]byte(`{"id":"u1","username":"jenkins-x-bot"}`))
+		case "GET /api/v4/users/me/teams":
+			w.Write([]byte(`[{"id":"t1","name":"myteam"}]`))
+		case "GET /api/v4/teams/name/myteam/channels/name/builds":
+			w.Write([]byte(`{"id":"c1","name":"builds","display_name":"Builds","team_id":"t1"}`))
+		case "GET /api/v4/channels/c1/stats":
+			w.Write([]byte(`{"channel_id":"c1","member_count":2}`))
+		case "GET /api/v4/channels/c1/members":
+			w.Write([]byte(`[{"user_id":"u1"},{"user_id":"u2"}]`))
+		case "POST /api/v4/posts":
+			post := map[string]interface{}{}
+			data, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(data, &post)
+			posts = append(posts, post)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"p1","channel_id":"c1"}`))
+		case "PUT /api/v4/posts/p1/patch":
+			data, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(data, &patched)
+			w.Write([]byte(`{"id":"p1","channel_id":"c1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := chats.CreateChatProvider(chats.Mattermost, &auth.AuthServer{URL: server.URL}, &auth.UserAuth{ApiToken: "mytoken"}, true)
+	require.NoError(t, err)
+
+	user, err := provider.AuthenticatedUser()
+	require.NoError(t, err)
+	assert.Equal(t, "jenkins-x-bot", user)
+
+	metrics, err := provider.GetChannelMetrics("#builds")
+	require.NoError(t, err)
+	assert.Equal(t, "c1", metrics.ID)
+	assert.Equal(t, 2, metrics.MemberCount)
+	assert.Equal(t, []string{"u1", "u2"}, metrics.Members)
+	assert.Equal(t, server.URL+"/myteam/channels/builds", metrics.URL)
+
+	ref, err := provider.PostMessage("myteam/builds", &chats.Message{
+		Text: "Pipeline <https://example.com/logs|myorg/myapp/master #1> started",
+		Attachments: []chats.Attachment{
+			{
+				Title: "myorg/myapp/master #1",
+				Color: "#439FE0",
+				Fields: []chats.AttachmentField{
+					{Title: "Status", Value: "Running", Short: true},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "p1", ref.ID)
+	require.Len(t, posts, 1)
+	assert.Equal(t, "c1", posts[0]["channel_id"])
+	assert.Equal(t, "Pipeline [myorg/myapp/master #1](https://example.com/logs) started", posts[0]["message"])
+	assert.NotNil(t, posts[0]["props"])
+
+	_, err = provider.PostMessage("#builds", &chats.Message{Text: "succeeded", ThreadID: ref.ID})
+	require.NoError(t, err)
+	require.Len(t, posts, 2)
+	assert.Equal(t, "p1", posts[1]["root_id"])
+
+	_, err = provider.UpdateMessage(ref, &chats.Message{Text: "Pipeline succeeded"})
+	require.NoError(t, err)
+	assert.Equal(t, "Pipeline succeeded", patched["message"])
+}
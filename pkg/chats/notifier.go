@@ -0,0 +1,443 @@
+package chats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// EventPipelineStarted is the notification of a pipeline starting
+	EventPipelineStarted = "pipeline-started"
+	// EventPipelineSucceeded is the notification of a pipeline succeeding
+	EventPipelineSucceeded = "pipeline-succeeded"
+	// EventPipelineFailed is the notification of a pipeline failing or being aborted
+	EventPipelineFailed = "pipeline-failed"
+	// EventPromotion is the notification of a Pull Request promoting a version to an environment
+	EventPromotion = "promotion"
+	// EventRelease is the notification of a new release
+	EventRelease = "release"
+
+	// ChatNotificationsConfigKey is the key of the ChatConfig in the ConfigMapChatNotifications ConfigMap
+	ChatNotificationsConfigKey = "config.yaml"
+
+	colorRunning = "#439FE0"
+	colorGood    = "good"
+	colorDanger  = "danger"
+
+	maxReleaseIssues = 10
+
+	// repositoryConfigTTL is how long the chat configuration of a repository is cached before it is loaded again
+	repositoryConfigTTL = 10 * time.Minute
+)
+
+// NotificationEvents are the kinds of notification which can be routed to channels
+var NotificationEvents = []string{EventPipelineStarted, EventPipelineSucceeded, EventPipelineFailed, EventPromotion, EventRelease}
+
+// Notifier posts notifications of pipelines, promotions and releases to the channels of a ChatConfig. The completion
+// of a pipeline updates the message posted when it started and is replied in its thread along with its promotions
+type Notifier struct {
+	Provider ChatProvider
+	Config   *config.ChatConfig
+	// RepositoryConfig loads the 'chat' section of the 'jenkins-x.yml' of a repository, if any. The pipelines of the
+	// repository are also posted to its developer channel and its promotions and releases to its user channel
+	RepositoryConfig func(gitURL string, owner string, repository string) (*config.ChatConfig, error)
+	// Since is the time before which completed pipelines, promotions and releases are ignored so that they are not
+	// notified again when the notifier restarts
+	Since time.Time
+
+	lock              sync.Mutex
+	events            map[string]string
+	threads           map[string]map[string]*MessageReference
+	promotions        map[string]map[string]bool
+	releases          map[string]bool
+	repositoryConfigs map[string]*repositoryConfig
+}
+
+type repositoryConfig struct {
+	config *config.ChatConfig
+	loaded time.Time
+}
+
+// NewNotifier creates a notifier posting to the chat provider
+func NewNotifier(provider ChatProvider, chatConfig *config.ChatConfig) *Notifier {
+	return &Notifier{
+		Provider:          provider,
+		Config:            chatConfig,
+		Since:             time.Now(),
+		events:            map[string]string{},
+		threads:           map[string]map[string]*MessageReference{},
+		promotions:        map[string]map[string]bool{},
+		releases:          map[string]bool{},
+		repositoryConfigs: map[string]*repositoryConfig{},
+	}
+}
+
+// LoadChatConfig loads the ChatConfig from the ConfigMapChatNotifications ConfigMap in the namespace returning nil if
+// there is no ConfigMap
+func LoadChatConfig(kubeClient kubernetes.Interface, ns string) (*config.ChatConfig, error) {
+	configMap, err := kubeClient.CoreV1().ConfigMaps(ns).Get(kube.ConfigMapChatNotifications, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get ConfigMap %s in namespace %s", kube.ConfigMapChatNotifications, ns)
+	}
+	chatConfig := &config.ChatConfig{}
+	err = yaml.Unmarshal([]byte(configMap.Data[ChatNotificationsConfigKey]), chatConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the %s in ConfigMap %s in namespace %s", ChatNotificationsConfigKey, kube.ConfigMapChatNotifications, ns)
+	}
+	return chatConfig, nil
+}
+
+// OnPipelineActivity notifies the start or completion of the pipeline and any new promotion Pull Requests
+func (n *Notifier) OnPipelineActivity(activity *v1.PipelineActivity) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	var errs []error
+	errs = append(errs, n.notifyPipeline(activity))
+	spec := &activity.Spec
+	promotions := n.promotions[activity.Name]
+	if promotions == nil {
+		promotions = map[string]bool{}
+		n.promotions[activity.Name] = promotions
+	}
+	for _, step := range spec.Steps {
+		promote := step.Promote
+		if promote == nil || promote.PullRequest == nil || promote.PullRequest.PullRequestURL == "" {
+			continue
+		}
+		if promotions[promote.Environment] {
+			continue
+		}
+		if n.isBefore(promote.PullRequest.StartedTimestamp, activity) {
+			promotions[promote.Environment] = true
+			continue
+		}
+		channels, err := n.channels(spec.GitURL, spec.GitOwner, spec.GitRepository, spec.GitBranch, EventPromotion)
+		posted, postErr := n.post(activity.Name, channels, promotionMessage(activity, promote))
+		if isSent(posted, channels, err) {
+			promotions[promote.Environment] = true
+		}
+		errs = append(errs, err, postErr)
+	}
+	// the thread is only needed until the promotions of the completed pipeline have been replied in it
+	if n.events[activity.Name] != EventPipelineStarted {
+		delete(n.threads, activity.Name)
+	}
+	return util.CombineErrors(errs...)
+}
+
+// OnPipelineActivityDeleted forgets the notifications of a deleted pipeline
+func (n *Notifier) OnPipelineActivityDeleted(name string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	delete(n.events, name)
+	delete(n.threads, name)
+	delete(n.promotions, name)
+}
+
+// OnRelease notifies a new release
+func (n *Notifier) OnRelease(release *v1.Release) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.releases[release.Name] {
+		return nil
+	}
+	if release.CreationTimestamp.Time.Before(n.Since) {
+		n.releases[release.Name] = true
+		return nil
+	}
+	spec := &release.Spec
+	channels, err := n.channels(spec.GitHTTPURL, spec.GitOwner, spec.GitRepository, "", EventRelease)
+	posted, postErr := n.post("", channels, releaseMessage(release))
+	if isSent(posted, channels, err) {
+		n.releases[release.Name] = true
+	}
+	return util.CombineErrors(err, postErr)
+}
+
+// OnReleaseDeleted forgets the notification of a deleted release
+func (n *Notifier) OnReleaseDeleted(name string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	delete(n.releases, name)
+}
+
+func (n *Notifier) notifyPipeline(activity *v1.PipelineActivity) error {
+	spec := &activity.Spec
+	event := pipelineEvent(spec.Status)
+	if event == "" || n.events[activity.Name] == event {
+		return nil
+	}
+	message := pipelineMessage(activity, event)
+	if event == EventPipelineStarted {
+		if n.isBefore(spec.StartedTimestamp, activity) {
+			n.events[activity.Name] = event
+			return nil
+		}
+		channels, err := n.channels(spec.GitURL, spec.GitOwner, spec.GitRepository, spec.GitBranch, event)
+		threads := map[string]*MessageReference{}
+		errs := []error{err}
+		for _, channel := range channels {
+			ref, err := n.Provider.PostMessage(channel, message)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			threads[channel] = ref
+		}
+		if isSent(len(threads) > 0, channels, err) {
+			n.events[activity.Name] = event
+			n.threads[activity.Name] = threads
+		}
+		return util.CombineErrors(errs...)
+	}
+
+	if n.isBefore(spec.CompletedTimestamp, activity) {
+		n.events[activity.Name] = event
+		return nil
+	}
+	channels, err := n.channels(spec.GitURL, spec.GitOwner, spec.GitRepository, spec.GitBranch, event)
+	threads := n.threads[activity.Name]
+	errs := []error{err}
+	posted := false
+	for _, channel := range channels {
+		ref := threads[channel]
+		if ref == nil {
+			_, err := n.Provider.PostMessage(channel, message)
+			errs = append(errs, err)
+			posted = posted || err == nil
+			continue
+		}
+		_, err := n.Provider.UpdateMessage(ref, message)
+		if errors.Cause(err) == ErrNotSupported {
+			// the message cannot be updated nor replied to so the completion is only posted as a new message
+			_, err = n.Provider.PostMessage(channel, message)
+			errs = append(errs, err)
+			posted = posted || err == nil
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			posted = true
+		}
+		_, err = n.Provider.PostMessage(channel, &Message{
+			Text:     message.Text,
+			ThreadID: ref.ID,
+		})
+		errs = append(errs, err)
+		posted = posted || err == nil
+	}
+	if isSent(posted, channels, err) {
+		n.events[activity.Name] = event
+	}
+	return util.CombineErrors(errs...)
+}
+
+// post posts the message to the channels replying in the thread of the pipeline if it has one in the channel. It
+// returns true if the message was posted to at least one of the channels
+func (n *Notifier) post(activityName string, channels []string, message *Message) (bool, error) {
+	var errs []error
+	posted := false
+	for _, channel := range channels {
+		m := *message
+		if ref := n.threads[activityName][channel]; ref != nil {
+			m.ThreadID = ref.ID
+		}
+		_, err := n.Provider.PostMessage(channel, &m)
+		errs = append(errs, err)
+		posted = posted || err == nil
+	}
+	return posted, util.CombineErrors(errs...)
+}
+
+// isSent returns true if a notification has been sent so that it is not posted again, which is once it has been posted
+// to at least one of its channels or if it has no channels. If its channels could not be found it is posted again on
+// the next change
+func isSent(posted bool, channels []string, channelsErr error) bool {
+	return posted || (len(channels) == 0 && channelsErr == nil)
+}
+
+// channels returns the channels of the notification routed by the ChatConfig along with the developer or user channel
+// of the chat configuration of the repository
+func (n *Notifier) channels(gitURL string, owner string, repository string, branch string, event string) ([]string, error) {
+	if n.Config == nil {
+		return nil, nil
+	}
+	answer := n.Config.NotificationChannels(owner, repository, branch, event)
+	repoConfig, err := n.repositoryConfig(gitURL, owner, repository)
+	if repoConfig == nil || (repoConfig.URL != "" && strings.TrimSuffix(repoConfig.URL, "/") != strings.TrimSuffix(n.Config.URL, "/")) {
+		// the repository posts to another chat server
+		return answer, err
+	}
+	channel := repoConfig.DeveloperChannel
+	if (event == EventPromotion || event == EventRelease) && repoConfig.UserChannel != "" {
+		channel = repoConfig.UserChannel
+	}
+	if channel != "" && util.StringArrayIndex(answer, channel) < 0 {
+		answer = append(answer, channel)
+	}
+	return answer, err
+}
+
+// repositoryConfig returns the cached chat configuration of the repository loading it again once it expires
+func (n *Notifier) repositoryConfig(gitURL string, owner string, repository string) (*config.ChatConfig, error) {
+	if n.RepositoryConfig == nil || owner == "" || repository == "" {
+		return nil, nil
+	}
+	key := owner + "/" + repository
+	cached := n.repositoryConfigs[key]
+	if cached != nil && time.Since(cached.loaded) < repositoryConfigTTL {
+		return cached.config, nil
+	}
+	chatConfig, err := n.RepositoryConfig(gitURL, owner, repository)
+	// a failure is cached too so that the repository is not loaded on every notification
+	n.repositoryConfigs[key] = &repositoryConfig{config: chatConfig, loaded: time.Now()}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the chat configuration of repository %s", key)
+	}
+	return chatConfig, nil
+}
+
+// isBefore returns true if the time, or the creation of the activity if there is no time, is before the notifier started
+func (n *Notifier) isBefore(t *metav1.Time, activity *v1.PipelineActivity) bool {
+	if t == nil {
+		t = &activity.CreationTimestamp
+	}
+	return t.Time.Before(n.Since)
+}
+
+func pipelineEvent(status v1.ActivityStatusType) string {
+	switch status {
+	case v1.ActivityStatusTypeRunning:
+		return EventPipelineStarted
+	case v1.ActivityStatusTypeSucceeded:
+		return EventPipelineSucceeded
+	case v1.ActivityStatusTypeFailed, v1.ActivityStatusTypeError, v1.ActivityStatusTypeAborted:
+		return EventPipelineFailed
+	default:
+		return ""
+	}
+}
+
+func pipelineMessage(activity *v1.PipelineActivity, event string) *Message {
+	spec := &activity.Spec
+	name := fmt.Sprintf("%s/%s/%s #%s", spec.GitOwner, spec.GitRepository, spec.GitBranch, spec.Build)
+	link := spec.BuildLogsURL
+	if link == "" {
+		link = spec.BuildURL
+	}
+	text := ""
+	color := colorRunning
+	switch event {
+	case EventPipelineStarted:
+		text = fmt.Sprintf("Pipeline %s started", name)
+	case EventPipelineSucceeded:
+		text = fmt.Sprintf("Pipeline %s succeeded", name)
+		color = colorGood
+	default:
+		text = fmt.Sprintf("Pipeline %s %s", name, strings.ToLower(string(spec.Status)))
+		color = colorDanger
+	}
+	attachment := Attachment{
+		Title:     name,
+		TitleLink: link,
+		Color:     color,
+		Fields: []AttachmentField{
+			{Title: "Status", Value: string(spec.Status), Short: true},
+		},
+	}
+	if spec.Version != "" {
+		attachment.Fields = append(attachment.Fields, AttachmentField{Title: "Version", Value: spec.Version, Short: true})
+	}
+	if spec.Author != "" {
+		attachment.Fields = append(attachment.Fields, AttachmentField{Title: "Author", Value: spec.Author, Short: true})
+	}
+	if spec.PullTitle != "" {
+		attachment.Fields = append(attachment.Fields, AttachmentField{Title: "Pull Request", Value: spec.PullTitle})
+	}
+	if spec.LastCommitMessage != "" {
+		commit := strings.SplitN(strings.TrimSpace(spec.LastCommitMessage), "\n", 2)[0]
+		if spec.LastCommitURL != "" {
+			commit = fmt.Sprintf("<%s|%s>", spec.LastCommitURL, commit)
+		}
+		attachment.Fields = append(attachment.Fields, AttachmentField{Title: "Commit", Value: commit})
+	}
+	return &Message{
+		Text:        text,
+		Attachments: []Attachment{attachment},
+	}
+}
+
+func promotionMessage(activity *v1.PipelineActivity, promote *v1.PromoteActivityStep) *Message {
+	spec := &activity.Spec
+	text := fmt.Sprintf("Promoting %s/%s version %s to %s", spec.GitOwner, spec.GitRepository, spec.Version, promote.Environment)
+	return &Message{
+		Text: text,
+		Attachments: []Attachment{
+			{
+				Title:     "Promotion Pull Request",
+				TitleLink: promote.PullRequest.PullRequestURL,
+				Color:     colorRunning,
+				Fields: []AttachmentField{
+					{Title: "Environment", Value: promote.Environment, Short: true},
+					{Title: "Version", Value: spec.Version, Short: true},
+				},
+			},
+		},
+	}
+}
+
+func releaseMessage(release *v1.Release) *Message {
+	spec := &release.Spec
+	name := spec.Name
+	if name == "" {
+		name = spec.GitOwner + "/" + spec.GitRepository
+	}
+	link := spec.ReleaseNotesURL
+	if link == "" {
+		link = spec.GitHTTPURL
+	}
+	var issues []string
+	for i, issue := range spec.Issues {
+		if i >= maxReleaseIssues {
+			issues = append(issues, fmt.Sprintf("and %d more", len(spec.Issues)-maxReleaseIssues))
+			break
+		}
+		issues = append(issues, fmt.Sprintf("• <%s|%s> %s", issue.URL, issue.ID, issue.Title))
+	}
+	return &Message{
+		Text: fmt.Sprintf("Released %s %s", name, spec.Version),
+		Attachments: []Attachment{
+			{
+				Title:     fmt.Sprintf("%s %s", name, spec.Version),
+				TitleLink: link,
+				Text:      strings.Join(issues, "\n"),
+				Color:     colorGood,
+				Fields: []AttachmentField{
+					{Title: "Commits", Value: strconv.Itoa(len(spec.Commits)), Short: true},
+					{Title: "Pull Requests", Value: strconv.Itoa(len(spec.PullRequests)), Short: true},
+				},
+			},
+		},
+	}
+}
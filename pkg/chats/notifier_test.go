@@ -0,0 +1,396 @@
+package chats_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/chats"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type postedMessage struct {
+	channel string
+	updated string
+	message chats.Message
+}
+
+type fakeChatProvider struct {
+	messages []postedMessage
+	// noUpdates makes UpdateMessage return ErrNotSupported like a Microsoft Teams incoming webhook
+	noUpdates bool
+	// failPosts makes PostMessage fail like an unavailable chat server
+	failPosts bool
+}
+
+func (p *fakeChatProvider) GetChannelMetrics(name string) (*chats.ChannelMetrics, error) {
+	return &chats.ChannelMetrics{Name: name}, nil
+}
+
+func (p *fakeChatProvider) AuthenticatedUser() (string, error) {
+	return "bot", nil
+}
+
+func (p *fakeChatProvider) PostMessage(channel string, message *chats.Message) (*chats.MessageReference, error) {
+	if p.failPosts {
+		return nil, errors.Errorf("failed to post to %s", channel)
+	}
+	p.messages = append(p.messages, postedMessage{channel: channel, message: *message})
+	return &chats.MessageReference{Channel: channel, ID: strconv.Itoa(len(p.messages))}, nil
+}
+
+func (p *fakeChatProvider) UpdateMessage(ref *chats.MessageReference, message *chats.Message) (*chats.MessageReference, error) {
+	if p.noUpdates {
+		return nil, chats.ErrNotSupported
+	}
+	p.messages = append(p.messages, postedMessage{channel: ref.Channel, updated: ref.ID, message: *message})
+	return ref, nil
+}
+
+func TestNotifierPipelineActivity(t *testing.T) {
+	t.Parallel()
+	provider := &fakeChatProvider{}
+	notifier := chats.NewNotifier(provider, &config.ChatConfig{
+		Notifications: []config.ChatNotificationRule{
+			{
+				Channel:      "#builds",
+				Repositories: []string{"myorg/*"},
+			},
+		},
+	})
+	notifier.Since = time.Now().Add(-time.Minute)
+
+	now := metav1.Now()
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "myorg-myapp-master-1",
+			CreationTimestamp: now,
+		},
+		Spec: v1.PipelineActivitySpec{
+			GitOwner:         "myorg",
+			GitRepository:    "myapp",
+			GitBranch:        "master",
+			Build:            "1",
+			Status:           v1.ActivityStatusTypeRunning,
+			StartedTimestamp: &now,
+		},
+	}
+	require.NoError(t, notifier.OnPipelineActivity(activity))
+	// the same status is only notified once
+	require.NoError(t, notifier.OnPipelineActivity(activity))
+	require.Len(t, provider.messages, 1)
+	assert.Equal(t, "#builds", provider.messages[0].channel)
+	assert.Equal(t, "Pipeline myorg/myapp/master #1 started", provider.messages[0].message.Text)
+
+	activity.Spec.Version = "1.0.0"
+	activity.Spec.Steps = []v1.PipelineActivityStep{
+		{
+			Kind: v1.ActivityStepKindTypePromote,
+			Promote: &v1.PromoteActivityStep{
+				Environment: "staging",
+				PullRequest: &v1.PromotePullRequestStep{
+					CoreActivityStep: v1.CoreActivityStep{StartedTimestamp: &now},
+					PullRequestURL:   "https://github.com/myorg/environment-staging/pull/1",
+				},
+			},
+		},
+	}
+	require.NoError(t, notifier.OnPipelineActivity(activity))
+	require.Len(t, provider.messages, 2)
+	assert.Equal(t, "1", provider.messages[1].message.ThreadID)
+	assert.Equal(t, "Promoting myorg/myapp version 1.0.0 to staging", provider.messages[1].message.Text)
+	assert.Equal(t, "https://github.com/myorg/environment-staging/pull/1", provider.messages[1].message.Attachments[0].TitleLink)
+
+	activity.Spec.Status = v1.ActivityStatusTypeFailed
+	activity.Spec.CompletedTimestamp = &now
+	require.NoError(t, notifier.OnPipelineActivity(activity))
+	require.Len(t, provider.messages, 4)
+	assert.Equal(t, "1", provider.messages[2].updated)
+	assert.Equal(t, "danger", provider.messages[2].message.Attachments[0].Color)
+	assert.Equal(t, "1", provider.messages[3].message.ThreadID)
+	assert.Equal(t, "Pipeline myorg/myapp/master #1 failed", provider.messages[3].message.Text)
+}
+
+func TestNotifierPostsCompletionOnceWhenUpdatesAreNotSupported(t *testing.T) {
+	t.Parallel()
+	provider := &fakeChatProvider{noUpdates: true}
+	notifier := chats.NewNotifier(provider, &config.ChatConfig{
+		DeveloperChannel: "https://example.webhook.office.com/webhookb2/abc",
+	})
+	notifier.Since = time.Now().Add(-time.Minute)
+
+	now := metav1.Now()
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "myorg-myapp-master-1",
+			CreationTimestamp: now,
+		},
+		Spec: v1.PipelineActivitySpec{
+			GitOwner:         "myorg",
+			GitRepository:    "myapp",
+			GitBranch:        "master",
+			Build:            "1",
+			Status:           v1.ActivityStatusTypeRunning,
+			StartedTimestamp: &now,
+		},
+	}
+	require.NoError(t, notifier.OnPipelineActivity(activity))
+
+	activity.Spec.Status = v1.ActivityStatusTypeSucceeded
+	activity.Spec.CompletedTimestamp = &now
+	require.NoError(t, notifier.OnPipelineActivity(activity))
+	require.Len(t, provider.messages, 2)
+	assert.Equal(t, "Pipeline myorg/myapp/master #1 succeeded", provider.messages[1].message.Text)
+	assert.Equal(t, "", provider.messages[1].message.ThreadID)
+	assert.Equal(t, "good", provider.messages[1].message.Attachments[0].Color)
+}
+
+func TestNotifierIgnoresOldActivitiesAndReleases(t *testing.T) {
+	t.Parallel()
+	provider := &fakeChatProvider{}
+	notifier := chats.NewNotifier(provider, &config.ChatConfig{
+		DeveloperChannel: "#dev",
+	})
+
+	old := metav1.NewTime(time.Now().Add(-time.Hour))
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "myorg-myapp-master-1",
+			CreationTimestamp: old,
+		},
+		Spec: v1.PipelineActivitySpec{
+			GitOwner:           "myorg",
+			GitRepository:      "myapp",
+			GitBranch:          "master",
+			Build:              "1",
+			Status:             v1.ActivityStatusTypeSucceeded,
+			StartedTimestamp:   &old,
+			CompletedTimestamp: &old,
+		},
+	}
+	require.NoError(t, notifier.OnPipelineActivity(activity))
+
+	release := &v1.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "myapp-1.0.0",
+			CreationTimestamp: old,
+		},
+		Spec: v1.ReleaseSpec{
+			Name:          "myapp",
+			Version:       "1.0.0",
+			GitOwner:      "myorg",
+			GitRepository: "myapp",
+		},
+	}
+	require.NoError(t, notifier.OnRelease(release))
+	assert.Empty(t, provider.messages)
+
+	release.Name = "myapp-1.0.1"
+	release.Spec.Version = "1.0.1"
+	release.CreationTimestamp = metav1.Now()
+	release.Spec.Issues = []v1.IssueSummary{
+		{ID: "123", URL: "https://github.com/myorg/myapp/issues/123", Title: "Fix the thing"},
+	}
+	require.NoError(t, notifier.OnRelease(release))
+	require.NoError(t, notifier.OnRelease(release))
+	require.Len(t, provider.messages, 1)
+	assert.Equal(t, "#dev", provider.messages[0].channel)
+	assert.Equal(t, "Released myapp 1.0.1", provider.messages[0].message.Text)
+	assert.Contains(t, provider.messages[0].message.Attachments[0].Text, "Fix the thing")
+}
+
+func TestNotifierRepliesPromotionsOfCompletedPipelineInThread(t *testing.T) {
+	t.Parallel()
+	provider := &fakeChatProvider{}
+	notifier := chats.NewNotifier(provider, &config.ChatConfig{
+		DeveloperChannel: "#dev",
+	})
+	notifier.Since = time.Now().Add(-time.Minute)
+
+	now := metav1.Now()
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "myorg-myapp-master-1",
+			CreationTimestamp: now,
+		},
+		Spec: v1.PipelineActivitySpec{
+			GitOwner:         "myorg",
+			GitRepository:    "myapp",
+			GitBranch:        "master",
+			Build:            "1",
+			Version:          "1.0.0",
+			Status:           v1.ActivityStatusTypeRunning,
+			StartedTimestamp: &now,
+		},
+	}
+	require.NoError(t, notifier.OnPipelineActivity(activity))
+
+	// the promotion is only seen in the same update as the completion of the pipeline
+	activity.Spec.Status = v1.ActivityStatusTypeSucceeded
+	activity.Spec.CompletedTimestamp = &now
+	activity.Spec.Steps = []v1.PipelineActivityStep{
+		{
+			Kind: v1.ActivityStepKindTypePromote,
+			Promote: &v1.PromoteActivityStep{
+				Environment: "staging",
+				PullRequest: &v1.PromotePullRequestStep{
+					CoreActivityStep: v1.CoreActivityStep{StartedTimestamp: &now},
+					PullRequestURL:   "https://github.com/myorg/environment-staging/pull/1",
+				},
+			},
+		},
+	}
+	require.NoError(t, notifier.OnPipelineActivity(activity))
+	require.Len(t, provider.messages, 4)
+	assert.Equal(t, "Promoting myorg/myapp version 1.0.0 to staging", provider.messages[3].message.Text)
+	assert.Equal(t, "1", provider.messages[3].message.ThreadID)
+
+	// a pipeline which is run again with the same name is notified again once the old one is deleted
+	notifier.OnPipelineActivityDeleted(activity.Name)
+	require.NoError(t, notifier.OnPipelineActivity(activity))
+	require.Len(t, provider.messages, 6)
+	assert.Equal(t, "Promoting myorg/myapp version 1.0.0 to staging", provider.messages[5].message.Text)
+	assert.Equal(t, "", provider.messages[5].message.ThreadID)
+}
+
+func TestNotifierPostsToRepositoryChannels(t *testing.T) {
+	t.Parallel()
+	provider := &fakeChatProvider{}
+	notifier := chats.NewNotifier(provider, &config.ChatConfig{
+		URL: "https://myorg.slack.com/",
+		Notifications: []config.ChatNotificationRule{
+			{
+				Channel: "#builds",
+				Events:  []string{chats.EventPipelineStarted, chats.EventRelease},
+			},
+		},
+	})
+	notifier.Since = time.Now().Add(-time.Minute)
+	loads := 0
+	notifier.RepositoryConfig = func(gitURL string, owner string, repository string) (*config.ChatConfig, error) {
+		loads++
+		assert.Equal(t, "https://github.com/myorg/myapp.git", gitURL)
+		if repository == "other" {
+			return &config.ChatConfig{URL: "https://other.slack.com/", DeveloperChannel: "#other"}, nil
+		}
+		return &config.ChatConfig{URL: "https://myorg.slack.com", DeveloperChannel: "#myapp-dev", UserChannel: "#myapp"}, nil
+	}
+
+	now := metav1.Now()
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "myorg-myapp-master-1",
+			CreationTimestamp: now,
+		},
+		Spec: v1.PipelineActivitySpec{
+			GitURL:           "https://github.com/myorg/myapp.git",
+			GitOwner:         "myorg",
+			GitRepository:    "myapp",
+			GitBranch:        "master",
+			Build:            "1",
+			Status:           v1.ActivityStatusTypeRunning,
+			StartedTimestamp: &now,
+		},
+	}
+	require.NoError(t, notifier.OnPipelineActivity(activity))
+	require.Len(t, provider.messages, 2)
+	assert.Equal(t, "#builds", provider.messages[0].channel)
+	assert.Equal(t, "#myapp-dev", provider.messages[1].channel)
+
+	release := &v1.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "myapp-1.0.0",
+			CreationTimestamp: now,
+		},
+		Spec: v1.ReleaseSpec{
+			Name:          "myapp",
+			Version:       "1.0.0",
+			GitHTTPURL:    "https://github.com/myorg/myapp.git",
+			GitOwner:      "myorg",
+			GitRepository: "myapp",
+		},
+	}
+	require.NoError(t, notifier.OnRelease(release))
+	require.Len(t, provider.messages, 4)
+	assert.Equal(t, "#builds", provider.messages[2].channel)
+	assert.Equal(t, "#myapp", provider.messages[3].channel)
+	assert.Equal(t, 1, loads, "the chat configuration of the repository should be cached")
+
+	// the channels of a repository using another chat server are ignored
+	activity.Name = "myorg-other-master-1"
+	activity.Spec.GitRepository = "other"
+	require.NoError(t, notifier.OnPipelineActivity(activity))
+	require.Len(t, provider.messages, 5)
+	assert.Equal(t, "#builds", provider.messages[4].channel)
+}
+
+func TestNotifierPostsAgainAfterFailingToPost(t *testing.T) {
+	t.Parallel()
+	provider := &fakeChatProvider{failPosts: true}
+	notifier := chats.NewNotifier(provider, &config.ChatConfig{
+		DeveloperChannel: "#builds",
+	})
+	notifier.Since = time.Now().Add(-time.Minute)
+
+	now := metav1.Now()
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "myorg-myapp-master-1",
+			CreationTimestamp: now,
+		},
+		Spec: v1.PipelineActivitySpec{
+			GitOwner:         "myorg",
+			GitRepository:    "myapp",
+			GitBranch:        "master",
+			Build:            "1",
+			Version:          "1.0.0",
+			Status:           v1.ActivityStatusTypeRunning,
+			StartedTimestamp: &now,
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypePromote,
+					Promote: &v1.PromoteActivityStep{
+						Environment: "staging",
+						PullRequest: &v1.PromotePullRequestStep{
+							CoreActivityStep: v1.CoreActivityStep{StartedTimestamp: &now},
+							PullRequestURL:   "https://github.com/myorg/environment-staging/pull/1",
+						},
+					},
+				},
+			},
+		},
+	}
+	release := &v1.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "myapp-1.0.0",
+			CreationTimestamp: now,
+		},
+		Spec: v1.ReleaseSpec{
+			Name:    "myapp",
+			Version: "1.0.0",
+		},
+	}
+	assert.Error(t, notifier.OnPipelineActivity(activity))
+	assert.Error(t, notifier.OnRelease(release))
+	require.Len(t, provider.messages, 0)
+
+	// the notifications which failed are posted on the next change
+	provider.failPosts = false
+	require.NoError(t, notifier.OnPipelineActivity(activity))
+	require.NoError(t, notifier.OnRelease(release))
+	require.Len(t, provider.messages, 3)
+	assert.Equal(t, "Pipeline myorg/myapp/master #1 started", provider.messages[0].message.Text)
+	assert.Equal(t, "Promoting myorg/myapp version 1.0.0 to staging", provider.messages[1].message.Text)
+	assert.Equal(t, "1", provider.messages[1].message.ThreadID)
+	assert.Equal(t, "Released myapp 1.0.0", provider.messages[2].message.Text)
+
+	// once posted they are not posted again
+	require.NoError(t, notifier.OnPipelineActivity(activity))
+	require.NoError(t, notifier.OnRelease(release))
+	require.Len(t, provider.messages, 3)
+}
@@ -5,11 +5,59 @@ import (
 
 	"github.com/jenkins-x/jx/pkg/auth"
 	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
 )
 
+// ErrNotSupported is returned by a ChatProvider for an operation which is not supported for the channel, such as
+// updating a message posted to a Microsoft Teams incoming webhook
+var ErrNotSupported = errors.New("not supported by the chat provider")
+
 // ChatProvider represents an integration interface to chat
 type ChatProvider interface {
 	GetChannelMetrics(name string) (*ChannelMetrics, error)
+
+	// AuthenticatedUser returns the name of the user the provider is authenticated as so that credentials can be verified
+	AuthenticatedUser() (string, error)
+
+	// PostMessage posts a message to the channel, or as a reply in a thread if the message has a ThreadID
+	PostMessage(channel string, message *Message) (*MessageReference, error)
+
+	// UpdateMessage replaces the text and attachments of a message which was posted earlier, returning ErrNotSupported
+	// if the message cannot be updated
+	UpdateMessage(ref *MessageReference, message *Message) (*MessageReference, error)
+}
+
+// Message is a message posted to a chat channel. Links in its text use the Slack format <url|text> which other
+// providers convert to their own format
+type Message struct {
+	Text        string
+	Attachments []Attachment
+	// ThreadID is the ID of the message to reply to in a thread
+	ThreadID string
+}
+
+// Attachment is rich content attached to a message
+type Attachment struct {
+	Title     string
+	TitleLink string
+	Text      string
+	// Color is the color of the attachment such as 'good', 'warning', 'danger' or a hex color code
+	Color  string
+	Fields []AttachmentField
+	Footer string
+}
+
+// AttachmentField is a field of an attachment
+type AttachmentField struct {
+	Title string
+	Value string
+	Short bool
+}
+
+// MessageReference identifies a posted message so that it can be updated or replied to
+type MessageReference struct {
+	Channel string
+	ID      string
 }
 
 // ChannelMetrics metrics for a channel
@@ -30,6 +78,10 @@ func CreateChatProvider(kind string, server *auth.AuthServer, userAuth *auth.Use
 	switch kind {
 	case Slack:
 		return CreateSlackChatProvider(server, userAuth, batchMode)
+	case Mattermost:
+		return CreateMattermostChatProvider(server, userAuth, batchMode)
+	case MicrosoftTeams:
+		return CreateTeamsChatProvider(server, userAuth, batchMode)
 	default:
 		return nil, fmt.Errorf("Unsupported chat provider kind: %s", kind)
 	}
@@ -39,6 +91,10 @@ func ProviderAccessTokenURL(kind string, url string) string {
 	switch kind {
 	case Slack:
 		return "https://my.slack.com/services/new/bot"
+	case Mattermost:
+		return util.UrlJoin(url, "_redirect/integrations/bots")
+	case MicrosoftTeams:
+		return "https://portal.azure.com/#blade/Microsoft_AAD_RegisteredApps/ApplicationsListBlade"
 	default:
 		return ""
 	}
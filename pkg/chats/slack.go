@@ -8,6 +8,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
 )
 
 type SlackChatProvider struct {
@@ -62,3 +63,66 @@ func (c *SlackChatProvider) GetChannelMetrics(name string) (*ChannelMetrics, err
 	metrics.URL = util.UrlJoin(c.Server.URL, "messages", info.ID)
 	return metrics, nil
 }
+
+// AuthenticatedUser returns the name of the user the Slack token belongs to
+func (c *SlackChatProvider) AuthenticatedUser() (string, error) {
+	response, err := c.SlackClient.AuthTest()
+	if err != nil {
+		return "", err
+	}
+	return response.User, nil
+}
+
+// PostMessage posts the message to the Slack channel as the bot user
+func (c *SlackChatProvider) PostMessage(channel string, message *Message) (*MessageReference, error) {
+	params := slack.PostMessageParameters{
+		AsUser:          true,
+		Attachments:     toSlackAttachments(message.Attachments),
+		ThreadTimestamp: message.ThreadID,
+	}
+	channelID, timestamp, err := c.SlackClient.PostMessage(channel, message.Text, params)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to post message to Slack channel %s", channel)
+	}
+	return &MessageReference{
+		Channel: channelID,
+		ID:      timestamp,
+	}, nil
+}
+
+// UpdateMessage updates the Slack message
+func (c *SlackChatProvider) UpdateMessage(ref *MessageReference, message *Message) (*MessageReference, error) {
+	channelID, timestamp, _, err := c.SlackClient.SendMessage(ref.Channel,
+		slack.MsgOptionUpdate(ref.ID),
+		slack.MsgOptionText(message.Text, false),
+		slack.MsgOptionAttachments(toSlackAttachments(message.Attachments)...))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update message %s in Slack channel %s", ref.ID, ref.Channel)
+	}
+	return &MessageReference{
+		Channel: channelID,
+		ID:      timestamp,
+	}, nil
+}
+
+func toSlackAttachments(attachments []Attachment) []slack.Attachment {
+	answer := []slack.Attachment{}
+	for _, a := range attachments {
+		attachment := slack.Attachment{
+			Title:     a.Title,
+			TitleLink: a.TitleLink,
+			Text:      a.Text,
+			Color:     a.Color,
+			Footer:    a.Footer,
+		}
+		for _, f := range a.Fields {
+			attachment.Fields = append(attachment.Fields, slack.AttachmentField{
+				Title: f.Title,
+				Value: f.Value,
+				Short: f.Short,
+			})
+		}
+		answer = append(answer, attachment)
+	}
+	return answer
+}
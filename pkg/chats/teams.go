@@ -0,0 +1,349 @@
+package chats
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	// TeamsGraphURL is the default base URL of the Microsoft Graph API used by the TeamsChatProvider
+	TeamsGraphURL = "https://graph.microsoft.com/v1.0"
+	// TeamsLoginURL is the default base URL of the Microsoft identity platform used to get Microsoft Graph tokens
+	TeamsLoginURL = "https://login.microsoftonline.com"
+
+	teamsGraphScope = "https://graph.microsoft.com/.default"
+)
+
+// teamsColors are the hex colors of the named attachment colors
+var teamsColors = map[string]string{
+	"good":    "2EB886",
+	"warning": "DAA038",
+	"danger":  "A30200",
+}
+
+// TeamsChatProvider is a ChatProvider for Microsoft Teams. Messages are posted to channels through their incoming
+// webhooks, so the channels messages are posted to are the URLs of the incoming webhooks. The Microsoft Graph API only
+// allows delegated user tokens, which expire after about an hour, to post channel messages so it is not used for
+// posting. Messages posted to an incoming webhook cannot be updated or replied to so replies are posted as new
+// messages.
+//
+// The metrics of channels named 'team/channel' are read with the Microsoft Graph API, using the client credentials of
+// an Azure AD application if the user name is '<tenant-id>/<client-id>', in which case the password or API token of
+// the user is the client secret of the application. The tokens are fetched and refreshed as they expire. Otherwise
+// the API token of the user is used as a static bearer token
+type TeamsChatProvider struct {
+	Server     *auth.AuthServer
+	UserAuth   *auth.UserAuth
+	HTTPClient *http.Client
+	// GraphURL is the base URL of the Microsoft Graph API
+	GraphURL string
+	// LoginURL is the base URL of the Microsoft identity platform used to get tokens with the client credentials
+	LoginURL string
+
+	lock        sync.Mutex
+	channels    map[string]*teamsChannel
+	tokenLock   sync.Mutex
+	tokenSource oauth2.TokenSource
+}
+
+type teamsUser struct {
+	DisplayName       string `json:"displayName"`
+	UserPrincipalName string `json:"userPrincipalName"`
+}
+
+type teamsTeam struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+type teamsTeams struct {
+	Value []teamsTeam `json:"value"`
+}
+
+type teamsChannel struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	WebURL      string `json:"webUrl"`
+
+	teamID string
+}
+
+type teamsChannels struct {
+	Value []teamsChannel `json:"value"`
+}
+
+type teamsMember struct {
+	DisplayName string `json:"displayName"`
+}
+
+type teamsMembers struct {
+	Value []teamsMember `json:"value"`
+}
+
+// teamsMessageCard is the legacy actionable message card format accepted by incoming webhooks
+type teamsMessageCard struct {
+	Type       string                    `json:"@type"`
+	Context    string                    `json:"@context"`
+	Summary    string                    `json:"summary"`
+	ThemeColor string                    `json:"themeColor,omitempty"`
+	Text       string                    `json:"text"`
+	Sections   []teamsMessageCardSection `json:"sections,omitempty"`
+}
+
+type teamsMessageCardSection struct {
+	ActivityTitle string                 `json:"activityTitle,omitempty"`
+	Text          string                 `json:"text,omitempty"`
+	Facts         []teamsMessageCardFact `json:"facts,omitempty"`
+}
+
+type teamsMessageCardFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CreateTeamsChatProvider creates a ChatProvider for Microsoft Teams. A token is only required to read the metrics of
+// channels with the Microsoft Graph API
+func CreateTeamsChatProvider(server *auth.AuthServer, userAuth *auth.UserAuth, batchMode bool) (ChatProvider, error) {
+	if server.URL == "" {
+		return nil, fmt.Errorf("No base URL for server!")
+	}
+	if userAuth == nil {
+		userAuth = &auth.UserAuth{}
+	}
+	return &TeamsChatProvider{
+		Server:     server,
+		UserAuth:   userAuth,
+		HTTPClient: http.DefaultClient,
+		GraphURL:   TeamsGraphURL,
+		LoginURL:   TeamsLoginURL,
+		channels:   map[string]*teamsChannel{},
+	}, nil
+}
+
+// GetChannelMetrics returns the metrics of the channel which is either the name of a channel of the first team of the
+// user or 'team/channel'
+func (c *TeamsChatProvider) GetChannelMetrics(name string) (*ChannelMetrics, error) {
+	metrics := &ChannelMetrics{
+		Name: name,
+	}
+	channel, err := c.findChannel(name)
+	if err != nil {
+		return metrics, err
+	}
+	members := &teamsMembers{}
+	err = c.do(http.MethodGet, fmt.Sprintf("teams/%s/channels/%s/members", channel.teamID, channel.ID), nil, members)
+	if err != nil {
+		return metrics, errors.Wrapf(err, "failed to get the members of Microsoft Teams channel %s", name)
+	}
+	metrics.ID = channel.ID
+	metrics.Name = channel.DisplayName
+	metrics.URL = channel.WebURL
+	metrics.MemberCount = len(members.Value)
+	for _, member := range members.Value {
+		metrics.Members = append(metrics.Members, member.DisplayName)
+	}
+	return metrics, nil
+}
+
+// AuthenticatedUser returns the name of the user the Microsoft Graph token belongs to or, when using client
+// credentials, the client ID of the application once a token has been issued for it
+func (c *TeamsChatProvider) AuthenticatedUser() (string, error) {
+	if c.isApplication() {
+		_, err := c.token()
+		if err != nil {
+			return "", err
+		}
+		_, clientID := c.clientCredentials()
+		return clientID, nil
+	}
+	if c.UserAuth.ApiToken == "" {
+		return "", fmt.Errorf("no Microsoft Graph token for Microsoft Teams server %s", c.Server.URL)
+	}
+	user := &teamsUser{}
+	err := c.do(http.MethodGet, "me", nil, user)
+	if err != nil {
+		return "", err
+	}
+	if user.UserPrincipalName != "" {
+		return user.UserPrincipalName, nil
+	}
+	return user.DisplayName, nil
+}
+
+// PostMessage posts the message to the incoming webhook which is the channel. The message is posted as a new message
+// even if it has a ThreadID as incoming webhooks cannot reply to messages
+func (c *TeamsChatProvider) PostMessage(channel string, message *Message) (*MessageReference, error) {
+	if !isWebhook(channel) {
+		return nil, fmt.Errorf("cannot post to Microsoft Teams channel %s as messages can only be posted to the URL of an incoming webhook of the channel", channel)
+	}
+	return c.postWebhook(channel, message)
+}
+
+// UpdateMessage returns ErrNotSupported as messages posted to an incoming webhook cannot be updated
+func (c *TeamsChatProvider) UpdateMessage(ref *MessageReference, message *Message) (*MessageReference, error) {
+	return nil, ErrNotSupported
+}
+
+func (c *TeamsChatProvider) postWebhook(webhookURL string, message *Message) (*MessageReference, error) {
+	err := util.CallJSON(c.HTTPClient, http.MethodPost, webhookURL, "", toTeamsMessageCard(message), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to post message to Microsoft Teams incoming webhook")
+	}
+	return &MessageReference{
+		Channel: webhookURL,
+	}, nil
+}
+
+func (c *TeamsChatProvider) findChannel(name string) (*teamsChannel, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	name = strings.TrimPrefix(name, "#")
+	if channel := c.channels[name]; channel != nil {
+		return channel, nil
+	}
+	teamName := ""
+	channelName := name
+	if i := strings.Index(name, "/"); i >= 0 {
+		teamName = name[:i]
+		channelName = name[i+1:]
+	}
+	teams := &teamsTeams{}
+	teamsPath := "me/joinedTeams"
+	if c.isApplication() {
+		// an application has no joined teams so it can see all of them
+		teamsPath = "teams"
+	}
+	err := c.do(http.MethodGet, teamsPath, nil, teams)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the Microsoft Teams teams of the user")
+	}
+	var team *teamsTeam
+	for i := range teams.Value {
+		if teamName == "" || strings.EqualFold(teams.Value[i].DisplayName, teamName) {
+			team = &teams.Value[i]
+			break
+		}
+	}
+	if team == nil {
+		return nil, fmt.Errorf("the user is not a member of the Microsoft Teams team %s", teamName)
+	}
+	channels := &teamsChannels{}
+	err = c.do(http.MethodGet, "teams/"+team.ID+"/channels", nil, channels)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the channels of Microsoft Teams team %s", team.DisplayName)
+	}
+	for i := range channels.Value {
+		channel := &channels.Value[i]
+		if strings.EqualFold(channel.DisplayName, channelName) {
+			channel.teamID = team.ID
+			c.channels[name] = channel
+			return channel, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to find Microsoft Teams channel %s in team %s", channelName, team.DisplayName)
+}
+
+func (c *TeamsChatProvider) do(method string, path string, body interface{}, result interface{}) error {
+	token, err := c.token()
+	if err != nil {
+		return err
+	}
+	return util.CallJSON(c.HTTPClient, method, util.UrlJoin(c.GraphURL, path), util.BearerAuthorization(token), body, result)
+}
+
+// isApplication returns true if the Microsoft Graph API is used with the client credentials of an application
+func (c *TeamsChatProvider) isApplication() bool {
+	return strings.Contains(c.UserAuth.Username, "/")
+}
+
+// clientCredentials returns the tenant and client ID of the application from the user name '<tenant-id>/<client-id>'
+func (c *TeamsChatProvider) clientCredentials() (string, string) {
+	parts := strings.SplitN(c.UserAuth.Username, "/", 2)
+	return parts[0], parts[1]
+}
+
+// token returns the bearer token for the Microsoft Graph API, getting a new token with the client credentials of the
+// application when the previous one has expired
+func (c *TeamsChatProvider) token() (string, error) {
+	if !c.isApplication() {
+		return c.UserAuth.ApiToken, nil
+	}
+	c.tokenLock.Lock()
+	if c.tokenSource == nil {
+		tenant, clientID := c.clientCredentials()
+		secret := c.UserAuth.Password
+		if secret == "" {
+			secret = c.UserAuth.ApiToken
+		}
+		if tenant == "" || clientID == "" || secret == "" {
+			c.tokenLock.Unlock()
+			return "", fmt.Errorf("the client credentials of Microsoft Teams server %s need a user name <tenant-id>/<client-id> and the client secret but the user name was %s", c.Server.URL, c.UserAuth.Username)
+		}
+		config := &clientcredentials.Config{
+			ClientID:     clientID,
+			ClientSecret: secret,
+			TokenURL:     util.UrlJoin(c.LoginURL, tenant, "oauth2/v2.0/token"),
+			Scopes:       []string{teamsGraphScope},
+		}
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, c.HTTPClient)
+		c.tokenSource = config.TokenSource(ctx)
+	}
+	tokenSource := c.tokenSource
+	c.tokenLock.Unlock()
+
+	token, err := tokenSource.Token()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get a Microsoft Graph token for client %s", c.UserAuth.Username)
+	}
+	return token.AccessToken, nil
+}
+
+func isWebhook(channel string) bool {
+	return strings.HasPrefix(channel, "https://") || strings.HasPrefix(channel, "http://")
+}
+
+func toTeamsMessageCard(message *Message) *teamsMessageCard {
+	card := &teamsMessageCard{
+		Type:    "MessageCard",
+		Context: "https://schema.org/extensions",
+		Summary: message.Text,
+		Text:    toMarkdown(message.Text),
+	}
+	for _, a := range message.Attachments {
+		if card.ThemeColor == "" && a.Color != "" {
+			card.ThemeColor = teamsColor(a.Color)
+		}
+		section := teamsMessageCardSection{
+			ActivityTitle: a.Title,
+			Text:          toMarkdown(a.Text),
+		}
+		if a.TitleLink != "" {
+			section.ActivityTitle = fmt.Sprintf("[%s](%s)", a.Title, a.TitleLink)
+		}
+		for _, f := range a.Fields {
+			section.Facts = append(section.Facts, teamsMessageCardFact{
+				Name:  f.Title,
+				Value: toMarkdown(f.Value),
+			})
+		}
+		card.Sections = append(card.Sections, section)
+	}
+	return card
+}
+
+func teamsColor(color string) string {
+	if hex, ok := teamsColors[color]; ok {
+		return hex
+	}
+	return strings.TrimPrefix(color, "#")
+}
@@ -0,0 +1,158 @@
+package chats_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/chats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamsChatProviderGraph(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer mytoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /me":
+			w.Write([]byte(`{"displayName":"Jenkins X","userPrincipalName":"jenkins-x@example.com"}`))
+		case "GET /me/joinedTeams":
+			w.Write([]byte(`{"value":[{"id":"t1","displayName":"Other"},{"id":"t2","displayName":"Engineering"}]}`))
+		case "GET /teams/t2/channels":
+			w.Write([]byte(`{"value":[{"id":"c1","displayName":"General"},{"id":"c2","displayName":"Builds","webUrl":"https://teams.microsoft.com/l/channel/c2"}]}`))
+		case "GET /teams/t2/channels/c2/members":
+			w.Write([]byte(`{"value":[{"displayName":"Alice"},{"displayName":"Bob"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := chats.CreateChatProvider(chats.MicrosoftTeams, &auth.AuthServer{URL: "https://teams.microsoft.com"}, &auth.UserAuth{ApiToken: "mytoken"}, true)
+	require.NoError(t, err)
+	provider.(*chats.TeamsChatProvider).GraphURL = server.URL
+
+	user, err := provider.AuthenticatedUser()
+	require.NoError(t, err)
+	assert.Equal(t, "jenkins-x@example.com", user)
+
+	metrics, err := provider.GetChannelMetrics("Engineering/Builds")
+	require.NoError(t, err)
+	assert.Equal(t, "c2", metrics.ID)
+	assert.Equal(t, 2, metrics.MemberCount)
+	assert.Equal(t, []string{"Alice", "Bob"}, metrics.Members)
+	assert.Equal(t, "https://teams.microsoft.com/l/channel/c2", metrics.URL)
+
+	// the Microsoft Graph API only allows delegated user tokens to post channel messages so they are posted to
+	// incoming webhooks
+	_, err = provider.PostMessage("Engineering/Builds", &chats.Message{Text: "Pipeline started"})
+	assert.Error(t, err)
+}
+
+func TestTeamsChatProviderWebhook(t *testing.T) {
+	t.Parallel()
+	var cards []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		card := map[string]interface{}{}
+		data, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(data, &card)
+		cards = append(cards, card)
+		w.Write([]byte("1"))
+	}))
+	defer server.Close()
+
+	provider, err := chats.CreateChatProvider(chats.MicrosoftTeams, &auth.AuthServer{URL: "https://teams.microsoft.com"}, nil, true)
+	require.NoError(t, err)
+
+	webhook := server.URL + "/webhookb2/abc"
+	ref, err := provider.PostMessage(webhook, &chats.Message{
+		Text: "Released myapp 1.0.0",
+		Attachments: []chats.Attachment{
+			{
+				Title:     "myapp 1.0.0",
+				TitleLink: "https://github.com/myorg/myapp/releases/tag/v1.0.0",
+				Color:     "good",
+				Fields:    []chats.AttachmentField{{Title: "Commits", Value: "3"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, cards, 1)
+	assert.Equal(t, "MessageCard", cards[0]["@type"])
+	assert.Equal(t, "2EB886", cards[0]["themeColor"])
+	section := cards[0]["sections"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "[myapp 1.0.0](https://github.com/myorg/myapp/releases/tag/v1.0.0)", section["activityTitle"])
+
+	// incoming webhooks cannot update messages
+	_, err = provider.UpdateMessage(ref, &chats.Message{Text: "Released myapp 1.0.0 again"})
+	assert.Equal(t, chats.ErrNotSupported, err)
+	require.Len(t, cards, 1)
+
+	_, err = provider.AuthenticatedUser()
+	assert.Error(t, err)
+}
+
+func TestTeamsChatProviderClientCredentials(t *testing.T) {
+	t.Parallel()
+	tokens := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method+" "+r.URL.Path == "POST /mytenant/oauth2/v2.0/token" {
+			r.ParseForm()
+			if r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("scope") != "https://graph.microsoft.com/.default" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			clientID, secret, _ := r.BasicAuth()
+			if clientID == "" {
+				clientID = r.PostForm.Get("client_id")
+				secret = r.PostForm.Get("client_secret")
+			}
+			if clientID != "myclient" || secret != "mysecret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			tokens++
+			w.Header().Set("Content-Type", "application/json")
+			// the token expires immediately so that a new one is requested each time
+			w.Write([]byte(`{"access_token":"apptoken","token_type":"Bearer","expires_in":1}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer apptoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /teams":
+			w.Write([]byte(`{"value":[{"id":"t2","displayName":"Engineering"}]}`))
+		case "GET /teams/t2/channels":
+			w.Write([]byte(`{"value":[{"id":"c2","displayName":"Builds"}]}`))
+		case "GET /teams/t2/channels/c2/members":
+			w.Write([]byte(`{"value":[{"displayName":"Alice"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := chats.CreateChatProvider(chats.MicrosoftTeams, &auth.AuthServer{URL: "https://teams.microsoft.com"}, &auth.UserAuth{Username: "mytenant/myclient", ApiToken: "mysecret"}, true)
+	require.NoError(t, err)
+	teams := provider.(*chats.TeamsChatProvider)
+	teams.GraphURL = server.URL
+	teams.LoginURL = server.URL
+
+	user, err := provider.AuthenticatedUser()
+	require.NoError(t, err)
+	assert.Equal(t, "myclient", user)
+
+	metrics, err := provider.GetChannelMetrics("Engineering/Builds")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Alice"}, metrics.Members)
+	assert.True(t, tokens > 1, "expected the expired token to be refreshed but got %d tokens", tokens)
+}
@@ -18,6 +18,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.AppList":                             schema_pkg_apis_jenkinsio_v1_AppList(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.AppSpec":                             schema_pkg_apis_jenkinsio_v1_AppSpec(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Approve":                             schema_pkg_apis_jenkinsio_v1_Approve(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ApproveWorkflowStep":                 schema_pkg_apis_jenkinsio_v1_ApproveWorkflowStep(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Attachment":                          schema_pkg_apis_jenkinsio_v1_Attachment(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BatchPipelineActivity":               schema_pkg_apis_jenkinsio_v1_BatchPipelineActivity(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Binary":                              schema_pkg_apis_jenkinsio_v1_Binary(ref),
@@ -26,6 +27,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BuildPack":                           schema_pkg_apis_jenkinsio_v1_BuildPack(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BuildPackList":                       schema_pkg_apis_jenkinsio_v1_BuildPackList(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BuildPackSpec":                       schema_pkg_apis_jenkinsio_v1_BuildPackSpec(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.CanaryAnalysisIteration":             schema_pkg_apis_jenkinsio_v1_CanaryAnalysisIteration(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ChartRef":                            schema_pkg_apis_jenkinsio_v1_ChartRef(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.CommitStatus":                        schema_pkg_apis_jenkinsio_v1_CommitStatus(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.CommitStatusCommitReference":         schema_pkg_apis_jenkinsio_v1_CommitStatusCommitReference(ref),
@@ -71,6 +73,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.FactList":                            schema_pkg_apis_jenkinsio_v1_FactList(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.FactSpec":                            schema_pkg_apis_jenkinsio_v1_FactSpec(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.FactStatus":                          schema_pkg_apis_jenkinsio_v1_FactStatus(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.FreezeWindow":                        schema_pkg_apis_jenkinsio_v1_FreezeWindow(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.GitService":                          schema_pkg_apis_jenkinsio_v1_GitService(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.GitServiceList":                      schema_pkg_apis_jenkinsio_v1_GitServiceList(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.GitServiceSpec":                      schema_pkg_apis_jenkinsio_v1_GitServiceSpec(ref),
@@ -83,6 +86,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Measurement":                         schema_pkg_apis_jenkinsio_v1_Measurement(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Merger":                              schema_pkg_apis_jenkinsio_v1_Merger(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Original":                            schema_pkg_apis_jenkinsio_v1_Original(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ParallelWorkflowStep":                schema_pkg_apis_jenkinsio_v1_ParallelWorkflowStep(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Periodic":                            schema_pkg_apis_jenkinsio_v1_Periodic(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Periodics":                           schema_pkg_apis_jenkinsio_v1_Periodics(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PipelineActivity":                    schema_pkg_apis_jenkinsio_v1_PipelineActivity(ref),
@@ -102,8 +106,11 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Presubmit":                           schema_pkg_apis_jenkinsio_v1_Presubmit(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Presubmits":                          schema_pkg_apis_jenkinsio_v1_Presubmits(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PreviewActivityStep":                 schema_pkg_apis_jenkinsio_v1_PreviewActivityStep(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PreviewGCPolicy":                     schema_pkg_apis_jenkinsio_v1_PreviewGCPolicy(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PreviewGitSpec":                      schema_pkg_apis_jenkinsio_v1_PreviewGitSpec(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteActivityStep":                 schema_pkg_apis_jenkinsio_v1_PromoteActivityStep(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteCanaryStep":                   schema_pkg_apis_jenkinsio_v1_PromoteCanaryStep(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteFreezeStep":                   schema_pkg_apis_jenkinsio_v1_PromoteFreezeStep(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromotePullRequestStep":              schema_pkg_apis_jenkinsio_v1_PromotePullRequestStep(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteUpdateStep":                   schema_pkg_apis_jenkinsio_v1_PromoteUpdateStep(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteWorkflowStep":                 schema_pkg_apis_jenkinsio_v1_PromoteWorkflowStep(ref),
@@ -143,11 +150,14 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.TeamSettings":                        schema_pkg_apis_jenkinsio_v1_TeamSettings(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.TeamSpec":                            schema_pkg_apis_jenkinsio_v1_TeamSpec(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.TeamStatus":                          schema_pkg_apis_jenkinsio_v1_TeamStatus(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.TimeWindow":                          schema_pkg_apis_jenkinsio_v1_TimeWindow(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Trigger":                             schema_pkg_apis_jenkinsio_v1_Trigger(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.User":                                schema_pkg_apis_jenkinsio_v1_User(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.UserDetails":                         schema_pkg_apis_jenkinsio_v1_UserDetails(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.UserList":                            schema_pkg_apis_jenkinsio_v1_UserList(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.UserSpec":                            schema_pkg_apis_jenkinsio_v1_UserSpec(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.VerifyWorkflowStep":                  schema_pkg_apis_jenkinsio_v1_VerifyWorkflowStep(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.WaitWorkflowStep":                    schema_pkg_apis_jenkinsio_v1_WaitWorkflowStep(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Welcome":                             schema_pkg_apis_jenkinsio_v1_Welcome(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Workflow":                            schema_pkg_apis_jenkinsio_v1_Workflow(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.WorkflowList":                        schema_pkg_apis_jenkinsio_v1_WorkflowList(ref),
@@ -155,6 +165,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.WorkflowSpec":                        schema_pkg_apis_jenkinsio_v1_WorkflowSpec(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.WorkflowStatus":                      schema_pkg_apis_jenkinsio_v1_WorkflowStatus(ref),
 		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.WorkflowStep":                        schema_pkg_apis_jenkinsio_v1_WorkflowStep(ref),
+		"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.WorkflowStepStatus":                  schema_pkg_apis_jenkinsio_v1_WorkflowStepStatus(ref),
 	}
 }
 
@@ -343,6 +354,41 @@ func schema_pkg_apis_jenkinsio_v1_Approve(ref common.ReferenceCallback) common.O
 	}
 }
 
+func schema_pkg_apis_jenkinsio_v1_ApproveWorkflowStep(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ApproveWorkflowStep is the step of waiting for someone to approve the workflow via 'jx approve workflow'",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the message shown to the approvers",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"approvers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the users who can approve the step. If empty anyone can approve it",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
 func schema_pkg_apis_jenkinsio_v1_Attachment(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -633,6 +679,44 @@ func schema_pkg_apis_jenkinsio_v1_BuildPackSpec(ref common.ReferenceCallback) co
 	}
 }
 
+func schema_pkg_apis_jenkinsio_v1_CanaryAnalysisIteration(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "CanaryAnalysisIteration records the state of the canary at one iteration of the Flagger canary analysis",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"weight": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"failedChecks": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"timestamp": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
 func schema_pkg_apis_jenkinsio_v1_ChartRef(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -1126,6 +1210,13 @@ func schema_pkg_apis_jenkinsio_v1_CoreActivityStep(ref common.ReferenceCallback)
 							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
+					"attempts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Attempts is the number of times the step was run when it has been retried",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 				},
 			},
 		},
@@ -1723,11 +1814,24 @@ func schema_pkg_apis_jenkinsio_v1_EnvironmentSpec(ref common.ReferenceCallback)
 							Format:      "",
 						},
 					},
+					"freezeWindows": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FreezeWindows are the periods during which promotions into the Environment are blocked",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.FreezeWindow"),
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.EnvironmentRepository", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PreviewGitSpec", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.TeamSettings"},
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.EnvironmentRepository", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.FreezeWindow", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PreviewGitSpec", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.TeamSettings"},
 	}
 }
 
@@ -2655,6 +2759,90 @@ func schema_pkg_apis_jenkinsio_v1_FactStatus(ref common.ReferenceCallback) commo
 	}
 }
 
+func schema_pkg_apis_jenkinsio_v1_FreezeWindow(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FreezeWindow is a period during which promotions into an Environment are blocked, such as the weekend, a holiday code freeze or a recurring blackout. If none of the window, period or schedule is specified the freeze is in place until it is removed.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the name of the freeze which is shown when a promotion is blocked",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "why promotions are blocked",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"window": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the recurring window of time of the freeze, such as the whole of Saturday and Sunday",
+							Ref:         ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.TimeWindow"),
+						},
+					},
+					"from": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the start of a one-off freeze as a date such as '2019-12-20' or in RFC 3339 format",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"to": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the end of a one-off freeze as a date, which is inclusive, or in RFC 3339 format",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"schedule": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the cron expression for when a recurring freeze starts, such as '0 18 * * 5', which lasts for the duration",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"duration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "how long a freeze started by the schedule lasts, such as '62h'",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"timeZone": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the IANA name of the time zone of the dates and schedule, such as 'Europe/London'. Defaults to UTC",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"environments": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the Environments the freeze applies to when it is specified in the TeamSettings. If empty it applies to all permanent Environments",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.TimeWindow"},
+	}
+}
+
 func schema_pkg_apis_jenkinsio_v1_GitService(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -3193,6 +3381,33 @@ func schema_pkg_apis_jenkinsio_v1_Original(ref common.ReferenceCallback) common.
 	}
 }
 
+func schema_pkg_apis_jenkinsio_v1_ParallelWorkflowStep(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ParallelWorkflowStep is the step of promoting a version of an application to several environments at the same time",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"environments": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
 func schema_pkg_apis_jenkinsio_v1_Periodic(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -3545,11 +3760,23 @@ func schema_pkg_apis_jenkinsio_v1_PipelineActivitySpec(ref common.ReferenceCallb
 							Format: "",
 						},
 					},
+					"workflowSteps": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.WorkflowStepStatus"),
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Attachment", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BatchPipelineActivity", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ExtensionExecution", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PipelineActivityStep", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.Attachment", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.BatchPipelineActivity", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ExtensionExecution", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PipelineActivityStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.WorkflowStepStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -3843,6 +4070,41 @@ func schema_pkg_apis_jenkinsio_v1_PipelineStructureStage(ref common.ReferenceCal
 							Format: "",
 						},
 					},
+					"skipped": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Skipped is true if the stage is not run because its when conditions were not met",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"matrixAxes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MatrixAxes holds the names of the axes of the matrix this stage was expanded from, if any. The stages for the cells of the matrix are the parallel stages nested within it.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"matrixValues": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MatrixValues holds the value of each axis for the cell of a matrix this stage runs, if any.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
 				},
 				Required: []string{"name", "depth"},
 			},
@@ -4248,6 +4510,13 @@ func schema_pkg_apis_jenkinsio_v1_PreviewActivityStep(ref common.ReferenceCallba
 							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
+					"attempts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Attempts is the number of times the step was run when it has been retried",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 					"environment": {
 						SchemaProps: spec.SchemaProps{
 							Type:   []string{"string"},
@@ -4274,6 +4543,55 @@ func schema_pkg_apis_jenkinsio_v1_PreviewActivityStep(ref common.ReferenceCallba
 	}
 }
 
+func schema_pkg_apis_jenkinsio_v1_PreviewGCPolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PreviewGCPolicy is the policy used by 'jx gc previews' to reap Preview Environments which have not been deployed for a while or which are idle, in addition to those whose Pull Request has been closed",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"ttl": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TTL is how long after it was last deployed a Preview Environment is reaped, such as '72h'",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"idleTimeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "IdleTimeout is how long a Preview Environment can receive no requests via its ingress before it is reaped, such as '24h'. Requires the MetricsURL",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metricsUrl": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MetricsURL is the URL of the Prometheus server which scrapes the metrics of the nginx ingress controller",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"scaleToZero": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ScaleToZero scales the deployments of a reaped Preview Environment down to zero replicas rather than deleting it. A Preview Environment whose Pull Request has been closed is always deleted",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"wakeupService": {
+						SchemaProps: spec.SchemaProps{
+							Description: "WakeupService is the host name of the 'jx controller wakeup' service, such as 'jx-preview-wakeup.jx.svc.cluster.local', which the ingresses of a Preview Environment scaled to zero are routed to so that it is scaled back up on its first request",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
 func schema_pkg_apis_jenkinsio_v1_PreviewGitSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -4377,6 +4695,13 @@ func schema_pkg_apis_jenkinsio_v1_PromoteActivityStep(ref common.ReferenceCallba
 							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
+					"attempts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Attempts is the number of times the step was run when it has been retried",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 					"environment": {
 						SchemaProps: spec.SchemaProps{
 							Type:   []string{"string"},
@@ -4399,12 +4724,176 @@ func schema_pkg_apis_jenkinsio_v1_PromoteActivityStep(ref common.ReferenceCallba
 							Format: "",
 						},
 					},
-				},
-			},
-		},
-		Dependencies: []string{
-			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromotePullRequestStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteUpdateStep", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
-	}
+					"canary": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteCanaryStep"),
+						},
+					},
+					"freeze": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteFreezeStep"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteCanaryStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteFreezeStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromotePullRequestStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteUpdateStep", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_jenkinsio_v1_PromoteCanaryStep(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PromoteCanaryStep is the step for waiting for the Flagger canary analysis of the promoted version to complete",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"description": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"startedTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"completedTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"attempts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Attempts is the number of times the step was run when it has been retried",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"weight": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"failedChecks": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"iterations": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.CanaryAnalysisIteration"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.CanaryAnalysisIteration", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_jenkinsio_v1_PromoteFreezeStep(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PromoteFreezeStep records a promotion which was blocked by a freeze window of the environment until the window closed, or which overrode the freeze",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"description": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"startedTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"completedTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"attempts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Attempts is the number of times the step was run when it has been retried",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"window": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"overriddenBy": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"overrideReason": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
 }
 
 func schema_pkg_apis_jenkinsio_v1_PromotePullRequestStep(ref common.ReferenceCallback) common.OpenAPIDefinition {
@@ -4442,6 +4931,13 @@ func schema_pkg_apis_jenkinsio_v1_PromotePullRequestStep(ref common.ReferenceCal
 							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
+					"attempts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Attempts is the number of times the step was run when it has been retried",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 					"pullRequestURL": {
 						SchemaProps: spec.SchemaProps{
 							Type:   []string{"string"},
@@ -4497,6 +4993,13 @@ func schema_pkg_apis_jenkinsio_v1_PromoteUpdateStep(ref common.ReferenceCallback
 							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
+					"attempts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Attempts is the number of times the step was run when it has been retried",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 					"statuses": {
 						SchemaProps: spec.SchemaProps{
 							Type: []string{"array"},
@@ -5814,6 +6317,13 @@ func schema_pkg_apis_jenkinsio_v1_StageActivityStep(ref common.ReferenceCallback
 							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
+					"attempts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Attempts is the number of times the step was run when it has been retried",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 					"steps": {
 						SchemaProps: spec.SchemaProps{
 							Type: []string{"array"},
@@ -5826,6 +6336,19 @@ func schema_pkg_apis_jenkinsio_v1_StageActivityStep(ref common.ReferenceCallback
 							},
 						},
 					},
+					"retryAttempts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RetryAttempts records the earlier, failed attempts at running the stage when it has been retried",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.CoreActivityStep"),
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
@@ -6247,11 +6770,30 @@ func schema_pkg_apis_jenkinsio_v1_TeamSettings(ref common.ReferenceCallback) com
 							Format:      "",
 						},
 					},
+					"freezeWindows": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FreezeWindows are the periods during which promotions into the permanent Environments of the team are blocked",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.FreezeWindow"),
+									},
+								},
+							},
+						},
+					},
+					"previewGC": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PreviewGC is the policy for garbage collecting the Preview Environments of the team which are no longer used",
+							Ref:         ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PreviewGCPolicy"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.QuickStartLocation", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ResourceReference", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.StorageLocation", "k8s.io/api/batch/v1.Job"},
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.FreezeWindow", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PreviewGCPolicy", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.QuickStartLocation", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ResourceReference", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.StorageLocation", "k8s.io/api/batch/v1.Job"},
 	}
 }
 
@@ -6320,6 +6862,55 @@ func schema_pkg_apis_jenkinsio_v1_TeamStatus(ref common.ReferenceCallback) commo
 	}
 }
 
+func schema_pkg_apis_jenkinsio_v1_TimeWindow(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TimeWindow is a window of time which is open on some or all days of the week",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"start": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the time the window opens in the form 'HH:MM'",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"end": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the time the window closes in the form 'HH:MM'. If it is before the start the window is open over midnight",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"days": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the days of the week the window opens on, such as 'Mon'. If empty the window opens every day",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"timeZone": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the IANA name of the time zone of the window, such as 'Europe/London'. Defaults to UTC",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
 func schema_pkg_apis_jenkinsio_v1_Trigger(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -6567,6 +7158,83 @@ func schema_pkg_apis_jenkinsio_v1_UserSpec(ref common.ReferenceCallback) common.
 	}
 }
 
+func schema_pkg_apis_jenkinsio_v1_VerifyWorkflowStep(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "VerifyWorkflowStep is the step of verifying the workflow by running a pipeline or a 'jx step verify' check, which must succeed",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"pipeline": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the name of the pipeline to run in the form 'owner/repository/branch'",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"check": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the 'jx step verify' check to run and its arguments, such as 'url --endpoint https://myapp.example.com'",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"rollback": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the environments to roll back to the previous version of the application if the verification fails",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_jenkinsio_v1_WaitWorkflowStep(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "WaitWorkflowStep is the step of waiting for a duration and/or until a time window is open",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"duration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "how long to wait once the step is triggered, such as '30m'",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"window": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the time window in which the step can complete",
+							Ref:         ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.TimeWindow"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.TimeWindow"},
+	}
+}
+
 func schema_pkg_apis_jenkinsio_v1_Welcome(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -6700,6 +7368,20 @@ func schema_pkg_apis_jenkinsio_v1_WorkflowPreconditions(ref common.ReferenceCall
 							},
 						},
 					},
+					"steps": {
+						SchemaProps: spec.SchemaProps{
+							Description: "the names of the steps which need to have succeeded before this step can be triggered",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
@@ -6795,10 +7477,85 @@ func schema_pkg_apis_jenkinsio_v1_WorkflowStep(ref common.ReferenceCallback) com
 							Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteWorkflowStep"),
 						},
 					},
+					"approve": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ApproveWorkflowStep"),
+						},
+					},
+					"wait": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.WaitWorkflowStep"),
+						},
+					},
+					"verify": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.VerifyWorkflowStep"),
+						},
+					},
+					"parallel": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ParallelWorkflowStep"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteWorkflowStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.WorkflowPreconditions"},
+			"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ApproveWorkflowStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.ParallelWorkflowStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.PromoteWorkflowStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.VerifyWorkflowStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.WaitWorkflowStep", "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1.WorkflowPreconditions"},
+	}
+}
+
+func schema_pkg_apis_jenkinsio_v1_WorkflowStepStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "WorkflowStepStatus is the status of a step of a Workflow, other than a promotion, for a particular run of a pipeline",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"approvedBy": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"startedTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"completedTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
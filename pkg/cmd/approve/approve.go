@@ -0,0 +1,53 @@
+package approve
+
+import (
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/spf13/cobra"
+
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+)
+
+// Approve contains the command line options
+type Approve struct {
+	*opts.CommonOptions
+}
+
+var (
+	approveLong = templates.LongDesc(`
+		Approves a process which is waiting for approval such as a workflow.
+`)
+
+	approveExample = templates.Examples(`
+		# Approve the workflow of a pipeline which is waiting for approval
+		jx approve workflow
+	`)
+)
+
+// NewCmdApprove creates the command object
+func NewCmdApprove(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &Approve{
+		commonOpts,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "approve TYPE [flags]",
+		Short:   "Approves a process such as a workflow",
+		Long:    approveLong,
+		Example: approveExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.AddCommand(NewCmdApproveWorkflow(commonOpts))
+	return cmd
+}
+
+// Run implements this command
+func (o *Approve) Run() error {
+	return o.Cmd.Help()
+}
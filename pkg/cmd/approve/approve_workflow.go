@@ -0,0 +1,161 @@
+package approve
+
+import (
+	"sort"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/jenkins-x/jx/pkg/workflow"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApproveWorkflowOptions contains the command line options
+type ApproveWorkflowOptions struct {
+	*opts.CommonOptions
+
+	Step     string
+	Approver string
+	Reject   bool
+}
+
+var (
+	approveWorkflowLong = templates.LongDesc(`
+		Approves or rejects the approval step of the workflow of a pipeline activity which is waiting for approval.
+
+		Once approved the workflow controller carries on with the next steps of the workflow. If the step is rejected
+		the workflow fails.
+
+		The approver is the user authenticated with the git provider of the pipeline activity, so only the users
+		listed as approvers of the step can approve it.
+`)
+
+	approveWorkflowExample = templates.Examples(`
+		# Select the pipeline activity waiting for approval to approve
+		jx approve workflow
+
+		# Approve the workflow of a pipeline activity
+		jx approve workflow myorg-myapp-master-12
+
+		# Reject the change-board step of the workflow
+		jx approve workflow myorg-myapp-master-12 --step change-board --reject
+	`)
+)
+
+// NewCmdApproveWorkflow creates the command
+func NewCmdApproveWorkflow(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &ApproveWorkflowOptions{
+		CommonOptions: commonOpts,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "workflow [activity] [flags]",
+		Short:   "Approves the workflow of a pipeline activity which is waiting for approval",
+		Long:    approveWorkflowLong,
+		Example: approveWorkflowExample,
+		Aliases: []string{"workflows", "flow"},
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Step, "step", "s", "", "The name of the approval step. Defaults to the step waiting for approval")
+	cmd.Flags().StringVarP(&options.Approver, "approver", "a", "", "The name of the user approving the step. Must match the user authenticated with the git provider of the pipeline activity")
+	cmd.Flags().BoolVarP(&options.Reject, "reject", "", false, "Rejects the step so that the workflow fails")
+	return cmd
+}
+
+// Run implements this command
+func (o *ApproveWorkflowOptions) Run() error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	activities := jxClient.JenkinsV1().PipelineActivities(ns)
+
+	name := ""
+	if len(o.Args) > 0 {
+		name = o.Args[0]
+	} else {
+		list, err := activities.List(metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to list the PipelineActivity resources in namespace %s", ns)
+		}
+		names := []string{}
+		for _, activity := range list.Items {
+			if activity.Spec.WorkflowStatus == v1.ActivityStatusTypeWaitingForApproval {
+				names = append(names, activity.Name)
+			}
+		}
+		if len(names) == 0 {
+			return errors.New("there are no pipeline activities waiting for approval")
+		}
+		if len(names) == 1 {
+			name = names[0]
+		} else {
+			if o.BatchMode {
+				return errors.New("more than one pipeline activity is waiting for approval so please specify the one to approve")
+			}
+			sort.Strings(names)
+			name, err = util.PickName(names, "Which pipeline activity do you want to approve: ", "", o.GetIOFileHandles())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	activity, err := activities.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to find PipelineActivity %s in namespace %s", name, ns)
+	}
+	flow, err := workflow.GetWorkflow(activity.Spec.Workflow, jxClient, ns)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the workflow of PipelineActivity %s", name)
+	}
+	user, err := o.approver(activity)
+	if err != nil {
+		return err
+	}
+	err = workflow.Approve(activity, flow, o.Step, user, !o.Reject)
+	if err != nil {
+		return err
+	}
+	_, err = activities.PatchUpdate(activity)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update PipelineActivity %s", name)
+	}
+	if o.Reject {
+		log.Logger().Infof("Rejected the workflow of %s", util.ColorInfo(name))
+	} else {
+		log.Logger().Infof("Approved the workflow of %s", util.ColorInfo(name))
+	}
+	return nil
+}
+
+// approver returns the user authenticated with the git provider of the pipeline activity. The approver option is
+// only accepted when it matches that user so that nobody can approve a step on behalf of somebody else
+func (o *ApproveWorkflowOptions) approver(activity *v1.PipelineActivity) (string, error) {
+	gitURL := activity.Spec.GitURL
+	if gitURL == "" {
+		return "", errors.Errorf("cannot find the authenticated approver as PipelineActivity %s has no git URL", activity.Name)
+	}
+	provider, err := o.GitProviderForURL(gitURL, "approving the workflow")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find the authenticated user of %s", gitURL)
+	}
+	user := provider.CurrentUsername()
+	if user == "" {
+		return "", errors.Errorf("no authenticated user found for %s", gitURL)
+	}
+	if o.Approver != "" && o.Approver != user {
+		return "", util.InvalidOptionf("approver", o.Approver, "the authenticated user is %s", user)
+	}
+	return user, nil
+}
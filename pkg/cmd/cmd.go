@@ -24,6 +24,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/cmd/ui"
 	"github.com/spf13/viper"
 
+	"github.com/jenkins-x/jx/pkg/cmd/approve"
 	"github.com/jenkins-x/jx/pkg/cmd/boot"
 	"github.com/jenkins-x/jx/pkg/cmd/compliance"
 	"github.com/jenkins-x/jx/pkg/cmd/controller"
@@ -54,6 +55,9 @@ import (
 	"github.com/jenkins-x/jx/pkg/cmd/add"
 	"github.com/jenkins-x/jx/pkg/cmd/namespace"
 	"github.com/jenkins-x/jx/pkg/cmd/promote"
+	"github.com/jenkins-x/jx/pkg/cmd/restore"
+	"github.com/jenkins-x/jx/pkg/cmd/rollback"
+	"github.com/jenkins-x/jx/pkg/cmd/rotate"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -128,6 +132,7 @@ func NewJXCommand(f clients.Factory, in terminal.FileReader, out terminal.FileWr
 	environmentsCommands := []*cobra.Command{
 		preview.NewCmdPreview(commonOpts),
 		promote.NewCmdPromote(commonOpts),
+		rollback.NewCmdRollback(commonOpts),
 	}
 	environmentsCommands = append(environmentsCommands, findCommands("environment", createCommands, deleteCommands, editCommands, getCommands)...)
 
@@ -188,6 +193,9 @@ func NewJXCommand(f clients.Factory, in terminal.FileReader, out terminal.FileWr
 				addCommands,
 				start.NewCmdStart(commonOpts),
 				stop.NewCmdStop(commonOpts),
+				approve.NewCmdApprove(commonOpts),
+				restore.NewCmdRestore(commonOpts),
+				rotate.NewCmdRotate(commonOpts),
 			},
 		},
 		{
@@ -46,12 +46,15 @@ func NewCmdController(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdControllerBackup(commonOpts))
 	cmd.AddCommand(NewCmdControllerBuild(commonOpts))
 	cmd.AddCommand(NewCmdControllerBuildNumbers(commonOpts))
+	cmd.AddCommand(NewCmdControllerChat(commonOpts))
 	cmd.AddCommand(NewCmdControllerEnvironment(commonOpts))
+	cmd.AddCommand(NewCmdControllerPipelineEvents(commonOpts))
 	cmd.AddCommand(pipeline.NewCmdControllerPipelineRunner(commonOpts))
 	cmd.AddCommand(NewCmdControllerRole(commonOpts))
 	cmd.AddCommand(NewCmdControllerTeam(commonOpts))
 	cmd.AddCommand(NewCmdControllerWorkflow(commonOpts))
 	cmd.AddCommand(NewCmdControllerCommitStatus(commonOpts))
+	cmd.AddCommand(NewCmdControllerWakeup(commonOpts))
 	return cmd
 }
 
@@ -2,23 +2,21 @@ package controller
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
 
-	"github.com/ghodss/yaml"
-	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/backup"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/spf13/cobra"
-	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -30,6 +28,7 @@ type ControllerBackupOptions struct {
 
 	Namespace    string
 	Organisation string
+	Resources    []string
 }
 
 // NewCmdControllerBackup creates a command object for the generic "get" action, which
@@ -55,32 +54,41 @@ func NewCmdControllerBackup(commonOpts *opts.CommonOptions) *cobra.Command {
 
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace to watch or defaults to the current namespace")
 	cmd.Flags().StringVarP(&options.Organisation, "organisation", "o", "", "The organisation to backup")
+	cmd.Flags().StringArrayVarP(&options.Resources, "resources", "r", backup.DefaultResources,
+		fmt.Sprintf("The resources to backup. Supported resources are: %s", strings.Join(backup.ResourceNames(), ", ")))
 
 	return cmd
 }
 
 // Run implements this command
 func (o *ControllerBackupOptions) Run() error {
-	// ensure Environment / Team / User CRDs are registered before we start
-	err := o.RegisterEnvironmentCRD()
+	resources, err := backup.FindResources(o.Resources)
 	if err != nil {
 		return err
 	}
 
-	err = o.RegisterTeamCRD()
+	// ensure the CRDs are registered before we start
+	apisClient, err := o.ApiExtensionsClient()
 	if err != nil {
 		return err
 	}
-
-	err = o.RegisterUserCRD()
+	err = kube.RegisterAllCRDs(apisClient)
 	if err != nil {
 		return err
 	}
 
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
 	jxClient, devNs, err := o.JXClientAndDevNamespace()
 	if err != nil {
 		return err
 	}
+	clients := &backup.Clients{
+		KubeClient: kubeClient,
+		JXClient:   jxClient,
+	}
 
 	ns := o.Namespace
 	if ns == "" {
@@ -88,144 +96,97 @@ func (o *ControllerBackupOptions) Run() error {
 	}
 
 	dir, err := o.getOrCreateBackupRepository()
+	if err != nil {
+		return err
+	}
 
-	log.Logger().Infof("Watching for users/teams/environments in namespace %s", util.ColorInfo(ns))
-
-	_, environmentController := cache.NewInformer(
-		&cache.ListWatch{
-			ListFunc: func(lo meta_v1.ListOptions) (runtime.Object, error) {
-				return jxClient.JenkinsV1().Environments(ns).List(lo)
-			},
-			WatchFunc: func(lo meta_v1.ListOptions) (watch.Interface, error) {
-				return jxClient.JenkinsV1().Environments(ns).Watch(lo)
-			},
-		},
-		&v1.Environment{},
-		time.Minute*10,
-		cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				o.onEnvironmentChange(obj, ns, dir)
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				o.onEnvironmentChange(newObj, ns, dir)
-			},
-			DeleteFunc: func(obj interface{}) {
-				//o.onEnvironmentChange(obj, jxClient, ns)
-			},
-		},
-	)
+	log.Logger().Infof("Watching for %s in namespace %s", strings.Join(o.Resources, "/"), util.ColorInfo(ns))
 
 	stop := make(chan struct{})
-	go environmentController.Run(stop)
-
-	_, teamController := cache.NewInformer(
-		&cache.ListWatch{
-			ListFunc: func(lo meta_v1.ListOptions) (runtime.Object, error) {
-				return jxClient.JenkinsV1().Teams(ns).List(lo)
-			},
-			WatchFunc: func(lo meta_v1.ListOptions) (watch.Interface, error) {
-				return jxClient.JenkinsV1().Teams(ns).Watch(lo)
-			},
-		},
-		&v1.Team{},
-		time.Minute*10,
-		cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				o.onTeamChange(obj, ns, dir)
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				o.onTeamChange(newObj, ns, dir)
-			},
-			DeleteFunc: func(obj interface{}) {
-				//o.onEnvironmentChange(obj, jxClient, ns)
-			},
-		},
-	)
+	for _, resource := range resources {
+		o.watchResource(clients, resource, ns, dir, stop)
+	}
 
-	go teamController.Run(stop)
+	// Wait forever
+	select {}
+}
 
-	_, userController := cache.NewInformer(
-		&cache.ListWatch{
-			ListFunc: func(lo meta_v1.ListOptions) (runtime.Object, error) {
-				return jxClient.JenkinsV1().Users(ns).List(lo)
-			},
-			WatchFunc: func(lo meta_v1.ListOptions) (watch.Interface, error) {
-				return jxClient.JenkinsV1().Users(ns).Watch(lo)
-			},
-		},
-		&v1.User{},
+func (o *ControllerBackupOptions) watchResource(clients *backup.Clients, resource *backup.Resource, ns string, dir string, stop chan struct{}) {
+	store, controller := cache.NewInformer(
+		resource.ListWatch(clients, ns),
+		resource.ObjectType,
 		time.Minute*10,
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
-				o.onUserChange(obj, ns, dir)
+				o.onResourceChange(obj, resource, ns, dir)
 			},
 			UpdateFunc: func(oldObj, newObj interface{}) {
-				o.onUserChange(newObj, ns, dir)
+				o.onResourceChange(newObj, resource, ns, dir)
 			},
 			DeleteFunc: func(obj interface{}) {
-				//o.onEnvironmentChange(obj, jxClient, ns)
+				o.onResourceDelete(obj, resource, ns, dir)
 			},
 		},
 	)
 
-	go userController.Run(stop)
+	go controller.Run(stop)
 
-	// Wait forever
-	select {}
+	go func() {
+		if cache.WaitForCacheSync(stop, controller.HasSynced) {
+			o.pruneResources(store, resource, ns, dir)
+		}
+	}()
 }
 
-func (o *ControllerBackupOptions) onEnvironmentChange(obj interface{}, ns string, dir string) {
-	env, ok := obj.(*v1.Environment)
-	if !ok {
-		log.Logger().Infof("Object is not a Environment %#v", obj)
-		return
+// pruneResources removes the backups of the resources which were deleted while they were not watched
+func (o *ControllerBackupOptions) pruneResources(store cache.Store, resource *backup.Resource, ns string, dir string) {
+	names := []string{}
+	for _, obj := range store.List() {
+		name, err := backup.ObjectName(obj)
+		if err != nil {
+			log.Logger().Errorf("Unable to find the name of %s %s", resource.Name, err)
+			return
+		}
+		names = append(names, name)
 	}
-	o.writeResourceToBackupFile(env, "environment", env.ObjectMeta.Name, ns, dir)
-}
-
-func (o *ControllerBackupOptions) onTeamChange(obj interface{}, ns string, dir string) {
-	env, ok := obj.(*v1.Team)
-	if !ok {
-		log.Logger().Infof("Object is not a Team %#v", obj)
+	fileNames, err := backup.PruneResources(dir, resource, ns, names)
+	if err != nil {
+		log.Logger().Errorf("Unable to remove the backups of deleted %s %s", resource.Name, err)
 		return
 	}
-	o.writeResourceToBackupFile(env, "team", env.ObjectMeta.Name, ns, dir)
-}
-
-func (o *ControllerBackupOptions) onUserChange(obj interface{}, ns string, dir string) {
-	env, ok := obj.(*v1.User)
-	if !ok {
-		log.Logger().Infof("Object is not a User %#v", obj)
+	if len(fileNames) == 0 {
 		return
 	}
-	o.writeResourceToBackupFile(env, "user", env.ObjectMeta.Name, ns, dir)
+	for _, fileName := range fileNames {
+		log.Logger().Debugf("Removed %s backup %s", util.ColorInfo(resource.Name), util.ColorInfo(fileName))
+	}
+
+	o.commitDirIfChanges(dir, fmt.Sprintf("Deleting %d %s backups", len(fileNames), resource.Name))
 }
 
-func (o *ControllerBackupOptions) writeResourceToBackupFile(obj interface{}, resource string, key string, ns string, dir string) {
-	out, err := yaml.Marshal(obj)
+func (o *ControllerBackupOptions) onResourceChange(obj interface{}, resource *backup.Resource, ns string, dir string) {
+	fileName, err := backup.WriteResource(dir, resource, ns, obj)
 	if err != nil {
-		log.Logger().Errorf("Unable to marshall %s %s", resource, err)
+		log.Logger().Errorf("Unable to backup %s %s", resource.Name, err)
 		return
 	}
+	log.Logger().Debugf("Dumped %s to %s", util.ColorInfo(resource.Name), util.ColorInfo(fileName))
 
-	log.Logger().Debugf("Dumping %s with key %s...", util.ColorInfo(resource), util.ColorInfo(key))
-	log.Logger().Debugf("%s", string(out))
+	o.commitDirIfChanges(dir, fmt.Sprintf("Updating %s %s", resource.Name, strings.TrimSuffix(filepath.Base(fileName), ".yaml")))
+}
 
-	nsDir := path.Join(dir, fmt.Sprintf("%ss", resource), ns)
-	err = os.MkdirAll(nsDir, os.FileMode(0755))
+func (o *ControllerBackupOptions) onResourceDelete(obj interface{}, resource *backup.Resource, ns string, dir string) {
+	fileName, err := backup.DeleteResource(dir, resource, ns, obj)
 	if err != nil {
-		log.Logger().Errorf("Unable to create directory %s", err)
+		log.Logger().Errorf("Unable to remove the backup of %s %s", resource.Name, err)
 		return
 	}
-
-	envFile := path.Join(nsDir, fmt.Sprintf("%s.yaml", key))
-	err = ioutil.WriteFile(envFile, out, 0644)
-	if err != nil {
-		log.Logger().Errorf("Unable to write file %s", err)
+	if fileName == "" {
 		return
 	}
+	log.Logger().Debugf("Removed %s backup %s", util.ColorInfo(resource.Name), util.ColorInfo(fileName))
 
-	o.commitDirIfChanges(dir, fmt.Sprintf("Updating %s %s", resource, key))
+	o.commitDirIfChanges(dir, fmt.Sprintf("Deleting %s %s", resource.Name, strings.TrimSuffix(filepath.Base(fileName), ".yaml")))
 }
 
 func (o *ControllerBackupOptions) commitDirIfChanges(dir string, message string) {
@@ -74,16 +74,25 @@ func (w *LongTermStorageLogWriter) WriteLog(logLine logs.LogLine, lch chan<- log
 }
 
 // StreamLog will receive a logs channel and an errors channel which the logs producer will send
-// it will mask the lines marked as ShouldMask then it will append the line's bytes to the already stored ones
+// it will mask the lines marked as ShouldMask then it will append the line's bytes to the already stored ones.
+// A block such as a private key which spans the lines of a step is masked until the next header line
 func (w *LongTermStorageLogWriter) StreamLog(lch <-chan logs.LogLine, ech <-chan error) error {
+	var streamMasker *kube.LogStreamMasker
+	if w.logMasker != nil {
+		streamMasker = w.logMasker.NewStreamMasker()
+	}
 	for {
 		select {
 		case l, ok := <-lch:
 			if !ok {
 				return nil
 			}
-			if w.logMasker != nil && l.ShouldMask {
-				l.Line = w.logMasker.MaskLog(l.Line)
+			if streamMasker != nil {
+				if l.ShouldMask {
+					l.Line = streamMasker.MaskLine(l.Line)
+				} else {
+					streamMasker.Reset()
+				}
 			}
 			line := []byte(l.Line)
 			line = append(line, '\n')
@@ -568,7 +577,7 @@ func (o *ControllerBuildOptions) updatePipelineActivity(kubeClient kubernetes.In
 		step := &spec.Steps[i]
 		stage := step.Stage
 		if stage != nil {
-			stageFinished := spec.Status.IsTerminated()
+			stageFinished := spec.Status.IsTerminated() || stage.Status == v1.ActivityStatusTypeSkipped
 			if stage.StartedTimestamp != nil && spec.StartedTimestamp == nil {
 				spec.StartedTimestamp = stage.StartedTimestamp
 			}
@@ -583,7 +592,7 @@ func (o *ControllerBuildOptions) updatePipelineActivity(kubeClient kubernetes.In
 			}
 			if stageFinished {
 				switch stage.Status {
-				case v1.ActivityStatusTypeSucceeded, v1.ActivityStatusTypeNotExecuted:
+				case v1.ActivityStatusTypeSucceeded, v1.ActivityStatusTypeNotExecuted, v1.ActivityStatusTypeSkipped:
 					// stage did not fail
 				default:
 					failed = true
@@ -684,7 +693,7 @@ func (o *ControllerBuildOptions) updatePipelineActivityForRun(kubeClient kuberne
 		step := &spec.Steps[i]
 		stage := step.Stage
 		if stage != nil {
-			stageFinished := spec.Status.IsTerminated()
+			stageFinished := spec.Status.IsTerminated() || stage.Status == v1.ActivityStatusTypeSkipped
 			if stage.StartedTimestamp != nil && spec.StartedTimestamp == nil {
 				spec.StartedTimestamp = stage.StartedTimestamp
 			}
@@ -699,7 +708,7 @@ func (o *ControllerBuildOptions) updatePipelineActivityForRun(kubeClient kuberne
 			}
 			if stageFinished {
 				switch stage.Status {
-				case v1.ActivityStatusTypeSucceeded, v1.ActivityStatusTypeNotExecuted:
+				case v1.ActivityStatusTypeSucceeded, v1.ActivityStatusTypeNotExecuted, v1.ActivityStatusTypeSkipped:
 					// stage did not fail
 				default:
 					failed = true
@@ -810,6 +819,17 @@ func updateForStage(si *tekton.StageInfo, a *v1.PipelineActivity) {
 	_, stage, _ := kube.GetOrCreateStage(a, si.GetStageNameIncludingParents())
 	containersTerminated := false
 
+	if si.Skipped {
+		stage.Status = v1.ActivityStatusTypeSkipped
+		for _, nested := range si.Parallel {
+			updateForStage(nested, a)
+		}
+		for _, nested := range si.Stages {
+			updateForStage(nested, a)
+		}
+		return
+	}
+
 	if si.Pod != nil {
 		var stageSteps []v1.CoreActivityStep
 		pod := si.Pod
@@ -848,6 +868,7 @@ func updateForStage(si *tekton.StageInfo, a *v1.PipelineActivity) {
 				} else {
 					step.Status = v1.ActivityStatusTypeFailed
 				}
+				step.Attempts = stepAttempts(terminated.Message)
 			} else {
 				if running != nil && isStepRunning(i, stageSteps) {
 					step.Status = v1.ActivityStatusTypeRunning
@@ -859,6 +880,7 @@ func updateForStage(si *tekton.StageInfo, a *v1.PipelineActivity) {
 			stageSteps = append(stageSteps, step)
 		}
 		stage.Steps = stageSteps
+		stage.RetryAttempts = retryAttemptsForStage(si)
 	}
 
 	for _, nested := range si.Parallel {
@@ -904,7 +926,7 @@ func updateForStage(si *tekton.StageInfo, a *v1.PipelineActivity) {
 				}
 			}
 			if childFinished {
-				if child.Status != v1.ActivityStatusTypeSucceeded {
+				if child.Status != v1.ActivityStatusTypeSucceeded && child.Status != v1.ActivityStatusTypeSkipped {
 					childrenFailed = true
 				}
 			} else {
@@ -992,6 +1014,47 @@ func updateForStage(si *tekton.StageInfo, a *v1.PipelineActivity) {
 	}
 }
 
+// stepAttempts returns the number of times a step was run from the termination message of its container, or zero if
+// the step was not retried.
+func stepAttempts(terminationMessage string) int32 {
+	for _, line := range strings.Split(terminationMessage, "\n") {
+		if strings.HasPrefix(line, syntax.StepAttemptsMessagePrefix) {
+			attempts, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(line, syntax.StepAttemptsMessagePrefix)), 10, 32)
+			if err == nil {
+				return int32(attempts)
+			}
+		}
+	}
+	return 0
+}
+
+// retryAttemptsForStage returns a failed step for each earlier attempt at running the stage when its TaskRun has been
+// retried.
+func retryAttemptsForStage(si *tekton.StageInfo) []v1.CoreActivityStep {
+	var attempts []v1.CoreActivityStep
+	for i, pod := range si.RetriedPods {
+		attempt := v1.CoreActivityStep{
+			Name:        fmt.Sprintf("Attempt %d", i+1),
+			Description: fmt.Sprintf("Pod %s", pod.Name),
+			Status:      v1.ActivityStatusTypeFailed,
+		}
+		if pod.Status.StartTime != nil {
+			attempt.StartedTimestamp = pod.Status.StartTime
+		}
+		_, containerStatuses, _ := kube.GetContainersWithStatusAndIsInit(pod)
+		for _, container := range containerStatuses {
+			if terminated := container.State.Terminated; terminated != nil && !terminated.FinishedAt.IsZero() {
+				finishedAt := terminated.FinishedAt
+				if attempt.CompletedTimestamp == nil || finishedAt.After(attempt.CompletedTimestamp.Time) {
+					attempt.CompletedTimestamp = &finishedAt
+				}
+			}
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts
+}
+
 // didPreviousStepFail checks if the step before the given index failed. This is used to mark not-actually-executed steps
 // correctly.
 func didPreviousStepFail(index int, stageSteps []v1.CoreActivityStep) bool {
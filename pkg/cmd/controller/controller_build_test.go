@@ -14,6 +14,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/tekton"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
 	"github.com/jenkins-x/jx/pkg/tekton/tekton_helpers_test"
 	"github.com/stretchr/testify/assert"
 	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
@@ -142,6 +143,82 @@ func TestUpdateForStage(t *testing.T) {
 	}
 }
 
+func TestUpdateForStageWithRetries(t *testing.T) {
+	pod := tekton_helpers_test.AssertLoadSinglePod(t, path.Join("test_data", "controller_build", "update_stage_info"))
+	retriedPod := pod.DeepCopy()
+	retriedPod.Name = "jenkins-x-jx-pr-4135-integratio-42-ci-kdjkp-pod-a1b2c3"
+	si := &tekton.StageInfo{
+		Name:        "ci",
+		CreatedTime: *parseTime(t, "2019-06-07T18:14:19-00:00"),
+		PodName:     pod.Name,
+		TaskRun:     "jenkins-x-jx-pr-4135-integratio-42-ci-kdjkp",
+		Task:        "jenkins-x-jx-pr-4135-integratio-ci-42",
+		Parents:     []string{},
+		Pod:         pod,
+		RetriedPods: []*corev1.Pod{retriedPod},
+	}
+
+	act := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "jenkins-x-jx-pr-4135-42",
+		},
+	}
+
+	updateForStage(si, act)
+
+	assert.Len(t, act.Spec.Steps, 1, "No steps/stages found on activity")
+	stage := act.Spec.Steps[0].Stage
+	assert.NotNil(t, stage, "First step on activity is not a stage")
+	assert.Len(t, stage.RetryAttempts, 1)
+
+	attempt := stage.RetryAttempts[0]
+	assert.Equal(t, "Attempt 1", attempt.Name)
+	assert.Equal(t, "Pod "+retriedPod.Name, attempt.Description)
+	assert.Equal(t, v1.ActivityStatusTypeFailed, attempt.Status)
+	assert.NotNil(t, attempt.CompletedTimestamp, "retry attempt has no completion time")
+	assert.Equal(t, stage.CompletedTimestamp, attempt.CompletedTimestamp)
+}
+
+func TestUpdateForStageWithStepRetries(t *testing.T) {
+	pod := tekton_helpers_test.AssertLoadSinglePod(t, path.Join("test_data", "controller_build", "update_stage_info"))
+	retried := -1
+	for i, c := range pod.Status.ContainerStatuses {
+		if c.Name == "step-build" {
+			retried = i
+			pod.Status.ContainerStatuses[i].State.Terminated.Message = syntax.StepAttemptsMessagePrefix + "3\n"
+		}
+	}
+	assert.True(t, retried >= 0, "no build step found in pod")
+	si := &tekton.StageInfo{
+		Name:        "ci",
+		CreatedTime: *parseTime(t, "2019-06-07T18:14:19-00:00"),
+		PodName:     pod.Name,
+		TaskRun:     "jenkins-x-jx-pr-4135-integratio-42-ci-kdjkp",
+		Task:        "jenkins-x-jx-pr-4135-integratio-ci-42",
+		Parents:     []string{},
+		Pod:         pod,
+	}
+
+	act := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "jenkins-x-jx-pr-4135-42",
+		},
+	}
+
+	updateForStage(si, act)
+
+	assert.Len(t, act.Spec.Steps, 1, "No steps/stages found on activity")
+	stage := act.Spec.Steps[0].Stage
+	assert.NotNil(t, stage, "First step on activity is not a stage")
+	for _, step := range stage.Steps {
+		if step.Name == "Build" {
+			assert.Equal(t, int32(3), step.Attempts, "attempts of step %s", step.Name)
+		} else {
+			assert.Equal(t, int32(0), step.Attempts, "attempts of step %s", step.Name)
+		}
+	}
+}
+
 func TestCreateReportTargetURL(t *testing.T) {
 	params := ReportParams{
 		Owner:      "jstrachan",
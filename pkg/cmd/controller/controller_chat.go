@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/chats"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/yaml"
+)
+
+// ControllerChatOptions the options for the controller
+type ControllerChatOptions struct {
+	ControllerOptions
+
+	notifier *chats.Notifier
+}
+
+var (
+	controllerChatLong = templates.LongDesc(`
+		Posts notifications of pipelines, promotions and releases to chat channels.
+
+		The start of a pipeline is posted to a channel and updated when the pipeline succeeds or fails. The completion
+		and the promotion Pull Requests of the pipeline are replied in the thread of the message too.
+
+		The chat configuration is the 'config.yaml' key of the ConfigMap ` + kube.ConfigMapChatNotifications + ` which
+		uses the same format as the 'chat' section of 'jenkins-x.yml'. Its 'notifications' rules route the notifications
+		of repositories to channels by their branch and event. If there are no rules, all notifications are posted to
+		the 'developerChannel'.
+
+		The pipelines of a repository are also posted to the 'developerChannel' of the 'chat' section of its
+		'jenkins-x.yml' and its promotions and releases to its 'userChannel', if it uses the same chat server.
+
+		The events are: pipeline-started, pipeline-succeeded, pipeline-failed, promotion and release.
+`)
+
+	controllerChatExample = templates.Examples(`
+		# run the chat notification controller
+		jx controller chat
+
+		# an example configuration posting failures of all repositories and the releases of the 'myorg' repositories
+		kind: slack
+		url: https://myorg.slack.com/
+		notifications:
+		- channel: '#builds'
+		  events: [pipeline-failed]
+		- channel: '#releases'
+		  repositories: ['myorg/*']
+		  events: [release, promotion]
+	`)
+)
+
+// NewCmdControllerChat creates the command
+func NewCmdControllerChat(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &ControllerChatOptions{
+		ControllerOptions: ControllerOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "chat",
+		Short:   "Posts notifications of pipelines, promotions and releases to chat channels",
+		Long:    controllerChatLong,
+		Example: controllerChatExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	return cmd
+}
+
+// Run implements this command
+func (o *ControllerChatOptions) Run() error {
+	// Always run in batch mode as a controller is never run interactively
+	o.BatchMode = true
+
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	chatConfig, err := chats.LoadChatConfig(kubeClient, ns)
+	if err != nil {
+		return err
+	}
+	if chatConfig == nil || chatConfig.URL == "" {
+		return fmt.Errorf("no chat server URL is configured in the ConfigMap %s in namespace %s", kube.ConfigMapChatNotifications, ns)
+	}
+	provider, err := o.createChatProvider(chatConfig)
+	if err != nil {
+		return err
+	}
+	o.notifier = chats.NewNotifier(provider, chatConfig)
+	o.notifier.RepositoryConfig = o.repositoryChatConfig
+
+	releaseListWatch := cache.NewListWatchFromClient(jxClient.JenkinsV1().RESTClient(), "releases", ns, fields.Everything())
+	kube.SortListWatchByName(releaseListWatch)
+	_, releaseController := cache.NewInformer(
+		releaseListWatch,
+		&v1.Release{},
+		time.Minute*10,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				o.onRelease(obj)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				o.onRelease(newObj)
+			},
+			DeleteFunc: func(obj interface{}) {
+				o.onDeleted(obj, o.notifier.OnReleaseDeleted)
+			},
+		},
+	)
+	stop := make(chan struct{})
+	go releaseController.Run(stop)
+
+	activityListWatch := cache.NewListWatchFromClient(jxClient.JenkinsV1().RESTClient(), "pipelineactivities", ns, fields.Everything())
+	kube.SortListWatchByName(activityListWatch)
+	_, activityController := cache.NewInformer(
+		activityListWatch,
+		&v1.PipelineActivity{},
+		time.Minute*10,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				o.onPipelineActivity(obj)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				o.onPipelineActivity(newObj)
+			},
+			DeleteFunc: func(obj interface{}) {
+				o.onDeleted(obj, o.notifier.OnPipelineActivityDeleted)
+			},
+		},
+	)
+	log.Logger().Infof("Posting notifications to the chat server %s", util.ColorInfo(chatConfig.URL))
+	activityController.Run(stop)
+	return nil
+}
+
+func (o *ControllerChatOptions) onPipelineActivity(obj interface{}) {
+	activity, ok := obj.(*v1.PipelineActivity)
+	if !ok {
+		log.Logger().Warnf("chat controller: unexpected type %v", obj)
+		return
+	}
+	err := o.notifier.OnPipelineActivity(activity)
+	if err != nil {
+		log.Logger().Warnf("failed to post the notifications of PipelineActivity %s: %s", activity.Name, err)
+	}
+}
+
+func (o *ControllerChatOptions) onRelease(obj interface{}) {
+	release, ok := obj.(*v1.Release)
+	if !ok {
+		log.Logger().Warnf("chat controller: unexpected type %v", obj)
+		return
+	}
+	err := o.notifier.OnRelease(release)
+	if err != nil {
+		log.Logger().Warnf("failed to post the notification of Release %s: %s", release.Name, err)
+	}
+}
+
+// onDeleted forgets the notifications of a deleted resource including one deleted while it was not watched
+func (o *ControllerChatOptions) onDeleted(obj interface{}, forget func(name string)) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		log.Logger().Warnf("chat controller: failed to get the key of the deleted resource %v: %s", obj, err)
+		return
+	}
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		log.Logger().Warnf("chat controller: failed to get the name of the deleted resource %v: %s", obj, err)
+		return
+	}
+	forget(name)
+}
+
+// repositoryChatConfig loads the 'chat' section of the 'jenkins-x.yml' in the default branch of the repository
+// returning nil if the repository has none
+func (o *ControllerChatOptions) repositoryChatConfig(gitURL string, owner string, repository string) (*config.ChatConfig, error) {
+	if gitURL == "" {
+		return nil, nil
+	}
+	gitProvider, err := o.GitProviderForURL(gitURL, "git provider")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the git provider of %s", gitURL)
+	}
+	content, err := gitProvider.GetContent(owner, repository, config.ProjectConfigFileName, "")
+	if err != nil || content == nil {
+		log.Logger().Debugf("no %s in repository %s/%s: %v", config.ProjectConfigFileName, owner, repository, err)
+		return nil, nil
+	}
+	data := []byte(content.Content)
+	if content.Encoding == "base64" {
+		data, err = base64.StdEncoding.DecodeString(content.Content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode the %s of repository %s/%s", config.ProjectConfigFileName, owner, repository)
+		}
+	}
+	projectConfig := &config.ProjectConfig{}
+	err = yaml.Unmarshal(data, projectConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the %s of repository %s/%s", config.ProjectConfigFileName, owner, repository)
+	}
+	return projectConfig.Chat, nil
+}
+
+// createChatProvider creates the chat provider of the chat server using the credentials in the chat auth config
+func (o *ControllerChatOptions) createChatProvider(chatConfig *config.ChatConfig) (chats.ChatProvider, error) {
+	authConfigSvc, err := o.CreateChatAuthConfigService("")
+	if err != nil {
+		return nil, err
+	}
+	cfg := authConfigSvc.Config()
+	server := cfg.GetOrCreateServer(chatConfig.URL)
+	if server.Kind == "" {
+		server.Kind = chatConfig.Kind
+	}
+	userAuth, err := cfg.PickServerUserAuth(server, "user to access the chat service at "+chatConfig.URL, o.BatchMode, "", o.GetIOFileHandles())
+	if err != nil {
+		return nil, err
+	}
+	return chats.CreateChatProvider(server.Kind, server, userAuth, o.BatchMode)
+}
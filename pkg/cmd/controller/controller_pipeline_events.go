@@ -0,0 +1,314 @@
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	pipline_events "github.com/jenkins-x/jx/pkg/pipeline_events"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	sentVersionsSaveInterval = 30 * time.Second
+
+	pipelineActivitiesKind = "pipelineactivities"
+	releasesKind           = "releases"
+)
+
+// ControllerPipelineEventsOptions the options for the controller
+type ControllerPipelineEventsOptions struct {
+	ControllerOptions
+
+	Resend bool
+
+	dispatcher *pipline_events.Dispatcher
+	started    time.Time
+
+	// sentVersions are the resourceVersions of the last resources sent by their kind, which are saved in the
+	// ConfigMapPipelineEventsStatus ConfigMap so that the resources changed while the controller was down are sent
+	// when it starts
+	sentVersions     map[string]string
+	sentVersionsLock sync.Mutex
+}
+
+var (
+	controllerPipelineEventsLong = templates.LongDesc(`
+		Streams the changes of PipelineActivity and Release resources to the configured pipeline events sinks.
+
+		The promotions of a pipeline are streamed as changes of its PipelineActivity. The events of each sink are
+		buffered and retried so that a slow or unavailable sink does not hold up the others. The events of a sink are
+		sent in order, so while an event is retried for up to the 'retryTimeout' the later events of that sink wait
+		in its buffer. The numbers of events sent, failed and dropped by each sink are logged every 'statsInterval'.
+
+		The resourceVersions of the last resources sent are recorded in the ConfigMap ` + kube.ConfigMapPipelineEventsStatus + `
+		so that the resources created or changed while the controller was down are sent when it starts.
+
+		The sinks are configured in the 'config.yaml' key of the ConfigMap ` + kube.ConfigMapPipelineEvents + `. The kinds
+		of sink are: cloudevents, elasticsearch, kafka (using the Kafka REST Proxy) and webhook. The 'secret' of a sink
+		names a Secret with the 'username' and 'password' or the 'token' used to authenticate with the sink.
+`)
+
+	controllerPipelineEventsExample = templates.Examples(`
+		# run the pipeline events controller
+		jx controller pipeline-events
+
+		# an example configuration streaming to a Knative broker, a Kafka topic and a webhook
+		bufferSize: 1000
+		retryTimeout: 30s
+		statsInterval: 5m
+		sinks:
+		- kind: cloudevents
+		  url: http://broker-ingress.knative-eventing.svc.cluster.local/jx/default
+		- kind: kafka
+		  url: http://kafka-rest-proxy:8082
+		  topic: jx-pipeline-events
+		- name: data-platform
+		  kind: webhook
+		  url: https://events.example.com/jx
+		  secret: data-platform-token
+	`)
+)
+
+// NewCmdControllerPipelineEvents creates the command
+func NewCmdControllerPipelineEvents(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &ControllerPipelineEventsOptions{
+		ControllerOptions: ControllerOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "pipeline-events",
+		Short:   "Streams the changes of pipelines, promotions and releases to the pipeline events sinks",
+		Long:    controllerPipelineEventsLong,
+		Example: controllerPipelineEventsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().BoolVarP(&options.Resend, "resend", "", false, "Sends the existing PipelineActivity and Release resources when the controller starts")
+	return cmd
+}
+
+// Run implements this command
+func (o *ControllerPipelineEventsOptions) Run() error {
+	// Always run in batch mode as a controller is never run interactively
+	o.BatchMode = true
+	o.started = time.Now()
+
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	eventsConfig, err := pipline_events.LoadPipelineEventsConfig(kubeClient, ns)
+	if err != nil {
+		return err
+	}
+	if eventsConfig == nil || len(eventsConfig.Sinks) == 0 {
+		return fmt.Errorf("no pipeline events sinks are configured in the ConfigMap %s in namespace %s", kube.ConfigMapPipelineEvents, ns)
+	}
+	retryTimeout, err := eventsConfig.GetRetryTimeout()
+	if err != nil {
+		return err
+	}
+	statsInterval, err := eventsConfig.GetStatsInterval()
+	if err != nil {
+		return err
+	}
+	o.dispatcher = pipline_events.NewDispatcher(eventsConfig.GetBufferSize(), retryTimeout)
+	o.dispatcher.StatsInterval = statsInterval
+	o.dispatcher.Delivered = o.onDelivered
+	for i := range eventsConfig.Sinks {
+		sink := &eventsConfig.Sinks[i]
+		provider, err := pipline_events.CreatePipelineEventsProvider(sink)
+		if err != nil {
+			return err
+		}
+		o.dispatcher.AddSink(sink.Name, provider)
+		log.Logger().Infof("Streaming pipeline events to the %s sink %s at %s", sink.Kind, util.ColorInfo(sink.Name), sink.URL)
+	}
+	o.sentVersions, err = loadSentVersions(kubeClient, ns)
+	if err != nil {
+		return err
+	}
+	stop := make(chan struct{})
+	o.dispatcher.Start(stop)
+	go o.saveSentVersions(kubeClient, ns, stop)
+
+	releaseListWatch := cache.NewListWatchFromClient(jxClient.JenkinsV1().RESTClient(), "releases", ns, fields.Everything())
+	kube.SortListWatchByName(releaseListWatch)
+	_, releaseController := cache.NewInformer(
+		releaseListWatch,
+		&v1.Release{},
+		time.Minute*10,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				o.onRelease(nil, obj)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				o.onRelease(oldObj, newObj)
+			},
+			DeleteFunc: func(obj interface{}) {
+			},
+		},
+	)
+	go releaseController.Run(stop)
+
+	activityListWatch := cache.NewListWatchFromClient(jxClient.JenkinsV1().RESTClient(), "pipelineactivities", ns, fields.Everything())
+	kube.SortListWatchByName(activityListWatch)
+	_, activityController := cache.NewInformer(
+		activityListWatch,
+		&v1.PipelineActivity{},
+		time.Minute*10,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				o.onPipelineActivity(nil, obj)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				o.onPipelineActivity(oldObj, newObj)
+			},
+			DeleteFunc: func(obj interface{}) {
+			},
+		},
+	)
+	activityController.Run(stop)
+	return nil
+}
+
+func (o *ControllerPipelineEventsOptions) onPipelineActivity(oldObj interface{}, obj interface{}) {
+	activity, ok := obj.(*v1.PipelineActivity)
+	if !ok {
+		log.Logger().Warnf("pipeline events controller: unexpected type %v", obj)
+		return
+	}
+	if !o.isChanged(pipelineActivitiesKind, oldObj, &activity.ObjectMeta) {
+		return
+	}
+	err := o.dispatcher.SendActivity(activity.DeepCopy())
+	if err != nil {
+		log.Logger().Warnf("%s", err)
+	}
+}
+
+func (o *ControllerPipelineEventsOptions) onRelease(oldObj interface{}, obj interface{}) {
+	release, ok := obj.(*v1.Release)
+	if !ok {
+		log.Logger().Warnf("pipeline events controller: unexpected type %v", obj)
+		return
+	}
+	if !o.isChanged(releasesKind, oldObj, &release.ObjectMeta) {
+		return
+	}
+	err := o.dispatcher.SendRelease(release.DeepCopy())
+	if err != nil {
+		log.Logger().Warnf("%s", err)
+	}
+}
+
+// onDelivered records the resourceVersion of a resource once its event has been sent to every sink, so that a
+// resource whose event was dropped or failed to be sent is not recorded as sent
+func (o *ControllerPipelineEventsOptions) onDelivered(resource interface{}) {
+	switch r := resource.(type) {
+	case *v1.PipelineActivity:
+		o.recordSent(pipelineActivitiesKind, &r.ObjectMeta)
+	case *v1.Release:
+		o.recordSent(releasesKind, &r.ObjectMeta)
+	}
+}
+
+// isChanged returns false for the periodic resyncs of the informers and, unless resending, for the resources which
+// have not changed since the last resource of their kind was sent. If nothing has been sent yet the resources which
+// existed before the controller started are not sent
+func (o *ControllerPipelineEventsOptions) isChanged(kind string, oldObj interface{}, objectMeta *metav1.ObjectMeta) bool {
+	if oldObj == nil {
+		if o.Resend {
+			return true
+		}
+		o.sentVersionsLock.Lock()
+		sentVersion := o.sentVersions[kind]
+		o.sentVersionsLock.Unlock()
+		if sentVersion == "" {
+			return !objectMeta.CreationTimestamp.Time.Before(o.started)
+		}
+		return kube.IsResourceVersionNewer(objectMeta.ResourceVersion, sentVersion)
+	}
+	oldMeta, err := meta.Accessor(oldObj)
+	if err != nil {
+		return true
+	}
+	return oldMeta.GetResourceVersion() != objectMeta.ResourceVersion
+}
+
+// recordSent records the resourceVersion of the resource if it is newer than the last one sent of its kind
+func (o *ControllerPipelineEventsOptions) recordSent(kind string, objectMeta *metav1.ObjectMeta) {
+	o.sentVersionsLock.Lock()
+	defer o.sentVersionsLock.Unlock()
+	if kube.IsResourceVersionNewer(objectMeta.ResourceVersion, o.sentVersions[kind]) {
+		o.sentVersions[kind] = objectMeta.ResourceVersion
+	}
+}
+
+// saveSentVersions saves the resourceVersions of the last resources sent whenever they change until the stop channel
+// is closed
+func (o *ControllerPipelineEventsOptions) saveSentVersions(kubeClient kubernetes.Interface, ns string, stop <-chan struct{}) {
+	saved := map[string]string{}
+	ticker := time.NewTicker(sentVersionsSaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			o.sentVersionsLock.Lock()
+			versions := util.MergeMaps(o.sentVersions)
+			o.sentVersionsLock.Unlock()
+			if reflect.DeepEqual(saved, versions) {
+				continue
+			}
+			_, err := kube.DefaultModifyConfigMap(kubeClient, ns, kube.ConfigMapPipelineEventsStatus, func(cm *corev1.ConfigMap) error {
+				cm.Data = versions
+				return nil
+			}, nil)
+			if err != nil {
+				log.Logger().Warnf("failed to save the resourceVersions of the last pipeline events sent: %s", err)
+				continue
+			}
+			saved = versions
+		}
+	}
+}
+
+// loadSentVersions loads the resourceVersions of the last resources sent returning an empty map if none were saved
+func loadSentVersions(kubeClient kubernetes.Interface, ns string) (map[string]string, error) {
+	cm, err := kubeClient.CoreV1().ConfigMaps(ns).Get(kube.ConfigMapPipelineEventsStatus, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return map[string]string{}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get ConfigMap %s in namespace %s", kube.ConfigMapPipelineEventsStatus, ns)
+	}
+	return util.MergeMaps(cm.Data), nil
+}
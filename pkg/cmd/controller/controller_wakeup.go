@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/previews"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ControllerWakeupOptions holds the command line arguments
+type ControllerWakeupOptions struct {
+	*opts.CommonOptions
+
+	BindAddress string
+	Port        int
+	RetryAfter  int
+
+	kubeClient kubernetes.Interface
+	jxClient   versioned.Interface
+	devNs      string
+}
+
+var (
+	controllerWakeupLong = templates.LongDesc(`
+		Wakes up Preview Environments which have been scaled to zero by 'jx gc previews' on their first request.
+
+		When the 'previewGC' policy in the team settings has 'scaleToZero' enabled and a 'wakeupService' which is the
+		host name of the service of this controller, the ingresses of a Preview Environment which is scaled to zero are
+		routed to this controller. The first request to a Preview Environment scales its deployments back up and
+		restores its ingresses, while the response asks the browser to retry once the application has started.
+`)
+
+	controllerWakeupExample = templates.Examples(`
+		# run the wakeup controller
+		jx controller wakeup
+	`)
+)
+
+// NewCmdControllerWakeup creates the command
+func NewCmdControllerWakeup(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := ControllerWakeupOptions{
+		CommonOptions: commonOpts,
+	}
+	cmd := &cobra.Command{
+		Use:     "wakeup",
+		Short:   "Wakes up Preview Environments which have been scaled to zero on their first request",
+		Long:    controllerWakeupLong,
+		Example: controllerWakeupExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().IntVarP(&options.Port, optionPort, "", 8080, "The TCP port to listen on.")
+	cmd.Flags().StringVarP(&options.BindAddress, optionBind, "", "",
+		"The interface address to bind to (by default, will listen on all interfaces/addresses).")
+	cmd.Flags().IntVarP(&options.RetryAfter, "retry-after", "", 15, "The number of seconds the browser is asked to wait before retrying the request")
+	return cmd
+}
+
+// Run implements this command
+func (o *ControllerWakeupOptions) Run() error {
+	var err error
+	o.kubeClient, err = o.KubeClient()
+	if err != nil {
+		return err
+	}
+	o.jxClient, o.devNs, err = o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(healthPath, http.HandlerFunc(o.health))
+	mux.Handle(readyPath, http.HandlerFunc(o.health))
+	mux.Handle("/", http.HandlerFunc(o.handleRequest))
+
+	address := o.BindAddress + ":" + strconv.Itoa(o.Port)
+	log.Logger().Infof("Wakeup Controller is now listening on %s for requests to Preview Environments scaled to zero", util.ColorInfo(address))
+	return http.ListenAndServe(address, mux)
+}
+
+// health returns HTTP 204 if the service is healthy
+func (o *ControllerWakeupOptions) health(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRequest wakes up the Preview Environment which has an ingress for the host of the request
+func (o *ControllerWakeupOptions) handleRequest(w http.ResponseWriter, r *http.Request) {
+	env, err := previews.FindByHost(o.kubeClient, o.jxClient, o.devNs, r.Host)
+	if err != nil {
+		log.Logger().Warnf("Failed to find the Preview Environment for host %s: %s", r.Host, err)
+		http.Error(w, "failed to find the preview environment", http.StatusInternalServerError)
+		return
+	}
+	if env == nil {
+		http.NotFound(w, r)
+		return
+	}
+	log.Logger().Infof("Waking up Preview Environment %s for a request to %s", util.ColorInfo(env.Name), r.Host)
+	err = previews.WakeUp(o.kubeClient, o.jxClient, o.devNs, env)
+	if err != nil {
+		log.Logger().Warnf("Failed to wake up Preview Environment %s: %s", env.Name, err)
+		http.Error(w, "failed to wake up the preview environment", http.StatusInternalServerError)
+		return
+	}
+	retryAfter := strconv.Itoa(o.RetryAfter)
+	w.Header().Set("Retry-After", retryAfter)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	fmt.Fprintf(w, `<html><head><meta http-equiv="refresh" content="%s"></head><body>The preview environment %s is starting up. This page will refresh in %s seconds.</body></html>`,
+		retryAfter, html.EscapeString(env.Name), retryAfter)
+}
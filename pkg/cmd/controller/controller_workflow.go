@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
@@ -46,10 +47,23 @@ type ControllerWorkflowOptions struct {
 	PullRequestPollTime     string
 	NoWaitForUpdatePipeline bool
 
+	// VerifyCheckRunner runs the 'jx step verify' arguments of a verify step, defaulting to invoking jx
+	VerifyCheckRunner func(args []string) (string, error)
+	// PipelineStarter triggers the pipeline of a verify step, defaulting to 'jx start pipeline'
+	PipelineStarter func(pipeline string) error
+	// RollbackRunner rolls back the application in the environment after a failed verify step, defaulting to 'jx rollback'
+	RollbackRunner func(app string, env string, reason string) error
+	// PromotionResumer runs a promotion which was queued until a freeze window ended, defaulting to promoting and
+	// waiting for the promotion to complete
+	PromotionResumer func(po *promote.PromoteOptions) error
+
 	// calculated fields
 	PullRequestPollDuration *time.Duration
 	workflowMap             map[string]*v1.Workflow
 	pipelineMap             map[string]*v1.PipelineActivity
+	runningChecks           map[string]bool
+	resumingPromotions      map[string]bool
+	checkLock               sync.Mutex
 }
 
 // NewCmdControllerWorkflow creates a command object for the generic "get" action, which
@@ -175,6 +189,8 @@ func (o *ControllerWorkflowOptions) Run() error {
 			log.Logger().Debugf("Polling to see if any PRs have merged: %v", t)
 			//o.pollGitPipelineStatuses(jxClient, ns)
 			o.ReloadAndPollGitPipelineStatuses(jxClient, ns)
+			o.pollWorkflowSteps(jxClient, ns)
+			o.ResumeQueuedPromotions(jxClient, ns)
 		}
 	}()
 
@@ -311,16 +327,18 @@ func (o *ControllerWorkflowOptions) onActivity(pipeline *v1.PipelineActivity, jx
 		promoteStatusMap := createPromoteStatus(pipeline)
 
 		allStepsComplete := true
-		for _, step := range flow.Spec.Steps {
-			promote := step.Promote
-			if promote != nil {
-				envName := promote.Environment
-				if envName != "" {
+		stepsChanged := false
+		for i := range flow.Spec.Steps {
+			step := &flow.Spec.Steps[i]
+			stepName := workflow.StepName(step, i)
+			switch workflow.StepKind(step) {
+			case v1.WorkflowStepKindTypePromote, v1.WorkflowStepKindTypeParallel:
+				for _, envName := range workflow.StepEnvironments(step) {
 					status := promoteStatusMap[envName]
 					if status == nil || status.PullRequest == nil || status.PullRequest.PullRequestURL == "" {
 						allStepsComplete = false
 						// can we generate a PR now?
-						if canExecuteStep(flow, pipeline, &step, promoteStatusMap, envName) {
+						if canExecuteStep(flow, pipeline, step, promoteStatusMap, envName) {
 							log.Logger().Infof("Creating PR for environment %s from PipelineActivity %s as current status is %#v", envName, pipeline.Name, status)
 							po := o.createPromoteOptions(repoName, envName, pipelineName, build, version)
 
@@ -334,9 +352,25 @@ func (o *ControllerWorkflowOptions) onActivity(pipeline *v1.PipelineActivity, jx
 						allStepsComplete = false
 					}
 				}
+			case v1.WorkflowStepKindTypeNone:
+				log.Logger().Debugf("Ignoring step %s of Workflow %s as it has no kind", stepName, flow.Name)
+			default:
+				succeeded, changed := o.runWorkflowStep(flow, pipeline, step, stepName, promoteStatusMap, jxClient, ns)
+				if !succeeded {
+					allStepsComplete = false
+				}
+				if changed {
+					stepsChanged = true
+				}
+			}
+		}
+		if stepsChanged && !allStepsComplete {
+			err := o.updateWorkflowSteps(pipeline, activities)
+			if err != nil {
+				log.Logger().Warnf("Failed to update the workflow steps of PipelineActivity %s: %s", pipeline.Name, err)
 			}
 		}
-		if allStepsComplete && (pipeline.Spec.Status != v1.ActivityStatusTypeSucceeded || pipeline.Spec.WorkflowStatus != v1.ActivityStatusTypeSucceeded) {
+		if allStepsComplete && (stepsChanged || pipeline.Spec.Status != v1.ActivityStatusTypeSucceeded || pipeline.Spec.WorkflowStatus != v1.ActivityStatusTypeSucceeded) {
 			pipeline.Spec.Status = v1.ActivityStatusTypeSucceeded
 			pipeline.Spec.WorkflowStatus = v1.ActivityStatusTypeSucceeded
 			_, err := jxClient.JenkinsV1().PipelineActivities(ns).PatchUpdate(pipeline)
@@ -679,7 +713,7 @@ func (o *ControllerWorkflowOptions) createReleaseInfo(activity *v1.PipelineActiv
 	}
 }
 
-func canExecuteStep(workflow *v1.Workflow, activity *v1.PipelineActivity, step *v1.WorkflowStep, statusMap map[string]*v1.PromoteActivityStep, promoteToEnv string) bool {
+func canExecuteStep(flow *v1.Workflow, activity *v1.PipelineActivity, step *v1.WorkflowStep, statusMap map[string]*v1.PromoteActivityStep, promoteToEnv string) bool {
 	for _, envName := range step.Preconditions.Environments {
 		status := statusMap[envName]
 		if status == nil {
@@ -691,6 +725,29 @@ func canExecuteStep(workflow *v1.Workflow, activity *v1.PipelineActivity, step *
 			return false
 		}
 	}
+	for _, stepName := range step.Preconditions.Steps {
+		precondition, _ := workflow.FindStep(flow, stepName)
+		if precondition == nil {
+			log.Logger().Warnf("Cannot execute %s as precondition step: %s does not exist in Workflow %s", promoteToEnv, stepName, flow.Name)
+			return false
+		}
+		envNames := workflow.StepEnvironments(precondition)
+		if len(envNames) > 0 {
+			for _, envName := range envNames {
+				status := statusMap[envName]
+				if status == nil || status.Status != v1.ActivityStatusTypeSucceeded {
+					log.Logger().Debugf("Cannot execute %s as precondition step: %s has not promoted to Environment: %s", promoteToEnv, stepName, envName)
+					return false
+				}
+			}
+			continue
+		}
+		status := workflow.GetStepStatus(activity, stepName)
+		if status == nil || status.Status != v1.WorkflowStatusTypeSucceeded {
+			log.Logger().Debugf("Cannot execute %s as precondition step: %s has not succeeded", promoteToEnv, stepName)
+			return false
+		}
+	}
 	return true
 }
 
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/cmd/promote"
+	"github.com/jenkins-x/jx/pkg/freeze"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// queuedPromotionTimeout is how long a resumed promotion waits for its Pull Request to merge and the environment to
+// be updated, which is the default of 'jx promote'
+const queuedPromotionTimeout = "1h"
+
+// queuedPromotions returns the environments of the promotions of the activity which are queued until a freeze
+// window ends
+func queuedPromotions(activity *v1.PipelineActivity) []string {
+	answer := []string{}
+	for _, step := range activity.Spec.Steps {
+		promote := step.Promote
+		if promote != nil && promote.Freeze != nil && promote.Freeze.Status == v1.ActivityStatusTypePending && promote.Environment != "" {
+			answer = append(answer, promote.Environment)
+		}
+	}
+	return answer
+}
+
+// ResumeQueuedPromotions promotes any queued promotions of PipelineActivity resources without a workflow whose freeze
+// window has ended. Queued promotions of workflows are resumed when the workflow is polled. A promotion is queued
+// while its Freeze step is pending whatever the status of the activity, as the pipeline which ran 'jx promote'
+// succeeds once the promotion is queued.
+func (o *ControllerWorkflowOptions) ResumeQueuedPromotions(jxClient versioned.Interface, ns string) {
+	activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	if err != nil {
+		log.Logger().Warnf("Failed to list PipelineActivity resources: %s", err)
+		return
+	}
+	var teamSettings *v1.TeamSettings
+	for i := range activities.Items {
+		activity := &activities.Items[i]
+		if activity.Spec.Workflow != "" {
+			continue
+		}
+		for _, envName := range queuedPromotions(activity) {
+			env, err := jxClient.JenkinsV1().Environments(ns).Get(envName, metav1.GetOptions{})
+			if err != nil {
+				log.Logger().Warnf("Failed to find Environment %s for the queued promotion of PipelineActivity %s: %s", envName, activity.Name, err)
+				continue
+			}
+			if teamSettings == nil {
+				teamSettings, err = o.TeamSettings()
+				if err != nil {
+					log.Logger().Warnf("Failed to load the team settings: %s", err)
+				}
+			}
+			window, err := freeze.ActiveWindow(freeze.Windows(env, teamSettings), time.Now())
+			if err != nil {
+				log.Logger().Warnf("Failed to check the freeze windows of Environment %s: %s", envName, err)
+				continue
+			}
+			if window != nil {
+				log.Logger().Debugf("Promotion of PipelineActivity %s to Environment %s is still frozen by %s", activity.Name, envName, freeze.Describe(window))
+				continue
+			}
+			o.resumePromotion(activity, envName)
+		}
+	}
+}
+
+// resumePromotion runs the queued promotion of the activity into the environment in the background, so that waiting
+// for the promotion to complete does not block polling. A promotion is only resumed once at a time by this process
+func (o *ControllerWorkflowOptions) resumePromotion(activity *v1.PipelineActivity, envName string) {
+	key := activity.Name + "/" + envName
+	o.checkLock.Lock()
+	defer o.checkLock.Unlock()
+	if o.resumingPromotions == nil {
+		o.resumingPromotions = map[string]bool{}
+	}
+	if o.resumingPromotions[key] {
+		return
+	}
+	o.resumingPromotions[key] = true
+
+	log.Logger().Infof("Resuming the queued promotion of PipelineActivity %s to Environment %s as the freeze has ended", activity.Name, util.ColorInfo(envName))
+	po := o.createPromoteOptionsFromActivity(activity, envName)
+	resumer := o.PromotionResumer
+	if resumer == nil {
+		resumer = o.runQueuedPromotion
+	}
+	activityName := activity.Name
+	go func() {
+		defer func() {
+			o.checkLock.Lock()
+			delete(o.resumingPromotions, key)
+			o.checkLock.Unlock()
+		}()
+		err := resumer(po)
+		if err != nil {
+			log.Logger().Warnf("Failed to resume the promotion of PipelineActivity %s to Environment %s: %s", activityName, envName, err)
+		}
+	}()
+}
+
+// runQueuedPromotion promotes and waits for the promotion to complete, as 'jx promote' would have done if it had not
+// been frozen, so that the issues of the release are commented on and the promotion is completed on the activity
+func (o *ControllerWorkflowOptions) runQueuedPromotion(po *promote.PromoteOptions) error {
+	po.NoPoll = false
+	po.Timeout = queuedPromotionTimeout
+	po.PullRequestPollTime = o.PullRequestPollTime
+	return po.Run()
+}
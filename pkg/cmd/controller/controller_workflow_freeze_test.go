@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/promote"
+	"github.com/jenkins-x/jx/pkg/cmd/testhelpers"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func queuedPromotionActivity(name string, env string, status v1.ActivityStatusType) *v1.PipelineActivity {
+	return &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "jx",
+		},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:      "jstrachan/" + name + "/master",
+			Build:         "1",
+			Version:       "0.0.1",
+			GitRepository: name,
+			Status:        status,
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypePromote,
+					Promote: &v1.PromoteActivityStep{
+						Environment: env,
+						CoreActivityStep: v1.CoreActivityStep{
+							Status: v1.ActivityStatusTypePending,
+						},
+						Freeze: &v1.PromoteFreezeStep{
+							CoreActivityStep: v1.CoreActivityStep{
+								Status: v1.ActivityStatusTypePending,
+							},
+							Window: "weekend",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestResumeQueuedPromotions(t *testing.T) {
+	staging := kube.NewPermanentEnvironment("staging")
+	production := kube.NewPermanentEnvironment("production")
+	production.Spec.FreezeWindows = []v1.FreezeWindow{
+		{
+			Name:   "code-freeze",
+			Reason: "until further notice",
+		},
+	}
+
+	// the pipeline which ran 'jx promote' succeeds once the promotion is queued
+	succeeded := queuedPromotionActivity("succeeded", "staging", v1.ActivityStatusTypeSucceeded)
+	running := queuedPromotionActivity("running", "staging", v1.ActivityStatusTypeRunning)
+	frozen := queuedPromotionActivity("frozen", "production", v1.ActivityStatusTypeSucceeded)
+	workflow := queuedPromotionActivity("workflow", "staging", v1.ActivityStatusTypeRunning)
+	workflow.Spec.Workflow = "default"
+	resumed := queuedPromotionActivity("resumed", "staging", v1.ActivityStatusTypeSucceeded)
+	resumed.Spec.Steps[0].Promote.Freeze.Status = v1.ActivityStatusTypeSucceeded
+
+	o := &ControllerWorkflowOptions{
+		CommonOptions: &opts.CommonOptions{},
+		Namespace:     "jx",
+	}
+	testhelpers.ConfigureTestOptionsWithResources(o.CommonOptions,
+		[]runtime.Object{},
+		[]runtime.Object{staging, production, succeeded, running, frozen, workflow, resumed},
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+
+	promotions := make(chan *promote.PromoteOptions, 10)
+	o.PromotionResumer = func(po *promote.PromoteOptions) error {
+		promotions <- po
+		return nil
+	}
+
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	require.NoError(t, err)
+	o.ResumeQueuedPromotions(jxClient, ns)
+
+	actual := map[string]string{}
+	for i := 0; i < 2; i++ {
+		select {
+		case po := <-promotions:
+			actual[po.Application] = po.Environment
+			assert.Equal(t, "0.0.1", po.Version, "version of the promotion of %s", po.Application)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for the queued promotions to be resumed, resumed %v", actual)
+		}
+	}
+	assert.Equal(t, map[string]string{"succeeded": "staging", "running": "staging"}, actual)
+
+	select {
+	case po := <-promotions:
+		t.Fatalf("unexpected promotion of %s to %s", po.Application, po.Environment)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/workflow"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -778,6 +779,151 @@ func TestPullRequestNumber(t *testing.T) {
 	}
 }
 
+func TestApprovalWorkflow(t *testing.T) {
+	originalJxHome, tempJxHome, err := testhelpers.CreateTestJxHomeDir()
+	assert.NoError(t, err)
+	defer func() {
+		err := testhelpers.CleanupTestJxHomeDir(originalJxHome, tempJxHome)
+		assert.NoError(t, err)
+	}()
+	originalKubeCfg, tempKubeCfg, err := testhelpers.CreateTestKubeConfigDir()
+	assert.NoError(t, err)
+	defer func() {
+		err := testhelpers.CleanupTestKubeConfigDir(originalKubeCfg, tempKubeCfg)
+		assert.NoError(t, err)
+	}()
+
+	testOrgName := "jstrachan"
+	testRepoName := "approved"
+	stagingRepoName := "environment-staging"
+	prodRepoName := "environment-production"
+
+	staging := kube.NewPermanentEnvironmentWithGit("staging", "https://fake.git/"+testOrgName+"/"+stagingRepoName+".git")
+	production := kube.NewPermanentEnvironmentWithGit("production", "https://fake.git/"+testOrgName+"/"+prodRepoName+".git")
+
+	gitter := gits.NewGitCLI()
+
+	addFiles := func(dir string) error {
+		err = os.MkdirAll(filepath.Join(dir, "templates"), 0700)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(staging)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(filepath.Join(dir, "templates", "environment-staging.yaml"), data, 0755)
+		if err != nil {
+			return err
+		}
+		data, err = json.Marshal(production)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(dir, "templates", "environment-production.yaml"), data, 0755)
+	}
+
+	fakeRepo, _ := gits.NewFakeRepository(testOrgName, testRepoName, nil, nil)
+	stagingRepo, _ := gits.NewFakeRepository(testOrgName, stagingRepoName, addFiles, gitter)
+	prodRepo, _ := gits.NewFakeRepository(testOrgName, prodRepoName, addFiles, gitter)
+
+	fakeGitProvider := gits.NewFakeProvider(fakeRepo, stagingRepo, prodRepo)
+	fakeGitProvider.User.Username = testOrgName
+
+	staging.Spec.Order = 100
+	production.Spec.Order = 200
+
+	o := &controller.ControllerWorkflowOptions{
+		CommonOptions: &opts.CommonOptions{},
+		NoWatch:       true,
+		Namespace:     "jx",
+	}
+
+	myFlowName := "myflow"
+
+	step1 := workflow.CreateWorkflowPromoteStep("staging")
+	step2 := workflow.CreateWorkflowApproveStep("change-board", "approve the release to production", step1)
+	step3 := workflow.CreateWorkflowPromoteStep("production")
+	step3.Preconditions.Steps = []string{step2.Name}
+	flow := workflow.CreateWorkflow("jx", myFlowName, step1, step2, step3)
+
+	testhelpers.ConfigureTestOptionsWithResources(o.CommonOptions,
+		[]runtime.Object{},
+		[]runtime.Object{
+			staging,
+			production,
+			flow,
+		},
+		gitter,
+		fakeGitProvider,
+		helm.NewHelmCLI("helm", helm.V2, "", true),
+		resources_test.NewMockInstaller(),
+	)
+
+	err = testhelpers.CreateTestEnvironmentDir(o.CommonOptions)
+	assert.NoError(t, err)
+
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	assert.NoError(t, err)
+
+	a, err := testhelpers.CreateTestPipelineActivity(jxClient, ns, testOrgName, testRepoName, "master", "1", myFlowName)
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+	err = o.Run()
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+	activities := jxClient.JenkinsV1().PipelineActivities(ns)
+	testhelpers.AssertHasPullRequestForEnv(t, activities, a.Name, "staging")
+
+	if !testhelpers.AssertSetPullRequestMerged(t, fakeGitProvider, stagingRepo.Owner, stagingRepo.GitRepo.Name, 1) {
+		return
+	}
+	if !testhelpers.AssertSetPullRequestComplete(t, fakeGitProvider, stagingRepo, 1) {
+		return
+	}
+	pollGitStatusAndReactToPipelineChanges(t, o, jxClient, ns)
+
+	// staging is complete but production must wait for the approval
+	testhelpers.AssertHasPromoteStatus(t, activities, a.Name, "staging", v1.ActivityStatusTypeSucceeded)
+	testhelpers.AssertHasNoPullRequestForEnv(t, activities, a.Name, "production")
+	activity, err := activities.Get(a.Name, metav1.GetOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, v1.ActivityStatusTypeWaitingForApproval, activity.Spec.WorkflowStatus)
+	assert.Equal(t, "change-board", workflow.FindApprovalStep(activity))
+
+	pollGitStatusAndReactToPipelineChanges(t, o, jxClient, ns)
+	testhelpers.AssertHasNoPullRequestForEnv(t, activities, a.Name, "production")
+
+	activity, err = activities.Get(a.Name, metav1.GetOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = workflow.Approve(activity, flow, "", "jstrachan", true)
+	assert.NoError(t, err)
+	_, err = activities.PatchUpdate(activity)
+	assert.NoError(t, err)
+
+	pollGitStatusAndReactToPipelineChanges(t, o, jxClient, ns)
+	testhelpers.AssertHasPullRequestForEnv(t, activities, a.Name, "production")
+
+	if !testhelpers.AssertSetPullRequestMerged(t, fakeGitProvider, prodRepo.Owner, prodRepo.GitRepo.Name, 1) {
+		return
+	}
+	if !testhelpers.AssertSetPullRequestComplete(t, fakeGitProvider, prodRepo, 1) {
+		return
+	}
+	pollGitStatusAndReactToPipelineChanges(t, o, jxClient, ns)
+
+	testhelpers.AssertHasPromoteStatus(t, activities, a.Name, "production", v1.ActivityStatusTypeSucceeded)
+	testhelpers.AssertAllPromoteStepsSuccessful(t, activities, a.Name)
+}
+
 func pollGitStatusAndReactToPipelineChanges(t *testing.T, o *controller.ControllerWorkflowOptions, jxClient versioned.Interface, ns string) error {
 	o.ReloadAndPollGitPipelineStatuses(jxClient, ns)
 	err := o.Run()
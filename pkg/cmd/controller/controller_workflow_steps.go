@@ -0,0 +1,361 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	typev1 "github.com/jenkins-x/jx/pkg/client/clientset/versioned/typed/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/cmd/promote"
+	"github.com/jenkins-x/jx/pkg/cmd/rollback"
+	"github.com/jenkins-x/jx/pkg/cmd/start"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/jenkins-x/jx/pkg/workflow"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// runWorkflowStep runs an approve, wait or verify step of the workflow once its preconditions are met. It returns
+// true if the step has succeeded along with whether the status of the step was modified.
+func (o *ControllerWorkflowOptions) runWorkflowStep(flow *v1.Workflow, pipeline *v1.PipelineActivity, step *v1.WorkflowStep, stepName string, promoteStatusMap map[string]*v1.PromoteActivityStep, jxClient versioned.Interface, ns string) (bool, bool) {
+	status := workflow.GetStepStatus(pipeline, stepName)
+	if status != nil && status.Status.IsTerminated() {
+		return status.Status == v1.WorkflowStatusTypeSucceeded, false
+	}
+	if status == nil && !canExecuteStep(flow, pipeline, step, promoteStatusMap, "step "+stepName) {
+		return false, false
+	}
+	kind := workflow.StepKind(step)
+	changed := false
+	if status == nil {
+		log.Logger().Infof("Starting %s step %s of PipelineActivity %s", kind, stepName, pipeline.Name)
+		status = workflow.GetOrCreateStepStatus(pipeline, stepName, kind)
+		changed = true
+	}
+	old := *status
+
+	var err error
+	switch kind {
+	case v1.WorkflowStepKindTypeApprove:
+		o.runApproveStep(pipeline, step, status)
+	case v1.WorkflowStepKindTypeWait:
+		err = o.runWaitStep(step, status)
+	case v1.WorkflowStepKindTypeVerify:
+		err = o.runVerifyStep(pipeline, step, status, jxClient, ns)
+	default:
+		err = errors.Errorf("unknown kind of step %s", string(kind))
+	}
+	if err != nil {
+		workflow.CompleteStep(status, v1.WorkflowStatusTypeError, err.Error())
+	}
+	if status.Status.IsTerminated() && status.Status != v1.WorkflowStatusTypeSucceeded {
+		log.Logger().Warnf("Step %s of PipelineActivity %s has status %s: %s", stepName, pipeline.Name, string(status.Status), status.Message)
+		pipeline.Spec.WorkflowStatus = v1.ActivityStatusTypeFailed
+		pipeline.Spec.WorkflowMessage = fmt.Sprintf("step %s %s: %s", stepName, strings.ToLower(string(status.Status)), status.Message)
+	}
+	if old.Status != status.Status || old.Message != status.Message {
+		changed = true
+	}
+	return status.Status == v1.WorkflowStatusTypeSucceeded, changed
+}
+
+// runApproveStep marks the step and the activity as waiting for approval via 'jx approve workflow'
+func (o *ControllerWorkflowOptions) runApproveStep(pipeline *v1.PipelineActivity, step *v1.WorkflowStep, status *v1.WorkflowStepStatus) {
+	if status.Status == v1.WorkflowStatusTypeWaitingForApproval {
+		return
+	}
+	message := ""
+	if step.Approve != nil {
+		message = step.Approve.Message
+	}
+	if message == "" {
+		message = fmt.Sprintf("waiting for approval of step %s", status.Name)
+	}
+	log.Logger().Infof("PipelineActivity %s is waiting for approval of step %s", pipeline.Name, status.Name)
+	status.Status = v1.WorkflowStatusTypeWaitingForApproval
+	status.Message = message
+	pipeline.Spec.WorkflowStatus = v1.ActivityStatusTypeWaitingForApproval
+	pipeline.Spec.WorkflowMessage = message
+}
+
+// runWaitStep succeeds the step once its duration has elapsed and its time window is open
+func (o *ControllerWorkflowOptions) runWaitStep(step *v1.WorkflowStep, status *v1.WorkflowStepStatus) error {
+	wait := step.Wait
+	if wait == nil {
+		return errors.Errorf("no wait configuration for step %s", status.Name)
+	}
+	now := time.Now()
+	if wait.Duration != "" {
+		duration, err := time.ParseDuration(wait.Duration)
+		if err != nil {
+			return errors.Wrapf(err, "invalid duration %s", wait.Duration)
+		}
+		until := now
+		if status.StartedTimestamp != nil {
+			until = status.StartedTimestamp.Add(duration)
+		}
+		if now.Before(until) {
+			status.Status = v1.WorkflowStatusTypeRunning
+			status.Message = fmt.Sprintf("waiting until %s", until.Format(time.RFC3339))
+			return nil
+		}
+	}
+	open, err := workflow.InTimeWindow(wait.Window, now)
+	if err != nil {
+		return err
+	}
+	if !open {
+		status.Status = v1.WorkflowStatusTypeRunning
+		status.Message = "waiting for the time window to open"
+		return nil
+	}
+	workflow.CompleteStep(status, v1.WorkflowStatusTypeSucceeded, "")
+	return nil
+}
+
+// runVerifyStep runs the check or triggers the pipeline of a verify step and succeeds or fails the step once it has
+// completed
+func (o *ControllerWorkflowOptions) runVerifyStep(pipeline *v1.PipelineActivity, step *v1.WorkflowStep, status *v1.WorkflowStepStatus, jxClient versioned.Interface, ns string) error {
+	verify := step.Verify
+	if verify == nil || (verify.Pipeline == "" && len(verify.Check) == 0) {
+		return errors.Errorf("no pipeline or check to verify for step %s", status.Name)
+	}
+	if len(verify.Check) > 0 {
+		switch status.Status {
+		case v1.WorkflowStatusTypePending:
+			status.Status = v1.WorkflowStatusTypeRunning
+			status.Message = fmt.Sprintf("running jx step verify %s", strings.Join(verify.Check, " "))
+			o.startVerifyCheck(pipeline, status.Name, verify, jxClient.JenkinsV1().PipelineActivities(ns))
+		case v1.WorkflowStatusTypeRunning:
+			// the check is not running in this process if the controller restarted while it was running
+			o.startVerifyCheck(pipeline, status.Name, verify, jxClient.JenkinsV1().PipelineActivities(ns))
+		}
+		return nil
+	}
+
+	if status.Status == v1.WorkflowStatusTypePending {
+		err := o.startVerifyPipeline(verify.Pipeline)
+		if err != nil {
+			return errors.Wrapf(err, "failed to start pipeline %s", verify.Pipeline)
+		}
+		status.Status = v1.WorkflowStatusTypeRunning
+		status.Message = fmt.Sprintf("started pipeline %s", verify.Pipeline)
+		return nil
+	}
+
+	activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	if err != nil {
+		log.Logger().Warnf("Failed to list PipelineActivity resources: %s", err)
+		return nil
+	}
+	var latest *v1.PipelineActivity
+	for i := range activities.Items {
+		activity := &activities.Items[i]
+		started := activity.Spec.StartedTimestamp
+		if activity.Spec.Pipeline != verify.Pipeline || started == nil || status.StartedTimestamp == nil || started.Before(status.StartedTimestamp) {
+			continue
+		}
+		if latest == nil || latest.Spec.StartedTimestamp.Before(started) {
+			latest = activity
+		}
+	}
+	if latest == nil {
+		return nil
+	}
+	switch latest.Spec.Status {
+	case v1.ActivityStatusTypeSucceeded:
+		workflow.CompleteStep(status, v1.WorkflowStatusTypeSucceeded, fmt.Sprintf("pipeline %s succeeded", latest.Name))
+	case v1.ActivityStatusTypeFailed, v1.ActivityStatusTypeError, v1.ActivityStatusTypeAborted:
+		message := fmt.Sprintf("pipeline %s %s", latest.Name, strings.ToLower(string(latest.Spec.Status)))
+		message += o.rollbackEnvironments(o.activityApplication(pipeline), verify.Rollback, message)
+		workflow.CompleteStep(status, v1.WorkflowStatusTypeFailed, message)
+	default:
+		status.Message = fmt.Sprintf("waiting for pipeline %s", latest.Name)
+	}
+	return nil
+}
+
+// startVerifyCheck runs the 'jx step verify' check in the background, recording its result on the activity once it
+// completes and rolling back the environments of the step if it fails. A check is only started once per step by
+// this process, so a finished check is not started again for a stale copy of the activity which is still running
+func (o *ControllerWorkflowOptions) startVerifyCheck(pipeline *v1.PipelineActivity, stepName string, verify *v1.VerifyWorkflowStep, activities typev1.PipelineActivityInterface) {
+	activityName := pipeline.Name
+	app := o.activityApplication(pipeline)
+	check := verify.Check
+	rollbackEnvs := verify.Rollback
+	key := activityName + "/" + stepName
+	o.checkLock.Lock()
+	defer o.checkLock.Unlock()
+	if o.runningChecks == nil {
+		o.runningChecks = map[string]bool{}
+	}
+	if _, started := o.runningChecks[key]; started {
+		return
+	}
+	o.runningChecks[key] = true
+
+	runner := o.VerifyCheckRunner
+	if runner == nil {
+		runner = runVerifyCheck
+	}
+	go func() {
+		defer func() {
+			o.checkLock.Lock()
+			o.runningChecks[key] = false
+			o.checkLock.Unlock()
+		}()
+		output, err := runner(check)
+		result := v1.WorkflowStatusTypeSucceeded
+		message := "verified"
+		if err != nil {
+			result = v1.WorkflowStatusTypeFailed
+			message = err.Error()
+			if output != "" {
+				message = output
+			}
+			message += o.rollbackEnvironments(app, rollbackEnvs, message)
+		}
+		err = updateStepStatus(activities, activityName, stepName, func(status *v1.WorkflowStepStatus) {
+			workflow.CompleteStep(status, result, message)
+		})
+		if err != nil {
+			log.Logger().Warnf("Failed to record the result of step %s of PipelineActivity %s: %s", stepName, activityName, err)
+		}
+	}()
+}
+
+// runVerifyCheck runs 'jx step verify' with the given arguments
+func runVerifyCheck(args []string) (string, error) {
+	cmd := util.Command{
+		Name: "jx",
+		Args: append([]string{"step", "verify"}, args...),
+	}
+	return cmd.RunWithoutRetry()
+}
+
+// rollbackEnvironments rolls back the application in each of the environments after a verify step failed, returning
+// a description of the rollbacks to add to the message of the step
+func (o *ControllerWorkflowOptions) rollbackEnvironments(app string, envs []string, reason string) string {
+	if len(envs) == 0 {
+		return ""
+	}
+	runner := o.RollbackRunner
+	if runner == nil {
+		runner = o.runRollback
+	}
+	results := []string{}
+	for _, env := range envs {
+		log.Logger().Warnf("Rolling back %s in Environment %s as verification failed: %s", app, env, reason)
+		err := runner(app, env, reason)
+		if err != nil {
+			log.Logger().Warnf("Failed to roll back %s in Environment %s: %s", app, env, err)
+			results = append(results, fmt.Sprintf("failed to roll back %s: %s", env, err))
+		} else {
+			results = append(results, fmt.Sprintf("rolled back %s", env))
+		}
+	}
+	return "; " + strings.Join(results, "; ")
+}
+
+// runRollback creates the Pull Request which rolls back the application in the environment without waiting for it
+func (o *ControllerWorkflowOptions) runRollback(app string, env string, reason string) error {
+	ro := &rollback.RollbackOptions{
+		PromoteOptions: promote.PromoteOptions{
+			CommonOptions:     o.CommonOptions,
+			Application:       app,
+			Environment:       env,
+			Namespace:         o.Namespace,
+			NoPoll:            true,
+			IgnoreLocalFiles:  true,
+			HelmRepositoryURL: o.DefaultChartRepositoryURL(),
+			LocalHelmRepoName: kube.LocalHelmRepoName,
+		},
+		Reason: "Verification failed: " + reason,
+	}
+	ro.BatchMode = true
+	return ro.Run()
+}
+
+// activityApplication returns the name of the application promoted by the activity
+func (o *ControllerWorkflowOptions) activityApplication(pipeline *v1.PipelineActivity) string {
+	return o.createPromoteOptionsFromActivity(pipeline, "").Application
+}
+
+// startVerifyPipeline triggers the pipeline of a verify step
+func (o *ControllerWorkflowOptions) startVerifyPipeline(pipeline string) error {
+	if o.PipelineStarter != nil {
+		return o.PipelineStarter(pipeline)
+	}
+	so := &start.StartPipelineOptions{
+		CommonOptions: o.CommonOptions,
+	}
+	so.Args = []string{pipeline}
+	so.BatchMode = true
+	return so.Run()
+}
+
+// updateStepStatus modifies the named workflow step status of the latest version of the activity
+func updateStepStatus(activities typev1.PipelineActivityInterface, activityName string, stepName string, fn func(status *v1.WorkflowStepStatus)) error {
+	activity, err := activities.Get(activityName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	status := workflow.GetStepStatus(activity, stepName)
+	if status == nil || status.Status.IsTerminated() {
+		return nil
+	}
+	fn(status)
+	_, err = activities.PatchUpdate(activity)
+	return err
+}
+
+// updateWorkflowSteps saves the workflow step statuses of the activity, without overwriting any steps which have
+// terminated in the meantime such as an approval
+func (o *ControllerWorkflowOptions) updateWorkflowSteps(pipeline *v1.PipelineActivity, activities typev1.PipelineActivityInterface) error {
+	latest, err := activities.Get(pipeline.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	for _, status := range pipeline.Spec.WorkflowSteps {
+		current := workflow.GetStepStatus(latest, status.Name)
+		if current == nil {
+			latest.Spec.WorkflowSteps = append(latest.Spec.WorkflowSteps, status)
+		} else if !current.Status.IsTerminated() {
+			*current = status
+		}
+	}
+	if !latest.Spec.WorkflowStatus.IsTerminated() {
+		latest.Spec.WorkflowStatus = pipeline.Spec.WorkflowStatus
+		latest.Spec.WorkflowMessage = pipeline.Spec.WorkflowMessage
+	}
+	_, err = activities.PatchUpdate(latest)
+	return err
+}
+
+// pollWorkflowSteps re-evaluates the activities which have running workflow steps or queued promotions so that waits,
+// verifications and promotions blocked by a freeze window can progress without the activity changing
+func (o *ControllerWorkflowOptions) pollWorkflowSteps(jxClient versioned.Interface, ns string) {
+	activities := jxClient.JenkinsV1().PipelineActivities(ns)
+	for name, pipeline := range o.pipelineMap {
+		running := len(queuedPromotions(pipeline)) > 0
+		for _, status := range pipeline.Spec.WorkflowSteps {
+			if status.Status == v1.WorkflowStatusTypeRunning {
+				running = true
+				break
+			}
+		}
+		if !running {
+			continue
+		}
+		activity, err := activities.Get(name, metav1.GetOptions{})
+		if err != nil {
+			log.Logger().Warnf("Failed to get PipelineActivity %s: %s", name, err)
+			continue
+		}
+		o.onActivity(activity, jxClient, ns)
+	}
+}
@@ -37,7 +37,9 @@ const (
 
 var (
 	createAddonPipelineEventsLong = templates.LongDesc(`
-		Creates the Jenkins X pipeline events addon
+		Creates the Jenkins X pipeline events addon which stores the pipeline events in Elasticsearch and Kibana.
+
+		To stream the pipeline events to other sinks such as CloudEvents, Kafka or a webhook see 'jx controller pipeline-events'
 `)
 
 	createAddonPipelineEventsExample = templates.Examples(`
@@ -22,6 +22,12 @@ var (
 	createChatServer_example = templates.Examples(`
 		# Add a new chat server URL
 		jx create chat server slack https://myroom.slack.server
+
+		# Add a Mattermost server
+		jx create chat server mattermost https://mattermost.example.com
+
+		# Add Microsoft Teams
+		jx create chat server teams https://teams.microsoft.com
 	`)
 )
 
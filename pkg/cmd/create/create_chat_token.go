@@ -29,6 +29,10 @@ var (
 
 		# As above with the password being passed in
 		jx create git token -n jira -p somePassword someUserName	
+
+		# Add the client credentials of an Azure AD application used to read the metrics of Microsoft Teams channels.
+		# Messages are posted to Microsoft Teams channels through their incoming webhooks which need no token
+		jx create chat token -n teams myTenantID/myClientID myClientSecret
 	`)
 )
 
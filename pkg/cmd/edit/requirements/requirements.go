@@ -205,6 +205,10 @@ func (o *RequirementsOptions) applyDefaults() error {
 			r.SecretStorage = config.SecretStorageTypeLocal
 		case "vault":
 			r.SecretStorage = config.SecretStorageTypeVault
+		case "kubernetes":
+			r.SecretStorage = config.SecretStorageTypeKubernetes
+		case "sops":
+			r.SecretStorage = config.SecretStorageTypeSOPS
 		default:
 			return util.InvalidOption("secret", o.SecretStorage, config.SecretStorageTypeValues)
 		}
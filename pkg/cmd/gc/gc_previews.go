@@ -15,12 +15,15 @@ import (
 	"strconv"
 
 	"strings"
+	"time"
 
 	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/previews"
+	"github.com/jenkins-x/jx/pkg/util"
 )
 
 // GetOptions is the start of the data required to perform the operation.  As new fields are added, add them here instead of
@@ -30,6 +33,12 @@ type GCPreviewsOptions struct {
 
 	DisableImport bool
 	OutDir        string
+	DryRun        bool
+
+	// Metrics counts the requests to the Preview Environments, defaulting to the Prometheus server of the policy
+	Metrics previews.RequestMetrics
+
+	gitProviders map[string]gits.GitProvider
 }
 
 var (
@@ -37,11 +46,19 @@ var (
 		Garbage collect Jenkins X preview environments.  If a pull request is merged or closed the associated preview
 		environment will be deleted.
 
+		If the team settings have a 'previewGC' policy, preview environments which have not been deployed within its
+		'ttl' or which have received no requests via their ingress within its 'idleTimeout' are also reaped. Reaped
+		preview environments are deleted or, if the policy has 'scaleToZero' enabled, their deployments are scaled
+		down to zero replicas until they are next deployed or requested.
+
 `)
 
 	GCPreviewsExample = templates.Examples(`
 		jx garbage collect previews
 		jx gc previews
+
+		# list the preview environments which would be reaped
+		jx gc previews --dry-run
 `)
 )
 
@@ -63,6 +80,7 @@ func NewCmdGCPreviews(commonOpts *opts.CommonOptions) *cobra.Command {
 			helper.CheckErr(err)
 		},
 	}
+	cmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false, "Reports the preview environments which would be reaped without deleting or scaling them")
 	return cmd
 }
 
@@ -84,63 +102,159 @@ func (o *GCPreviewsOptions) Run() error {
 		return nil
 	}
 
+	var policy *v1.PreviewGCPolicy
+	teamSettings, err := o.TeamSettings()
+	if err != nil {
+		log.Logger().Warnf("Failed to load the team settings so only reaping preview environments of closed pull requests: %s", err)
+	} else {
+		policy = teamSettings.PreviewGC
+	}
+	if o.Metrics == nil && policy != nil && policy.MetricsURL != "" {
+		o.Metrics = &previews.PrometheusMetrics{URL: policy.MetricsURL}
+	}
+
+	table := o.CreateTable()
+	table.AddRow("NAME", "PULL REQUEST", "LAST DEPLOYED", "ACTION", "REASON")
+	now := time.Now()
 	var previewFound bool
-	for _, e := range envs.Items {
-		if e.Spec.Kind == v1.EnvironmentKindTypePreview {
-			previewFound = true
-			gitInfo, err := gits.ParseGitURL(e.Spec.Source.URL)
+	for i := range envs.Items {
+		e := &envs.Items[i]
+		if e.Spec.Kind != v1.EnvironmentKindTypePreview {
+			continue
+		}
+		previewFound = true
+		decision, err := previews.Evaluate(e, policy, o.Metrics, now)
+		if err != nil {
+			log.Logger().Warnf("Failed to apply the garbage collection policy to preview environment %s: %s", e.Name, err)
+			decision = &previews.Decision{}
+		}
+		if decision.Action != previews.ActionDelete {
+			closed, state, err := o.isPullRequestClosed(e)
 			if err != nil {
 				return err
 			}
-			// we need pull request info to include
-			authConfigSvc, err := o.GitAuthConfigService()
-			if err != nil {
-				return err
+			if closed {
+				decision = &previews.Decision{
+					Action: previews.ActionDelete,
+					Reason: fmt.Sprintf("pull request is %s", state),
+				}
 			}
+		}
+		if decision.Action == previews.ActionNone {
+			continue
+		}
+		table.AddRow(e.Name, e.Spec.PreviewGitSpec.URL, previews.LastDeployed(e).Format(time.RFC3339), string(decision.Action), decision.Reason)
+		if o.DryRun {
+			continue
+		}
+		err = o.reapPreview(e, decision, policy, currentNs)
+		if err != nil {
+			return err
+		}
+	}
+	if !previewFound {
+		log.Logger().Debug("no preview environments found")
+		return nil
+	}
+	if o.DryRun {
+		table.Render()
+	}
+	return nil
+}
 
-			gitKind, err := o.GitServerKind(gitInfo)
-			if err != nil {
-				return err
-			}
+// isPullRequestClosed returns true along with its state if the pull request of the preview environment is closed,
+// merged, superseded or declined
+func (o *GCPreviewsOptions) isPullRequestClosed(e *v1.Environment) (bool, string, error) {
+	gitInfo, err := gits.ParseGitURL(e.Spec.Source.URL)
+	if err != nil {
+		return false, "", err
+	}
+	gitProvider, err := o.gitProvider(gitInfo)
+	if err != nil {
+		return false, "", err
+	}
+	prNum, err := strconv.Atoi(e.Spec.PreviewGitSpec.Name)
+	if err != nil {
+		log.Logger().Warn("Unable to convert PR " + e.Spec.PreviewGitSpec.Name + " to a number")
+	}
+	pullRequest, err := gitProvider.GetPullRequest(gitInfo.Organisation, gitInfo, prNum)
+	if err != nil {
+		log.Logger().Warnf("Can not get pull request %s, skipping: %s", e.Spec.PreviewGitSpec.Name, err)
+		return false, "", nil
+	}
 
-			ghOwner, err := o.GetGitHubAppOwner(gitInfo)
-			if err != nil {
-				return err
-			}
-			gitProvider, err := gitInfo.CreateProvider(o.InCluster(), authConfigSvc, gitKind, ghOwner, o.Git(), o.BatchMode, o.GetIOFileHandles())
-			if err != nil {
-				return err
-			}
-			prNum, err := strconv.Atoi(e.Spec.PreviewGitSpec.Name)
-			if err != nil {
-				log.Logger().Warn("Unable to convert PR " + e.Spec.PreviewGitSpec.Name + " to a number")
-			}
-			pullRequest, err := gitProvider.GetPullRequest(gitInfo.Organisation, gitInfo, prNum)
-			if err != nil {
-				log.Logger().Warnf("Can not get pull request %s, skipping: %s", e.Spec.PreviewGitSpec.Name, err)
-				continue
-			}
+	lowerState := strings.ToLower(*pullRequest.State)
 
-			lowerState := strings.ToLower(*pullRequest.State)
+	if strings.HasPrefix(lowerState, "clos") || strings.HasPrefix(lowerState, "merged") || strings.HasPrefix(lowerState, "superseded") || strings.HasPrefix(lowerState, "declined") {
+		return true, lowerState, nil
+	}
+	return false, lowerState, nil
+}
 
-			if strings.HasPrefix(lowerState, "clos") || strings.HasPrefix(lowerState, "merged") || strings.HasPrefix(lowerState, "superseded") || strings.HasPrefix(lowerState, "declined") {
-				// lets delete the preview environment
-				deleteOpts := deletecmd.DeletePreviewOptions{
-					PreviewOptions: preview.PreviewOptions{
-						PromoteOptions: promote.PromoteOptions{
-							CommonOptions: o.CommonOptions,
-						},
-					},
-				}
-				err = deleteOpts.DeletePreview(e.Name)
-				if err != nil {
-					return fmt.Errorf("failed to delete preview environment %s: %v\n", e.Name, err)
-				}
-			}
+// gitProvider returns the git provider for the owner of the repository, reusing the provider for other previews of
+// the same owner
+func (o *GCPreviewsOptions) gitProvider(gitInfo *gits.GitRepository) (gits.GitProvider, error) {
+	key := gitInfo.ProviderURL() + "/" + gitInfo.Organisation
+	if o.gitProviders == nil {
+		o.gitProviders = map[string]gits.GitProvider{}
+	}
+	if gitProvider := o.gitProviders[key]; gitProvider != nil {
+		return gitProvider, nil
+	}
+	// we need pull request info to include
+	authConfigSvc, err := o.GitAuthConfigService()
+	if err != nil {
+		return nil, err
+	}
+
+	gitKind, err := o.GitServerKind(gitInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	ghOwner, err := o.GetGitHubAppOwner(gitInfo)
+	if err != nil {
+		return nil, err
+	}
+	gitProvider, err := gitInfo.CreateProvider(o.InCluster(), authConfigSvc, gitKind, ghOwner, o.Git(), o.BatchMode, o.GetIOFileHandles())
+	if err != nil {
+		return nil, err
+	}
+	o.gitProviders[key] = gitProvider
+	return gitProvider, nil
+}
+
+// reapPreview deletes or scales down the preview environment
+func (o *GCPreviewsOptions) reapPreview(e *v1.Environment, decision *previews.Decision, policy *v1.PreviewGCPolicy, ns string) error {
+	if decision.Action == previews.ActionScaleToZero {
+		log.Logger().Infof("Scaling preview environment %s to zero: %s", util.ColorInfo(e.Name), decision.Reason)
+		kubeClient, err := o.KubeClient()
+		if err != nil {
+			return err
 		}
+		jxClient, _, err := o.JXClient()
+		if err != nil {
+			return err
+		}
+		err = previews.ScaleToZero(kubeClient, jxClient, ns, e, policy.WakeupService)
+		if err != nil {
+			return fmt.Errorf("failed to scale preview environment %s to zero: %v\n", e.Name, err)
+		}
+		return nil
 	}
-	if !previewFound {
-		log.Logger().Debug("no preview environments found")
+
+	log.Logger().Infof("Deleting preview environment %s: %s", util.ColorInfo(e.Name), decision.Reason)
+	// lets delete the preview environment
+	deleteOpts := deletecmd.DeletePreviewOptions{
+		PreviewOptions: preview.PreviewOptions{
+			PromoteOptions: promote.PromoteOptions{
+				CommonOptions: o.CommonOptions,
+			},
+		},
+	}
+	err := deleteOpts.DeletePreview(e.Name)
+	if err != nil {
+		return fmt.Errorf("failed to delete preview environment %s: %v\n", e.Name, err)
 	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package get
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -138,6 +139,9 @@ func (o *GetActivityOptions) addTableRow(table *tbl.Table, activity *v1.Pipeline
 		for _, step := range spec.Steps {
 			o.addStepRow(table, &step, indent)
 		}
+		for _, step := range spec.WorkflowSteps {
+			addWorkflowStepRow(table, &step, indent)
+		}
 		return true
 	}
 	return false
@@ -240,6 +244,10 @@ func addPromoteRow(table *tbl.Table, parent *v1.PromoteActivityStep, indent stri
 	addStepRowItem(table, &parent.CoreActivityStep, indent, "Promote: "+parent.Environment, "")
 	indent += indentation
 
+	if freeze := parent.Freeze; freeze != nil {
+		addStepRowItem(table, &freeze.CoreActivityStep, indent, "Freeze", describePromoteFreeze(freeze))
+	}
+
 	pullRequest := parent.PullRequest
 	update := parent.Update
 	if pullRequest != nil {
@@ -248,12 +256,26 @@ func addPromoteRow(table *tbl.Table, parent *v1.PromoteActivityStep, indent stri
 	if update != nil {
 		addStepRowItem(table, &update.CoreActivityStep, indent, "Update", describePromoteUpdate(update))
 	}
+	if canary := parent.Canary; canary != nil {
+		addStepRowItem(table, &canary.CoreActivityStep, indent, "Canary", describePromoteCanary(canary))
+	}
 	appURL := parent.ApplicationURL
 	if appURL != "" {
 		addStepRowItem(table, &update.CoreActivityStep, indent, "Promoted", " Application is at: "+util.ColorInfo(appURL))
 	}
 }
 
+func addWorkflowStepRow(table *tbl.Table, step *v1.WorkflowStepStatus, indent string) {
+	text := step.Message
+	if step.ApprovedBy != "" && step.Status == v1.WorkflowStatusTypeSucceeded {
+		text = "Approved by: " + util.ColorInfo(step.ApprovedBy)
+	}
+	table.AddRow(indent+string(step.Kind)+":"+step.Name,
+		timeToString(step.StartedTimestamp),
+		util.DurationString(step.StartedTimestamp, step.CompletedTimestamp),
+		statusString(v1.ActivityStatusType(step.Status))+" "+text)
+}
+
 func addStepRowItem(table *tbl.Table, step *v1.CoreActivityStep, indent string, name string, description string) {
 	text := step.Description
 	if description != "" {
@@ -314,6 +336,28 @@ func describePromoteUpdate(promote *v1.PromoteUpdateStep) string {
 	return description
 }
 
+func describePromoteFreeze(freeze *v1.PromoteFreezeStep) string {
+	description := ""
+	if freeze.Window != "" {
+		description += " Window: " + util.ColorInfo(freeze.Window)
+	}
+	if freeze.Reason != "" {
+		description += " Reason: " + freeze.Reason
+	}
+	if freeze.OverriddenBy != "" {
+		description += " Overridden by: " + util.ColorWarning(freeze.OverriddenBy) + " because: " + freeze.OverrideReason
+	}
+	return description
+}
+
+func describePromoteCanary(canary *v1.PromoteCanaryStep) string {
+	if canary.Phase == "" {
+		return ""
+	}
+	return fmt.Sprintf(" Phase: %s Weight: %s Failed checks: %s Iterations: %s", util.ColorInfo(canary.Phase),
+		util.ColorInfo(canary.Weight), util.ColorInfo(canary.FailedChecks), util.ColorInfo(len(canary.Iterations)))
+}
+
 func pullRequestStatusString(text string) string {
 	title := strings.Title(text)
 	switch text {
@@ -72,6 +72,18 @@ func (o *GetIssueOptions) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "failed to create the issue tracker")
 	}
+	issueKeyPattern := ""
+	issueTrackerConfig, err := o.IssueTrackerConfig(o.Dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to load the issue tracker configuration")
+	}
+	if issueTrackerConfig != nil {
+		issueKeyPattern = issueTrackerConfig.IssueKeyPattern
+	}
+	issueKeyRegex, err := issues.IssueKeyRegex(tracker, issueKeyPattern)
+	if err != nil {
+		return err
+	}
 
 	issue, err := tracker.GetIssue(o.Id)
 	if err != nil {
@@ -106,7 +118,7 @@ func (o *GetIssueOptions) Run() error {
 		if err != nil {
 			return errors.Wrap(err, "cannot list the releases")
 		}
-		rel := o.findRelease(tracker, issue, releaseList.Items)
+		rel := o.findRelease(tracker, issueKeyRegex, issue, releaseList.Items)
 		if rel == nil {
 			continue
 		}
@@ -129,7 +141,7 @@ func (o *GetIssueOptions) Run() error {
 	return nil
 }
 
-func (o *GetIssueOptions) findRelease(tracker issues.IssueProvider, issue *gits.GitIssue, releases []v1.Release) *v1.Release {
+func (o *GetIssueOptions) findRelease(tracker issues.IssueProvider, issueKeyRegex *regexp.Regexp, issue *gits.GitIssue, releases []v1.Release) *v1.Release {
 	for _, rel := range releases {
 		prs := rel.Spec.PullRequests
 		// checks all the PRs and the issues linked into their bodies
@@ -137,8 +149,7 @@ func (o *GetIssueOptions) findRelease(tracker issues.IssueProvider, issue *gits.
 			if pr.URL == issue.URL {
 				return &rel
 			} else {
-				issueKind := issues.GetIssueProvider(tracker)
-				issueIDs := o.parseIssueIDs(pr, issueKind)
+				issueIDs := issues.FindIssueKeys(issueKeyRegex, pr.Body)
 				issueURLs := o.convertIssueIDsToURLs(tracker, issueIDs)
 				for _, issueURL := range issueURLs {
 					if issueURL == issue.URL {
@@ -158,27 +169,6 @@ func (o *GetIssueOptions) findRelease(tracker issues.IssueProvider, issue *gits.
 	return nil
 }
 
-func (o *GetIssueOptions) parseIssueIDs(issue v1.IssueSummary, issueKind string) []string {
-	regex := regexp.MustCompile(`(\#\d+)`)
-	if issueKind == issues.Jira {
-		regex = regexp.MustCompile(`[A-Z][A-Z]+-(\d+)`)
-	}
-	issues := []string{}
-	foundIssues := map[string]bool{}
-	matches := regex.FindAllStringSubmatch(issue.Body, -1)
-	for _, match := range matches {
-		for _, result := range match {
-			id := strings.TrimPrefix(result, "#")
-			found, ok := foundIssues[id]
-			if !found || !ok {
-				issues = append(issues, id)
-				foundIssues[id] = true
-			}
-		}
-	}
-	return issues
-}
-
 func (o *GetIssueOptions) convertIssueIDsToURLs(tracker issues.IssueProvider, issueIDs []string) []string {
 	issueURLs := []string{}
 	for _, id := range issueIDs {
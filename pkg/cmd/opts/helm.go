@@ -14,7 +14,9 @@ import (
 	"github.com/jenkins-x/jx/pkg/versionstream"
 
 	"github.com/jenkins-x/jx/pkg/secreturl"
+	"github.com/jenkins-x/jx/pkg/secreturl/kubesecret"
 	"github.com/jenkins-x/jx/pkg/secreturl/localvault"
+	"github.com/jenkins-x/jx/pkg/secreturl/sops"
 	"github.com/pborman/uuid"
 
 	"github.com/jenkins-x/jx/pkg/environments"
@@ -426,7 +428,23 @@ func (o *CommonOptions) GetSecretURLClient(location secrets.SecretsLocationKind)
 		if err != nil {
 			return o.secretURLClient, errors.Wrapf(err, "getting the file system secrets directory")
 		}
-		o.secretURLClient = localvault.NewFileSystemClient(dir)
+		cipher, err := localvault.CipherFromEnvironment()
+		if err != nil {
+			return o.secretURLClient, errors.Wrapf(err, "loading the key of the file system secrets")
+		}
+		o.secretURLClient = localvault.NewEncryptedFileSystemClient(dir, cipher)
+	case secrets.KubeLocationKind:
+		kubeClient, ns, err := o.KubeClientAndDevNamespace()
+		if err != nil {
+			return o.secretURLClient, errors.Wrapf(err, "creating the kubernetes client")
+		}
+		o.secretURLClient = kubesecret.NewClient(kubeClient, ns)
+	case secrets.SOPSLocationKind:
+		dir, err := o.SOPSSecretsDir()
+		if err != nil {
+			return o.secretURLClient, errors.Wrapf(err, "getting the SOPS secrets directory")
+		}
+		o.secretURLClient = sops.NewClient(dir)
 	case secrets.AutoLocationKind:
 		location := o.detectSecretsLocation()
 		o.secretURLClient, err = o.GetSecretURLClient(location)
@@ -437,8 +455,30 @@ func (o *CommonOptions) GetSecretURLClient(location secrets.SecretsLocationKind)
 	return o.secretURLClient, err
 }
 
-// detectSecretsLocation detects dynamically the secrets location by trying to create a vault client
+// SOPSSecretsDir returns the directory of the SOPS encrypted secret files in the boot config repository containing the
+// requirements file of the current directory
+func (o *CommonOptions) SOPSSecretsDir() (string, error) {
+	requirements, fileName, err := config.LoadRequirementsConfig("")
+	if err != nil {
+		return "", err
+	}
+	dir := sops.DefaultDir
+	if requirements.SOPS != nil && requirements.SOPS.Dir != "" {
+		dir = requirements.SOPS.Dir
+	}
+	if filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	return filepath.Join(filepath.Dir(fileName), dir), nil
+}
+
+// detectSecretsLocation detects dynamically the secrets location by trying to create a vault client unless the secrets
+// are stored in Kubernetes or SOPS files
 func (o *CommonOptions) detectSecretsLocation() secrets.SecretsLocationKind {
+	location := o.GetSecretsLocation()
+	if location == secrets.KubeLocationKind || location == secrets.SOPSLocationKind {
+		return location
+	}
 	_, err := o.SystemVaultClient(o.devNamespace)
 	if err == nil {
 		return secrets.VaultLocationKind
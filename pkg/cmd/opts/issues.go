@@ -20,39 +20,51 @@ func (o *CommonOptions) CreateIssueTrackerAuthConfigService(kind string) (auth.C
 	return o.factory.CreateIssueTrackerAuthConfigService(namespace, "")
 }
 
-// CreateIssueProvider creates a issues provider
-func (o *CommonOptions) CreateIssueProvider(dir string) (issues.IssueProvider, error) {
-	gitDir, gitConfDir, err := o.Git().FindGitConfigDir(dir)
+// IssueTrackerConfig returns the issue tracker configuration of the project in the directory or its git directory
+// or nil if there is none
+func (o *CommonOptions) IssueTrackerConfig(dir string) (*config.IssueTrackerConfig, error) {
+	gitDir, _, err := o.Git().FindGitConfigDir(dir)
 	if err != nil {
-		return nil, fmt.Errorf("No issue tracker configured for this project and cannot find the .git directory: %s", err)
+		return nil, err
 	}
 	pc, _, err := config.LoadProjectConfig(dir)
 	if err != nil {
 		return nil, err
 	}
-	if pc != nil && pc.IssueTracker == nil {
+	if pc != nil && pc.IssueTracker == nil && gitDir != "" {
 		pc, _, err = config.LoadProjectConfig(gitDir)
 		if err != nil {
 			return nil, err
 		}
 	}
-	if pc != nil {
-		it := pc.IssueTracker
-		if it != nil {
-			if it.URL != "" && it.Kind != "" {
-				authConfigSvc, err := o.CreateIssueTrackerAuthConfigService(it.Kind)
-				if err != nil {
-					return nil, err
-				}
-				config := authConfigSvc.Config()
-				server := config.GetOrCreateServer(it.URL)
-				userAuth, err := config.PickServerUserAuth(server, "user to access the issue tracker", o.BatchMode, "", o.GetIOFileHandles())
-				if err != nil {
-					return nil, err
-				}
-				return issues.CreateIssueProvider(it.Kind, server, userAuth, it.Project, o.BatchMode, o.Git())
-			}
+	if pc == nil {
+		return nil, nil
+	}
+	return pc.IssueTracker, nil
+}
+
+// CreateIssueProvider creates a issues provider
+func (o *CommonOptions) CreateIssueProvider(dir string) (issues.IssueProvider, error) {
+	_, gitConfDir, err := o.Git().FindGitConfigDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("No issue tracker configured for this project and cannot find the .git directory: %s", err)
+	}
+	it, err := o.IssueTrackerConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	if it != nil && it.URL != "" && it.Kind != "" {
+		authConfigSvc, err := o.CreateIssueTrackerAuthConfigService(it.Kind)
+		if err != nil {
+			return nil, err
+		}
+		config := authConfigSvc.Config()
+		server := config.GetOrCreateServer(it.URL)
+		userAuth, err := config.PickServerUserAuth(server, "user to access the issue tracker", o.BatchMode, "", o.GetIOFileHandles())
+		if err != nil {
+			return nil, err
 		}
+		return issues.CreateIssueProvider(it.Kind, server, userAuth, it.Project, o.BatchMode, o.Git())
 	}
 
 	if gitConfDir == "" {
@@ -36,6 +36,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/previews"
 	"github.com/jenkins-x/jx/pkg/util"
 	kserve "github.com/knative/serving/pkg/client/clientset/versioned"
 	"github.com/spf13/cobra"
@@ -345,12 +346,22 @@ func (o *PreviewOptions) Run() error {
 		}
 	}
 
+	deployedAt := time.Now().UTC().Format(time.RFC3339)
 	environmentsResource := jxClient.JenkinsV1().Environments(ns)
 	env, err := environmentsResource.Get(o.Name, metav1.GetOptions{})
 	if err == nil {
 		// lets check for updates...
 		update := false
 
+		// record the deploy so that the preview is not garbage collected while it is still being worked on
+		if env.Annotations == nil {
+			env.Annotations = map[string]string{}
+		}
+		if env.Annotations[kube.AnnotationPreviewLastDeployed] != deployedAt {
+			env.Annotations[kube.AnnotationPreviewLastDeployed] = deployedAt
+			update = true
+		}
+
 		spec := &env.Spec
 		source := &spec.Source
 		if spec.Label != o.Label {
@@ -445,7 +456,8 @@ func (o *PreviewOptions) Run() error {
 			ObjectMeta: metav1.ObjectMeta{
 				Name: o.Name,
 				Annotations: map[string]string{
-					kube.AnnotationReleaseName: o.ReleaseName,
+					kube.AnnotationReleaseName:         o.ReleaseName,
+					kube.AnnotationPreviewLastDeployed: deployedAt,
 				},
 			},
 			Spec: v1.EnvironmentSpec{
@@ -517,6 +529,13 @@ func (o *PreviewOptions) Run() error {
 		helmOptions.ValueFiles = append(helmOptions.ValueFiles, defaultValuesFileName)
 	}
 
+	// lets scale the preview back up if it was scaled to zero by the garbage collector before upgrading the chart,
+	// so that the routes of the ingresses saved when scaling to zero don't replace those of the new chart
+	err = previews.WakeUp(kubeClient, jxClient, ns, env)
+	if err != nil {
+		return errors.Wrapf(err, "failed to wake up preview environment %s", o.Name)
+	}
+
 	err = o.InstallChartWithOptions(helmOptions)
 	if err != nil {
 		return err
@@ -27,8 +27,11 @@ import (
 	typev1 "github.com/jenkins-x/jx/pkg/client/clientset/versioned/typed/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/flagger"
+	"github.com/jenkins-x/jx/pkg/freeze"
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/issues"
 	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
@@ -37,7 +40,8 @@ import (
 )
 
 const (
-	optionPullRequestPollTime = "pull-request-poll-time"
+	optionPullRequestPollTime  = "pull-request-poll-time"
+	optionOverrideFreezeReason = "override-freeze-reason"
 
 	GitStatusSuccess = "success"
 )
@@ -70,6 +74,10 @@ type PromoteOptions struct {
 	PullRequestPollTime     string
 	Filter                  string
 	Alias                   string
+	Canary                  bool
+	CanaryRevert            bool
+	OverrideFreeze          bool
+	OverrideFreezeReason    string
 
 	// calculated fields
 	TimeoutDuration         *time.Duration
@@ -79,6 +87,7 @@ type PromoteOptions struct {
 	jenkinsURL              string
 	releaseResource         *v1.Release
 	ReleaseInfo             *ReleaseInfo
+	CanaryLister            flagger.CanaryLister
 	prow                    bool
 }
 
@@ -86,7 +95,9 @@ type ReleaseInfo struct {
 	ReleaseName     string
 	FullAppName     string
 	Version         string
+	PreviousVersion string
 	PullRequestInfo *gits.PullRequestInfo
+	Frozen          bool
 }
 
 var (
@@ -161,6 +172,10 @@ func (o *PromoteOptions) AddPromoteOptions(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&o.NoPoll, "no-poll", "", false, "Disables polling for Pull Request or Pipeline status")
 	cmd.Flags().BoolVarP(&o.NoWaitAfterMerge, "no-wait", "", false, "Disables waiting for completing promotion after the Pull request is merged")
 	cmd.Flags().BoolVarP(&o.IgnoreLocalFiles, "ignore-local-file", "", false, "Ignores the local file system when deducing the Git repository")
+	cmd.Flags().BoolVarP(&o.Canary, "canary", "", false, "Waits for the Flagger canary analysis of the new version to succeed after the Pull Request merges. This is the default for Environments with the Canary promotion strategy")
+	cmd.Flags().BoolVarP(&o.CanaryRevert, "canary-revert", "", false, "Creates a Pull Request reverting the Environment to the previous version if the Flagger canary analysis fails")
+	cmd.Flags().BoolVarP(&o.OverrideFreeze, "override-freeze", "", false, "Promotes even if a freeze window of the Environment is active. The override is recorded on the PipelineActivity")
+	cmd.Flags().StringVarP(&o.OverrideFreezeReason, optionOverrideFreezeReason, "", "", "The reason for overriding the freeze window which is required by --override-freeze")
 }
 
 func (o *PromoteOptions) hasApplicationFlag() bool {
@@ -293,19 +308,9 @@ func (o *PromoteOptions) Run() error {
 		}
 	}
 
-	if o.PullRequestPollTime != "" {
-		duration, err := time.ParseDuration(o.PullRequestPollTime)
-		if err != nil {
-			return fmt.Errorf("Invalid duration format %s for option --%s: %s", o.PullRequestPollTime, optionPullRequestPollTime, err)
-		}
-		o.PullRequestPollDuration = &duration
-	}
-	if o.Timeout != "" {
-		duration, err := time.ParseDuration(o.Timeout)
-		if err != nil {
-			return fmt.Errorf("Invalid duration format %s for option --%s: %s", o.Timeout, opts.OptionTimeout, err)
-		}
-		o.TimeoutDuration = &duration
+	err = o.ParseDurations()
+	if err != nil {
+		return err
 	}
 
 	targetNS, env, err := o.GetTargetNamespace(o.Namespace, o.Environment)
@@ -342,7 +347,7 @@ func (o *PromoteOptions) Run() error {
 	}
 
 	o.ReleaseInfo = releaseInfo
-	if !o.NoPoll {
+	if !o.NoPoll && !releaseInfo.IsFrozen() {
 		err = o.WaitForPromotion(targetNS, env, releaseInfo)
 		if err != nil {
 			return err
@@ -351,6 +356,25 @@ func (o *PromoteOptions) Run() error {
 	return err
 }
 
+// ParseDurations parses the timeout and the Pull Request poll time options used to wait for a promotion
+func (o *PromoteOptions) ParseDurations() error {
+	if o.PullRequestPollTime != "" {
+		duration, err := time.ParseDuration(o.PullRequestPollTime)
+		if err != nil {
+			return fmt.Errorf("Invalid duration format %s for option --%s: %s", o.PullRequestPollTime, optionPullRequestPollTime, err)
+		}
+		o.PullRequestPollDuration = &duration
+	}
+	if o.Timeout != "" {
+		duration, err := time.ParseDuration(o.Timeout)
+		if err != nil {
+			return fmt.Errorf("Invalid duration format %s for option --%s: %s", o.Timeout, opts.OptionTimeout, err)
+		}
+		o.TimeoutDuration = &duration
+	}
+	return nil
+}
+
 func (o *PromoteOptions) PromoteAllAutomatic() error {
 	kubeClient, currentNs, err := o.KubeClientAndNamespace()
 	if err != nil {
@@ -378,7 +402,7 @@ func (o *PromoteOptions) PromoteAllAutomatic() error {
 
 	for _, env := range environments {
 		kind := env.Spec.Kind
-		if env.Spec.PromotionStrategy == v1.PromotionStrategyTypeAutomatic && kind.IsPermanent() {
+		if env.Spec.PromotionStrategy.IsAutomatic() && kind.IsPermanent() {
 			ns := env.Spec.Namespace
 			if ns == "" {
 				return fmt.Errorf("No namespace for environment %s", env.Name)
@@ -388,7 +412,7 @@ func (o *PromoteOptions) PromoteAllAutomatic() error {
 				return err
 			}
 			o.ReleaseInfo = releaseInfo
-			if !o.NoPoll {
+			if !o.NoPoll && !releaseInfo.IsFrozen() {
 				err = o.WaitForPromotion(ns, &env, releaseInfo)
 				if err != nil {
 					return err
@@ -428,7 +452,7 @@ func (o *PromoteOptions) Promote(targetNS string, env *v1.Environment, warnIfAut
 		Version:     version,
 	}
 
-	if warnIfAuto && env != nil && env.Spec.PromotionStrategy == v1.PromotionStrategyTypeAutomatic && !o.BatchMode {
+	if warnIfAuto && env != nil && env.Spec.PromotionStrategy.IsAutomatic() && !o.BatchMode {
 		log.Logger().Infof("%s", util.ColorWarning(fmt.Sprintf("WARNING: The Environment %s is setup to promote automatically as part of the CI/CD Pipelines.\n", env.Name)))
 
 		confirm := &survey.Confirm{
@@ -455,6 +479,14 @@ func (o *PromoteOptions) Promote(targetNS string, env *v1.Environment, warnIfAut
 	}
 	promoteKey := o.CreatePromoteKey(env)
 	if env != nil {
+		frozen, err := o.checkFreezeWindows(env, promoteKey)
+		if err != nil {
+			return releaseInfo, err
+		}
+		if frozen {
+			releaseInfo.Frozen = true
+			return releaseInfo, nil
+		}
 		source := &env.Spec.Source
 		if source.URL != "" && env.Spec.Kind.IsPermanent() {
 			err := o.PromoteViaPullRequest(env, releaseInfo)
@@ -525,6 +557,65 @@ func (o *PromoteOptions) Promote(targetNS string, env *v1.Environment, warnIfAut
 	return releaseInfo, err
 }
 
+// checkFreezeWindows returns true if promotions into the environment are frozen, in which case the promotion is
+// recorded on the PipelineActivity as queued so that it is resumed once the freeze ends. If the freeze is overridden
+// the override is recorded instead.
+func (o *PromoteOptions) checkFreezeWindows(env *v1.Environment, promoteKey *kube.PromoteStepActivityKey) (bool, error) {
+	teamSettings, err := o.TeamSettings()
+	if err != nil {
+		log.Logger().Warnf("Failed to load the team settings so only checking the freeze windows of Environment %s: %s", env.Name, err)
+		teamSettings = nil
+	}
+	window, err := freeze.ActiveWindow(freeze.Windows(env, teamSettings), time.Now())
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to check the freeze windows of Environment %s", env.Name)
+	}
+	if window == nil {
+		return false, nil
+	}
+	description := freeze.Describe(window)
+
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return false, err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return false, err
+	}
+	if o.OverrideFreeze {
+		if o.OverrideFreezeReason == "" {
+			return false, util.MissingOption(optionOverrideFreezeReason)
+		}
+		user, err := o.GetUsername("")
+		if err != nil {
+			return false, err
+		}
+		log.Logger().Warnf("Overriding the freeze of Environment %s (%s) on behalf of %s because: %s", util.ColorInfo(env.Name),
+			description, util.ColorInfo(user), o.OverrideFreezeReason)
+		err = promoteKey.OnPromoteFreeze(kubeClient, jxClient, o.Namespace, kube.OverriddenPromotionFreeze(window.Name, window.Reason, user, o.OverrideFreezeReason))
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to record the override of the freeze of Environment %s", env.Name)
+		}
+		return false, nil
+	}
+	if !promoteKey.IsValid() {
+		return true, errors.Errorf("promotions into Environment %s are frozen by %s. To promote anyway use --override-freeze", env.Name, description)
+	}
+	log.Logger().Warnf("Promotions into Environment %s are frozen by %s so the promotion is queued until the freeze ends",
+		util.ColorInfo(env.Name), description)
+	err = promoteKey.OnPromoteFreeze(kubeClient, jxClient, o.Namespace, kube.BlockedPromotionFreeze(window.Name, window.Reason))
+	if err != nil {
+		return true, errors.Wrapf(err, "failed to queue the promotion into Environment %s", env.Name)
+	}
+	return true, nil
+}
+
+// IsFrozen returns true if the release was not promoted as the environment is frozen
+func (r *ReleaseInfo) IsFrozen() bool {
+	return r != nil && r.Frozen
+}
+
 func (o *PromoteOptions) PromoteViaPullRequest(env *v1.Environment, releaseInfo *ReleaseInfo) error {
 	version := o.Version
 	versionName := version
@@ -548,16 +639,57 @@ func (o *PromoteOptions) PromoteViaPullRequest(env *v1.Environment, releaseInfo
 				return err
 			}
 		}
+		for _, dep := range requirements.Dependencies {
+			if dep != nil && dep.Name == app && dep.Version != version {
+				releaseInfo.PreviousVersion = dep.Version
+			}
+		}
 		requirements.SetAppVersion(app, version, o.HelmRepositoryURL, o.Alias)
 		return nil
 	}
+	filter := &gits.PullRequestFilter{}
+	if releaseInfo.PullRequestInfo != nil && releaseInfo.PullRequestInfo.PullRequest != nil {
+		filter.Number = releaseInfo.PullRequestInfo.PullRequest.Number
+	}
+	info, err := o.CreateEnvironmentPullRequest(env, modifyChartFn, &details, filter)
+	releaseInfo.PullRequestInfo = info
+	return err
+}
+
+// RevertViaPullRequest creates a Pull Request which puts the previous version of the application back in the
+// Environment, such as when the canary analysis of the promoted version failed
+func (o *PromoteOptions) RevertViaPullRequest(env *v1.Environment, releaseInfo *ReleaseInfo) (*gits.PullRequestInfo, error) {
+	app := o.Application
+	previousVersion := releaseInfo.PreviousVersion
+	if previousVersion == "" {
+		return nil, errors.Errorf("no previous version of %s was found in the Environment %s", app, env.Name)
+	}
+
+	details := gits.PullRequestDetails{
+		BranchName: "revert-" + app + "-" + previousVersion,
+		Title:      "chore: revert " + app + " to " + previousVersion,
+		Message:    fmt.Sprintf("chore: Revert %s to version %s", app, previousVersion),
+	}
+
+	modifyChartFn := func(requirements *helm.Requirements, metadata *chart.Metadata, values map[string]interface{},
+		templates map[string]string, dir string, details *gits.PullRequestDetails) error {
+		requirements.SetAppVersion(app, previousVersion, o.HelmRepositoryURL, o.Alias)
+		return nil
+	}
+	return o.CreateEnvironmentPullRequest(env, modifyChartFn, &details, &gits.PullRequestFilter{})
+}
+
+// CreateEnvironmentPullRequest creates or updates a Pull Request on the git repository of the Environment which
+// modifies its chart with the given function
+func (o *PromoteOptions) CreateEnvironmentPullRequest(env *v1.Environment, modifyChartFn environments.ModifyChartFn,
+	details *gits.PullRequestDetails, filter *gits.PullRequestFilter) (*gits.PullRequestInfo, error) {
 	gitProvider, _, err := o.CreateGitProviderForURLWithoutKind(env.Spec.Source.URL)
 	if err != nil {
-		return errors.Wrapf(err, "creating git provider for %s", env.Spec.Source.URL)
+		return nil, errors.Wrapf(err, "creating git provider for %s", env.Spec.Source.URL)
 	}
 	environmentsDir, err := o.EnvironmentsDir()
 	if err != nil {
-		return errors.Wrapf(err, "getting environments dir")
+		return nil, errors.Wrapf(err, "getting environments dir")
 	}
 
 	options := environments.EnvironmentPullRequestOptions{
@@ -565,13 +697,7 @@ func (o *PromoteOptions) PromoteViaPullRequest(env *v1.Environment, releaseInfo
 		ModifyChartFn: modifyChartFn,
 		GitProvider:   gitProvider,
 	}
-	filter := &gits.PullRequestFilter{}
-	if releaseInfo.PullRequestInfo != nil && releaseInfo.PullRequestInfo.PullRequest != nil {
-		filter.Number = releaseInfo.PullRequestInfo.PullRequest.Number
-	}
-	info, err := options.Create(env, environmentsDir, &details, filter, "", true)
-	releaseInfo.PullRequestInfo = info
-	return err
+	return options.Create(env, environmentsDir, details, filter, "", true)
 }
 
 func (o *PromoteOptions) GetTargetNamespace(ns string, env string) (string, *v1.Environment, error) {
@@ -657,6 +783,7 @@ func (o *PromoteOptions) WaitForPromotion(ns string, env *v1.Environment, releas
 
 // TODO This could do with a refactor and some tests...
 func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment, releaseInfo *ReleaseInfo, end time.Time, duration time.Duration, promoteKey *kube.PromoteStepActivityKey) error {
+	started := time.Now()
 	pullRequestInfo := releaseInfo.PullRequestInfo
 	logMergeFailure := false
 	logNoMergeCommitSha := false
@@ -712,6 +839,12 @@ func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment
 
 						if o.NoWaitForUpdatePipeline {
 							log.Logger().Info("Pull Request merged but we are not waiting for the update pipeline to complete!")
+							if o.IsCanaryPromotion(env) {
+								err = o.WaitForCanary(ns, env, releaseInfo, started, end, promoteKey)
+								if err != nil {
+									return err
+								}
+							}
 							err = o.CommentOnIssues(ns, env, promoteKey)
 							if err == nil {
 								err = promoteKey.OnPromoteUpdate(kubeClient, jxClient, o.Namespace, kube.CompletePromotionUpdate)
@@ -777,6 +910,12 @@ func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment
 								}
 								if succeeded {
 									log.Logger().Info("Merge status checks all passed so the promotion worked!")
+									if o.IsCanaryPromotion(env) {
+										err = o.WaitForCanary(ns, env, releaseInfo, started, end, promoteKey)
+										if err != nil {
+											return err
+										}
+									}
 									err = o.CommentOnIssues(ns, env, promoteKey)
 									if err == nil {
 										err = promoteKey.OnPromoteUpdate(kubeClient, jxClient, o.Namespace, kube.CompletePromotionUpdate)
@@ -850,6 +989,70 @@ func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment
 	return nil
 }
 
+// IsCanaryPromotion returns true if the promotion to the Environment completes only once the Flagger canary analysis
+// of the new version succeeds
+func (o *PromoteOptions) IsCanaryPromotion(env *v1.Environment) bool {
+	return o.Canary || (env != nil && env.Spec.PromotionStrategy == v1.PromotionStrategyTypeCanary)
+}
+
+// WaitForCanary waits for the Flagger canary analysis of the promoted version to complete, recording the canary weight
+// and each iteration of the analysis on the PipelineActivity. If the analysis fails the promotion is marked as failed
+// and, if --canary-revert is enabled, a Pull Request reverting the Environment to the previous version is created.
+func (o *PromoteOptions) WaitForCanary(ns string, env *v1.Environment, releaseInfo *ReleaseInfo, since time.Time, end time.Time, promoteKey *kube.PromoteStepActivityKey) error {
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return errors.Wrap(err, "Getting jx client")
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return errors.Wrap(err, "Getting kube client")
+	}
+	lister := o.CanaryLister
+	if lister == nil {
+		lister = flagger.NewCanaryLister(kubeClient)
+	}
+	pollTime := time.Second * 20
+	if o.PullRequestPollDuration != nil {
+		pollTime = *o.PullRequestPollDuration
+	}
+
+	app := o.Application
+	log.Logger().Infof("Waiting for the canary analysis of %s in namespace %s", util.ColorInfo(app), util.ColorInfo(ns))
+	promoteKey.OnPromoteCanary(kubeClient, jxClient, o.Namespace, kube.StartPromotionCanary)
+
+	updateCanary := func(canary *flagger.Canary) error {
+		status := canary.Status
+		log.Logger().Infof("canary %s is %s with weight %d and %d failed checks", util.ColorInfo(canary.Name),
+			util.ColorInfo(status.Phase), status.CanaryWeight, status.FailedChecks)
+		return promoteKey.OnPromoteCanary(kubeClient, jxClient, o.Namespace, func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteCanaryStep) error {
+			flagger.UpdateCanaryStep(p, canary)
+			return nil
+		})
+	}
+	canary, err := flagger.WaitForCanary(lister, app, ns, since, pollTime, end, updateCanary)
+	if err == nil && canary.Status.IsSucceeded() {
+		log.Logger().Infof("The canary analysis of %s succeeded", util.ColorInfo(app))
+		return promoteKey.OnPromoteCanary(kubeClient, jxClient, o.Namespace, kube.CompletePromotionCanary)
+	}
+	if err == nil {
+		err = fmt.Errorf("the canary analysis of %s in namespace %s failed after %d failed checks", app, ns, canary.Status.FailedChecks)
+	}
+	log.Logger().Warnf("%s", err)
+	promoteKey.OnPromoteCanary(kubeClient, jxClient, o.Namespace, kube.FailedPromotionCanary)
+	promoteKey.OnPromoteUpdate(kubeClient, jxClient, o.Namespace, kube.FailedPromotionUpdate)
+
+	if o.CanaryRevert {
+		info, revertErr := o.RevertViaPullRequest(env, releaseInfo)
+		if revertErr != nil {
+			log.Logger().Warnf("Failed to create a Pull Request to revert %s: %s", app, revertErr)
+		} else if info != nil && info.PullRequest != nil {
+			log.Logger().Infof("Created Pull Request %s to revert %s to version %s", util.ColorInfo(info.PullRequest.URL),
+				util.ColorInfo(app), util.ColorInfo(releaseInfo.PreviousVersion))
+		}
+	}
+	return err
+}
+
 func (o *PromoteOptions) findLatestVersion(app string) (string, error) {
 	charts, err := o.Helm().SearchCharts(app, true)
 	if err != nil {
@@ -1093,13 +1296,14 @@ func (o *PromoteOptions) CommentOnIssues(targetNS string, environment *v1.Enviro
 	release, err := jxClient.JenkinsV1().Releases(ens).Get(releaseName, metav1.GetOptions{})
 	if err == nil && release != nil {
 		o.releaseResource = release
-		issues := release.Spec.Issues
+		releaseIssues := release.Spec.Issues
 
+		var tracker issues.IssueProvider
 		versionMessage := version
 		if release.Spec.ReleaseNotesURL != "" {
 			versionMessage = "[" + version + "](" + release.Spec.ReleaseNotesURL + ")"
 		}
-		for _, issue := range issues {
+		for _, issue := range releaseIssues {
 			if issue.IsClosed() {
 				log.Logger().Infof("Commenting that issue %s is now in %s", util.ColorInfo(issue.URL), util.ColorInfo(envName))
 
@@ -1108,7 +1312,18 @@ func (o *PromoteOptions) CommentOnIssues(targetNS string, environment *v1.Enviro
 				if id != "" {
 					number, err := strconv.Atoi(id)
 					if err != nil {
-						log.Logger().Warnf("Could not parse issue id %s for URL %s", id, issue.URL)
+						// the issue is in an issue tracker such as JIRA rather than the git provider
+						if tracker == nil {
+							tracker, err = o.CreateIssueProvider("")
+							if err != nil {
+								log.Logger().Warnf("Could not create the issue tracker to comment on issue %s for URL %s: %s", id, issue.URL, err)
+								continue
+							}
+						}
+						err = tracker.CreateIssueComment(id, comment)
+						if err != nil {
+							log.Logger().Warnf("Failed to add comment to issue %s: %s", issue.URL, err)
+						}
 					} else {
 						if number > 0 {
 							err = provider.CreateIssueComment(gitInfo.Organisation, gitInfo.Name, number, comment)
@@ -1120,10 +1335,46 @@ func (o *PromoteOptions) CommentOnIssues(targetNS string, environment *v1.Enviro
 				}
 			}
 		}
+		o.transitionIssues(environment, releaseIssues, tracker)
 	}
 	return nil
 }
 
+// transitionIssues transitions the issues of the release into the state configured for the Environment in the
+// issue tracker configuration of the project
+func (o *PromoteOptions) transitionIssues(environment *v1.Environment, releaseIssues []v1.IssueSummary, tracker issues.IssueProvider) {
+	if len(releaseIssues) == 0 {
+		return
+	}
+	issueTrackerConfig, err := o.IssueTrackerConfig("")
+	if err != nil {
+		log.Logger().Warnf("Failed to load the issue tracker configuration so not transitioning issues: %s", err)
+		return
+	}
+	state := issueTrackerConfig.TransitionState(environment.Name)
+	if state == "" {
+		return
+	}
+	if tracker == nil {
+		tracker, err = o.CreateIssueProvider("")
+		if err != nil {
+			log.Logger().Warnf("Could not create the issue tracker to transition issues to %s: %s", state, err)
+			return
+		}
+	}
+	for _, issue := range releaseIssues {
+		if issue.ID == "" {
+			continue
+		}
+		err = tracker.TransitionIssue(issue.ID, state)
+		if err != nil {
+			log.Logger().Warnf("Failed to transition issue %s to %s: %s", issue.URL, state, err)
+			continue
+		}
+		log.Logger().Infof("Transitioned issue %s to %s", util.ColorInfo(issue.URL), util.ColorInfo(state))
+	}
+}
+
 func (o *PromoteOptions) SearchForChart(filter string) (string, error) {
 	answer := ""
 	charts, err := o.Helm().SearchCharts(filter, false)
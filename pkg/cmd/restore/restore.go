@@ -0,0 +1,146 @@
+package restore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/backup"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// RestoreOptions contains the command line options
+type RestoreOptions struct {
+	*opts.CommonOptions
+
+	Dir             string
+	URL             string
+	Namespace       string
+	BackupNamespace string
+	Resources       []string
+	DryRun          bool
+}
+
+var (
+	restoreLong = templates.LongDesc(`
+		Restores the resources backed up by 'jx controller backup' from its git repository into the current cluster.
+
+		The resources are restored in dependency order so that, for example, Teams and Users are restored before the
+		Environments and EnvironmentRoleBindings which refer to them. Resources which already exist are updated.
+
+`)
+
+	restoreExample = templates.Examples(`
+		# Restore the default resources from a backup repository
+		jx restore --url https://github.com/myorg/organisation-myorg-backup.git
+
+		# Restore all the resources from a local clone of a backup repository
+		jx restore --dir ./organisation-myorg-backup -r team -r user -r environment -r environmentrolebinding -r scheduler -r sourcerepository -r app -r extensionconfig
+
+		# List the backup files which would be restored
+		jx restore --dir ./organisation-myorg-backup --dry-run
+	`)
+)
+
+// NewCmdRestore creates the new command for: jx restore
+func NewCmdRestore(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &RestoreOptions{
+		CommonOptions: commonOpts,
+	}
+	cmd := &cobra.Command{
+		Use:     "restore",
+		Short:   "Restores the Jenkins X resources from a backup git repository",
+		Long:    restoreLong,
+		Example: restoreExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory of a local clone of the backup git repository")
+	cmd.Flags().StringVarP(&options.URL, "url", "u", "", "The URL of the backup git repository to clone if no directory is specified")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace to restore the resources into. Defaults to the current namespace")
+	cmd.Flags().StringVarP(&options.BackupNamespace, "backup-namespace", "", "", "The namespace the resources were backed up from. Defaults to the namespace to restore into")
+	cmd.Flags().StringArrayVarP(&options.Resources, "resources", "r", backup.DefaultResources,
+		fmt.Sprintf("The resources to restore. Supported resources are: %s", strings.Join(backup.ResourceNames(), ", ")))
+	cmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false, "Lists the backup files which would be restored without restoring them")
+	return cmd
+}
+
+// Run implements this command
+func (o *RestoreOptions) Run() error {
+	resources, err := backup.FindResources(o.Resources)
+	if err != nil {
+		return err
+	}
+	dir := o.Dir
+	if dir == "" {
+		if o.URL == "" {
+			return util.MissingOption("dir")
+		}
+		dir, err = ioutil.TempDir("", "jx-restore-")
+		if err != nil {
+			return errors.Wrap(err, "failed to create a temporary directory")
+		}
+		defer os.RemoveAll(dir)
+		log.Logger().Infof("Cloning backup repository %s", util.ColorInfo(o.URL))
+		err = o.Git().Clone(o.URL, dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to clone %s", o.URL)
+		}
+	}
+
+	clients := &backup.Clients{}
+	if !o.DryRun {
+		apisClient, err := o.ApiExtensionsClient()
+		if err != nil {
+			return err
+		}
+		err = kube.RegisterAllCRDs(apisClient)
+		if err != nil {
+			return err
+		}
+		clients.KubeClient, err = o.KubeClient()
+		if err != nil {
+			return err
+		}
+	}
+	jxClient, devNs, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	clients.JXClient = jxClient
+	ns := o.Namespace
+	if ns == "" {
+		ns = devNs
+	}
+	backupNs := o.BackupNamespace
+	if backupNs == "" {
+		backupNs = ns
+	}
+
+	restored, err := backup.RestoreResources(clients, dir, resources, backupNs, ns, o.DryRun)
+	for _, fileName := range restored {
+		if o.DryRun {
+			log.Logger().Infof("Would restore %s", util.ColorInfo(fileName))
+		} else {
+			log.Logger().Infof("Restored %s", util.ColorInfo(fileName))
+		}
+	}
+	if err != nil || o.DryRun {
+		return err
+	}
+	log.Logger().Infof("Restored %d resources into namespace %s", len(restored), util.ColorInfo(ns))
+	return nil
+}
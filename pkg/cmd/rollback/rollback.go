@@ -0,0 +1,296 @@
+package rollback
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/promote"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// maxHistory is the maximum number of commits of the requirements of an Environment searched for a previous version
+const maxHistory = 50
+
+// RollbackOptions contains the command line options
+type RollbackOptions struct {
+	promote.PromoteOptions
+
+	Reason string
+}
+
+var (
+	rollbackLong = templates.LongDesc(`
+		Rolls back an application in an Environment to the version it was running before the current version.
+
+		A Pull Request is created on the git repository of the Environment which restores the previous version in its
+		'requirements.yaml'. The previous version is found from the history of the Environment's git repository, falling
+		back to the Release resources of the application. By default the command waits for the Pull Request to merge
+		like 'jx promote'.
+
+`)
+
+	rollbackExample = templates.Examples(`
+		# Roll back the myapp application in production to its previous version
+		jx rollback myapp --env production
+
+		# Roll back the myapp application in staging to a specific version without waiting for the Pull Request
+		jx rollback myapp --env staging --version 1.2.3 --no-wait
+	`)
+)
+
+// NewCmdRollback creates the new command for: jx rollback
+func NewCmdRollback(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &RollbackOptions{
+		PromoteOptions: promote.PromoteOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "rollback [application]",
+		Short:   "Rolls back an application in an Environment to its previous version",
+		Long:    rollbackLong,
+		Example: rollbackExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Application, opts.OptionApplication, "a", "", "The Application to roll back")
+	cmd.Flags().StringVarP(&options.Environment, opts.OptionEnvironment, "e", "", "The Environment to roll back")
+	cmd.Flags().StringVarP(&options.Version, "version", "v", "", "The version to roll back to. Defaults to the version before the current version")
+	cmd.Flags().StringVarP(&options.Alias, "alias", "", "", "The optional alias used in the 'requirements.yaml' file")
+	cmd.Flags().StringVarP(&options.HelmRepositoryURL, "helm-repo-url", "u", "", "The Helm Repository URL to use for the App")
+	cmd.Flags().StringVarP(&options.Reason, "reason", "", "", "The reason for the rollback which is added to the Pull Request")
+	cmd.Flags().StringVarP(&options.Timeout, opts.OptionTimeout, "t", "1h", "The timeout to wait for the rollback to succeed in the Environment. The command fails if the timeout is exceeded or the rollback does not complete")
+	cmd.Flags().StringVarP(&options.PullRequestPollTime, "pull-request-poll-time", "", "20s", "Poll time when waiting for a Pull Request to merge")
+	cmd.Flags().BoolVarP(&options.NoMergePullRequest, "no-merge", "", false, "Disables automatic merge of the rollback Pull Request")
+	cmd.Flags().BoolVarP(&options.NoPoll, "no-wait", "", false, "Disables waiting for the rollback Pull Request to merge")
+	return cmd
+}
+
+// Run implements this command
+func (o *RollbackOptions) Run() error {
+	if o.Application == "" && len(o.Args) > 0 {
+		o.Application = o.Args[0]
+	}
+	if o.Application == "" {
+		return util.MissingOption(opts.OptionApplication)
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	if o.Namespace == "" {
+		o.Namespace = ns
+	}
+	if o.HelmRepositoryURL == "" {
+		o.HelmRepositoryURL = o.DefaultChartRepositoryURL()
+	}
+	if o.Environment == "" {
+		if o.BatchMode {
+			return util.MissingOption(opts.OptionEnvironment)
+		}
+		names := []string{}
+		m, allEnvNames, err := kube.GetOrderedEnvironments(jxClient, ns)
+		if err != nil {
+			return err
+		}
+		for _, n := range allEnvNames {
+			if m[n].Spec.Kind.IsPermanent() {
+				names = append(names, n)
+			}
+		}
+		o.Environment, err = kube.PickEnvironment(names, "", o.GetIOFileHandles())
+		if err != nil {
+			return err
+		}
+	}
+	err = o.ParseDurations()
+	if err != nil {
+		return err
+	}
+
+	env, err := jxClient.JenkinsV1().Environments(ns).Get(o.Environment, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to find Environment %s", o.Environment)
+	}
+	if env.Spec.Source.URL == "" {
+		return errors.Errorf("the Environment %s has no git repository so cannot be rolled back", env.Name)
+	}
+
+	releases, err := kube.GetOrderedReleases(jxClient, ns, "")
+	if err != nil {
+		log.Logger().Warnf("Failed to load the Release resources in namespace %s: %s", ns, err)
+	}
+
+	releaseInfo := &promote.ReleaseInfo{
+		ReleaseName: env.Spec.Namespace + "-" + o.Application,
+		FullAppName: o.Application,
+	}
+	_, err = o.RollbackViaPullRequest(env, releaseInfo, releases)
+	if err != nil {
+		return err
+	}
+	pr := releaseInfo.PullRequestInfo
+	if pr != nil && pr.PullRequest != nil {
+		log.Logger().Infof("Created Pull Request %s to roll back %s in Environment %s from %s to %s", util.ColorInfo(pr.PullRequest.URL),
+			util.ColorInfo(o.Application), util.ColorInfo(env.Name), releaseInfo.PreviousVersion, util.ColorInfo(releaseInfo.Version))
+	}
+	o.ReleaseInfo = releaseInfo
+	if o.NoPoll {
+		return nil
+	}
+	o.Version = releaseInfo.Version
+	return o.WaitForPromotion(env.Spec.Namespace, env, releaseInfo)
+}
+
+// RollbackViaPullRequest creates a Pull Request which puts the previous version of the application back in the
+// Environment. The version being rolled back is recorded as the PreviousVersion of the release info
+func (o *RollbackOptions) RollbackViaPullRequest(env *v1.Environment, releaseInfo *promote.ReleaseInfo, releases []v1.Release) (*gits.PullRequestInfo, error) {
+	app := o.Application
+	branchName := "rollback-" + app
+	if o.Version != "" {
+		branchName += "-" + o.Version
+	}
+	details := gits.PullRequestDetails{
+		BranchName: branchName,
+	}
+
+	modifyChartFn := func(requirements *helm.Requirements, metadata *chart.Metadata, values map[string]interface{},
+		templates map[string]string, dir string, details *gits.PullRequestDetails) error {
+		current := dependencyVersion(requirements, app)
+		if current == "" {
+			return errors.Errorf("the application %s is not deployed in the Environment %s", app, env.Name)
+		}
+		version := o.Version
+		if version == "" {
+			history, err := o.requirementsHistory(dir, app)
+			if err != nil {
+				log.Logger().Warnf("Failed to load the history of the Environment %s so using the Release resources: %s", env.Name, err)
+			}
+			version = PreviousVersion(app, current, history, releases)
+			if version == "" {
+				return errors.Errorf("no version of %s before %s was found in the Environment %s. Please specify one via --version", app, current, env.Name)
+			}
+		}
+		if version == current {
+			return errors.Errorf("the application %s is already at version %s in the Environment %s", app, current, env.Name)
+		}
+		releaseInfo.PreviousVersion = current
+		releaseInfo.Version = version
+		details.Title = fmt.Sprintf("chore: rollback %s to %s", app, version)
+		details.Message = fmt.Sprintf("chore: Roll back %s from version %s to version %s", app, current, version)
+		if o.Reason != "" {
+			details.Message += "\n\n" + o.Reason
+		}
+		requirements.SetAppVersion(app, version, o.HelmRepositoryURL, o.Alias)
+		return nil
+	}
+	info, err := o.CreateEnvironmentPullRequest(env, modifyChartFn, &details, &gits.PullRequestFilter{})
+	releaseInfo.PullRequestInfo = info
+	return info, err
+}
+
+// requirementsHistory returns the version of the app in each commit of the requirements file in the given chart dir,
+// newest first
+func (o *RollbackOptions) requirementsHistory(dir string, app string) ([]string, error) {
+	fileName, err := helm.FindRequirementsFileName(dir)
+	if err != nil {
+		return nil, err
+	}
+	relPath := "./" + filepath.Base(fileName)
+	cmd := util.Command{
+		Dir:  dir,
+		Name: "git",
+		Args: []string{"log", "--format=%H", "-n", fmt.Sprintf("%d", maxHistory), "--", relPath},
+	}
+	out, err := cmd.RunWithoutRetry()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the git history of %s", fileName)
+	}
+	answer := []string{}
+	for _, sha := range strings.Fields(out) {
+		text, err := o.Git().LoadFileFromBranch(dir, sha, relPath)
+		if err != nil {
+			return answer, errors.Wrapf(err, "failed to load %s at commit %s", relPath, sha)
+		}
+		requirements, err := helm.LoadRequirements([]byte(text))
+		if err != nil {
+			log.Logger().Debugf("Ignoring invalid %s at commit %s: %s", relPath, sha, err)
+			continue
+		}
+		answer = append(answer, dependencyVersion(requirements, app))
+	}
+	return answer, nil
+}
+
+// PreviousVersion returns the version of the app which was deployed before the current version. The history is the
+// version of the app in each commit of the Environment's requirements, newest first. Versions which were rolled back
+// are skipped, so that rolling back twice does not restore the version rolled back from. If the history does not
+// contain a previous version the newest Release of the app older than the current version is used
+func PreviousVersion(app string, current string, history []string, releases []v1.Release) string {
+	// replay the history oldest first; deploying a version which is already on the stack rolls back the newer ones
+	deployed := []string{}
+	for i := len(history) - 1; i >= 0; i-- {
+		version := history[i]
+		if version == "" {
+			continue
+		}
+		idx := util.StringArrayIndex(deployed, version)
+		if idx >= 0 {
+			deployed = deployed[:idx+1]
+		} else {
+			deployed = append(deployed, version)
+		}
+	}
+	idx := util.StringArrayIndex(deployed, current)
+	if idx > 0 {
+		return deployed[idx-1]
+	}
+
+	sorted := []v1.Release{}
+	for _, release := range releases {
+		if release.Spec.Name == app && release.Spec.Version != "" {
+			sorted = append(sorted, release)
+		}
+	}
+	kube.SortReleases(sorted)
+	currentRelease := v1.Release{
+		Spec: v1.ReleaseSpec{
+			Name:    app,
+			Version: current,
+		},
+	}
+	for _, release := range sorted {
+		// the releases are sorted newest first
+		if kube.ReleaseOrder([]v1.Release{currentRelease, release}).Less(0, 1) {
+			return release.Spec.Version
+		}
+	}
+	return ""
+}
+
+func dependencyVersion(requirements *helm.Requirements, app string) string {
+	for _, dep := range requirements.Dependencies {
+		if dep != nil && dep.Name == app {
+			return dep.Version
+		}
+	}
+	return ""
+}
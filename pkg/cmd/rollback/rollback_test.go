@@ -0,0 +1,51 @@
+package rollback_test
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/cmd/rollback"
+	"github.com/stretchr/testify/assert"
+)
+
+func release(name string, version string) v1.Release {
+	return v1.Release{
+		Spec: v1.ReleaseSpec{
+			Name:    name,
+			Version: version,
+		},
+	}
+}
+
+func TestPreviousVersion(t *testing.T) {
+	t.Parallel()
+	releases := []v1.Release{
+		release("myapp", "1.0.1"),
+		release("myapp", "1.0.3"),
+		release("other", "1.0.2"),
+		release("myapp", "1.0.0"),
+		release("myapp", "1.0.2"),
+	}
+
+	testCases := []struct {
+		name     string
+		current  string
+		history  []string
+		releases []v1.Release
+		expected string
+	}{
+		{"history", "1.0.3", []string{"1.0.3", "1.0.3", "1.0.1", "1.0.0"}, releases, "1.0.1"},
+		{"history after rollback", "1.0.1", []string{"1.0.1", "1.0.3", "1.0.1", "1.0.0"}, releases, "1.0.0"},
+		{"history after rollback without older version", "1.0.1", []string{"1.0.1", "1.0.3", "1.0.1"}, releases, "1.0.0"},
+		{"history after promoting a rolled back version", "1.0.3", []string{"1.0.3", "1.0.1", "1.0.3", "1.0.1"}, releases, "1.0.1"},
+		{"history without app", "1.0.3", []string{"1.0.3", ""}, releases, "1.0.2"},
+		{"no history", "1.0.3", nil, releases, "1.0.2"},
+		{"unknown current release", "1.0.1-SNAPSHOT", nil, releases, "1.0.0"},
+		{"oldest release", "1.0.0", nil, releases, ""},
+		{"no releases", "1.0.3", []string{"1.0.3"}, nil, ""},
+	}
+	for _, tc := range testCases {
+		actual := rollback.PreviousVersion("myapp", tc.current, tc.history, tc.releases)
+		assert.Equal(t, tc.expected, actual, tc.name)
+	}
+}
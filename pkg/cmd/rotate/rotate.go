@@ -0,0 +1,53 @@
+package rotate
+
+import (
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/spf13/cobra"
+
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+)
+
+// Rotate contains the command line options
+type Rotate struct {
+	*opts.CommonOptions
+}
+
+var (
+	rotateLong = templates.LongDesc(`
+		Rotates a credential such as the token of the pipeline user.
+`)
+
+	rotateExample = templates.Examples(`
+		# Rotate the token of the pipeline user of the git provider
+		jx rotate secret git
+	`)
+)
+
+// NewCmdRotate creates the command object
+func NewCmdRotate(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &Rotate{
+		commonOpts,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "rotate TYPE [flags]",
+		Short:   "Rotates a credential such as a secret",
+		Long:    rotateLong,
+		Example: rotateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.AddCommand(NewCmdRotateSecret(commonOpts))
+	return cmd
+}
+
+// Run implements this command
+func (o *Rotate) Run() error {
+	return o.Cmd.Help()
+}
@@ -0,0 +1,431 @@
+package rotate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/chats"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/io/secrets"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/rotation"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/AlecAivazis/survey.v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RotateSecretOptions contains the command line options
+type RotateSecretOptions struct {
+	*opts.CommonOptions
+
+	Kind          string
+	Value         string
+	Generate      bool
+	Server        string
+	Username      string
+	Registry      string
+	BasePath      string
+	SecretPath    string
+	SecretKey     string
+	NoSecretStore bool
+	NoVerify      bool
+	NoRestart     bool
+
+	// authConfigSvc is the auth config service of the git and chat credentials
+	authConfigSvc auth.ConfigService
+	server        *auth.AuthServer
+	userAuth      *auth.UserAuth
+}
+
+var (
+	rotateSecretLong = templates.LongDesc(`
+		Rotates a credential which was set up by 'jx boot'.
+
+		The new credential is verified against the git provider, chat service or docker registry before anything is changed.
+		It is then written to the secret store, the Kubernetes Secrets derived from it are updated and the deployments using
+		those Secrets are restarted. Each rotation is recorded, without the credential itself, in the ConfigMap ` + rotation.ConfigMapRotationHistory + `
+		in the development namespace.
+
+		The kinds of secret which can be rotated are:
+
+` + describeSecretKinds() + `
+
+`)
+
+	rotateSecretExample = templates.Examples(`
+		# Rotate the token of the pipeline user, prompting for the new token
+		jx rotate secret git
+
+		# Rotate the password of the docker registry
+		jx rotate secret docker --registry docker.io --username myuser --value mypassword
+
+		# Generate a new HMAC token for the webhooks
+		jx rotate secret hmac
+
+		# View the rotation history
+		kubectl get configmap ` + rotation.ConfigMapRotationHistory + ` -o yaml
+	`)
+)
+
+// NewCmdRotateSecret creates the command
+func NewCmdRotateSecret(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &RotateSecretOptions{
+		CommonOptions: commonOpts,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "secret KIND",
+		Short:   "Rotates a credential such as the git token of the pipeline user",
+		Long:    rotateSecretLong,
+		Example: rotateSecretExample,
+		Aliases: []string{"secrets"},
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Value, "value", "v", "", "The new credential. If not specified it is generated for the kinds which support it or prompted for")
+	cmd.Flags().BoolVarP(&options.Generate, "generate", "g", false, "Generates a new random credential")
+	cmd.Flags().StringVarP(&options.Server, "server", "s", "", "The URL of the git provider or chat service. Defaults to the pipeline git server or the current chat server")
+	cmd.Flags().StringVarP(&options.Username, "username", "u", "", "The user name of the credential. Defaults to the pipeline user or the user of the docker registry")
+	cmd.Flags().StringVarP(&options.Registry, "registry", "r", "", "The docker registry. Defaults to the single registry in the docker config of the pipelines")
+	cmd.Flags().StringVarP(&options.BasePath, "secret-base-path", "", "", fmt.Sprintf("The base path of the secrets in the secret store. Defaults to the cluster name, or the namespace for Kubernetes secret storage, from the %s file of the cluster", config.RequirementsConfigFileName))
+	cmd.Flags().StringVarP(&options.SecretPath, "path", "", "", "The path of the secret in the secret store below the base path. Defaults to the path used by 'jx boot' for the kind")
+	cmd.Flags().StringVarP(&options.SecretKey, "key", "", "", "The key of the credential in the secret in the secret store. Defaults to the key used by 'jx boot' for the kind")
+	cmd.Flags().BoolVarP(&options.NoSecretStore, "no-secret-store", "", false, "Does not write the credential to the secret store")
+	cmd.Flags().BoolVarP(&options.NoVerify, "no-verify", "", false, "Does not verify the new credential")
+	cmd.Flags().BoolVarP(&options.NoRestart, "no-restart", "", false, "Does not restart the deployments using the rotated Kubernetes Secrets")
+	return cmd
+}
+
+// Run implements this command
+func (o *RotateSecretOptions) Run() error {
+	if o.Kind == "" && len(o.Args) > 0 {
+		o.Kind = o.Args[0]
+	}
+	if o.Kind == "" {
+		return util.MissingArgument("kind")
+	}
+	kind, err := rotation.FindSecretKind(o.Kind)
+	if err != nil {
+		return err
+	}
+	kubeClient, ns, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	value, generated, err := o.credential(kind)
+	if err != nil {
+		return err
+	}
+	err = o.findUser(kubeClient, ns, kind)
+	if err != nil {
+		return err
+	}
+
+	record := &rotation.Record{
+		Kind:        kind.Name,
+		Fingerprint: rotation.Fingerprint(value),
+		Generated:   generated,
+		RotatedAt:   time.Now().UTC(),
+	}
+	record.RotatedBy, err = o.GetUsername("")
+	if err != nil {
+		log.Logger().Warnf("Failed to find the current user name: %s", err)
+	}
+	if !o.NoVerify {
+		err = o.verify(kind, value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to verify the new %s", kind.Description)
+		}
+		record.Verified = true
+	}
+
+	if !o.NoSecretStore {
+		record.Path, record.Key, err = o.writeSecretStore(kind, value)
+		if err != nil {
+			record.Error = err.Error()
+			recordErr := rotation.AddRecord(kubeClient, ns, record)
+			if recordErr != nil {
+				recordErr = errors.Wrapf(recordErr, "failed to record the rotation in ConfigMap %s", rotation.ConfigMapRotationHistory)
+			}
+			return util.CombineErrors(err, recordErr)
+		}
+	}
+	err = o.rotate(kubeClient, ns, kind, value, record)
+	if err != nil {
+		record.Error = err.Error()
+	}
+	recordErr := rotation.AddRecord(kubeClient, ns, record)
+	if recordErr != nil {
+		recordErr = errors.Wrapf(recordErr, "failed to record the rotation in ConfigMap %s", rotation.ConfigMapRotationHistory)
+	}
+	err = util.CombineErrors(err, recordErr)
+	if err != nil {
+		return err
+	}
+
+	log.Logger().Infof("Rotated %s with fingerprint %s", kind.Description, util.ColorInfo(record.Fingerprint))
+	if kind.Name == rotation.KindHMAC {
+		log.Logger().Infof("Run %s to update the webhooks of the git repositories with the new HMAC token", util.ColorInfo("jx update webhooks"))
+	}
+	return nil
+}
+
+// credential returns the new credential and whether it was generated
+func (o *RotateSecretOptions) credential(kind *rotation.SecretKind) (string, bool, error) {
+	if o.Value != "" {
+		return o.Value, false, nil
+	}
+	if o.Generate || kind.Generated {
+		if !kind.Generated {
+			return "", false, util.InvalidOptionf("generate", o.Generate, "the %s cannot be generated", kind.Description)
+		}
+		value, err := rotation.GenerateCredential()
+		if err != nil {
+			return "", false, errors.Wrapf(err, "failed to generate the %s", kind.Description)
+		}
+		return value, true, nil
+	}
+	if o.BatchMode {
+		return "", false, util.MissingOption("value")
+	}
+	value := ""
+	prompt := &survey.Password{
+		Message: fmt.Sprintf("Enter the new %s", kind.Description),
+	}
+	surveyOpts := survey.WithStdio(o.In, o.Out, o.Err)
+	err := survey.AskOne(prompt, &value, survey.Required, surveyOpts)
+	return value, false, err
+}
+
+// findUser finds the server and user whose credential is rotated
+func (o *RotateSecretOptions) findUser(kubeClient kubernetes.Interface, ns string, kind *rotation.SecretKind) error {
+	var err error
+	switch kind.Name {
+	case rotation.KindGit:
+		o.authConfigSvc, err = o.GitAuthConfigService()
+		if err != nil {
+			return err
+		}
+		cfg := o.authConfigSvc.Config()
+		if o.Server == "" {
+			o.Server = cfg.PipeLineServer
+		}
+		if o.Username == "" {
+			o.Username = cfg.PipeLineUsername
+		}
+	case rotation.KindChat:
+		o.authConfigSvc, err = o.CreateChatAuthConfigService("")
+		if err != nil {
+			return err
+		}
+		cfg := o.authConfigSvc.Config()
+		if o.Server == "" {
+			o.Server = cfg.CurrentServer
+		}
+		if server := cfg.GetServer(o.Server); server != nil && o.Username == "" {
+			o.Username = server.CurrentUser
+		}
+	case rotation.KindDocker:
+		if o.Registry == "" || o.Username == "" {
+			registry, username, err := rotation.FindDockerAuth(kubeClient, ns)
+			if err != nil {
+				return err
+			}
+			if o.Registry == "" {
+				o.Registry = registry
+			}
+			if o.Username == "" {
+				o.Username = username
+			}
+		}
+		if o.Registry == "" {
+			return util.MissingOption("registry")
+		}
+		if o.Username == "" {
+			return util.MissingOption("username")
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	o.server = o.authConfigSvc.Config().GetServer(o.Server)
+	if o.server == nil {
+		return fmt.Errorf("no %s server found for %q", kind.Name, o.Server)
+	}
+	o.userAuth = o.server.GetUserAuth(o.Username)
+	if o.userAuth == nil && o.Username == "" && len(o.server.Users) == 1 {
+		o.userAuth = o.server.Users[0]
+	}
+	if o.userAuth == nil {
+		return fmt.Errorf("no user %q found for %s server %s", o.Username, kind.Name, o.server.URL)
+	}
+	return nil
+}
+
+// verify verifies the new credential against the git provider, chat service or docker registry
+func (o *RotateSecretOptions) verify(kind *rotation.SecretKind, value string) error {
+	switch kind.Name {
+	case rotation.KindGit:
+		userAuth := *o.userAuth
+		userAuth.ApiToken = value
+		provider, err := gits.CreateProvider(o.server, &userAuth, o.Git())
+		if err != nil {
+			return err
+		}
+		_, err = provider.ListOrganisations()
+		if err != nil {
+			return errors.Wrapf(err, "failed to access %s as %s", o.server.URL, userAuth.Username)
+		}
+		log.Logger().Infof("Verified the new token of %s on %s", util.ColorInfo(userAuth.Username), util.ColorInfo(o.server.URL))
+	case rotation.KindChat:
+		userAuth := *o.userAuth
+		userAuth.ApiToken = value
+		provider, err := chats.CreateChatProvider(o.server.Kind, o.server, &userAuth, true)
+		if err != nil {
+			return err
+		}
+		username, err := provider.AuthenticatedUser()
+		if err != nil {
+			return errors.Wrapf(err, "failed to access %s", o.server.URL)
+		}
+		log.Logger().Infof("Verified the new token of %s on %s", util.ColorInfo(username), util.ColorInfo(o.server.URL))
+	case rotation.KindDocker:
+		err := rotation.VerifyRegistry(util.GetClient(), o.Registry, o.Username, value)
+		if err != nil {
+			return err
+		}
+		log.Logger().Infof("Verified the new password of %s on %s", util.ColorInfo(o.Username), util.ColorInfo(o.Registry))
+	}
+	return nil
+}
+
+// writeSecretStore writes the credential to the secret store returning its path and key
+func (o *RotateSecretOptions) writeSecretStore(kind *rotation.SecretKind, value string) (string, string, error) {
+	location := secrets.AutoLocationKind
+	basePath := o.BasePath
+	if basePath == "" || o.SecretPath == "" {
+		requirements, err := o.requirements()
+		if err != nil {
+			return "", "", err
+		}
+		if requirements.SecretStorage != "" {
+			location = secrets.ToSecretsLocation(string(requirements.SecretStorage))
+		}
+		if basePath == "" {
+			basePath = requirements.Cluster.ClusterName
+			if requirements.SecretStorage == config.SecretStorageTypeKubernetes {
+				// the first part of the path of a Kubernetes Secret is its namespace
+				basePath = requirements.Cluster.Namespace
+				if basePath == "" {
+					basePath = kube.DefaultNamespace
+				}
+			}
+		}
+	}
+	secretKind := *kind
+	if o.SecretPath != "" {
+		secretKind.Path = o.SecretPath
+	}
+	if o.SecretKey != "" {
+		secretKind.Key = o.SecretKey
+	}
+	client, err := o.GetSecretURLClient(location)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to create the secret store client")
+	}
+	path := rotation.SecretPath(basePath, &secretKind)
+	err = rotation.WriteSecret(client, path, secretKind.Key, value)
+	if err != nil {
+		return "", "", err
+	}
+	log.Logger().Infof("Wrote the new %s to %s in the secret store", kind.Description, util.ColorInfo(secretKind.Key+"@"+path))
+	return path, secretKind.Key, nil
+}
+
+// requirements returns the requirements of the cluster from the team settings or the requirements file
+func (o *RotateSecretOptions) requirements() (*config.RequirementsConfig, error) {
+	teamSettings, err := o.TeamSettings()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load the team settings")
+	}
+	requirements, err := config.GetRequirementsConfigFromTeamSettings(teamSettings)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the requirements from the team settings")
+	}
+	if requirements == nil {
+		requirements, _, err = config.LoadRequirementsConfig("")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load the requirements file")
+		}
+	}
+	return requirements, nil
+}
+
+// rotate updates the Kubernetes Secrets derived from the credential and restarts the deployments using them
+func (o *RotateSecretOptions) rotate(kubeClient kubernetes.Interface, ns string, kind *rotation.SecretKind, value string, record *rotation.Record) error {
+	var err error
+	var names []string
+	switch kind.Name {
+	case rotation.KindGit, rotation.KindChat:
+		authKind := kube.ValueKindGit
+		if kind.Name == rotation.KindChat {
+			authKind = kube.ValueKindChat
+		}
+		// the auth Secrets are updated first as they may be derived from the secret store rather than saved with the
+		// auth config, which fails if they are managed externally
+		names, err = rotation.UpdateAuthSecrets(kubeClient, ns, authKind, o.userAuth.Username, value)
+		if err == nil {
+			o.userAuth.ApiToken = value
+			err = o.authConfigSvc.SaveConfig()
+			if err != nil {
+				err = errors.Wrapf(err, "failed to save the %s auth config", kind.Name)
+			}
+		}
+	case rotation.KindDocker:
+		names, err = rotation.UpdateDockerConfigSecrets(kubeClient, ns, o.Registry, o.Username, value)
+	}
+	record.Secrets = append(record.Secrets, names...)
+	if err != nil {
+		return err
+	}
+	names, err = rotation.UpdateSecretKeys(kubeClient, ns, kind.Secrets, value)
+	record.Secrets = append(record.Secrets, names...)
+	if err != nil {
+		return err
+	}
+	if len(record.Secrets) > 0 {
+		log.Logger().Infof("Updated the Kubernetes Secrets %s", util.ColorInfo(strings.Join(record.Secrets, ", ")))
+	}
+	if o.NoRestart {
+		return nil
+	}
+	record.RestartedDeployments, err = rotation.RestartDeployments(kubeClient, ns, record.Secrets)
+	if len(record.RestartedDeployments) > 0 {
+		log.Logger().Infof("Restarted the deployments %s", util.ColorInfo(strings.Join(record.RestartedDeployments, ", ")))
+	}
+	return err
+}
+
+// describeSecretKinds returns the description of the kinds of secrets for the long description of the command
+func describeSecretKinds() string {
+	lines := []string{}
+	for _, kind := range rotation.SecretKinds {
+		lines = append(lines, fmt.Sprintf("\t\t* %s: %s", kind.Name, kind.Description))
+	}
+	return strings.Join(lines, "\n")
+}
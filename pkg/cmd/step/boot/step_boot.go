@@ -32,6 +32,7 @@ func NewCmdStepBoot(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 	cmd.AddCommand(NewCmdStepBootVault(commonOpts))
+	cmd.AddCommand(NewCmdStepBootSecrets(commonOpts))
 	return cmd
 }
 
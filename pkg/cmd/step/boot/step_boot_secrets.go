@@ -0,0 +1,185 @@
+package boot
+
+import (
+	"os"
+
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/secreturl/localvault"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// StepBootSecretsOptions contains the command line flags
+type StepBootSecretsOptions struct {
+	*opts.CommonOptions
+	Dir           string
+	KeyFile       string
+	GenerateKey   bool
+	PassphraseEnv string
+	Decrypt       bool
+}
+
+var (
+	stepBootSecretsLong = templates.LongDesc(`
+		This step encrypts, re-encrypts with a new key or decrypts the local secrets used when the 'secretStorage' in the 'jx-requirements.yml' file is 'local'.
+
+		The local secrets are encrypted with the key file in $JX_LOCAL_SECRETS_KEY_FILE, a key derived from the passphrase in $JX_LOCAL_SECRETS_PASSPHRASE or the default key file ~/.jx/localSecrets.key if it exists.
+		Otherwise they are stored as plain text. This step reads the secrets with the current key and writes them with the new key so it is used to migrate plain text secrets and to rotate keys.
+
+		When the local secrets are decrypted or encrypted with a passphrase or another key file, the default key file is renamed to ~/.jx/localSecrets.key.old so that it is no longer used.
+`)
+
+	stepBootSecretsExample = templates.Examples(`
+		# encrypts the local secrets with the default key file, generating it if it does not exist
+		jx step boot secrets
+
+		# rotates the key by generating a new default key file and re-encrypting the local secrets
+		jx step boot secrets --generate-key
+
+		# encrypts the local secrets with a passphrase
+		JX_NEW_PASSPHRASE=mysecret jx step boot secrets --passphrase-env JX_NEW_PASSPHRASE
+
+		# decrypts the local secrets
+		jx step boot secrets --decrypt
+`)
+)
+
+// NewCmdStepBootSecrets creates the command
+func NewCmdStepBootSecrets(commonOpts *opts.CommonOptions) *cobra.Command {
+	o := StepBootSecretsOptions{
+		CommonOptions: commonOpts,
+	}
+	cmd := &cobra.Command{
+		Use:     "secrets",
+		Short:   "This step encrypts, re-encrypts with a new key or decrypts the local secrets",
+		Long:    stepBootSecretsLong,
+		Example: stepBootSecretsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			o.Cmd = cmd
+			o.Args = args
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", "", "the directory of the local secrets. Defaults to ~/.jx/localSecrets")
+	cmd.Flags().StringVarP(&o.KeyFile, "key-file", "k", "", "the key file to encrypt the local secrets with. Defaults to ~/.jx/localSecrets.key")
+	cmd.Flags().BoolVarP(&o.GenerateKey, "generate-key", "", false, "generates a new key in the key file even if it already exists")
+	cmd.Flags().StringVarP(&o.PassphraseEnv, "passphrase-env", "", "", "the name of the environment variable containing the passphrase to encrypt the local secrets with instead of a key file")
+	cmd.Flags().BoolVarP(&o.Decrypt, "decrypt", "", false, "stores the local secrets as plain text")
+	return cmd
+}
+
+// Run runs the command
+func (o *StepBootSecretsOptions) Run() error {
+	var err error
+	dir := o.Dir
+	if dir == "" {
+		dir, err = util.LocalFileSystemSecretsDir()
+		if err != nil {
+			return err
+		}
+	}
+	current, err := localvault.CipherFromEnvironment()
+	if err != nil {
+		return errors.Wrap(err, "loading the current key of the local secrets")
+	}
+
+	var newCipher localvault.Cipher
+	var newKey []byte
+	keyFile := o.KeyFile
+	switch {
+	case o.Decrypt:
+	case o.PassphraseEnv != "":
+		newCipher, err = localvault.NewPassphraseCipher(os.Getenv(o.PassphraseEnv))
+		if err != nil {
+			return errors.Wrapf(err, "creating the cipher from $%s", o.PassphraseEnv)
+		}
+	default:
+		if keyFile == "" {
+			keyFile, err = localvault.DefaultKeyFile()
+			if err != nil {
+				return err
+			}
+		}
+		exists, err := util.FileExists(keyFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check if file exists %s", keyFile)
+		}
+		if exists && !o.GenerateKey {
+			newCipher, err = localvault.LoadKeyFile(keyFile)
+		} else {
+			newKey, err = localvault.GenerateKey()
+			if err == nil {
+				newCipher, err = localvault.NewKeyCipher(newKey)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// a generated key is saved next to the key file before the secrets are encrypted with it, so that it is not lost
+	// if saving it fails, and only replaces the key file once the secrets are encrypted so that the current key is not
+	// lost if re-encrypting fails
+	tempKeyFile := ""
+	if newKey != nil {
+		tempKeyFile = keyFile + ".tmp"
+		err = localvault.SaveKeyFile(tempKeyFile, newKey)
+		if err != nil {
+			return err
+		}
+	}
+	client := &localvault.FileSystemClient{
+		Dir:    dir,
+		Cipher: current,
+	}
+	fileNames, err := client.Rekey(newCipher)
+	if err != nil {
+		if tempKeyFile != "" {
+			removeErr := os.Remove(tempKeyFile)
+			if removeErr != nil {
+				log.Logger().Warnf("Failed to remove the generated key file %s: %s", tempKeyFile, removeErr)
+			}
+		}
+		return errors.Wrapf(err, "re-encrypting the local secrets in %s", dir)
+	}
+	if tempKeyFile != "" {
+		err = os.Rename(tempKeyFile, keyFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to rename the generated key file %s to %s", tempKeyFile, keyFile)
+		}
+		log.Logger().Infof("Generated key file %s", util.ColorInfo(keyFile))
+	}
+	defaultKeyFile, err := localvault.DefaultKeyFile()
+	if err != nil {
+		return err
+	}
+	if keyFile != defaultKeyFile {
+		// the default key file is used whenever no key file or passphrase is configured so it must not be left behind
+		// once the secrets are decrypted or encrypted with something else
+		oldKeyFile, err := localvault.RetireKeyFile(defaultKeyFile)
+		if err != nil {
+			return err
+		}
+		if oldKeyFile != "" {
+			log.Logger().Infof("Renamed the previous key file to %s", util.ColorInfo(oldKeyFile))
+		}
+	}
+
+	info := util.ColorInfo
+	if newCipher == nil {
+		log.Logger().Infof("Decrypted %d local secret files in %s", len(fileNames), info(dir))
+		return nil
+	}
+	log.Logger().Infof("Encrypted %d local secret files in %s with %s", len(fileNames), info(dir), info(newCipher.ID()))
+	if o.PassphraseEnv != "" {
+		log.Logger().Infof("Set $%s to the passphrase to read the local secrets", localvault.PassphraseEnvVar)
+	} else if keyFile != defaultKeyFile {
+		log.Logger().Infof("Set $%s to %s to read the local secrets", localvault.KeyFileEnvVar, keyFile)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,6 +21,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/cmd/step/git"
 
 	"github.com/ghodss/yaml"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	jxclient "github.com/jenkins-x/jx/pkg/client/clientset/versioned"
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
@@ -123,6 +125,7 @@ type StepCreateTaskOptions struct {
 	GitInfo              *gits.GitRepository
 	BuildNumber          string
 	labels               map[string]string
+	pullRefs             *tekton.PullRefs
 	Results              tekton.CRDWrapper
 	pipelineParams       []pipelineapi.Param
 	version              string
@@ -257,6 +260,7 @@ func (o *StepCreateTaskOptions) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "Unable to find or parse PULL_REFS from custom environment")
 	}
+	o.pullRefs = pr
 
 	exists, err := o.effectiveProjectConfigExists()
 	if err != nil {
@@ -334,7 +338,19 @@ func (o *StepCreateTaskOptions) Run() error {
 	}
 
 	log.Logger().Debug("Creating Tekton CRDs")
-	tektonCRDs, err := o.generateTektonCRDs(effectiveProjectConfig, ns, pipelineName, resourceName)
+	tektonCRDs, skippedStructure, err := o.generateTektonCRDs(effectiveProjectConfig, ns, pipelineName, resourceName)
+	if errors.Cause(err) == syntax.ErrNoStagesToRun {
+		log.Logger().Infof("Nothing to run for pipeline %s as the when conditions of every stage are not met", util.ColorInfo(pipelineName))
+		if o.ViewSteps || o.InterpretMode || *o.NoApply || o.DryRun {
+			return nil
+		}
+		activityKey := tekton.GeneratePipelineActivity(o.BuildNumber, o.Branch, o.GitInfo, o.Context, pr)
+		err = tekton.ApplySkippedPipeline(jxClient, skippedStructure, ns, activityKey)
+		if err != nil {
+			return errors.Wrapf(err, "failed to record the skipped pipeline %s", pipelineName)
+		}
+		return nil
+	}
 	if err != nil {
 		return errors.Wrap(err, "failed to generate Tekton CRDs")
 	}
@@ -627,14 +643,16 @@ func (o *StepCreateTaskOptions) createEffectiveProjectConfig(packsDir string, pr
 }
 
 // GenerateTektonCRDs creates the Pipeline, Task, PipelineResource, PipelineRun, and PipelineStructure CRDs that will be applied to actually kick off the pipeline
-func (o *StepCreateTaskOptions) generateTektonCRDs(effectiveProjectConfig *config.ProjectConfig, ns string, pipelineName string, resourceName string) (*tekton.CRDWrapper, error) {
+// generateTektonCRDs generates the Tekton CRDs for the pipeline. If the when conditions of every stage are not met, it
+// returns the PipelineStructure of the skipped stages along with syntax.ErrNoStagesToRun instead.
+func (o *StepCreateTaskOptions) generateTektonCRDs(effectiveProjectConfig *config.ProjectConfig, ns string, pipelineName string, resourceName string) (*tekton.CRDWrapper, *v1.PipelineStructure, error) {
 	if effectiveProjectConfig == nil {
-		return nil, errors.New("effective project config cannot be nil")
+		return nil, nil, errors.New("effective project config cannot be nil")
 	}
 
 	effectivePipeline, err := effectiveProjectConfig.GetPipeline(o.PipelineKind)
 	if err != nil {
-		return nil, errors.Wrapf(err, "unable to extract the requested pipeline")
+		return nil, nil, errors.Wrapf(err, "unable to extract the requested pipeline")
 	}
 
 	crdParams := syntax.CRDsFromPipelineParams{
@@ -650,9 +668,16 @@ func (o *StepCreateTaskOptions) generateTektonCRDs(effectiveProjectConfig *confi
 		DefaultImage:       "",
 		InterpretMode:      o.InterpretMode,
 	}
+	if effectivePipeline.HasWhenConditions() {
+		whenContext := o.createWhenContext()
+		crdParams.When = &whenContext
+	}
 	pipeline, tasks, structure, err := effectivePipeline.GenerateCRDs(crdParams)
+	if err == syntax.ErrNoStagesToRun {
+		return nil, structure, err
+	}
 	if err != nil {
-		return nil, errors.Wrapf(err, "generation failed for Pipeline")
+		return nil, nil, errors.Wrapf(err, "generation failed for Pipeline")
 	}
 
 	tasks, pipeline = o.enhanceTasksAndPipeline(tasks, pipeline, effectiveProjectConfig.PipelineConfig.Env)
@@ -662,7 +687,7 @@ func (o *StepCreateTaskOptions) generateTektonCRDs(effectiveProjectConfig *confi
 	if effectivePipeline.Options != nil && effectivePipeline.Options.Timeout != nil {
 		timeout, err = effectivePipeline.Options.Timeout.ToDuration()
 		if err != nil {
-			return nil, errors.Wrapf(err, "parsing of pipeline timeout failed")
+			return nil, nil, errors.Wrapf(err, "parsing of pipeline timeout failed")
 		}
 	}
 	prLabels := util.MergeMaps(o.labels, effectivePipeline.GetPodLabels())
@@ -670,10 +695,10 @@ func (o *StepCreateTaskOptions) generateTektonCRDs(effectiveProjectConfig *confi
 
 	tektonCRDs, err := tekton.NewCRDWrapper(pipeline, tasks, resources, structure, run)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return tektonCRDs, nil
+	return tektonCRDs, nil, nil
 }
 
 func (o *StepCreateTaskOptions) loadProjectConfig() (*config.ProjectConfig, string, error) {
@@ -1205,6 +1230,80 @@ func (o *StepCreateTaskOptions) parsePullRefs() (*tekton.PullRefs, error) {
 	return pr, nil
 }
 
+// createWhenContext creates the context against which the when conditions on the pipeline stages are evaluated, looking
+// up the labels and changed files of the pull request being built, if any.
+func (o *StepCreateTaskOptions) createWhenContext() syntax.WhenContext {
+	whenContext := syntax.WhenContext{
+		Branch: o.Branch,
+		Env:    make(map[string]string),
+	}
+	for _, envVar := range o.CustomEnvs {
+		parts := strings.SplitN(envVar, "=", 2)
+		if len(parts) == 2 {
+			whenContext.Env[parts[0]] = parts[1]
+		}
+	}
+	for k, v := range o.AdditionalEnvVars {
+		whenContext.Env[k] = v
+	}
+
+	prNumber := o.PullRequestNumber
+	if prNumber == "" && o.pullRefs != nil {
+		for number := range o.pullRefs.ToMerge {
+			prNumber = number
+		}
+	}
+	if prNumber == "" {
+		return whenContext
+	}
+
+	if o.GitInfo != nil {
+		labels, err := o.pullRequestLabels(prNumber)
+		if err != nil {
+			log.Logger().Warnf("failed to find the labels of pull request %s: %s", prNumber, err.Error())
+		}
+		whenContext.Labels = labels
+	}
+
+	if o.pullRefs != nil && o.pullRefs.BaseSha != "" {
+		out, err := o.Git().ListChangedFilesFromBranch(o.CloneDir, o.pullRefs.BaseSha)
+		if err != nil {
+			log.Logger().Warnf("failed to list the files changed since %s: %s", o.pullRefs.BaseSha, err.Error())
+		} else {
+			whenContext.ChangedFiles = []string{}
+			for _, line := range strings.Split(out, "\n") {
+				fields := strings.Split(strings.TrimSpace(line), "\t")
+				if len(fields) > 1 {
+					whenContext.ChangedFiles = append(whenContext.ChangedFiles, fields[1:]...)
+				}
+			}
+		}
+	}
+	return whenContext
+}
+
+func (o *StepCreateTaskOptions) pullRequestLabels(prNumber string) ([]string, error) {
+	number, err := strconv.Atoi(prNumber)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid pull request number %s", prNumber)
+	}
+	provider, err := o.GitProviderForURL(o.GitInfo.URL, "git provider")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create git provider for %s", o.GitInfo.URL)
+	}
+	pullRequest, err := provider.GetPullRequest(o.GitInfo.Organisation, o.GitInfo, number)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get pull request %d", number)
+	}
+	var labels []string
+	for _, label := range pullRequest.Labels {
+		if label != nil && label.Name != nil {
+			labels = append(labels, *label.Name)
+		}
+	}
+	return labels, nil
+}
+
 // mergePullRefs merges the pull refs specified into the git repository specified via CloneDir.
 func (o *StepCreateTaskOptions) mergePullRefs(pr *tekton.PullRefs, cloneDir string) error {
 	if pr == nil {
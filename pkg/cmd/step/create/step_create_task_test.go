@@ -487,7 +487,7 @@ func TestGenerateTektonCRDs(t *testing.T) {
 
 				resourceName := tekton.PipelineResourceNameFromGitInfo(createTask.GitInfo, createTask.Branch, createTask.Context, tekton.BuildPipeline.String(), nil, "")
 				pipelineName := tekton.PipelineResourceNameFromGitInfo(createTask.GitInfo, createTask.Branch, createTask.Context, tekton.BuildPipeline.String(), tektonClient, ns)
-				crds, err := createTask.generateTektonCRDs(effectiveProjectConfig, ns, pipelineName, resourceName)
+				crds, _, err := createTask.generateTektonCRDs(effectiveProjectConfig, ns, pipelineName, resourceName)
 				if tt.generateError != nil {
 					if err == nil {
 						t.Fatalf("Expected an error %s generating CRDs, did not see it", tt.generateError)
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/jenkins-x/jx/pkg/kube/cluster"
 	v1 "k8s.io/api/core/v1"
@@ -19,6 +20,8 @@ import (
 	"github.com/jenkins-x/jx/pkg/io/secrets"
 	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/secreturl"
+	"github.com/jenkins-x/jx/pkg/secreturl/kubesecret"
+	"github.com/jenkins-x/jx/pkg/secreturl/localvault"
 
 	"github.com/jenkins-x/jx/pkg/config"
 	"github.com/jenkins-x/jx/pkg/helm"
@@ -110,7 +113,7 @@ func NewCmdStepCreateValues(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Name, "name", "", "values", "the kind of the file to create (and, by default, the schema name)")
 	cmd.Flags().StringVarP(&options.BasePath, "secret-base-path", "", "", fmt.Sprintf("the secret path used to store secrets in vault / file system. Typically a unique name per cluster+team. If none is specified we will default it to the cluster name from the %s file in the current or a parent directory.", config.RequirementsConfigFileName))
 	cmd.Flags().StringVarP(&options.ValuesFile, "out", "", "", "the path to the file to create, overrides --dir and --name")
-	cmd.Flags().StringVarP(&options.SecretsScheme, optionSecretsScheme, "", "", fmt.Sprintf("the scheme to store/reference any secrets in, valid options are vault and local. If none are specified we will default it from the %s file in the current or a parent directory.", config.RequirementsConfigFileName))
+	cmd.Flags().StringVarP(&options.SecretsScheme, optionSecretsScheme, "", "", fmt.Sprintf("the scheme to store/reference any secrets in, valid options are vault, local, kubernetes and sops. If none are specified we will default it from the %s file in the current or a parent directory.", config.RequirementsConfigFileName))
 	return cmd
 }
 
@@ -146,6 +149,14 @@ func (o *StepCreateValuesOptions) Run() error {
 			log.Logger().Warnf("there is no requirements file at %s\n", fileName)
 		}
 	}
+	if o.BasePath == "" && o.SecretsScheme == string(config.SecretStorageTypeKubernetes) {
+		// the first part of the path of a Kubernetes Secret is its namespace
+		o.BasePath = requirements.Cluster.Namespace
+		if o.BasePath == "" {
+			o.BasePath = kube.DefaultNamespace
+		}
+		log.Logger().Infof("defaulting to secret base path to the namespace %s\n", info(o.BasePath))
+	}
 	if o.BasePath == "" {
 		if exists {
 			o.BasePath = requirements.Cluster.ClusterName
@@ -155,8 +166,8 @@ func (o *StepCreateValuesOptions) Run() error {
 		}
 
 	}
-	if !(o.SecretsScheme == "vault" || o.SecretsScheme == "local") {
-		util.InvalidArgf(optionSecretsScheme, "Use one of vault or local")
+	if util.StringArrayIndex(config.SecretStorageTypeValues, o.SecretsScheme) < 0 {
+		util.InvalidArgf(optionSecretsScheme, "Use one of %s", strings.Join(config.SecretStorageTypeValues, ", "))
 	}
 	if o.Schema == "" {
 		o.Schema = filepath.Join(o.Dir, fmt.Sprintf("%s.schema.json", o.Name))
@@ -222,7 +233,7 @@ func (o *StepCreateValuesOptions) CreateValuesFile(secretURLClient secreturl.Cli
 		return errors.Wrapf(err, "failed to load values file %s", o.ValuesFile)
 	}
 
-	valuesFileName, cleanup, err := apps.ProcessValues(schema, o.Name, gitOpsURL, teamName, o.BasePath, o.BatchMode, false, secretURLClient, existing, o.SecretsScheme, o.GetIOFileHandles(), o.Verbose)
+	valuesFileName, cleanup, err := apps.ProcessValues(schema, o.Name, gitOpsURL, teamName, o.BasePath, o.BatchMode, false, secretURLClient, existing, o.secretURLScheme(), o.GetIOFileHandles(), o.Verbose)
 	defer cleanup()
 	if err != nil {
 		return errors.WithStack(err)
@@ -371,6 +382,14 @@ func (o *StepCreateValuesOptions) createLocalSecretFilesSecret(requirements *con
 	return nil
 }
 
+// secretURLScheme returns the scheme of the URLs of the secrets referenced in the values file
+func (o *StepCreateValuesOptions) secretURLScheme() string {
+	if o.SecretsScheme == string(config.SecretStorageTypeKubernetes) {
+		return strings.TrimSuffix(kubesecret.Scheme, ":")
+	}
+	return o.SecretsScheme
+}
+
 func getLocalSecretFilesAsMap(requirements *config.RequirementsConfig) (map[string][]byte, error) {
 	dir, err := util.LocalFileSystemSecretsDir()
 	if err != nil {
@@ -388,9 +407,15 @@ func getLocalSecretFilesAsMap(requirements *config.RequirementsConfig) (map[stri
 	if err != nil {
 		return nil, errors.Wrap(err, "error reading secret files from localStorage")
 	}
+	// the secret files are decrypted as they are stored in a Secret in the cluster which does not hold the local key
+	cipher, err := localvault.CipherFromEnvironment()
+	if err != nil {
+		return nil, errors.Wrap(err, "there was a problem loading the key of the local secrets")
+	}
+	localVault := &localvault.FileSystemClient{Dir: dir, Cipher: cipher}
 	secretFiles := make(map[string][]byte)
 	for _, f := range files {
-		bytes, err := ioutil.ReadFile(filepath.Join(fullSecretsPath, f.Name()))
+		bytes, err := localVault.LoadFile(filepath.Join(fullSecretsPath, f.Name()))
 		if err != nil {
 			return nil, errors.Wrap(err, "there was a problem reading a local secrets file")
 		}
@@ -75,6 +75,7 @@ type StepChangelogState struct {
 	GitInfo         *gits.GitRepository
 	GitProvider     gits.GitProvider
 	Tracker         issues.IssueProvider
+	IssueKeyRegex   *regexp.Regexp
 	FoundIssueNames map[string]bool
 	LoggedIssueKind bool
 	Release         *v1.Release
@@ -147,9 +148,6 @@ e.g. define environment variables GIT_USERNAME and GIT_API_TOKEN
 		jx step changelog --header-file docs/dev/changelog-header.md --version 1.2.3
 
 `)
-
-	GitHubIssueRegex = regexp.MustCompile(`(\#\d+)`)
-	JIRAIssueRegex   = regexp.MustCompile(`[A-Z][A-Z]+-(\d+)`)
 )
 
 func NewCmdStepChangelog(commonOpts *opts.CommonOptions) *cobra.Command {
@@ -315,6 +313,19 @@ func (o *StepChangelogOptions) Run() error {
 	}
 	o.State.Tracker = tracker
 
+	issueKeyPattern := ""
+	issueTrackerConfig, err := o.IssueTrackerConfig(dir)
+	if err != nil {
+		return err
+	}
+	if issueTrackerConfig != nil {
+		issueKeyPattern = issueTrackerConfig.IssueKeyPattern
+	}
+	o.State.IssueKeyRegex, err = issues.IssueKeyRegex(tracker, issueKeyPattern)
+	if err != nil {
+		return err
+	}
+
 	authConfigSvc, err := o.GitAuthConfigService()
 	if err != nil {
 		return err
@@ -551,6 +562,9 @@ func (o *StepChangelogOptions) Run() error {
 	}
 	cleanVersion := strings.TrimPrefix(version, "v")
 	release.Spec.Version = cleanVersion
+	if issueTrackerConfig != nil && issueTrackerConfig.FixVersions && o.Version != "" {
+		o.addIssueFixVersions(release.Spec.Issues, cleanVersion)
+	}
 	if o.GenerateCRD {
 		exists, err := util.FileExists(crdFile)
 		if err != nil {
@@ -702,17 +716,25 @@ func (o *StepChangelogOptions) addIssuesAndPullRequests(spec *v1.ReleaseSpec, co
 	tracker := o.State.Tracker
 
 	gitProvider := o.State.GitProvider
-	if gitProvider == nil || !gitProvider.HasIssues() {
+	if gitProvider == nil {
 		return nil
 	}
-	regex := GitHubIssueRegex
 	issueKind := issues.GetIssueProvider(tracker)
+	if issueKind == issues.Git && !gitProvider.HasIssues() {
+		return nil
+	}
 	if !o.State.LoggedIssueKind {
 		o.State.LoggedIssueKind = true
 		log.Logger().Infof("Finding issues in commit messages using %s format", issueKind)
 	}
-	if issueKind == issues.Jira {
-		regex = JIRAIssueRegex
+	regex := o.State.IssueKeyRegex
+	if regex == nil {
+		var err error
+		regex, err = issues.IssueKeyRegex(tracker, "")
+		if err != nil {
+			return err
+		}
+		o.State.IssueKeyRegex = regex
 	}
 	message := fullCommitMessageText(rawCommit)
 
@@ -727,8 +749,7 @@ func (o *StepChangelogOptions) addIssuesAndPullRequests(spec *v1.ReleaseSpec, co
 		GitProvider: gitProvider,
 	}
 	for _, match := range matches {
-		for _, result := range match {
-			result = strings.TrimPrefix(result, "#")
+		for _, result := range issues.FindIssueKeys(regex, match[0]) {
 			if _, ok := o.State.FoundIssueNames[result]; !ok {
 				o.State.FoundIssueNames[result] = true
 				issue, err := tracker.GetIssue(result)
@@ -806,6 +827,19 @@ func (o *StepChangelogOptions) addIssuesAndPullRequests(spec *v1.ReleaseSpec, co
 	return nil
 }
 
+// addIssueFixVersions adds the version to the fix versions of the issues of the release
+func (o *StepChangelogOptions) addIssueFixVersions(releaseIssues []v1.IssueSummary, version string) {
+	tracker := o.State.Tracker
+	for _, issue := range releaseIssues {
+		err := tracker.AddIssueFixVersion(issue.ID, version)
+		if err != nil {
+			log.Logger().Warnf("Failed to add fix version %s to issue %s: %s", version, issue.URL, err)
+			continue
+		}
+		log.Logger().Infof("Added fix version %s to issue %s", util.ColorInfo(version), util.ColorInfo(issue.URL))
+	}
+}
+
 // toV1Labels converts git labels to IssueLabel
 func toV1Labels(labels []gits.GitLabel) []v1.IssueLabel {
 	answer := []v1.IssueLabel{}
@@ -863,7 +897,7 @@ func (o *StepChangelogOptions) getTemplateResult(releaseSpec *v1.ReleaseSpec, te
 	return buffer.String(), err
 }
 
-//CollapseDependencyUpdates takes a raw set of dependencyUpdates, removes duplicates and collapses multiple updates to
+// CollapseDependencyUpdates takes a raw set of dependencyUpdates, removes duplicates and collapses multiple updates to
 // the same org/repo:components into a sungle update
 func CollapseDependencyUpdates(dependencyUpdates []v1.DependencyUpdate) []v1.DependencyUpdate {
 	// Sort the dependency updates. This makes the outputs more readable, and it also allows us to more easily do duplicate removal and collapsing
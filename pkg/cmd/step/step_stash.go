@@ -1,6 +1,7 @@
 package step
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -38,6 +39,7 @@ type StepStashOptions struct {
 	StorageLocation jenkinsv1.StorageLocation
 	ProjectGitURL   string
 	ProjectBranch   string
+	Name            string
 }
 
 const (
@@ -75,6 +77,9 @@ var (
 		# lets collect some files to a specific cloud storage bucket and specify the path to store them inside
 		jx step stash -c tests -p "target/test-reports/*" --bucket-url gs://my-gcp-bucket --to-path tests/mystuff
 
+		# lets stash some files as a single named archive so a later stage can unstash them
+		jx step stash -c stash -p "target/*" --name build-output --to-path jenkins-x/stash/myapp/1
+
 `)
 )
 
@@ -107,6 +112,7 @@ func NewCmdStepStash(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Basedir, "basedir", "", "", "The base directory to use to create relative output file names. e.g. if you specify '--pattern \"target/*.xml\" then you may want to supply '--basedir target' to strip the 'target/' prefix from all collected files")
 	cmd.Flags().StringVarP(&options.ProjectGitURL, "project-git-url", "", "", "The project git URL to collect for. Used to default the organisation and repository folders in the storage. If not specified its discovered from the local '.git' folder")
 	cmd.Flags().StringVarP(&options.ProjectBranch, "project-branch", "", "", "The project git branch of the project to collect for. Used to default the branch folder in the storage. If not specified its discovered from the local '.git' folder")
+	cmd.Flags().StringVarP(&options.Name, "name", "n", "", "Stores the files in a single archive with this name, so they can be restored together with 'jx step unstash --name'. Requires bucket storage or git storage on GitHub")
 	return cmd
 }
 
@@ -133,34 +139,10 @@ func (o *StepStashOptions) Run() error {
 			return err
 		}
 	}
-	settings, err := o.TeamSettings()
+	o.StorageLocation, err = resolveStorageLocation(o.CommonOptions, o.StorageLocation, o.Dir)
 	if err != nil {
 		return err
 	}
-	if o.StorageLocation.IsEmpty() {
-		// lets try get the location from the team settings
-		o.StorageLocation = settings.StorageLocationOrDefault(classifier)
-
-		if o.StorageLocation.IsEmpty() {
-			// we have no team settings so lets try detect the git repository using an env var or local file system
-			sourceURL := os.Getenv(envVarSourceURL)
-			if sourceURL == "" {
-				_, gitConf, err := o.Git().FindGitConfigDir(o.Dir)
-				if err != nil {
-					log.Logger().Warnf("Could not find a .git directory: %s", err)
-				} else {
-					sourceURL, err = o.DiscoverGitURL(gitConf)
-				}
-			}
-			if sourceURL == "" {
-				return fmt.Errorf("Missing option --git-url and we could not detect the current git repository URL")
-			}
-			o.StorageLocation.GitURL = sourceURL
-		}
-	}
-	if o.StorageLocation.IsEmpty() {
-		return fmt.Errorf("Missing option --git-url and we could not detect the current git repository URL")
-	}
 
 	coll, err := collector.NewCollector(o.StorageLocation, o.Git())
 	if err != nil {
@@ -203,9 +185,23 @@ func (o *StepStashOptions) Run() error {
 		storagePath = filepath.Join("jenkins-x", classifier, projectOrg, projectRepoName, projectBranchName, buildNo)
 	}
 
-	urls, err := coll.CollectFiles(o.Pattern, storagePath, o.Basedir)
-	if err != nil {
-		return errors.Wrapf(err, "failed to collect patterns %s to path %s", strings.Join(o.Pattern, ", "), storagePath)
+	var urls []string
+	if o.Name != "" {
+		// fail before storing a stash which could not be unstashed
+		_, err = collector.StoredURL(o.StorageLocation, stashArchivePath(storagePath, o.Name))
+		if err != nil {
+			return err
+		}
+		u, err := o.collectArchive(coll, storagePath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to stash patterns %s as %s to path %s", strings.Join(o.Pattern, ", "), o.Name, storagePath)
+		}
+		urls = append(urls, u)
+	} else {
+		urls, err = coll.CollectFiles(o.Pattern, storagePath, o.Basedir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to collect patterns %s to path %s", strings.Join(o.Pattern, ", "), storagePath)
+		}
 	}
 
 	for _, u := range urls {
@@ -244,6 +240,69 @@ func (o *StepStashOptions) Run() error {
 	return nil
 }
 
+// collectArchive stores all the files matching the patterns in a single archive named after the stash
+func (o *StepStashOptions) collectArchive(coll collector.Collector, storagePath string) (string, error) {
+	var files []string
+	for _, p := range o.Pattern {
+		err := util.GlobAllFiles("", p, func(name string) error {
+			files = append(files, name)
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(files) == 0 {
+		log.Logger().Warnf("No files matched the patterns %s", strings.Join(o.Pattern, ", "))
+	}
+
+	var buffer bytes.Buffer
+	err := util.Targz(files, o.Basedir, &buffer)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the archive")
+	}
+	return coll.CollectData(buffer.Bytes(), stashArchivePath(storagePath, o.Name))
+}
+
+// stashArchivePath returns the path in the storage of the archive for a named stash
+func stashArchivePath(storagePath string, name string) string {
+	return filepath.Join(storagePath, name+".tar.gz")
+}
+
+// resolveStorageLocation defaults the storage location from the team settings, or failing that from the current git
+// repository
+func resolveStorageLocation(o *opts.CommonOptions, location jenkinsv1.StorageLocation, dir string) (jenkinsv1.StorageLocation, error) {
+	settings, err := o.TeamSettings()
+	if err != nil {
+		return location, err
+	}
+	if location.IsEmpty() {
+		// lets try get the location from the team settings
+		location = settings.StorageLocationOrDefault(location.Classifier)
+
+		if location.IsEmpty() {
+			// we have no team settings so lets try detect the git repository using an env var or local file system
+			sourceURL := os.Getenv(envVarSourceURL)
+			if sourceURL == "" {
+				_, gitConf, err := o.Git().FindGitConfigDir(dir)
+				if err != nil {
+					log.Logger().Warnf("Could not find a .git directory: %s", err)
+				} else {
+					sourceURL, err = o.DiscoverGitURL(gitConf)
+				}
+			}
+			if sourceURL == "" {
+				return location, fmt.Errorf("Missing option --git-url and we could not detect the current git repository URL")
+			}
+			location.GitURL = sourceURL
+		}
+	}
+	if location.IsEmpty() {
+		return location, fmt.Errorf("Missing option --git-url and we could not detect the current git repository URL")
+	}
+	return location, nil
+}
+
 func (o *StepStashOptions) determineProjectBranchName(projectBranchName string, gitURL string) (string, error) {
 	if projectBranchName != "" {
 		return projectBranchName, nil
@@ -3,6 +3,7 @@ package step
 import (
 	"io/ioutil"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -11,10 +12,12 @@ import (
 
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
 
+	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/auth"
 	"github.com/jenkins-x/jx/pkg/cloud/buckets"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/collector"
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
@@ -26,9 +29,12 @@ import (
 type StepUnstashOptions struct {
 	step.StepOptions
 
-	URL     string
-	OutDir  string
-	Timeout time.Duration
+	URL             string
+	OutDir          string
+	Timeout         time.Duration
+	Name            string
+	ToPath          string
+	StorageLocation jenkinsv1.StorageLocation
 }
 
 var (
@@ -42,6 +48,9 @@ var (
 
 		# unstash the file to the from GCS to the console
 		jx step unstash -u gs://mybucket/foo/bar/output.log
+
+		# unstash the files of a named stash into the current directory
+		jx step unstash -c stash --name build-output --to-path jenkins-x/stash/myapp/1 -o .
 `)
 )
 
@@ -68,11 +77,18 @@ func NewCmdStepUnstash(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.URL, "url", "u", "", "The fully qualified URL to the file to unstash including the storage host, path and file name")
 	cmd.Flags().StringVarP(&options.OutDir, "output", "o", "", "The output file or directory")
 	cmd.Flags().DurationVarP(&options.Timeout, "timeout", "t", time.Second*30, "The timeout period before we should fail unstashing the entry")
+	cmd.Flags().StringVarP(&options.Name, "name", "n", "", "The name of a stash created with 'jx step stash --name' to unstash into the output directory")
+	cmd.Flags().StringVarP(&options.ToPath, "to-path", "", "", "The path within the storage the named stash was stored in")
+
+	addStorageLocationFlags(cmd, &options.StorageLocation)
 	return cmd
 }
 
 // Run runs the command
 func (o *StepUnstashOptions) Run() error {
+	if o.Name != "" {
+		return o.unstashNamed()
+	}
 	u := o.URL
 	if u == "" {
 		// TODO lets guess from the project etc...
@@ -118,6 +134,59 @@ func (o *StepUnstashOptions) Run() error {
 	return nil
 }
 
+// unstashNamed downloads the archive of a named stash and extracts it into the output directory
+func (o *StepUnstashOptions) unstashNamed() error {
+	if o.ToPath == "" {
+		return util.MissingOption("to-path")
+	}
+	dir := o.OutDir
+	if dir == "" {
+		dir = "."
+	}
+	if o.StorageLocation.Classifier == "" {
+		o.StorageLocation.Classifier = "default"
+	}
+	location, err := resolveStorageLocation(o.CommonOptions, o.StorageLocation, dir)
+	if err != nil {
+		return err
+	}
+	u, err := collector.StoredURL(location, stashArchivePath(o.ToPath, o.Name))
+	if err != nil {
+		return err
+	}
+
+	authSvc, err := o.GitAuthConfigService()
+	if err != nil {
+		return err
+	}
+	data, err := buckets.ReadURL(u, o.Timeout, CreateBucketHTTPFn(authSvc))
+	if err != nil {
+		return errors.Wrapf(err, "failed to read stash %s from %s", o.Name, u)
+	}
+
+	tmpFile, err := ioutil.TempFile("", "jx-unstash-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	_, err = tmpFile.Write(data)
+	tmpFile.Close()
+	if err != nil {
+		return errors.Wrapf(err, "failed to write file %s", tmpFile.Name())
+	}
+
+	err = os.MkdirAll(dir, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", dir)
+	}
+	err = util.UnTargzAll(tmpFile.Name(), dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to extract stash %s into %s", o.Name, dir)
+	}
+	log.Logger().Infof("unstashed %s into: %s", util.ColorInfo(o.Name), util.ColorInfo(dir))
+	return nil
+}
+
 // CreateBucketHTTPFn creates a function to transform a git URL to add the token for accessing a git based bucket
 func CreateBucketHTTPFn(authSvc auth.ConfigService) func(string) (string, error) {
 	return func(urlText string) (string, error) {
@@ -24,6 +24,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/tekton/syntax"
 	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/pkg/errors"
+	"github.com/sergi/go-diff/diffmatchpatch"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/yaml"
@@ -48,11 +49,23 @@ type StepSyntaxEffectiveOptions struct {
 	CustomEnvs        []string
 	OutputFile        string
 	ShortView         bool
+	Provenance        bool
+	FromRef           string
+	ToRef             string
+	FromPackRef       string
+	ToPackRef         string
 
 	PodTemplates map[string]*corev1.Pod
 
 	GitInfo         *gits.GitRepository
 	VersionResolver *versionstream.VersionResolver
+
+	// TemplateProvenance describes the template each stage included from a template came from, keyed by stage name
+	TemplateProvenance map[string]string
+	// ContainerOptionSources lists where the container options of each pipeline came from, keyed by pipeline kind
+	ContainerOptionSources map[string][]string
+
+	configContainerSources []string
 }
 
 var (
@@ -67,6 +80,15 @@ var (
 		# view the short version of the effective pipeline
 		jx step syntax effective -s
 
+		# view where each step and the container options of the effective pipeline came from
+		jx step syntax effective --provenance
+
+		# compare the effective pipeline on master with the current one
+		jx step syntax effective --from-ref master
+
+		# compare the effective pipeline using two versions of the build pack
+		jx step syntax effective --from-pack-ref v1.0.0 --to-pack-ref v1.1.0
+
 `)
 )
 
@@ -110,6 +132,11 @@ func (o *StepSyntaxEffectiveOptions) addFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.DefaultImage, "default-image", "", syntax.DefaultContainerImage, "Specify the docker image to use if there is no image specified for a step and there's no Pod Template")
 	cmd.Flags().BoolVarP(&o.UseKaniko, "use-kaniko", "", true, "Enables using kaniko directly for building docker images")
 	cmd.Flags().BoolVarP(&o.ShortView, "short", "s", false, "Use short concise output")
+	cmd.Flags().BoolVarP(&o.Provenance, "provenance", "", false, "Output the file and override rule each step and the container options came from, instead of the pipeline")
+	cmd.Flags().StringVarP(&o.FromRef, "from-ref", "", "", "The Git reference of the project to compare the effective pipeline from. Shows the differences between two effective pipelines if any of the from or to references are specified")
+	cmd.Flags().StringVarP(&o.ToRef, "to-ref", "", "", "The Git reference of the project to compare the effective pipeline to. Defaults to the current working directory")
+	cmd.Flags().StringVarP(&o.FromPackRef, "from-pack-ref", "", "", "The Git reference of the build pack to compare the effective pipeline from. Defaults to the build pack reference")
+	cmd.Flags().StringVarP(&o.ToPackRef, "to-pack-ref", "", "", "The Git reference of the build pack to compare the effective pipeline to. Defaults to the build pack reference")
 	cmd.Flags().StringVarP(&o.KanikoImage, "kaniko-image", "", syntax.KanikoDockerImage, "The docker image for Kaniko")
 	cmd.Flags().StringVarP(&o.ProjectID, "project-id", "", "", "The cloud project ID. If not specified we default to the install project")
 	cmd.Flags().StringVarP(&o.DockerRegistry, "docker-registry", "", "", "The Docker Registry host name to use which is added as a prefix to docker images")
@@ -183,18 +210,24 @@ func (o *StepSyntaxEffectiveOptions) Run() error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to load project config in dir %s", workingDir)
 	}
-	if o.BuildPackURL == "" || o.BuildPackRef == "" {
-		if projectConfig.BuildPackGitURL != "" {
-			o.BuildPackURL = projectConfig.BuildPackGitURL
-		} else if o.BuildPackURL == "" {
-			o.BuildPackURL = settings.BuildPackURL
-		}
-		if projectConfig.BuildPackGitURef != "" {
-			o.BuildPackRef = projectConfig.BuildPackGitURef
-		} else if o.BuildPackRef == "" {
-			o.BuildPackRef = settings.BuildPackRef
+	urlFlag, refFlag := o.BuildPackURL, o.BuildPackRef
+	buildPackFor := func(projectConfig *config.ProjectConfig) (string, string) {
+		url, ref := urlFlag, refFlag
+		if url == "" || ref == "" {
+			if projectConfig.BuildPackGitURL != "" {
+				url = projectConfig.BuildPackGitURL
+			} else if url == "" {
+				url = settings.BuildPackURL
+			}
+			if projectConfig.BuildPackGitURef != "" {
+				ref = projectConfig.BuildPackGitURef
+			} else if ref == "" {
+				ref = settings.BuildPackRef
+			}
 		}
+		return url, ref
 	}
+	o.BuildPackURL, o.BuildPackRef = buildPackFor(projectConfig)
 	if o.BuildPackURL == "" {
 		return util.MissingOption("url")
 	}
@@ -221,19 +254,44 @@ func (o *StepSyntaxEffectiveOptions) Run() error {
 		return err
 	}
 
-	packsDir, err := gitresolver.InitBuildPack(o.Git(), o.BuildPackURL, o.BuildPackRef)
+	if o.FromRef != "" || o.ToRef != "" || o.FromPackRef != "" || o.ToPackRef != "" {
+		diff, err := o.diffEffectivePipelines(workingDir, buildPackFor)
+		if err != nil {
+			return err
+		}
+		return o.writeOutput(diff, "-effective.diff")
+	}
+
+	effectiveYaml, err := o.createEffectiveOutput(projectConfig, projectConfigFile, o.BuildPackRef)
 	if err != nil {
 		return err
 	}
+	if o.Provenance {
+		return o.writeOutput(effectiveYaml, "-provenance.txt")
+	}
+	return o.writeOutput(effectiveYaml, "-effective.yml")
+}
+
+// createEffectiveOutput creates the effective pipeline for the project config using the given build pack git ref,
+// returning it as YAML, or as a report of where each step came from if provenance is enabled
+func (o *StepSyntaxEffectiveOptions) createEffectiveOutput(projectConfig *config.ProjectConfig, projectConfigFile string, packRef string) ([]byte, error) {
+	packsDir, err := gitresolver.InitBuildPack(o.Git(), o.BuildPackURL, packRef)
+	if err != nil {
+		return nil, err
+	}
 
 	resolver, err := gitresolver.CreateResolver(packsDir, o.Git())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	effectiveConfig, err := o.CreateEffectivePipeline(packsDir, projectConfig, projectConfigFile, resolver)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if o.Provenance {
+		return []byte(o.provenanceReport(effectiveConfig)), nil
 	}
 
 	if o.ShortView {
@@ -242,11 +300,17 @@ func (o *StepSyntaxEffectiveOptions) Run() error {
 
 	effectiveYaml, err := yaml.Marshal(effectiveConfig)
 	if err != nil {
-		return errors.Wrap(err, "failed to marshal effective pipeline")
+		return nil, errors.Wrap(err, "failed to marshal effective pipeline")
 	}
+	return annotateTemplateProvenance(effectiveYaml, o.TemplateProvenance), nil
+}
+
+// writeOutput writes the output to the console, or to the output file if one is specified, using the given suffix
+// for the default file name
+func (o *StepSyntaxEffectiveOptions) writeOutput(output []byte, fileSuffix string) error {
 	if o.OutDir == "" && o.OutputFile == "" {
-		if o.ShortView {
-			for _, line := range strings.Split(string(effectiveYaml), "\n") {
+		if o.ShortView && fileSuffix == "-effective.yml" {
+			for _, line := range strings.Split(string(output), "\n") {
 				prefix := "command: "
 				idx := strings.Index(line, prefix)
 				if idx >= 0 {
@@ -255,31 +319,32 @@ func (o *StepSyntaxEffectiveOptions) Run() error {
 				fmt.Printf("%s\n", line)
 			}
 		} else {
-			fmt.Printf("%s\n", effectiveYaml)
+			fmt.Printf("%s\n", output)
 		}
-	} else {
-		outputDir := o.OutDir
-		if outputDir == "" {
-			outputDir, err = os.Getwd()
-			if err != nil {
-				return errors.Wrap(err, "failed to get current directory")
-			}
-		}
-		outputFilename := o.OutputFile
-		if outputFilename == "" {
-			outputFilename = "jenkins-x"
-			if o.Context != "" {
-				outputFilename += "-" + o.Context
-			}
-			outputFilename += "-effective.yml"
-		}
-		outputFile := filepath.Join(outputDir, outputFilename)
-		err = ioutil.WriteFile(outputFile, effectiveYaml, util.DefaultWritePermissions)
+		return nil
+	}
+	outputDir := o.OutDir
+	if outputDir == "" {
+		var err error
+		outputDir, err = os.Getwd()
 		if err != nil {
-			return errors.Wrapf(err, "failed to write effective pipeline to %s", outputFile)
+			return errors.Wrap(err, "failed to get current directory")
 		}
-		log.Logger().Infof("Effective pipeline written to %s", outputFile)
 	}
+	outputFilename := o.OutputFile
+	if outputFilename == "" {
+		outputFilename = "jenkins-x"
+		if o.Context != "" {
+			outputFilename += "-" + o.Context
+		}
+		outputFilename += fileSuffix
+	}
+	outputFile := filepath.Join(outputDir, outputFilename)
+	err := ioutil.WriteFile(outputFile, output, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write effective pipeline to %s", outputFile)
+	}
+	log.Logger().Infof("Effective pipeline written to %s", outputFile)
 	return nil
 }
 
@@ -289,6 +354,24 @@ func (o *StepSyntaxEffectiveOptions) CreateEffectivePipeline(packsDir string, pr
 	name := o.Pack
 	packDir := filepath.Join(packsDir, name)
 
+	// Stages included from templates are expanded first, so that the build pack and overrides apply to them
+	templateResolver := gitresolver.CreateTemplateResolver(o.Git(), o.VersionResolver, filepath.Dir(projectConfigFile))
+	provenance, err := projectConfig.ExpandPipelineTemplates(templateResolver)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to expand the templates in file %s", projectConfigFile)
+	}
+	o.TemplateProvenance = provenance
+	o.ContainerOptionSources = make(map[string][]string)
+	o.configContainerSources = nil
+	if o.Provenance && projectConfig.PipelineConfig != nil {
+		projectFileName := filepath.Base(projectConfigFile)
+		setTemplateStepSources(projectConfig.PipelineConfig, provenance)
+		projectConfig.PipelineConfig.SetStepSources(projectFileName)
+		if projectConfig.PipelineConfig.ContainerOptions != nil {
+			o.configContainerSources = append(o.configContainerSources, projectFileName)
+		}
+	}
+
 	pipelineConfig := projectConfig.PipelineConfig
 	if name != "none" {
 		pipelineFile := filepath.Join(packDir, jenkinsfile.PipelineConfigFileName)
@@ -299,10 +382,17 @@ func (o *StepSyntaxEffectiveOptions) CreateEffectivePipeline(packsDir string, pr
 		if !exists {
 			return nil, fmt.Errorf("no build pack for %s exists at directory %s", name, packDir)
 		}
-		pipelineConfig, err = jenkinsfile.LoadPipelineConfig(pipelineFile, resolver, true, false)
+		if o.Provenance {
+			pipelineConfig, err = jenkinsfile.LoadPipelineConfigWithProvenance(pipelineFile, resolver, true, false)
+		} else {
+			pipelineConfig, err = jenkinsfile.LoadPipelineConfig(pipelineFile, resolver, true, false)
+		}
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to load build pack pipeline YAML: %s", pipelineFile)
 		}
+		if pipelineConfig.ContainerOptions != nil {
+			o.configContainerSources = append([]string{pipelineFile}, o.configContainerSources...)
+		}
 
 		localPipelineConfig := projectConfig.PipelineConfig
 		if localPipelineConfig != nil {
@@ -320,7 +410,7 @@ func (o *StepSyntaxEffectiveOptions) CreateEffectivePipeline(packsDir string, pr
 		return nil, fmt.Errorf("failed to find PipelineConfig in file %s", projectConfigFile)
 	}
 
-	err := o.combineEnvVars(pipelineConfig)
+	err = o.combineEnvVars(pipelineConfig)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to combine env vars")
 	}
@@ -340,6 +430,9 @@ func (o *StepSyntaxEffectiveOptions) CreateEffectivePipeline(packsDir string, pr
 				Name:    "jx-git-credentials",
 			},
 		}
+		if o.Provenance {
+			steps[0].Source = "added by jx for release pipelines"
+		}
 		releaseLifecycles.Setup.Steps = append(steps, releaseLifecycles.Setup.Steps...)
 		parsed, err := o.createPipelineForKind(jenkinsfile.PipelineKindRelease, releaseLifecycles, pipelines, projectConfig, pipelineConfig)
 		if err != nil {
@@ -397,6 +490,9 @@ func (o *StepSyntaxEffectiveOptions) createPipelineForKind(kind string, lifecycl
 				}
 			}
 		}
+		if parsed.Options != nil && parsed.Options.ContainerOptions != nil {
+			o.addContainerOptionSource(kind, "the options of the pipeline")
+		}
 	} else {
 		args := jenkinsfile.CreatePipelineArguments{
 			Lifecycles:        lifecycles,
@@ -420,6 +516,9 @@ func (o *StepSyntaxEffectiveOptions) createPipelineForKind(kind string, lifecycl
 		if err != nil {
 			return nil, errors.Wrapf(err, "Failed to generate pipeline from build pack")
 		}
+		if parsed.Options != nil && parsed.Options.ContainerOptions != nil {
+			o.addContainerOptionSource(kind, fmt.Sprintf("the pod template %s", pipelineConfig.Agent.GetImage()))
+		}
 	}
 
 	parsed.AddContainerEnvVarsToPipeline(pipelineConfig.Env)
@@ -433,11 +532,24 @@ func (o *StepSyntaxEffectiveOptions) createPipelineForKind(kind string, lifecycl
 			return nil, errors.Wrapf(err, "Could not merge containerOptions from parent")
 		}
 		parsed.Options.ContainerOptions = mergedContainer
+		for _, source := range o.configContainerSources {
+			o.addContainerOptionSource(kind, source)
+		}
 	}
 
 	for _, override := range pipelines.Overrides {
 		if override.MatchesPipeline(kind) {
 			parsed = syntax.ApplyNonStepOverridesToPipeline(parsed, override)
+			if override.ContainerOptions != nil {
+				source := "override of containerOptions"
+				if override.Stage != "" {
+					source += " in stage " + override.Stage
+				}
+				if override.Source != "" {
+					source = fmt.Sprintf("%s (%s)", override.Source, source)
+				}
+				o.addContainerOptionSource(kind, source)
+			}
 		}
 	}
 
@@ -534,3 +646,307 @@ func (o *StepSyntaxEffectiveOptions) makeConciseStep(step *syntax.Step) {
 	}
 	step.Command = c
 }
+
+// annotateTemplateProvenance adds a comment before the name of each stage which was included from a template, saying
+// which template it came from. The YAML is walked keeping track of the parent key of each key so that only the names
+// of stages, and not of steps or other things with the same name, are annotated
+func annotateTemplateProvenance(effectiveYaml []byte, provenance map[string]string) []byte {
+	if len(provenance) == 0 {
+		return effectiveYaml
+	}
+	type yamlKey struct {
+		indent int
+		name   string
+	}
+	var lines []string
+	var parents []yamlKey
+	blockIndent := -1
+	for _, line := range strings.Split(string(effectiveYaml), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		if blockIndent >= 0 {
+			// the line is part of a multi-line string
+			if trimmed == "" || indent > blockIndent {
+				lines = append(lines, line)
+				continue
+			}
+			blockIndent = -1
+		}
+		for strings.HasPrefix(trimmed, "- ") {
+			trimmed = trimmed[2:]
+			indent += 2
+		}
+		key := strings.SplitN(trimmed, ":", 2)
+		if trimmed == "" || len(key) < 2 || (key[1] != "" && !strings.HasPrefix(key[1], " ")) {
+			lines = append(lines, line)
+			continue
+		}
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			parents = parents[:len(parents)-1]
+		}
+		value := strings.TrimSpace(key[1])
+		if key[0] == "name" && len(parents) > 0 {
+			parent := parents[len(parents)-1].name
+			if parent == "stages" || parent == "parallel" {
+				if source, ok := provenance[value]; ok {
+					lines = append(lines, fmt.Sprintf("%s# included from %s", strings.Repeat(" ", indent), source))
+				}
+			}
+		}
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockIndent = indent
+		}
+		parents = append(parents, yamlKey{indent: indent, name: key[0]})
+		lines = append(lines, line)
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+const diffContextLines = 3
+
+func (o *StepSyntaxEffectiveOptions) addContainerOptionSource(kind string, source string) {
+	o.ContainerOptionSources[kind] = append(o.ContainerOptionSources[kind], source)
+}
+
+// setTemplateStepSources sets the source of the steps in each stage which was included from a template
+func setTemplateStepSources(pipelineConfig *jenkinsfile.PipelineConfig, provenance map[string]string) {
+	if len(provenance) == 0 {
+		return
+	}
+	parsedPipelines := []*syntax.ParsedPipeline{pipelineConfig.Pipelines.Default}
+	for _, lifecycles := range pipelineConfig.Pipelines.All() {
+		if lifecycles != nil {
+			parsedPipelines = append(parsedPipelines, lifecycles.Pipeline)
+		}
+	}
+	for _, parsed := range parsedPipelines {
+		if parsed != nil {
+			setTemplateStageStepSources(parsed.Stages, provenance)
+		}
+	}
+}
+
+func setTemplateStageStepSources(stages []syntax.Stage, provenance map[string]string) {
+	for i := range stages {
+		if source, ok := provenance[stages[i].Name]; ok {
+			syntax.SetStageStepSources(stages[i:i+1], source)
+			continue
+		}
+		setTemplateStageStepSources(stages[i].Stages, provenance)
+		setTemplateStageStepSources(stages[i].Parallel, provenance)
+	}
+}
+
+// provenanceReport lists the stages and steps of each effective pipeline along with where each step, and the
+// container options of the pipeline, came from
+func (o *StepSyntaxEffectiveOptions) provenanceReport(projectConfig *config.ProjectConfig) string {
+	var lines []string
+	pipelines := projectConfig.PipelineConfig.Pipelines.AllMap()
+	for _, kind := range jenkinsfile.PipelineKinds {
+		lifecycles := pipelines[kind]
+		if lifecycles == nil || lifecycles.Pipeline == nil {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s pipeline", kind))
+		if sources := o.ContainerOptionSources[kind]; len(sources) > 0 {
+			lines = append(lines, "  container options from:")
+			for _, source := range sources {
+				lines = append(lines, "    "+source)
+			}
+		}
+		lines = appendStageProvenance(lines, lifecycles.Pipeline.Stages, "  ")
+		lines = appendPostProvenance(lines, lifecycles.Pipeline.Post, "  ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func appendStageProvenance(lines []string, stages []syntax.Stage, indent string) []string {
+	for i := range stages {
+		stage := &stages[i]
+		lines = append(lines, fmt.Sprintf("%sstage %s", indent, stage.Name))
+		for j := range stage.Steps {
+			lines = appendStepProvenance(lines, &stage.Steps[j], indent+"  ")
+		}
+		lines = appendPostProvenance(lines, stage.Post, indent+"  ")
+		lines = appendStageProvenance(lines, stage.Stages, indent+"  ")
+		lines = appendStageProvenance(lines, stage.Parallel, indent+"  ")
+	}
+	return lines
+}
+
+func appendPostProvenance(lines []string, posts []syntax.Post, indent string) []string {
+	for i := range posts {
+		if len(posts[i].Steps) == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%spost %s", indent, posts[i].Condition))
+		for j := range posts[i].Steps {
+			lines = appendStepProvenance(lines, &posts[i].Steps[j], indent+"  ")
+		}
+	}
+	return lines
+}
+
+func appendStepProvenance(lines []string, step *syntax.Step, indent string) []string {
+	description := step.Name
+	if command := step.GetFullCommand(); command != "" {
+		if description != "" {
+			description += ": "
+		}
+		description += command
+	}
+	source := step.Source
+	if source == "" {
+		source = "unknown"
+	}
+	lines = append(lines, fmt.Sprintf("%sstep %s", indent, description), fmt.Sprintf("%s  from %s", indent, source))
+	for _, child := range step.Steps {
+		lines = appendStepProvenance(lines, child, indent+"  ")
+	}
+	return lines
+}
+
+// diffEffectivePipelines compares the effective pipelines for the project and build pack at the from and to git refs.
+// The build pack URL and ref for the project config at each git ref are given by buildPackFor, unless the build pack
+// refs to compare are specified
+func (o *StepSyntaxEffectiveOptions) diffEffectivePipelines(workingDir string, buildPackFor func(*config.ProjectConfig) (string, string)) ([]byte, error) {
+	from, fromPackRef, err := o.effectiveOutputAtRef(workingDir, o.FromRef, o.FromPackRef, buildPackFor)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the effective pipeline to compare from")
+	}
+	to, toPackRef, err := o.effectiveOutputAtRef(workingDir, o.ToRef, o.ToPackRef, buildPackFor)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the effective pipeline to compare to")
+	}
+	if string(from) == string(to) {
+		log.Logger().Info("The effective pipelines are the same")
+	}
+	return []byte(diffLines(string(from), string(to), describeRefs(o.FromRef, fromPackRef), describeRefs(o.ToRef, toPackRef))), nil
+}
+
+// effectiveOutputAtRef creates the effective pipeline for the project at the given git ref, or the working dir if no
+// ref is given, using the build pack at the given git ref, or else the build pack of the project at the git ref. It
+// returns the build pack ref which was used along with the effective pipeline
+func (o *StepSyntaxEffectiveOptions) effectiveOutputAtRef(workingDir string, gitRef string, packRef string, buildPackFor func(*config.ProjectConfig) (string, string)) ([]byte, string, error) {
+	dir := workingDir
+	if gitRef != "" {
+		refDir, cleanup, err := checkoutRef(workingDir, gitRef)
+		if err != nil {
+			return nil, "", err
+		}
+		defer cleanup()
+		dir = refDir
+	}
+	projectConfig, projectConfigFile, err := o.loadProjectConfig(dir)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to load project config in dir %s", dir)
+	}
+
+	pack, packURL := o.Pack, o.BuildPackURL
+	defer func() {
+		o.Pack, o.BuildPackURL = pack, packURL
+	}()
+	if projectConfig.BuildPack != "" {
+		o.Pack = projectConfig.BuildPack
+	}
+	url, ref := buildPackFor(projectConfig)
+	o.BuildPackURL = url
+	if packRef == "" {
+		packRef = ref
+	}
+	output, err := o.createEffectiveOutput(projectConfig, projectConfigFile, packRef)
+	return output, packRef, err
+}
+
+// checkoutRef checks out the project at the git ref into a temporary git worktree, so that the files the project config
+// refers to, such as the files of templates, are read at the same ref. It returns the directory of the worktree which
+// corresponds to the working dir along with a function which removes the worktree
+func checkoutRef(workingDir string, gitRef string) (string, func(), error) {
+	prefix, err := (&util.Command{Dir: workingDir, Name: "git", Args: []string{"rev-parse", "--show-prefix"}}).RunWithoutRetry()
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to find the git repository of %s", workingDir)
+	}
+	tmpDir, err := ioutil.TempDir("", "jx-effective-")
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to create temporary directory")
+	}
+	worktree := filepath.Join(tmpDir, "worktree")
+	cleanup := func() {
+		err := os.RemoveAll(tmpDir)
+		if err != nil {
+			log.Logger().Warnf("failed to remove %s: %s", tmpDir, err)
+		}
+		_, err = (&util.Command{Dir: workingDir, Name: "git", Args: []string{"worktree", "prune"}}).RunWithoutRetry()
+		if err != nil {
+			log.Logger().Warnf("failed to prune the git worktree %s: %s", worktree, err)
+		}
+	}
+	_, err = (&util.Command{Dir: workingDir, Name: "git", Args: []string{"worktree", "add", "--detach", worktree, gitRef}}).RunWithoutRetry()
+	if err != nil {
+		cleanup()
+		return "", nil, errors.Wrapf(err, "failed to check out %s", gitRef)
+	}
+	return filepath.Join(worktree, prefix), cleanup, nil
+}
+
+func describeRefs(gitRef string, packRef string) string {
+	if gitRef == "" {
+		gitRef = "working dir"
+	}
+	return fmt.Sprintf("%s with build pack %s", gitRef, packRef)
+}
+
+// diffLines returns a unified style diff of the lines of the two texts, showing only a few lines of context around
+// each change
+func diffLines(from string, to string, fromLabel string, toLabel string) string {
+	dmp := diffmatchpatch.New()
+	fromChars, toChars, lineArray := dmp.DiffLinesToChars(from, to)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(fromChars, toChars, false), lineArray)
+
+	lines := []string{"--- " + fromLabel, "+++ " + toLabel}
+	for i, d := range diffs {
+		textLines := strings.Split(strings.TrimSuffix(d.Text, "\n"), "\n")
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			for _, line := range textLines {
+				lines = append(lines, "-"+line)
+			}
+		case diffmatchpatch.DiffInsert:
+			for _, line := range textLines {
+				lines = append(lines, "+"+line)
+			}
+		default:
+			var leading, trailing []string
+			if i > 0 {
+				leading = textLines[:minInt(diffContextLines, len(textLines))]
+			}
+			if i < len(diffs)-1 {
+				trailing = textLines[maxInt(len(textLines)-diffContextLines, len(leading)):]
+			}
+			for _, line := range leading {
+				lines = append(lines, " "+line)
+			}
+			if len(diffs) > 1 && len(leading)+len(trailing) < len(textLines) {
+				lines = append(lines, "@@")
+			}
+			for _, line := range trailing {
+				lines = append(lines, " "+line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
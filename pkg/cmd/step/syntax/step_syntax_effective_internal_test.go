@@ -0,0 +1,295 @@
+package syntax
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotateTemplateProvenance(t *testing.T) {
+	t.Parallel()
+
+	provenance := map[string]string{
+		"build": "templates/build.yaml",
+		"lint":  "templates/lint.yaml",
+	}
+	tests := []struct {
+		name     string
+		yaml     string
+		expected string
+	}{
+		{
+			name: "stages",
+			yaml: `pipeline:
+  stages:
+  - name: build
+    steps:
+    - sh: make
+  - agent:
+      image: go
+    name: test
+`,
+			expected: `pipeline:
+  stages:
+    # included from templates/build.yaml
+  - name: build
+    steps:
+    - sh: make
+  - agent:
+      image: go
+    name: test
+`,
+		},
+		{
+			name: "parallel and nested stages",
+			yaml: `pipeline:
+  stages:
+  - name: checks
+    parallel:
+    - agent:
+        image: go
+      name: lint
+    - stages:
+      - name: build
+`,
+			expected: `pipeline:
+  stages:
+  - name: checks
+    parallel:
+    - agent:
+        image: go
+      # included from templates/lint.yaml
+      name: lint
+    - stages:
+        # included from templates/build.yaml
+      - name: build
+`,
+		},
+		{
+			name: "steps, containers and scripts with the name of a stage",
+			yaml: `pipeline:
+  stages:
+  - name: test
+    steps:
+    - name: build
+      sh: make
+    - args:
+      - name: build
+    - sh: |
+        name: build
+  containerOptions:
+    name: lint
+`,
+			expected: `pipeline:
+  stages:
+  - name: test
+    steps:
+    - name: build
+      sh: make
+    - args:
+      - name: build
+    - sh: |
+        name: build
+  containerOptions:
+    name: lint
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, string(annotateTemplateProvenance([]byte(tt.yaml), provenance)))
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected string
+	}{
+		{
+			name: "no changes",
+			from: "a\nb\n",
+			to:   "a\nb\n",
+			expected: `--- from
++++ to`,
+		},
+		{
+			name: "changed line with context",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			to:   "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n",
+			expected: `--- from
++++ to
+@@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+@@`,
+		},
+		{
+			name: "changes separated by more than the context",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			to:   "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			expected: `--- from
++++ to
+-1
++one
+ 2
+ 3
+ 4
+@@
+ 7
+ 8
+ 9
+-10
++ten`,
+		},
+		{
+			name: "added and removed lines",
+			from: "a\nb\n",
+			to:   "b\nc\n",
+			expected: `--- from
++++ to
+-a
+ b
++c`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, diffLines(tt.from, tt.to, "from", "to"))
+		})
+	}
+}
+
+func TestDiffEffectivePipelinesOutput(t *testing.T) {
+	jxHome, err := ioutil.TempDir("", "jx-home-")
+	require.NoError(t, err)
+	defer os.RemoveAll(jxHome)
+	err = os.Setenv("JX_HOME", jxHome)
+	require.NoError(t, err)
+	defer os.Unsetenv("JX_HOME")
+
+	gitter := gits.NewGitCLI()
+	commitFile := func(dir string, fileName string, content string) {
+		err := ioutil.WriteFile(filepath.Join(dir, fileName), []byte(content), util.DefaultWritePermissions)
+		require.NoError(t, err)
+		require.NoError(t, gitter.Add(dir, fileName))
+		require.NoError(t, gitter.CommitDir(dir, "update "+fileName))
+	}
+
+	// the project has no build pack so that the build pack repository only needs to exist
+	packRepo, err := ioutil.TempDir("", "jx-build-pack-")
+	require.NoError(t, err)
+	defer os.RemoveAll(packRepo)
+	require.NoError(t, gitter.Init(packRepo))
+	commitFile(packRepo, "README.md", "build packs")
+	_, err = (&util.Command{Dir: packRepo, Name: "git", Args: []string{"checkout", "-B", "master"}}).RunWithoutRetry()
+	require.NoError(t, err)
+
+	projectDir, err := ioutil.TempDir("", "jx-effective-diff-")
+	require.NoError(t, err)
+	defer os.RemoveAll(projectDir)
+	require.NoError(t, gitter.Init(projectDir))
+	projectConfig := `buildPack: none
+templates:
+- file: stages.yml
+pipelineConfig:
+  pipelines:
+    release:
+      pipeline:
+        agent:
+          image: golang
+        stages:
+        - name: build
+          steps:
+          - name: compile
+            command: make build
+        - name: lint
+          template:
+            name: lint
+`
+	stageTemplates := `templates:
+- name: lint
+  stage:
+    name: lint
+    steps:
+    - command: golangci-lint run
+`
+	commitFile(projectDir, "stages.yml", stageTemplates)
+	commitFile(projectDir, config.ProjectConfigFileName, projectConfig)
+	projectConfigFile := filepath.Join(projectDir, config.ProjectConfigFileName)
+	err = ioutil.WriteFile(projectConfigFile, []byte(strings.Replace(projectConfig, "make build", "make test", 1)), util.DefaultWritePermissions)
+	require.NoError(t, err)
+	// the templates are changed in the working dir too, so they must be read at the ref to be compared
+	err = ioutil.WriteFile(filepath.Join(projectDir, "stages.yml"), []byte(strings.Replace(stageTemplates, "golangci-lint run", "golangci-lint run --fast", 1)), util.DefaultWritePermissions)
+	require.NoError(t, err)
+
+	outDir, err := ioutil.TempDir("", "jx-effective-out-")
+	require.NoError(t, err)
+	defer os.RemoveAll(outDir)
+	o := &StepSyntaxEffectiveOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &opts.CommonOptions{},
+			OutDir:        outDir,
+		},
+		Pack:         "none",
+		BuildPackURL: packRepo,
+		BuildPackRef: "master",
+		FromRef:      "HEAD",
+		SourceName:   "source",
+		DefaultImage: "maven",
+		GitInfo: &gits.GitRepository{
+			Host:         "github.com",
+			Organisation: "myorg",
+			Name:         "myapp",
+		},
+	}
+	o.SetGit(gitter)
+
+	diff, err := o.diffEffectivePipelines(projectDir, func(*config.ProjectConfig) (string, string) {
+		return packRepo, "master"
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.writeOutput(diff, "-effective.diff"))
+
+	data, err := ioutil.ReadFile(filepath.Join(outDir, "jenkins-x-effective.diff"))
+	require.NoError(t, err)
+	lines := strings.Split(string(data), "\n")
+	require.True(t, len(lines) > 2, "the diff should have changes: %s", string(data))
+	assert.Equal(t, "--- HEAD with build pack master", lines[0])
+	assert.Equal(t, "+++ working dir with build pack master", lines[1])
+	var removed, added []string
+	for _, line := range lines[2:] {
+		if strings.HasPrefix(line, "-") {
+			removed = append(removed, strings.TrimSpace(line[1:]))
+		} else if strings.HasPrefix(line, "+") {
+			added = append(added, strings.TrimSpace(line[1:]))
+		}
+	}
+	assert.Equal(t, []string{"command: make build", "command: golangci-lint run"}, removed)
+	assert.Equal(t, []string{"command: make test", "command: golangci-lint run --fast"}, added)
+
+	worktrees, err := (&util.Command{Dir: projectDir, Name: "git", Args: []string{"worktree", "list"}}).RunWithoutRetry()
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(worktrees, "\n"), 1, "the worktree of the ref should be removed: %s", worktrees)
+}
@@ -434,8 +434,13 @@ func (o *StepVerifyPreInstallOptions) VerifyInstallConfig(kubeClient kubernetes.
 	_, err := kube.DefaultModifyConfigMap(kubeClient, ns, kube.ConfigMapNameJXInstallConfig,
 		func(configMap *corev1.ConfigMap) error {
 			secretsLocation := string(secrets.FileSystemLocationKind)
-			if requirements.SecretStorage == config.SecretStorageTypeVault {
+			switch requirements.SecretStorage {
+			case config.SecretStorageTypeVault:
 				secretsLocation = string(secrets.VaultLocationKind)
+			case config.SecretStorageTypeKubernetes:
+				secretsLocation = string(secrets.KubeLocationKind)
+			case config.SecretStorageTypeSOPS:
+				secretsLocation = string(secrets.SOPSLocationKind)
 			}
 			modifyMapIfNotBlank(configMap.Data, kube.KubeProvider, requirements.Cluster.Provider)
 			modifyMapIfNotBlank(configMap.Data, kube.ProjectID, requirements.Cluster.ProjectID)
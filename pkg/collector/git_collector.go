@@ -159,16 +159,20 @@ func (c *GitCollector) CollectData(data []byte, outputPath string) (string, erro
 }
 
 func (c *GitCollector) generateURL(storageOrg string, storageRepoName string, rPath string) (url string) {
-	if !c.gitInfo.IsGitHub() && gits.SaasGitKind(c.gitInfo.Host) == gits.KindGitHub {
-		url = fmt.Sprintf("https://raw.%s/%s/%s/%s/%s", c.gitInfo.Host, storageOrg, storageRepoName, c.gitBranch, rPath)
-	} else {
-		// TODO only supporting github for now!!!
-		url = fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", storageOrg, storageRepoName, c.gitBranch, rPath)
-	}
+	url = gitRawURL(c.gitInfo, storageOrg, storageRepoName, c.gitBranch, rPath)
 	log.Logger().Infof("Publishing %s", util.ColorInfo(url))
 	return url
 }
 
+// gitRawURL returns the URL to read the raw contents of the file at the given path in the branch of the repository
+func gitRawURL(gitInfo *gits.GitRepository, storageOrg string, storageRepoName string, gitBranch string, rPath string) string {
+	if !gitInfo.IsGitHub() && gits.SaasGitKind(gitInfo.Host) == gits.KindGitHub {
+		return fmt.Sprintf("https://raw.%s/%s/%s/%s/%s", gitInfo.Host, storageOrg, storageRepoName, gitBranch, rPath)
+	}
+	// TODO only supporting github for now!!!
+	return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", storageOrg, storageRepoName, gitBranch, rPath)
+}
+
 // cloneGitHubPagesBranchToTempDir clones the github pages branch to a temp dir
 func cloneGitHubPagesBranchToTempDir(sourceURL string, gitClient gits.Gitter, branchName string) (string, error) {
 	// First clone the git repo
@@ -5,6 +5,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/cloud/factory"
 	"github.com/jenkins-x/jx/pkg/cmd/clients"
 	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/pkg/errors"
 )
 
@@ -24,3 +25,22 @@ func NewCollector(storageLocation v1.StorageLocation, gitter gits.Gitter) (Colle
 	}
 	return NewBucketCollector(storageLocation.BucketURL, classifier, bucketProvider)
 }
+
+// StoredURL returns the URL that the data collected at the given output path in the storage location can be read from.
+// Only git storage on GitHub can be read from as the URL of the raw contents of other git providers is not known
+func StoredURL(storageLocation v1.StorageLocation, outputPath string) (string, error) {
+	if storageLocation.GitURL != "" {
+		gitInfo, err := gits.ParseGitURL(storageLocation.GitURL)
+		if err != nil {
+			return "", err
+		}
+		if !gitInfo.IsGitHub() {
+			return "", errors.Errorf("stored data can only be read from git storage on GitHub but the storage is %s, please use a bucket for the storage instead, see 'jx edit storage'", storageLocation.Description())
+		}
+		return gitRawURL(gitInfo, gitInfo.Organisation, gitInfo.Name, storageLocation.GetGitBranch(), outputPath), nil
+	}
+	if storageLocation.BucketURL != "" {
+		return util.UrlJoin(storageLocation.BucketURL, outputPath), nil
+	}
+	return "", errors.Errorf("no git or bucket URL configured for storage %s", storageLocation.Description())
+}
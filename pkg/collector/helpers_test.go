@@ -0,0 +1,53 @@
+package collector
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoredURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		location v1.StorageLocation
+		expected string
+		err      string
+	}{
+		{
+			name:     "github",
+			location: v1.StorageLocation{GitURL: "https://github.com/myorg/mystorage.git", GitBranch: "stash"},
+			expected: "https://raw.githubusercontent.com/myorg/mystorage/stash/jenkins-x/stash/build.tar.gz",
+		},
+		{
+			name:     "bucket",
+			location: v1.StorageLocation{BucketURL: "gs://mybucket"},
+			expected: "gs://mybucket/jenkins-x/stash/build.tar.gz",
+		},
+		{
+			name:     "gitlab",
+			location: v1.StorageLocation{GitURL: "https://gitlab.com/myorg/mystorage.git"},
+			err:      "stored data can only be read from git storage on GitHub",
+		},
+		{
+			name:     "no storage",
+			location: v1.StorageLocation{Classifier: "stash"},
+			err:      "no git or bucket URL configured",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := StoredURL(tt.location, "jenkins-x/stash/build.tar.gz")
+			if tt.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, u)
+		})
+	}
+}
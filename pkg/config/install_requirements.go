@@ -124,10 +124,14 @@ const (
 	// SecretStorageTypeLocal specifies that we use the local file system in
 	// `~/.jx/localSecrets` to store secrets
 	SecretStorageTypeLocal SecretStorageType = "local"
+	// SecretStorageTypeKubernetes specifies that we use Kubernetes Secrets to store secrets
+	SecretStorageTypeKubernetes SecretStorageType = "kubernetes"
+	// SecretStorageTypeSOPS specifies that we use SOPS encrypted files in the boot config repository to store secrets
+	SecretStorageTypeSOPS SecretStorageType = "sops"
 )
 
 // SecretStorageTypeValues the string values for the secret storage
-var SecretStorageTypeValues = []string{"local", "vault"}
+var SecretStorageTypeValues = []string{"local", "vault", "kubernetes", "sops"}
 
 // WebhookType is the type of a webhook strategy
 type WebhookType string
@@ -347,6 +351,12 @@ type VaultConfig struct {
 	AWSConfig           *VaultAWSConfig `json:"aws,omitempty"`
 }
 
+// SOPSConfig contains the configuration of the secrets stored in SOPS encrypted files
+type SOPSConfig struct {
+	// Dir the directory of the SOPS encrypted files relative to the requirements file. Defaults to 'secrets'
+	Dir string `json:"dir,omitempty"`
+}
+
 // VaultAWSConfig contains all the Vault configuration needed by Vault to be deployed in AWS
 type VaultAWSConfig struct {
 	VaultAWSUnsealConfig
@@ -462,6 +472,8 @@ type RequirementsConfig struct {
 	Repository RepositoryType `json:"repository,omitempty"`
 	// SecretStorage how should we store secrets for the cluster
 	SecretStorage SecretStorageType `json:"secretStorage,omitempty"`
+	// SOPS the configuration for SOPS if using SOPS encrypted files for secrets
+	SOPS *SOPSConfig `json:"sops,omitempty"`
 	// Storage contains storage requirements
 	Storage StorageConfig `json:"storage"`
 	// Terraform specifies if  we are managing the kubernetes cluster and cloud resources with Terraform
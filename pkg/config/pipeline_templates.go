@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/jenkins-x/jx/pkg/jenkinsfile"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// TemplateSource is a file of stage templates which can be included in the pipelines of a project, either in the
+// project itself or in a git repository
+type TemplateSource struct {
+	// GitURL is the URL of the git repository containing the file. If empty, the file is in the project itself.
+	GitURL string `json:"gitUrl,omitempty"`
+	// GitRef is the branch, tag or SHA of the git repository to use. If empty, the version of the git repository in the
+	// version stream is used.
+	GitRef string `json:"gitRef,omitempty"`
+	// File is the path of the file within the git repository or project
+	File string `json:"file"`
+}
+
+// TemplateFileResolver returns the path of the local copy of the file for a template source, along with the git ref
+// it was resolved to if the file is in a git repository
+type TemplateFileResolver func(source *TemplateSource) (string, string, error)
+
+// describe returns a description of where the templates from the source come from
+func (s *TemplateSource) describe(gitRef string) string {
+	if s.GitURL == "" {
+		return s.File
+	}
+	return fmt.Sprintf("%s in %s at %s", s.File, s.GitURL, gitRef)
+}
+
+// LoadPipelineTemplates loads the stage templates from the template sources of the project, returning them keyed by
+// name, along with a description of where each of them came from.
+func (c *ProjectConfig) LoadPipelineTemplates(resolver TemplateFileResolver) (map[string]*syntax.StageTemplate, map[string]string, error) {
+	templates := make(map[string]*syntax.StageTemplate)
+	sources := make(map[string]string)
+	for _, source := range c.Templates {
+		if source.File == "" {
+			return nil, nil, errors.New("template sources must specify a file")
+		}
+		fileName, gitRef, err := resolver(source)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to resolve template file %s", source.describe(source.GitRef))
+		}
+		description := source.describe(gitRef)
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to load template file %s", description)
+		}
+		fileTemplates := syntax.PipelineTemplates{}
+		err = yaml.Unmarshal(data, &fileTemplates)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to unmarshal template file %s", description)
+		}
+		for i := range fileTemplates.Templates {
+			template := &fileTemplates.Templates[i]
+			err = template.Validate()
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "invalid template in %s", description)
+			}
+			if existing, ok := sources[template.Name]; ok {
+				return nil, nil, errors.Errorf("the template %s is defined in both %s and %s", template.Name, existing, description)
+			}
+			templates[template.Name] = template
+			sources[template.Name] = description
+		}
+	}
+	return templates, sources, nil
+}
+
+// ExpandPipelineTemplates replaces the stages which include templates in the pipelines of the project with the stages
+// from the templates. It returns a description of where each expanded stage came from, keyed by stage name.
+func (c *ProjectConfig) ExpandPipelineTemplates(resolver TemplateFileResolver) (map[string]string, error) {
+	provenance := make(map[string]string)
+	if len(c.Templates) == 0 || c.PipelineConfig == nil {
+		return provenance, nil
+	}
+	templates, sources, err := c.LoadPipelineTemplates(resolver)
+	if err != nil {
+		return nil, err
+	}
+
+	pipelines := c.PipelineConfig.Pipelines
+	parsedPipelines := map[string]*syntax.ParsedPipeline{
+		"default": pipelines.Default,
+	}
+	for kind, lifecycles := range map[string]*jenkinsfile.PipelineLifecycles{
+		jenkinsfile.PipelineKindRelease:     pipelines.Release,
+		jenkinsfile.PipelineKindPullRequest: pipelines.PullRequest,
+		jenkinsfile.PipelineKindFeature:     pipelines.Feature,
+	} {
+		if lifecycles != nil {
+			parsedPipelines[kind] = lifecycles.Pipeline
+		}
+	}
+	for kind, parsed := range parsedPipelines {
+		if parsed == nil {
+			continue
+		}
+		included, err := parsed.ExpandStageTemplates(templates)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to expand the templates in the %s pipeline", kind)
+		}
+		for stageName, templateName := range included {
+			provenance[stageName] = fmt.Sprintf("template %s from %s", templateName, sources[templateName])
+		}
+	}
+	return provenance, nil
+}
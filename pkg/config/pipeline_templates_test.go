@@ -0,0 +1,59 @@
+package config_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandPipelineTemplates(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("test_data", "pipeline_templates")
+	projectConfig, _, err := config.LoadProjectConfig(dir)
+	require.NoError(t, err)
+
+	resolver := func(source *config.TemplateSource) (string, string, error) {
+		return filepath.Join(dir, source.File), "", nil
+	}
+	provenance, err := projectConfig.ExpandPipelineTemplates(resolver)
+	require.NoError(t, err)
+
+	source := "templates/stages.yml"
+	assert.Equal(t, map[string]string{
+		"deploy-staging": "template helm-deploy from " + source,
+		"lint":           "template lint from " + source,
+	}, provenance)
+
+	releaseStages := projectConfig.PipelineConfig.Pipelines.Release.Pipeline.Stages
+	require.Len(t, releaseStages, 2)
+	deploy := releaseStages[1]
+	assert.Equal(t, "deploy-staging", deploy.Name)
+	assert.Nil(t, deploy.Template)
+	require.Len(t, deploy.Steps, 1)
+	assert.Equal(t, "helm upgrade --install --namespace jx-staging --wait=true app charts/app", deploy.Steps[0].Command)
+
+	prStages := projectConfig.PipelineConfig.Pipelines.PullRequest.Pipeline.Stages
+	require.Len(t, prStages, 1)
+	assert.Equal(t, "golangci-lint run", prStages[0].Steps[0].Command)
+}
+
+func TestLoadPipelineTemplatesDuplicateName(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("test_data", "pipeline_templates")
+	projectConfig := &config.ProjectConfig{
+		Templates: []*config.TemplateSource{
+			{File: "templates/stages.yml"},
+			{File: "templates/stages.yml"},
+		},
+	}
+	resolver := func(source *config.TemplateSource) (string, string, error) {
+		return filepath.Join(dir, source.File), "", nil
+	}
+	_, _, err := projectConfig.LoadPipelineTemplates(resolver)
+	assert.EqualError(t, err, "the template helm-deploy is defined in both templates/stages.yml and templates/stages.yml")
+}
@@ -41,6 +41,8 @@ type ProjectConfig struct {
 	NoReleasePrepare    bool                        `json:"noReleasePrepare,omitempty"`
 	DockerRegistryHost  string                      `json:"dockerRegistryHost,omitempty"`
 	DockerRegistryOwner string                      `json:"dockerRegistryOwner,omitempty"`
+	// Templates lists the files of stage templates which can be included in the pipelines of the project
+	Templates []*TemplateSource `json:"templates,omitempty"`
 }
 
 type PreviewEnvironmentConfig struct {
@@ -52,6 +54,23 @@ type IssueTrackerConfig struct {
 	Kind    string `json:"kind,omitempty"`
 	URL     string `json:"url,omitempty"`
 	Project string `json:"project,omitempty"`
+	// IssueKeyPattern is the regular expression which finds the keys of issues in commit messages. If it has a
+	// capture group the group is the key. Defaults to the pattern of the kind of issue tracker
+	IssueKeyPattern string `json:"issueKeyPattern,omitempty"`
+	// FixVersions adds the version of a release to the fix versions of the issues in its changelog
+	FixVersions bool `json:"fixVersions,omitempty"`
+	// Transitions maps the names of Environments to the states the issues of a release are transitioned into when
+	// it is promoted to the Environment
+	Transitions map[string]string `json:"transitions,omitempty"`
+}
+
+// TransitionState returns the state the issues of a release are transitioned into when it is promoted to the
+// Environment or an empty string if they are not transitioned
+func (c *IssueTrackerConfig) TransitionState(environment string) string {
+	if c == nil || c.Transitions == nil {
+		return ""
+	}
+	return c.Transitions[environment]
 }
 
 type WikiConfig struct {
@@ -65,6 +84,44 @@ type ChatConfig struct {
 	URL              string `json:"url,omitempty"`
 	DeveloperChannel string `json:"developerChannel,omitempty"`
 	UserChannel      string `json:"userChannel,omitempty"`
+	// Notifications route the pipeline, promotion and release notifications of repositories to channels. If there
+	// are no rules all notifications are posted to the DeveloperChannel
+	Notifications []ChatNotificationRule `json:"notifications,omitempty"`
+}
+
+// ChatNotificationRule routes the notifications of the matching repositories to a channel
+type ChatNotificationRule struct {
+	// Repositories are the repositories as 'owner/name' which can end with a '*' wildcard such as 'myorg/*'.
+	// All repositories match if empty
+	Repositories []string `json:"repositories,omitempty"`
+	// Branches are the branches of pipelines and promotions which can end with a '*' wildcard. All branches match if
+	// empty
+	Branches []string `json:"branches,omitempty"`
+	// Events are the kinds of notification such as 'pipeline-failed' or 'release'. All events match if empty
+	Events []string `json:"events,omitempty"`
+	// Channel is the channel the notifications are posted to
+	Channel string `json:"channel,omitempty"`
+}
+
+// NotificationChannels returns the channels the notification of the event for the branch of the repository is
+// posted to
+func (c *ChatConfig) NotificationChannels(owner string, repository string, branch string, event string) []string {
+	answer := []string{}
+	fullName := owner + "/" + repository
+	for _, rule := range c.Notifications {
+		if rule.Channel == "" || util.StringArrayIndex(answer, rule.Channel) >= 0 {
+			continue
+		}
+		if util.StringMatchesAny(fullName, rule.Repositories, nil) &&
+			(branch == "" || util.StringMatchesAny(branch, rule.Branches, nil)) &&
+			util.StringMatchesAny(event, rule.Events, nil) {
+			answer = append(answer, rule.Channel)
+		}
+	}
+	if len(c.Notifications) == 0 && c.DeveloperChannel != "" {
+		answer = append(answer, c.DeveloperChannel)
+	}
+	return answer
 }
 
 type AddonConfig struct {
@@ -102,3 +102,54 @@ func TestGetPipeline(t *testing.T) {
 	assert.Equal(t, err.Error(), "no pipeline defined for kind feature")
 	assert.Nil(t, featurePipeline)
 }
+
+func TestChatNotificationChannels(t *testing.T) {
+	t.Parallel()
+	chatConfig := &config.ChatConfig{
+		DeveloperChannel: "#dev",
+	}
+	assert.Equal(t, []string{"#dev"}, chatConfig.NotificationChannels("myorg", "myapp", "master", "release"))
+
+	chatConfig.Notifications = []config.ChatNotificationRule{
+		{
+			Channel: "#builds",
+			Events:  []string{"pipeline-failed"},
+		},
+		{
+			Channel:      "#releases",
+			Repositories: []string{"myorg/*"},
+			Branches:     []string{"master"},
+			Events:       []string{"release", "promotion"},
+		},
+		{
+			Channel:      "#builds",
+			Repositories: []string{"myorg/myapp"},
+		},
+	}
+	assert.Equal(t, []string{"#builds"}, chatConfig.NotificationChannels("other", "thing", "master", "pipeline-failed"))
+	assert.Empty(t, chatConfig.NotificationChannels("other", "thing", "master", "release"))
+	assert.Equal(t, []string{"#releases", "#builds"}, chatConfig.NotificationChannels("myorg", "myapp", "", "release"))
+	assert.Equal(t, []string{"#builds"}, chatConfig.NotificationChannels("myorg", "myapp", "PR-1", "promotion"))
+	assert.Equal(t, []string{"#releases"}, chatConfig.NotificationChannels("myorg", "other", "master", "promotion"))
+}
+
+func TestIssueTrackerTransitionState(t *testing.T) {
+	t.Parallel()
+	var issueTrackerConfig *config.IssueTrackerConfig
+	assert.Equal(t, "", issueTrackerConfig.TransitionState("staging"))
+
+	issueTrackerConfig = &config.IssueTrackerConfig{
+		Kind: "jira",
+		Transitions: map[string]string{
+			"staging":    "In Staging",
+			"production": "Released",
+		},
+	}
+	assert.Equal(t, "In Staging", issueTrackerConfig.TransitionState("staging"))
+	assert.Equal(t, "Released", issueTrackerConfig.TransitionState("production"))
+	assert.Equal(t, "", issueTrackerConfig.TransitionState("dev"))
+
+	copied := issueTrackerConfig.DeepCopy()
+	copied.Transitions["staging"] = "QA"
+	assert.Equal(t, "In Staging", issueTrackerConfig.TransitionState("staging"))
+}
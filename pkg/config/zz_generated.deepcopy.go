@@ -231,6 +231,13 @@ func (in *ChartMuseumSecret) DeepCopy() *ChartMuseumSecret {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChatConfig) DeepCopyInto(out *ChatConfig) {
 	*out = *in
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = make([]ChatNotificationRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -244,6 +251,37 @@ func (in *ChatConfig) DeepCopy() *ChatConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChatNotificationRule) DeepCopyInto(out *ChatNotificationRule) {
+	*out = *in
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Branches != nil {
+		in, out := &in.Branches, &out.Branches
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChatNotificationRule.
+func (in *ChatNotificationRule) DeepCopy() *ChatNotificationRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ChatNotificationRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterConfig) DeepCopyInto(out *ClusterConfig) {
 	*out = *in
@@ -524,6 +562,13 @@ func (in *IngressConfig) DeepCopy() *IngressConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IssueTrackerConfig) DeepCopyInto(out *IssueTrackerConfig) {
 	*out = *in
+	if in.Transitions != nil {
+		in, out := &in.Transitions, &out.Transitions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -858,7 +903,7 @@ func (in *ProjectConfig) DeepCopyInto(out *ProjectConfig) {
 			*out = nil
 		} else {
 			*out = new(IssueTrackerConfig)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Chat != nil {
@@ -867,7 +912,7 @@ func (in *ProjectConfig) DeepCopyInto(out *ProjectConfig) {
 			*out = nil
 		} else {
 			*out = new(ChatConfig)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Wiki != nil {
@@ -900,6 +945,18 @@ func (in *ProjectConfig) DeepCopyInto(out *ProjectConfig) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make([]*TemplateSource, len(*in))
+		for i := range *in {
+			if (*in)[i] == nil {
+				(*out)[i] = nil
+			} else {
+				(*out)[i] = new(TemplateSource)
+				(*in)[i].DeepCopyInto((*out)[i])
+			}
+		}
+	}
 	return
 }
 
@@ -949,6 +1006,15 @@ func (in *RequirementsConfig) DeepCopyInto(out *RequirementsConfig) {
 		}
 	}
 	out.Ingress = in.Ingress
+	if in.SOPS != nil {
+		in, out := &in.SOPS, &out.SOPS
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(SOPSConfig)
+			**out = **in
+		}
+	}
 	out.Storage = in.Storage
 	in.Vault.DeepCopyInto(&out.Vault)
 	out.Velero = in.Velero
@@ -966,6 +1032,22 @@ func (in *RequirementsConfig) DeepCopy() *RequirementsConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SOPSConfig) DeepCopyInto(out *SOPSConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SOPSConfig.
+func (in *SOPSConfig) DeepCopy() *SOPSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SOPSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageConfig) DeepCopyInto(out *StorageConfig) {
 	*out = *in
@@ -1018,6 +1100,22 @@ func (in *TLSConfig) DeepCopy() *TLSConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateSource) DeepCopyInto(out *TemplateSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateSource.
+func (in *TemplateSource) DeepCopy() *TemplateSource {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultAWSConfig) DeepCopyInto(out *VaultAWSConfig) {
 	*out = *in
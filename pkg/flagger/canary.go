@@ -0,0 +1,192 @@
+package flagger
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// CanaryGroup is the API group of the Flagger Canary resource
+	CanaryGroup = "flagger.app"
+	// CanaryVersion is the API version of the Flagger Canary resource
+	CanaryVersion = "v1alpha3"
+)
+
+// CanaryPhase is the phase of the analysis of a Flagger canary
+type CanaryPhase string
+
+const (
+	// CanaryPhaseInitializing means the canary is being set up for the first time
+	CanaryPhaseInitializing CanaryPhase = "Initializing"
+	// CanaryPhaseInitialized means the primary has been created from the first version, without any analysis
+	CanaryPhaseInitialized CanaryPhase = "Initialized"
+	// CanaryPhaseWaiting means the analysis is waiting for confirmation to start
+	CanaryPhaseWaiting CanaryPhase = "Waiting"
+	// CanaryPhaseProgressing means the analysis is running and traffic is being shifted to the canary
+	CanaryPhaseProgressing CanaryPhase = "Progressing"
+	// CanaryPhasePromoting means the analysis has passed and the canary is being copied to the primary
+	CanaryPhasePromoting CanaryPhase = "Promoting"
+	// CanaryPhaseFinalising means the traffic is being routed back to the primary
+	CanaryPhaseFinalising CanaryPhase = "Finalising"
+	// CanaryPhaseSucceeded means the canary has been promoted
+	CanaryPhaseSucceeded CanaryPhase = "Succeeded"
+	// CanaryPhaseFailed means the analysis failed and the canary has been rolled back
+	CanaryPhaseFailed CanaryPhase = "Failed"
+)
+
+// Canary is the subset of the Flagger Canary resource used to follow the progress of its analysis
+type Canary struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CanarySpec   `json:"spec"`
+	Status CanaryStatus `json:"status"`
+}
+
+// CanaryList is a list of Flagger Canary resources
+type CanaryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Canary `json:"items"`
+}
+
+// CanarySpec is the subset of the specification of a Flagger canary
+type CanarySpec struct {
+	TargetRef CanaryTargetRef `json:"targetRef"`
+}
+
+// CanaryTargetRef refers to the deployment which the canary analyses
+type CanaryTargetRef struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Name       string `json:"name"`
+}
+
+// CanaryStatus is the status of the analysis of a Flagger canary
+type CanaryStatus struct {
+	Phase              CanaryPhase `json:"phase,omitempty"`
+	FailedChecks       int         `json:"failedChecks"`
+	CanaryWeight       int         `json:"canaryWeight"`
+	Iterations         int         `json:"iterations"`
+	LastAppliedSpec    string      `json:"lastAppliedSpec,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// IsComplete returns true if the analysis of the canary has finished, successfully or not
+func (s *CanaryStatus) IsComplete() bool {
+	return s.IsSucceeded() || s.Phase == CanaryPhaseFailed
+}
+
+// IsSucceeded returns true if the canary has been promoted, or was initialized without needing any analysis
+func (s *CanaryStatus) IsSucceeded() bool {
+	return s.Phase == CanaryPhaseSucceeded || s.Phase == CanaryPhaseInitialized
+}
+
+// CanaryLister lists the Flagger canaries in a namespace
+type CanaryLister func(ns string) ([]Canary, error)
+
+// NewCanaryLister returns a CanaryLister which queries the Flagger API of the cluster. If Flagger is not installed
+// no canaries are returned.
+func NewCanaryLister(kubeClient kubernetes.Interface) CanaryLister {
+	return func(ns string) ([]Canary, error) {
+		uri := fmt.Sprintf("/apis/%s/%s/namespaces/%s/canaries", CanaryGroup, CanaryVersion, ns)
+		data, err := kubeClient.CoreV1().RESTClient().Get().RequestURI(uri).DoRaw()
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, errors.Wrapf(err, "failed to list the canaries in namespace %s", ns)
+		}
+		list := CanaryList{}
+		err = json.Unmarshal(data, &list)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal the canaries in namespace %s", ns)
+		}
+		return list.Items, nil
+	}
+}
+
+// FindCanaryForApp returns the canary whose target deployment is the given app, or nil if there is none
+func FindCanaryForApp(canaries []Canary, app string, ns string) *Canary {
+	for i := range canaries {
+		canary := &canaries[i]
+		target := canary.Spec.TargetRef.Name
+		if target == app || kube.GetAppName(target, ns) == kube.GetAppName(app, ns) {
+			return canary
+		}
+	}
+	return nil
+}
+
+// WaitForCanary polls the canary of the app until an analysis which completed after the given time is found, calling
+// onChange whenever the status of the canary changes. It returns the last status seen, along with an error if the
+// canary could not be found or the analysis did not complete before the end time.
+func WaitForCanary(lister CanaryLister, app string, ns string, since time.Time, pollTime time.Duration, end time.Time, onChange func(*Canary) error) (*Canary, error) {
+	var last *Canary
+	started := false
+	for {
+		canaries, err := lister(ns)
+		if err != nil {
+			log.Logger().Warnf("Failed to query the canaries in namespace %s: %s", ns, err)
+		}
+		canary := FindCanaryForApp(canaries, app, ns)
+		if canary != nil {
+			if last == nil || last.Status.Phase != canary.Status.Phase || last.Status.CanaryWeight != canary.Status.CanaryWeight ||
+				last.Status.FailedChecks != canary.Status.FailedChecks || last.Status.Iterations != canary.Status.Iterations {
+				if onChange != nil {
+					err = onChange(canary)
+					if err != nil {
+						return canary, err
+					}
+				}
+			}
+			last = canary
+			status := &canary.Status
+			if !status.IsComplete() {
+				started = true
+			} else if started || status.LastTransitionTime.After(since) {
+				return canary, nil
+			}
+		}
+		if time.Now().After(end) {
+			if last == nil {
+				return nil, errors.Errorf("no Flagger canary found for %s in namespace %s", app, ns)
+			}
+			return last, errors.Errorf("timed out waiting for the canary analysis of %s in namespace %s which is %s", app, ns, last.Status.Phase)
+		}
+		time.Sleep(pollTime)
+	}
+}
+
+// UpdateCanaryStep records the status of the canary on the promotion step, adding an iteration if the phase, weight
+// or failed checks have changed since the last one. It returns true if the step was modified.
+func UpdateCanaryStep(step *v1.PromoteCanaryStep, canary *Canary) bool {
+	status := canary.Status
+	phase := string(status.Phase)
+	if len(step.Iterations) > 0 {
+		lastIteration := step.Iterations[len(step.Iterations)-1]
+		if lastIteration.Phase == phase && lastIteration.Weight == status.CanaryWeight && lastIteration.FailedChecks == status.FailedChecks {
+			return false
+		}
+	}
+	step.Phase = phase
+	step.Weight = status.CanaryWeight
+	step.FailedChecks = status.FailedChecks
+	step.Iterations = append(step.Iterations, v1.CanaryAnalysisIteration{
+		Phase:        phase,
+		Weight:       status.CanaryWeight,
+		FailedChecks: status.FailedChecks,
+		Timestamp:    metav1.Now(),
+	})
+	return true
+}
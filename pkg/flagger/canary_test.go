@@ -0,0 +1,133 @@
+package flagger_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/flagger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newCanary(target string, phase flagger.CanaryPhase, weight int, failedChecks int, transition time.Time) flagger.Canary {
+	return flagger.Canary{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: target,
+		},
+		Spec: flagger.CanarySpec{
+			TargetRef: flagger.CanaryTargetRef{
+				Name: target,
+			},
+		},
+		Status: flagger.CanaryStatus{
+			Phase:              phase,
+			CanaryWeight:       weight,
+			FailedChecks:       failedChecks,
+			LastTransitionTime: metav1.NewTime(transition),
+		},
+	}
+}
+
+// sequenceLister returns a CanaryLister which returns the next canary of the sequence each time it is called,
+// repeating the last one once the sequence is exhausted
+func sequenceLister(sequence ...flagger.Canary) flagger.CanaryLister {
+	i := 0
+	return func(ns string) ([]flagger.Canary, error) {
+		canary := sequence[i]
+		if i < len(sequence)-1 {
+			i++
+		}
+		return []flagger.Canary{canary}, nil
+	}
+}
+
+func TestFindCanaryForApp(t *testing.T) {
+	t.Parallel()
+	canaries := []flagger.Canary{
+		newCanary("jx-staging-other", flagger.CanaryPhaseSucceeded, 0, 0, time.Now()),
+		newCanary("jx-staging-myapp", flagger.CanaryPhaseSucceeded, 0, 0, time.Now()),
+	}
+
+	canary := flagger.FindCanaryForApp(canaries, "myapp", "jx-staging")
+	require.NotNil(t, canary)
+	assert.Equal(t, "jx-staging-myapp", canary.Name)
+
+	assert.Nil(t, flagger.FindCanaryForApp(canaries, "missing", "jx-staging"))
+}
+
+func TestWaitForCanarySucceeded(t *testing.T) {
+	t.Parallel()
+	before := time.Now().Add(-time.Hour)
+	since := time.Now()
+	after := since.Add(time.Minute)
+	lister := sequenceLister(
+		newCanary("jx-staging-myapp", flagger.CanaryPhaseSucceeded, 0, 0, before),
+		newCanary("jx-staging-myapp", flagger.CanaryPhaseProgressing, 10, 0, after),
+		newCanary("jx-staging-myapp", flagger.CanaryPhaseProgressing, 10, 0, after),
+		newCanary("jx-staging-myapp", flagger.CanaryPhaseProgressing, 20, 1, after),
+		newCanary("jx-staging-myapp", flagger.CanaryPhaseSucceeded, 0, 0, after),
+	)
+
+	step := &v1.PromoteCanaryStep{}
+	canary, err := flagger.WaitForCanary(lister, "myapp", "jx-staging", since, time.Millisecond, time.Now().Add(time.Minute), func(c *flagger.Canary) error {
+		flagger.UpdateCanaryStep(step, c)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, canary.Status.IsSucceeded())
+
+	var weights []int
+	for _, iteration := range step.Iterations {
+		weights = append(weights, iteration.Weight)
+	}
+	assert.Equal(t, []int{0, 10, 20, 0}, weights)
+	assert.Equal(t, string(flagger.CanaryPhaseSucceeded), step.Phase)
+	assert.Equal(t, 1, step.Iterations[2].FailedChecks)
+}
+
+func TestWaitForCanaryFailed(t *testing.T) {
+	t.Parallel()
+	since := time.Now()
+	after := since.Add(time.Minute)
+	lister := sequenceLister(
+		newCanary("jx-staging-myapp", flagger.CanaryPhaseProgressing, 10, 0, after),
+		newCanary("jx-staging-myapp", flagger.CanaryPhaseProgressing, 10, 5, after),
+		newCanary("jx-staging-myapp", flagger.CanaryPhaseFailed, 0, 5, after),
+	)
+
+	canary, err := flagger.WaitForCanary(lister, "myapp", "jx-staging", since, time.Millisecond, time.Now().Add(time.Minute), nil)
+	require.NoError(t, err)
+	assert.True(t, canary.Status.IsComplete())
+	assert.False(t, canary.Status.IsSucceeded())
+	assert.Equal(t, 5, canary.Status.FailedChecks)
+}
+
+func TestWaitForCanaryTimesOut(t *testing.T) {
+	t.Parallel()
+	lister := sequenceLister(newCanary("jx-staging-myapp", flagger.CanaryPhaseProgressing, 10, 0, time.Now()))
+
+	canary, err := flagger.WaitForCanary(lister, "myapp", "jx-staging", time.Now(), time.Millisecond, time.Now().Add(10*time.Millisecond), nil)
+	assert.Error(t, err)
+	require.NotNil(t, canary)
+	assert.Equal(t, flagger.CanaryPhaseProgressing, canary.Status.Phase)
+
+	_, err = flagger.WaitForCanary(sequenceLister(newCanary("jx-staging-other", flagger.CanaryPhaseProgressing, 10, 0, time.Now())),
+		"myapp", "jx-staging", time.Now(), time.Millisecond, time.Now().Add(10*time.Millisecond), nil)
+	assert.Error(t, err)
+}
+
+func TestUpdateCanaryStepIgnoresUnchangedStatus(t *testing.T) {
+	t.Parallel()
+	step := &v1.PromoteCanaryStep{}
+	canary := newCanary("jx-staging-myapp", flagger.CanaryPhaseProgressing, 10, 0, time.Now())
+
+	assert.True(t, flagger.UpdateCanaryStep(step, &canary))
+	assert.False(t, flagger.UpdateCanaryStep(step, &canary))
+
+	canary.Status.CanaryWeight = 20
+	assert.True(t, flagger.UpdateCanaryStep(step, &canary))
+	assert.Len(t, step.Iterations, 2)
+	assert.Equal(t, 20, step.Weight)
+}
@@ -0,0 +1,136 @@
+package freeze
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// schedule is a parsed cron expression of the form 'minute hour day-of-month month day-of-week'
+type schedule struct {
+	minutes     map[int]bool
+	hours       map[int]bool
+	daysOfMonth map[int]bool
+	months      map[int]bool
+	daysOfWeek  map[int]bool
+
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+var (
+	monthNames   = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// parseSchedule parses a standard 5 field cron expression supporting '*', lists, ranges, steps and the names of
+// months and days of the week
+func parseSchedule(expression string) (*schedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, errors.Errorf("invalid cron expression '%s', expected 5 fields but found %d", expression, len(fields))
+	}
+	var err error
+	answer := &schedule{
+		anyDayOfMonth: fields[2] == "*",
+		anyDayOfWeek:  fields[4] == "*",
+	}
+	if answer.minutes, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, errors.Wrapf(err, "invalid minutes in cron expression '%s'", expression)
+	}
+	if answer.hours, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, errors.Wrapf(err, "invalid hours in cron expression '%s'", expression)
+	}
+	if answer.daysOfMonth, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, errors.Wrapf(err, "invalid days of the month in cron expression '%s'", expression)
+	}
+	if answer.months, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, errors.Wrapf(err, "invalid months in cron expression '%s'", expression)
+	}
+	if answer.daysOfWeek, err = parseField(fields[4], 0, 7, weekdayNames); err != nil {
+		return nil, errors.Wrapf(err, "invalid days of the week in cron expression '%s'", expression)
+	}
+	// both 0 and 7 are Sunday
+	if answer.daysOfWeek[7] {
+		answer.daysOfWeek[0] = true
+	}
+	return answer, nil
+}
+
+// matches returns true if the schedule triggers at the minute of the given time
+func (s *schedule) matches(t time.Time) bool {
+	if !s.minutes[t.Minute()] || !s.hours[t.Hour()] || !s.months[int(t.Month())] {
+		return false
+	}
+	dayOfMonth := s.daysOfMonth[t.Day()]
+	dayOfWeek := s.daysOfWeek[int(t.Weekday())]
+	// like cron if both days are restricted then either can match
+	if !s.anyDayOfMonth && !s.anyDayOfWeek {
+		return dayOfMonth || dayOfWeek
+	}
+	return dayOfMonth && dayOfWeek
+}
+
+// parseField parses a comma separated list of values, ranges and steps between min and max inclusive
+func parseField(field string, min int, max int, names []string) (map[int]bool, error) {
+	answer := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[idx+1:])
+			if err != nil || step <= 0 {
+				return nil, errors.Errorf("invalid step in '%s'", part)
+			}
+			part = part[0:idx]
+		}
+		from, to := min, max
+		if part != "*" {
+			idx := strings.Index(part, "-")
+			var err error
+			if idx > 0 {
+				if from, err = parseValue(part[0:idx], min, max, names); err != nil {
+					return nil, err
+				}
+				if to, err = parseValue(part[idx+1:], min, max, names); err != nil {
+					return nil, err
+				}
+			} else {
+				if from, err = parseValue(part, min, max, names); err != nil {
+					return nil, err
+				}
+				to = from
+				if step > 1 {
+					to = max
+				}
+			}
+		}
+		if from > to {
+			return nil, errors.Errorf("invalid range '%s'", part)
+		}
+		for i := from; i <= to; i += step {
+			answer[i] = true
+		}
+	}
+	return answer, nil
+}
+
+func parseValue(text string, min int, max int, names []string) (int, error) {
+	lower := strings.ToLower(text)
+	for i, name := range names {
+		if lower == name {
+			// month names start from 1 whereas day names start from 0
+			return i + min, nil
+		}
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, errors.Errorf("invalid value '%s'", text)
+	}
+	if value < min || value > max {
+		return 0, errors.Errorf("value %d is not between %d and %d", value, min, max)
+	}
+	return value, nil
+}
@@ -0,0 +1,152 @@
+package freeze
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/jenkins-x/jx/pkg/workflow"
+	"github.com/pkg/errors"
+)
+
+const dateFormat = "2006-01-02"
+
+// Windows returns the freeze windows of the environment along with the freeze windows of the team which apply to it
+func Windows(env *v1.Environment, teamSettings *v1.TeamSettings) []v1.FreezeWindow {
+	answer := append([]v1.FreezeWindow{}, env.Spec.FreezeWindows...)
+	if teamSettings != nil && env.Spec.Kind.IsPermanent() {
+		for _, window := range teamSettings.FreezeWindows {
+			if len(window.Environments) == 0 || util.StringArrayIndex(window.Environments, env.Name) >= 0 {
+				answer = append(answer, window)
+			}
+		}
+	}
+	return answer
+}
+
+// ActiveWindow returns the first of the freeze windows which is active at the given time or nil if promotions are
+// not frozen
+func ActiveWindow(windows []v1.FreezeWindow, t time.Time) (*v1.FreezeWindow, error) {
+	for i := range windows {
+		window := &windows[i]
+		active, err := IsActive(window, t)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid freeze window %s", window.Name)
+		}
+		if active {
+			return window, nil
+		}
+	}
+	return nil, nil
+}
+
+// IsActive returns true if promotions are frozen by the window at the given time
+func IsActive(window *v1.FreezeWindow, t time.Time) (bool, error) {
+	if window.Window == nil && window.From == "" && window.To == "" && window.Schedule == "" {
+		return true, nil
+	}
+	loc := time.UTC
+	if window.TimeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(window.TimeZone)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid time zone %s", window.TimeZone)
+		}
+	}
+	t = t.In(loc)
+
+	if window.Window != nil {
+		timeWindow := *window.Window
+		if timeWindow.TimeZone == "" {
+			timeWindow.TimeZone = window.TimeZone
+		}
+		active, err := workflow.InTimeWindow(&timeWindow, t)
+		if err != nil || active {
+			return active, err
+		}
+	}
+	if window.From != "" || window.To != "" {
+		active, err := inPeriod(window.From, window.To, loc, t)
+		if err != nil || active {
+			return active, err
+		}
+	}
+	if window.Schedule != "" {
+		return inSchedule(window.Schedule, window.Duration, t)
+	}
+	return false, nil
+}
+
+// Describe returns a description of the freeze window for messages
+func Describe(window *v1.FreezeWindow) string {
+	name := window.Name
+	if name == "" {
+		name = "freeze window"
+	}
+	if window.Reason != "" {
+		return fmt.Sprintf("%s: %s", name, window.Reason)
+	}
+	return name
+}
+
+// inPeriod returns true if the time is within the one-off period. An end date is inclusive of the whole day
+func inPeriod(from string, to string, loc *time.Location, t time.Time) (bool, error) {
+	if from != "" {
+		start, _, err := parseDate(from, loc)
+		if err != nil {
+			return false, err
+		}
+		if t.Before(start) {
+			return false, nil
+		}
+	}
+	if to != "" {
+		end, isDate, err := parseDate(to, loc)
+		if err != nil {
+			return false, err
+		}
+		if isDate {
+			end = end.AddDate(0, 0, 1)
+		}
+		if !t.Before(end) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// parseDate parses either a date or an RFC 3339 time, returning true if it was a date
+func parseDate(text string, loc *time.Location) (time.Time, bool, error) {
+	t, err := time.ParseInLocation(dateFormat, text, loc)
+	if err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, text)
+	if err != nil {
+		return t, false, errors.Errorf("invalid date %s, expected the form %s or RFC 3339", text, dateFormat)
+	}
+	return t, false, nil
+}
+
+// inSchedule returns true if the cron schedule has triggered within the duration before the given time
+func inSchedule(expression string, durationText string, t time.Time) (bool, error) {
+	if durationText == "" {
+		return false, errors.Errorf("no duration for the schedule %s", expression)
+	}
+	duration, err := time.ParseDuration(durationText)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid duration %s", durationText)
+	}
+	s, err := parseSchedule(expression)
+	if err != nil {
+		return false, err
+	}
+	earliest := t.Add(-duration)
+	for m := t.Truncate(time.Minute); m.After(earliest); m = m.Add(-time.Minute) {
+		if s.matches(m) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
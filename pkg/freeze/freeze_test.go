@@ -0,0 +1,131 @@
+package freeze_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/freeze"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 2019-12-20 was a Friday
+func friday(hour int, minute int) time.Time {
+	return time.Date(2019, time.December, 20, hour, minute, 0, 0, time.UTC)
+}
+
+func TestIsActive(t *testing.T) {
+	t.Parallel()
+	weekend := v1.FreezeWindow{
+		Name:   "weekend",
+		Window: &v1.TimeWindow{Days: []string{"Sat", "Sun"}},
+	}
+	holidays := v1.FreezeWindow{
+		Name: "holidays",
+		From: "2019-12-21",
+		To:   "2020-01-01",
+	}
+	fridayEvening := v1.FreezeWindow{
+		Name:     "friday-evening",
+		Schedule: "0 17 * * fri",
+		Duration: "63h",
+	}
+
+	testCases := []struct {
+		name     string
+		window   v1.FreezeWindow
+		time     time.Time
+		expected bool
+	}{
+		{"always frozen", v1.FreezeWindow{Name: "incident"}, friday(12, 0), true},
+		{"weekday", weekend, friday(12, 0), false},
+		{"weekend", weekend, friday(12, 0).AddDate(0, 0, 1), true},
+		{"before holidays", holidays, friday(23, 59), false},
+		{"holidays", holidays, friday(0, 0).AddDate(0, 0, 1), true},
+		{"last day of holidays", holidays, time.Date(2020, time.January, 1, 23, 0, 0, 0, time.UTC), true},
+		{"after holidays", holidays, time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC), false},
+		{"before schedule", fridayEvening, friday(16, 59), false},
+		{"schedule starts", fridayEvening, friday(17, 0), true},
+		{"during schedule", fridayEvening, friday(17, 0).Add(62 * time.Hour), true},
+		{"schedule ended", fridayEvening, friday(17, 0).Add(63 * time.Hour), false},
+		{"rfc3339 period", v1.FreezeWindow{From: "2019-12-20T12:00:00Z", To: "2019-12-20T13:00:00Z"}, friday(12, 30), true},
+		{"time zone", v1.FreezeWindow{Window: &v1.TimeWindow{Start: "09:00", End: "17:00"}, TimeZone: "Asia/Tokyo"}, friday(3, 0), true},
+	}
+	for _, tc := range testCases {
+		actual, err := freeze.IsActive(&tc.window, tc.time)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, actual, tc.name)
+	}
+}
+
+func TestIsActiveInvalid(t *testing.T) {
+	t.Parallel()
+	for _, window := range []v1.FreezeWindow{
+		{From: "20th December"},
+		{Schedule: "0 17 * * fri"},
+		{Schedule: "0 17 * *", Duration: "1h"},
+		{Schedule: "0 25 * * *", Duration: "1h"},
+		{Schedule: "0 17 * * fri", Duration: "forever"},
+		{Window: &v1.TimeWindow{Days: []string{"Caturday"}}},
+	} {
+		_, err := freeze.IsActive(&window, friday(12, 0))
+		assert.Error(t, err, "window %#v", window)
+	}
+}
+
+func TestSchedules(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		schedule string
+		time     time.Time
+		expected bool
+	}{
+		{"*/15 * * * *", friday(12, 30), true},
+		{"*/15 * * * *", friday(12, 31), false},
+		{"0 9-17/2 * * *", friday(11, 0), true},
+		{"0 9-17/2 * * *", friday(12, 0), false},
+		{"0 12 20 dec *", friday(12, 0), true},
+		{"0 12 1 * mon", friday(12, 0), false},
+		{"0 12 1 * 1,5", friday(12, 0), true},
+		{"0 12 * * 7", friday(12, 0).AddDate(0, 0, 2), true},
+	}
+	for _, tc := range testCases {
+		window := v1.FreezeWindow{Schedule: tc.schedule, Duration: "1m"}
+		actual, err := freeze.IsActive(&window, tc.time)
+		require.NoError(t, err, tc.schedule)
+		assert.Equal(t, tc.expected, actual, "%s at %s", tc.schedule, tc.time.String())
+	}
+}
+
+func TestWindows(t *testing.T) {
+	t.Parallel()
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "production",
+		},
+		Spec: v1.EnvironmentSpec{
+			Kind:          v1.EnvironmentKindTypePermanent,
+			FreezeWindows: []v1.FreezeWindow{{Name: "env"}},
+		},
+	}
+	teamSettings := &v1.TeamSettings{
+		FreezeWindows: []v1.FreezeWindow{
+			{Name: "all"},
+			{Name: "staging-only", Environments: []string{"staging"}},
+			{Name: "production-only", Environments: []string{"production"}},
+		},
+	}
+	var names []string
+	for _, window := range freeze.Windows(env, teamSettings) {
+		names = append(names, window.Name)
+	}
+	assert.Equal(t, []string{"env", "all", "production-only"}, names)
+
+	active, err := freeze.ActiveWindow(nil, friday(12, 0))
+	require.NoError(t, err)
+	assert.Nil(t, active)
+
+	assert.Equal(t, "weekend: no deploys", freeze.Describe(&v1.FreezeWindow{Name: "weekend", Reason: "no deploys"}))
+}
@@ -1,9 +1,18 @@
 package gits
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -24,12 +33,24 @@ type BitbucketCloudProvider struct {
 	Client   *bitbucket.APIClient
 	Username string
 	Context  context.Context
+	// APIURL is the base URL of the Bitbucket Cloud API, used for the hooks, refs and downloads endpoints which the
+	// client does not support
+	APIURL string
 
 	Server auth.AuthServer
 	User   auth.UserAuth
 	Git    Gitter
 }
 
+// bitbucketCloudWebHookEvents are the events Jenkins X webhooks are triggered by
+var bitbucketCloudWebHookEvents = []string{
+	"repo:push",
+	"pullrequest:created",
+	"pullrequest:updated",
+	"pullrequest:fulfilled",
+	"pullrequest:rejected",
+}
+
 var stateMap = map[string]string{
 	"SUCCESSFUL": "success",
 	"FAILED":     "failure",
@@ -56,6 +77,7 @@ func NewBitbucketCloudProvider(server *auth.AuthServer, user *auth.UserAuth, git
 
 	cfg := bitbucket.NewConfiguration()
 	provider.Client = bitbucket.NewAPIClient(cfg)
+	provider.APIURL = cfg.BasePath
 
 	return &provider, nil
 }
@@ -714,15 +736,9 @@ func (b *BitbucketCloudProvider) CreateWebHook(data *GitWebHookArguments) error
 
 	options := map[string]interface{}{
 		"body": map[string]interface{}{
-			"url":    data.URL,
-			"active": true,
-			"events": []string{
-				"repo:push",
-				"pullrequest:created",
-				"pullrequest:updated",
-				"pullrequest:fulfilled",
-				"pullrequest:rejected",
-			},
+			"url":         data.URL,
+			"active":      true,
+			"events":      bitbucketCloudWebHookEvents,
 			"description": "Jenkins X Web Hook",
 		},
 	}
@@ -740,15 +756,97 @@ func (b *BitbucketCloudProvider) CreateWebHook(data *GitWebHookArguments) error
 	return nil
 }
 
-// ListWebHooks lists the webhooks
+// bitbucketCloudWebHook is a webhook subscription on a repository
+type bitbucketCloudWebHook struct {
+	UUID        string   `json:"uuid,omitempty"`
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Active      bool     `json:"active"`
+	Events      []string `json:"events"`
+}
+
+// ListWebHooks lists the webhooks. Bitbucket identifies webhooks by UUID, so the ID of each webhook is derived from
+// its UUID with bitbucketCloudID.
 func (b *BitbucketCloudProvider) ListWebHooks(owner string, repo string) ([]*GitWebHookArguments, error) {
 	webHooks := []*GitWebHookArguments{}
-	return webHooks, fmt.Errorf("not implemented!")
+	hooks, err := b.listWebHooks(owner, repo)
+	if err != nil {
+		return webHooks, err
+	}
+	for _, hook := range hooks {
+		webHooks = append(webHooks, &GitWebHookArguments{
+			ID:    bitbucketCloudID(hook.UUID),
+			Owner: owner,
+			Repo:  nil,
+			URL:   hook.URL,
+		})
+	}
+	return webHooks, nil
+}
+
+func (b *BitbucketCloudProvider) listWebHooks(owner string, repo string) ([]*bitbucketCloudWebHook, error) {
+	var hooks []*bitbucketCloudWebHook
+	next := fmt.Sprintf("/repositories/%s/%s/hooks?pagelen=100", owner, repo)
+	for next != "" {
+		page := struct {
+			Values []*bitbucketCloudWebHook `json:"values"`
+			Next   string                   `json:"next"`
+		}{}
+		err := b.apiRequest(http.MethodGet, next, nil, &page)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the webhooks of %s/%s", owner, repo)
+		}
+		hooks = append(hooks, page.Values...)
+		next = page.Next
+	}
+	return hooks, nil
 }
 
-// UpdateWebHook updates the webhook
+// UpdateWebHook updates the webhook with the ID, or with the existing URL if no ID is given, to use the new URL
 func (b *BitbucketCloudProvider) UpdateWebHook(data *GitWebHookArguments) error {
-	return fmt.Errorf("not implemented!")
+	if data.Repo == nil || data.Repo.Name == "" {
+		return errors.New("missing property Repo")
+	}
+	if data.URL == "" {
+		return errors.New("missing property URL")
+	}
+	owner := data.Owner
+	if owner == "" {
+		owner = data.Repo.Organisation
+	}
+	if owner == "" {
+		owner = b.Username
+	}
+	repo := data.Repo.Name
+
+	hooks, err := b.listWebHooks(owner, repo)
+	if err != nil {
+		return err
+	}
+	var existing *bitbucketCloudWebHook
+	for _, hook := range hooks {
+		if (data.ID != 0 && bitbucketCloudID(hook.UUID) == data.ID) || (data.ID == 0 && data.ExistingURL != "" && hook.URL == data.ExistingURL) {
+			existing = hook
+			break
+		}
+	}
+	if existing == nil {
+		log.Logger().Warn("No webhooks found to update")
+		return nil
+	}
+
+	hook := &bitbucketCloudWebHook{
+		URL:         data.URL,
+		Description: "Jenkins X Web Hook",
+		Active:      true,
+		Events:      bitbucketCloudWebHookEvents,
+	}
+	log.Logger().Infof("Updating Bitbucket Cloud webhook for %s/%s for url %s", util.ColorInfo(owner), util.ColorInfo(repo), util.ColorInfo(data.URL))
+	err = b.apiRequest(http.MethodPut, fmt.Sprintf("/repositories/%s/%s/hooks/%s", owner, repo, url.PathEscape(existing.UUID)), hook, nil)
+	if err != nil {
+		return errors.Wrapf(err, "updating the webhook %s on %s/%s", existing.UUID, owner, repo)
+	}
+	return nil
 }
 
 func BitbucketIssueToGitIssue(bIssue bitbucket.Issue) *GitIssue {
@@ -1000,9 +1098,17 @@ func (b *BitbucketCloudProvider) UserInfo(username string) *GitUser {
 	}
 }
 
+// UpdateRelease updates the release notes of the tag. Bitbucket Cloud doesn't support releases, so the release notes
+// are uploaded to the downloads of the repository, alongside the release assets.
 func (b *BitbucketCloudProvider) UpdateRelease(owner string, repo string, tag string, releaseInfo *GitRelease) error {
-	log.Logger().Warn("Bitbucket Cloud doesn't support releases")
-	return nil
+	if releaseInfo == nil || releaseInfo.Body == "" {
+		return nil
+	}
+	_, err := b.getTag(owner, repo, tag)
+	if err != nil {
+		return err
+	}
+	return b.uploadDownload(owner, repo, bitbucketCloudReleaseNotesName(tag), strings.NewReader(releaseInfo.Body))
 }
 
 // UpdateReleaseStatus is not supported for this git provider
@@ -1011,17 +1117,50 @@ func (b *BitbucketCloudProvider) UpdateReleaseStatus(owner string, repo string,
 	return nil
 }
 
-// ListReleases lists the releases
+// ListReleases lists the releases, which are emulated by the tags of the repository, newest first
 func (b *BitbucketCloudProvider) ListReleases(org string, name string) ([]*GitRelease, error) {
 	answer := []*GitRelease{}
-	log.Logger().Warn("Bitbucket Cloud doesn't support releases")
+	tags, err := b.listTags(org, name)
+	if err != nil {
+		return answer, err
+	}
+	downloads, err := b.listDownloads(org, name)
+	if err != nil {
+		return answer, err
+	}
+	for _, tag := range tags {
+		answer = append(answer, b.toRelease(org, name, tag, downloads))
+	}
 	return answer, nil
 }
 
-// GetRelease is not supported on BitBucket Cloud
+// GetRelease returns the release for the tag, including its release notes and assets from the downloads of the
+// repository. It returns nil if there is no such tag.
 func (b *BitbucketCloudProvider) GetRelease(org string, name string, tag string) (*GitRelease, error) {
-	log.Logger().Warn("Bitbucket Cloud doesn't support releases")
-	return nil, nil
+	t, err := b.getTag(org, name, tag)
+	if err != nil {
+		if isBitbucketCloudNotFound(err) {
+			log.Logger().Warnf("No release found for %s/%s and tag %s", org, name, tag)
+			return nil, nil
+		}
+		return nil, err
+	}
+	downloads, err := b.listDownloads(org, name)
+	if err != nil {
+		return nil, err
+	}
+	release := b.toRelease(org, name, t, downloads)
+	for _, download := range downloads {
+		if download.Name == bitbucketCloudReleaseNotesName(tag) {
+			var notes bytes.Buffer
+			err = b.apiRequest(http.MethodGet, fmt.Sprintf("/repositories/%s/%s/downloads/%s", org, name, url.PathEscape(download.Name)), nil, &notes)
+			if err != nil {
+				return nil, errors.Wrapf(err, "getting the release notes of %s/%s tag %s", org, name, tag)
+			}
+			release.Body = notes.String()
+		}
+	}
+	return release, nil
 }
 
 func (b *BitbucketCloudProvider) AddCollaborator(user string, organisation string, repo string) error {
@@ -1071,12 +1210,42 @@ func (b *BitbucketCloudProvider) AddLabelsToIssue(owner, repo string, number int
 
 // GetLatestRelease fetches the latest release from the git provider for org and name
 func (b *BitbucketCloudProvider) GetLatestRelease(org string, name string) (*GitRelease, error) {
-	return nil, nil
+	tags, err := b.listTags(org, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return b.GetRelease(org, name, tags[0].Name)
 }
 
 // UploadReleaseAsset will upload an asset to org/repo to a release with id, giving it a name, it will return the release asset from the git provider
 func (b *BitbucketCloudProvider) UploadReleaseAsset(org string, repo string, id int64, name string, asset *os.File) (*GitReleaseAsset, error) {
-	return nil, nil
+	tags, err := b.listTags(org, repo)
+	if err != nil {
+		return nil, err
+	}
+	tag := ""
+	for _, t := range tags {
+		if bitbucketCloudID(t.Name) == id {
+			tag = t.Name
+			break
+		}
+	}
+	if tag == "" {
+		return nil, errors.Errorf("no release found for %s/%s with id %d", org, repo, id)
+	}
+	downloadName := bitbucketCloudAssetName(tag, name)
+	err = b.uploadDownload(org, repo, downloadName, asset)
+	if err != nil {
+		return nil, err
+	}
+	return &GitReleaseAsset{
+		ID:                 bitbucketCloudID(downloadName),
+		Name:               name,
+		BrowserDownloadURL: util.UrlJoin(b.ServerURL(), org, repo, "downloads", downloadName),
+	}, nil
 }
 
 // GetBranch returns the branch information for an owner/repo, including the commit at the tip
@@ -1098,3 +1267,210 @@ func (b *BitbucketCloudProvider) ConfigureFeatures(owner string, repo string, is
 func (b *BitbucketCloudProvider) IsWikiEnabled(owner string, repo string) (bool, error) {
 	return false, nil
 }
+
+// bitbucketCloudTag is a tag of a repository
+type bitbucketCloudTag struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Target  struct {
+		Hash string `json:"hash"`
+	} `json:"target"`
+}
+
+// bitbucketCloudDownload is a file in the downloads of a repository
+type bitbucketCloudDownload struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+// bitbucketCloudNotFoundError is returned by apiRequest when Bitbucket returns a 404
+type bitbucketCloudNotFoundError struct {
+	path string
+}
+
+func (e *bitbucketCloudNotFoundError) Error() string {
+	return fmt.Sprintf("%s not found", e.path)
+}
+
+func isBitbucketCloudNotFound(err error) bool {
+	_, ok := errors.Cause(err).(*bitbucketCloudNotFoundError)
+	return ok
+}
+
+// bitbucketCloudID returns a numeric ID for a webhook UUID or tag name, as Bitbucket doesn't give them numeric IDs
+func bitbucketCloudID(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64() >> 1)
+}
+
+// bitbucketCloudReleaseNotesName returns the name of the download holding the release notes for the tag
+func bitbucketCloudReleaseNotesName(tag string) string {
+	return tag + "-release-notes.md"
+}
+
+// bitbucketCloudAssetName returns the name of the download holding the release asset for the tag
+func bitbucketCloudAssetName(tag string, name string) string {
+	return tag + "-" + name
+}
+
+func (b *BitbucketCloudProvider) toRelease(org string, name string, tag *bitbucketCloudTag, downloads []*bitbucketCloudDownload) *GitRelease {
+	release := &GitRelease{
+		ID:      bitbucketCloudID(tag.Name),
+		Name:    tag.Name,
+		TagName: tag.Name,
+		Body:    tag.Message,
+		URL:     util.UrlJoin(b.APIURL, "repositories", org, name, "refs", "tags", tag.Name),
+		HTMLURL: util.UrlJoin(b.ServerURL(), org, name, "src", tag.Name),
+	}
+	var assets []GitReleaseAsset
+	prefix := bitbucketCloudAssetName(tag.Name, "")
+	for _, download := range downloads {
+		if !strings.HasPrefix(download.Name, prefix) || download.Name == bitbucketCloudReleaseNotesName(tag.Name) {
+			continue
+		}
+		assets = append(assets, GitReleaseAsset{
+			ID:                 bitbucketCloudID(download.Name),
+			Name:               strings.TrimPrefix(download.Name, prefix),
+			BrowserDownloadURL: util.UrlJoin(b.ServerURL(), org, name, "downloads", download.Name),
+		})
+	}
+	release.Assets = &assets
+	return release
+}
+
+func (b *BitbucketCloudProvider) getTag(owner string, repo string, tag string) (*bitbucketCloudTag, error) {
+	t := &bitbucketCloudTag{}
+	err := b.apiRequest(http.MethodGet, fmt.Sprintf("/repositories/%s/%s/refs/tags/%s", owner, repo, url.PathEscape(tag)), nil, t)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting the tag %s of %s/%s", tag, owner, repo)
+	}
+	return t, nil
+}
+
+// listTags lists the tags of the repository, newest first
+func (b *BitbucketCloudProvider) listTags(owner string, repo string) ([]*bitbucketCloudTag, error) {
+	var tags []*bitbucketCloudTag
+	next := fmt.Sprintf("/repositories/%s/%s/refs/tags?pagelen=100&sort=-target.date", owner, repo)
+	for next != "" {
+		page := struct {
+			Values []*bitbucketCloudTag `json:"values"`
+			Next   string               `json:"next"`
+		}{}
+		err := b.apiRequest(http.MethodGet, next, nil, &page)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the tags of %s/%s", owner, repo)
+		}
+		tags = append(tags, page.Values...)
+		next = page.Next
+	}
+	return tags, nil
+}
+
+func (b *BitbucketCloudProvider) listDownloads(owner string, repo string) ([]*bitbucketCloudDownload, error) {
+	var downloads []*bitbucketCloudDownload
+	next := fmt.Sprintf("/repositories/%s/%s/downloads?pagelen=100", owner, repo)
+	for next != "" {
+		page := struct {
+			Values []*bitbucketCloudDownload `json:"values"`
+			Next   string                    `json:"next"`
+		}{}
+		err := b.apiRequest(http.MethodGet, next, nil, &page)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the downloads of %s/%s", owner, repo)
+		}
+		downloads = append(downloads, page.Values...)
+		next = page.Next
+	}
+	return downloads, nil
+}
+
+// uploadDownload uploads the content to the downloads of the repository, replacing any existing download with the
+// same name
+func (b *BitbucketCloudProvider) uploadDownload(owner string, repo string, name string, content io.Reader) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("files", filepath.Base(name))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, content)
+	if err != nil {
+		return errors.Wrapf(err, "reading the content of %s", name)
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+	req, err := b.newAPIRequest(http.MethodPost, fmt.Sprintf("/repositories/%s/%s/downloads", owner, repo), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	err = b.doAPIRequest(req, nil)
+	if err != nil {
+		return errors.Wrapf(err, "uploading %s to the downloads of %s/%s", name, owner, repo)
+	}
+	return nil
+}
+
+// apiRequest sends a request to the Bitbucket Cloud API for the endpoints which are not supported by the client. The
+// path is either relative to the API URL or an absolute URL, such as the next link of a page. The body is sent as
+// JSON, and the response is decoded as JSON into result, or copied into it if it is a bytes.Buffer.
+func (b *BitbucketCloudProvider) apiRequest(method string, path string, body interface{}, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "marshalling request body")
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := b.newAPIRequest(method, path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return b.doAPIRequest(req, result)
+}
+
+func (b *BitbucketCloudProvider) newAPIRequest(method string, path string, body io.Reader) (*http.Request, error) {
+	u := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		u = strings.TrimSuffix(b.APIURL, "/") + path
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(b.User.Username, b.User.ApiToken)
+	return req, nil
+}
+
+func (b *BitbucketCloudProvider) doAPIRequest(req *http.Request, result interface{}) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return &bitbucketCloudNotFoundError{path: req.URL.Path}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s returned %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	if buf, ok := result.(*bytes.Buffer); ok {
+		_, err = buf.Write(data)
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
@@ -1,8 +1,12 @@
 package gits_test
 
 import (
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/jenkins-x/jx/pkg/auth"
@@ -24,6 +28,10 @@ type BitbucketCloudProviderTestSuite struct {
 	server    *httptest.Server
 	provider  gits.BitbucketCloudProvider
 	providers map[string]gits.BitbucketCloudProvider
+
+	updatedHookPath string
+	updatedHook     map[string]interface{}
+	uploads         map[string]string
 }
 
 const (
@@ -93,6 +101,16 @@ var bitbucketRouter = util.Router{
 	},
 	"/repositories/test-user/test-repo/hooks": util.MethodMap{
 		"POST": "webhooks.example.json",
+		"GET":  "hooks.test-repo.json",
+	},
+	"/repositories/test-user/test-repo/refs/tags": util.MethodMap{
+		"GET": "tags.test-repo.json",
+	},
+	"/repositories/test-user/test-repo/refs/tags/v1.0.0": util.MethodMap{
+		"GET": "tags.test-repo.v1.0.0.json",
+	},
+	"/repositories/test-user/test-repo/downloads/v1.0.0-release-notes.md": util.MethodMap{
+		"GET": "downloads.test-repo.v1.0.0-release-notes.md",
 	},
 	"/repositories/test-user/test-repo/issues": util.MethodMap{
 		"POST": "issues.test-repo.issue-1.json",
@@ -136,6 +154,30 @@ func (suite *BitbucketCloudProviderTestSuite) SetupSuite() {
 	for path, methodMap := range bitbucketRouter {
 		suite.mux.HandleFunc(path, util.GetMockAPIResponseFromFile("test_data/bitbucket_cloud", methodMap))
 	}
+	suite.mux.HandleFunc("/repositories/test-user/test-repo/hooks/", func(w http.ResponseWriter, r *http.Request) {
+		suite.Require().Equal(http.MethodPut, r.Method)
+		suite.updatedHookPath = r.URL.Path
+		suite.updatedHook = map[string]interface{}{}
+		suite.Require().Nil(json.NewDecoder(r.Body).Decode(&suite.updatedHook))
+		src, err := ioutil.ReadFile("test_data/bitbucket_cloud/webhooks.example.json")
+		suite.Require().Nil(err)
+		w.Write(src)
+	})
+	suite.uploads = map[string]string{}
+	suite.mux.HandleFunc("/repositories/test-user/test-repo/downloads", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			file, header, err := r.FormFile("files")
+			suite.Require().Nil(err)
+			data, err := ioutil.ReadAll(file)
+			suite.Require().Nil(err)
+			suite.uploads[header.Filename] = string(data)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		src, err := ioutil.ReadFile("test_data/bitbucket_cloud/downloads.test-repo.json")
+		suite.Require().Nil(err)
+		w.Write(src)
+	})
 
 	suite.server = httptest.NewServer(suite.mux)
 	suite.Require().NotNil(suite.server)
@@ -158,6 +200,7 @@ func (suite *BitbucketCloudProviderTestSuite) SetupSuite() {
 		suite.Require().NotNil(bp)
 		suite.Require().True(ok)
 		bp.Client = clientSingleton
+		bp.APIURL = suite.server.URL
 
 		suite.providers[profile.username] = *bp
 	}
@@ -403,6 +446,105 @@ func (suite *BitbucketCloudProviderTestSuite) TestCreateWebHook() {
 	suite.Require().Nil(err)
 }
 
+func (suite *BitbucketCloudProviderTestSuite) TestListWebHooks() {
+	hooks, err := suite.provider.ListWebHooks("test-user", "test-repo")
+
+	suite.Require().Nil(err)
+	suite.Require().Len(hooks, 2)
+	suite.Require().Equal("https://old-jenkins.example.com/bitbucket-webhook/", hooks[0].URL)
+	suite.Require().NotEqual(int64(0), hooks[0].ID)
+	suite.Require().NotEqual(hooks[0].ID, hooks[1].ID)
+}
+
+func (suite *BitbucketCloudProviderTestSuite) TestUpdateWebHook() {
+	hooks, err := suite.provider.ListWebHooks("test-user", "test-repo")
+	suite.Require().Nil(err)
+
+	scenarios := []struct {
+		description string
+		data        *gits.GitWebHookArguments
+	}{
+		{"Update webhook by ID", &gits.GitWebHookArguments{
+			ID:    hooks[0].ID,
+			Owner: "test-user",
+			Repo:  &gits.GitRepository{Name: "test-repo"},
+			URL:   "https://new-jenkins.example.com/bitbucket-webhook/",
+		}},
+		{"Update webhook by existing URL", &gits.GitWebHookArguments{
+			Owner:       "test-user",
+			Repo:        &gits.GitRepository{Name: "test-repo"},
+			URL:         "https://new-jenkins.example.com/bitbucket-webhook/",
+			ExistingURL: "https://old-jenkins.example.com/bitbucket-webhook/",
+		}},
+	}
+	for _, s := range scenarios {
+		suite.updatedHookPath = ""
+		err := suite.provider.UpdateWebHook(s.data)
+
+		suite.Require().Nil(err, s.description)
+		suite.Require().Equal("/repositories/test-user/test-repo/hooks/{81c9cddc-38ef-4ea2-bae7-4bf581f82c6c}", suite.updatedHookPath, s.description)
+		suite.Require().Equal("https://new-jenkins.example.com/bitbucket-webhook/", suite.updatedHook["url"], s.description)
+	}
+}
+
+func (suite *BitbucketCloudProviderTestSuite) TestGetRelease() {
+	release, err := suite.provider.GetRelease("test-user", "test-repo", "v1.0.0")
+
+	suite.Require().Nil(err)
+	suite.Require().NotNil(release)
+	suite.Require().Equal("v1.0.0", release.TagName)
+	suite.Require().Equal("## Changes\n\n* fix: handle empty values\n", release.Body)
+	suite.Require().Len(*release.Assets, 1)
+	suite.Require().Equal("test-repo-linux-amd64.tar.gz", (*release.Assets)[0].Name)
+}
+
+func (suite *BitbucketCloudProviderTestSuite) TestGetMissingRelease() {
+	release, err := suite.provider.GetRelease("test-user", "test-repo", "v9.9.9")
+
+	suite.Require().Nil(err)
+	suite.Require().Nil(release)
+}
+
+func (suite *BitbucketCloudProviderTestSuite) TestListReleases() {
+	releases, err := suite.provider.ListReleases("test-user", "test-repo")
+
+	suite.Require().Nil(err)
+	suite.Require().Len(releases, 2)
+	suite.Require().Equal("v1.0.1", releases[0].TagName)
+	suite.Require().Equal("Release 1.0.1\n", releases[0].Body)
+	suite.Require().Len(*releases[1].Assets, 1)
+}
+
+func (suite *BitbucketCloudProviderTestSuite) TestUpdateReleaseUploadsReleaseNotes() {
+	err := suite.provider.UpdateRelease("test-user", "test-repo", "v1.0.0", &gits.GitRelease{
+		TagName: "v1.0.0",
+		Body:    "## Changes\n\n* feat: something new\n",
+	})
+
+	suite.Require().Nil(err)
+	suite.Require().Equal("## Changes\n\n* feat: something new\n", suite.uploads["v1.0.0-release-notes.md"])
+}
+
+func (suite *BitbucketCloudProviderTestSuite) TestUploadReleaseAsset() {
+	releases, err := suite.provider.ListReleases("test-user", "test-repo")
+	suite.Require().Nil(err)
+
+	file, err := ioutil.TempFile("", "release-asset")
+	suite.Require().Nil(err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString("binary")
+	suite.Require().Nil(err)
+	_, err = file.Seek(0, 0)
+	suite.Require().Nil(err)
+
+	asset, err := suite.provider.UploadReleaseAsset("test-user", "test-repo", releases[1].ID, "test-repo-darwin-amd64.tar.gz", file)
+
+	suite.Require().Nil(err)
+	suite.Require().Equal("test-repo-darwin-amd64.tar.gz", asset.Name)
+	suite.Require().True(strings.HasSuffix(asset.BrowserDownloadURL, "/downloads/v1.0.0-test-repo-darwin-amd64.tar.gz"))
+	suite.Require().Equal("binary", suite.uploads["v1.0.0-test-repo-darwin-amd64.tar.gz"])
+}
+
 func (suite *BitbucketCloudProviderTestSuite) TestSearchIssues() {
 	issues, err := suite.provider.SearchIssues("test-user", "test-repo", "")
 
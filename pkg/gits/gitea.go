@@ -3,6 +3,8 @@ package gits
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -257,13 +259,83 @@ func (p *GiteaProvider) CreateWebHook(data *GitWebHookArguments) error {
 	return err
 }
 
+// ListWebHooks lists the webhooks on the repository
 func (p *GiteaProvider) ListWebHooks(owner string, repo string) ([]*GitWebHookArguments, error) {
 	webHooks := []*GitWebHookArguments{}
-	return webHooks, fmt.Errorf("not implemented!")
+	if owner == "" {
+		owner = p.Username
+	}
+	if repo == "" {
+		return webHooks, fmt.Errorf("Missing property Repo")
+	}
+	hooks, err := p.Client.ListRepoHooks(owner, repo)
+	if err != nil {
+		return webHooks, errors2.Wrapf(err, "listing webhooks on %s/%s", owner, repo)
+	}
+	for _, hook := range hooks {
+		webHooks = append(webHooks, &GitWebHookArguments{
+			ID:    hook.ID,
+			Owner: owner,
+			Repo:  nil,
+			URL:   hook.Config["url"],
+		})
+	}
+	return webHooks, nil
 }
 
+// UpdateWebHook updates the webhook with the ID, or with the existing URL if no ID is given, to use the new URL and
+// secret
 func (p *GiteaProvider) UpdateWebHook(data *GitWebHookArguments) error {
-	return fmt.Errorf("not implemented!")
+	owner := data.Owner
+	if owner == "" {
+		owner = p.Username
+	}
+	repo := data.Repo.Name
+	if repo == "" {
+		return fmt.Errorf("Missing property Repo")
+	}
+	webhookUrl := data.URL
+	if webhookUrl == "" {
+		return fmt.Errorf("Missing property URL")
+	}
+
+	dataId := data.ID
+	if dataId == 0 {
+		hooks, err := p.Client.ListRepoHooks(owner, repo)
+		if err != nil {
+			log.Logger().Warnf("Querying webhooks on %s/%s: %s", owner, repo, err)
+		}
+		for _, hook := range hooks {
+			if hook.Config["url"] == data.ExistingURL {
+				log.Logger().Warnf("Found existing webhook for url %s", data.ExistingURL)
+				dataId = hook.ID
+			}
+		}
+	}
+	if dataId == 0 {
+		log.Logger().Warn("No webhooks found to update")
+		return nil
+	}
+
+	config := map[string]string{
+		"url":          webhookUrl,
+		"content_type": "json",
+	}
+	if data.Secret != "" {
+		config["secret"] = data.Secret
+	}
+	active := true
+	hook := gitea.EditHookOption{
+		Config: config,
+		Events: []string{"create", "push", "pull_request"},
+		Active: &active,
+	}
+	log.Logger().Infof("Updating Gitea webhook for %s/%s for url %s", util.ColorInfo(owner), util.ColorInfo(repo), util.ColorInfo(webhookUrl))
+	err := p.Client.EditRepoHook(owner, repo, dataId, hook)
+	if err != nil {
+		return fmt.Errorf("Failed to update webhook %d for %s/%s due to: %s", dataId, owner, repo, err)
+	}
+	return nil
 }
 
 func (p *GiteaProvider) CreatePullRequest(data *GitPullRequestArguments) (*GitPullRequest, error) {
@@ -305,7 +377,22 @@ func (p *GiteaProvider) CreatePullRequest(data *GitPullRequestArguments) (*GitPu
 
 // UpdatePullRequest updates pull request with number using data
 func (p *GiteaProvider) UpdatePullRequest(data *GitPullRequestArguments, number int) (*GitPullRequest, error) {
-	return nil, errors2.Errorf("Not yet implemented for gitea")
+	owner := data.GitRepository.Organisation
+	repo := data.GitRepository.Name
+	// only the fields which are set are sent, as the edit option of the Gitea client clears those which are not
+	config := map[string]string{}
+	if data.Title != "" {
+		config["title"] = data.Title
+	}
+	if data.Body != "" {
+		config["body"] = data.Body
+	}
+	pr := &gitea.PullRequest{}
+	err := p.apiRequest(http.MethodPatch, fmt.Sprintf("/repos/%s/%s/pulls/%d", owner, repo, number), config, pr)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "updating pull request %s/%s #%d", owner, repo, number)
+	}
+	return p.toPullRequest(owner, repo, pr), nil
 }
 
 func (p *GiteaProvider) UpdatePullRequestStatus(pr *GitPullRequest) error {
@@ -393,14 +480,18 @@ func (p *GiteaProvider) GetPullRequest(owner string, repo *GitRepository, number
 	return pr, err
 }
 
+// GetPullRequestCommits returns the commits of the pull request
 func (p *GiteaProvider) GetPullRequestCommits(owner string, repository *GitRepository, number int) ([]*GitCommit, error) {
+	repo := repository.Name
+	commits := []*giteaCommit{}
+	err := p.apiRequest(http.MethodGet, fmt.Sprintf("/repos/%s/%s/pulls/%d/commits", owner, repo, number), nil, &commits)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "getting the commits of pull request %s/%s #%d", owner, repo, number)
+	}
 	answer := []*GitCommit{}
-
-	// TODO there does not seem to be any way to get a diff of commits
-	// unless maybe checking out the repo (do we have access to a local copy?)
-	// there is a pr.Base and pr.Head that might be able to compare to get
-	// commits somehow, but does not look like anything through the api
-
+	for _, commit := range commits {
+		answer = append(answer, commit.toGitCommit())
+	}
 	return answer, nil
 }
 
@@ -593,8 +684,19 @@ func (b *GiteaProvider) UpdateCommitStatus(org string, repo string, sha string,
 	return &GitRepoStatus{}, errors.New("TODO")
 }
 
+// RenameRepository renames the repository
 func (p *GiteaProvider) RenameRepository(org string, name string, newName string) (*GitRepository, error) {
-	return nil, fmt.Errorf("Rename of repositories is not supported for Gitea")
+	if org == "" {
+		org = p.Username
+	}
+	repo := &gitea.Repository{}
+	err := p.apiRequest(http.MethodPatch, fmt.Sprintf("/repos/%s/%s", org, name), map[string]string{"name": newName}, repo)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to edit repository %s/%s due to: %s", org, name, err)
+	}
+	answer := toGiteaRepo(newName, repo)
+	answer.Organisation = org
+	return answer, nil
 }
 
 func (p *GiteaProvider) ValidateRepositoryName(org string, name string) error {
@@ -782,8 +884,33 @@ func (p *GiteaProvider) AcceptInvitation(ID int64) (*github.Response, error) {
 	return &github.Response{}, nil
 }
 
+// GetContent returns the content of the file at the path in the repository at the given ref
 func (p *GiteaProvider) GetContent(org string, name string, path string, ref string) (*GitFileContent, error) {
-	return nil, fmt.Errorf("Getting content not supported on gitea")
+	contentPath := fmt.Sprintf("/repos/%s/%s/contents/%s", org, name, strings.TrimPrefix(path, "/"))
+	if ref != "" {
+		contentPath += "?ref=" + url.QueryEscape(ref)
+	}
+	content := &giteaContent{}
+	err := p.apiRequest(http.MethodGet, contentPath, nil, content)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "getting the content of %s in %s/%s", path, org, name)
+	}
+	if content.Type != "file" {
+		return nil, fmt.Errorf("Directory Content not yet supported")
+	}
+	return &GitFileContent{
+		Name:        content.Name,
+		Url:         content.URL,
+		Path:        content.Path,
+		Type:        content.Type,
+		Content:     content.Content,
+		DownloadUrl: content.DownloadURL,
+		Encoding:    content.Encoding,
+		GitUrl:      content.GitURL,
+		HtmlUrl:     content.HTMLURL,
+		Sha:         content.SHA,
+		Size:        content.Size,
+	}, nil
 }
 
 // ShouldForkForPullReques treturns true if we should create a personal fork of this repository
@@ -792,8 +919,36 @@ func (p *GiteaProvider) ShouldForkForPullRequest(originalOwner string, repoName
 	return originalOwner != username
 }
 
+// ListCommits lists the commits in the repository
 func (p *GiteaProvider) ListCommits(owner, repo string, opt *ListCommitsArguments) ([]*GitCommit, error) {
-	return nil, fmt.Errorf("Listing commits not supported on gitea")
+	params := url.Values{}
+	if opt.SHA != "" {
+		params.Set("sha", opt.SHA)
+	}
+	if opt.Path != "" {
+		params.Set("path", opt.Path)
+	}
+	if opt.Page > 0 {
+		params.Set("page", strconv.Itoa(opt.Page))
+	}
+	if opt.PerPage > 0 {
+		params.Set("limit", strconv.Itoa(opt.PerPage))
+	}
+	commitsPath := fmt.Sprintf("/repos/%s/%s/commits", owner, repo)
+	if len(params) > 0 {
+		commitsPath += "?" + params.Encode()
+	}
+	giteaCommits := []*giteaCommit{}
+	err := p.apiRequest(http.MethodGet, commitsPath, nil, &giteaCommits)
+	if err != nil {
+		log.Logger().Errorf("%s", err)
+		return nil, fmt.Errorf("Could not find commits for repository %s/%s", owner, repo)
+	}
+	var commits []*GitCommit
+	for _, commit := range giteaCommits {
+		commits = append(commits, commit.toGitCommit())
+	}
+	return commits, nil
 }
 
 // AddLabelsToIssue adds labels to issues or pullrequests
@@ -834,3 +989,72 @@ func (p *GiteaProvider) ConfigureFeatures(owner string, repo string, issues *boo
 func (p *GiteaProvider) IsWikiEnabled(owner string, repo string) (bool, error) {
 	return false, nil
 }
+
+// giteaContent is the contents of a file returned by the Gitea API
+type giteaContent struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	SHA         string `json:"sha"`
+	Type        string `json:"type"`
+	Size        int    `json:"size"`
+	Encoding    string `json:"encoding"`
+	Content     string `json:"content"`
+	URL         string `json:"url"`
+	HTMLURL     string `json:"html_url"`
+	GitURL      string `json:"git_url"`
+	DownloadURL string `json:"download_url"`
+}
+
+// giteaCommit is a commit returned by the Gitea API
+type giteaCommit struct {
+	SHA     string `json:"sha"`
+	HTMLURL string `json:"html_url"`
+	Commit  *struct {
+		Message   string           `json:"message"`
+		Author    *giteaCommitUser `json:"author"`
+		Committer *giteaCommitUser `json:"committer"`
+	} `json:"commit"`
+	Author    *gitea.User `json:"author"`
+	Committer *gitea.User `json:"committer"`
+}
+
+// giteaCommitUser is the git author or committer of a commit returned by the Gitea API
+type giteaCommitUser struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func (c *giteaCommit) toGitCommit() *GitCommit {
+	commit := &GitCommit{
+		SHA: c.SHA,
+		URL: c.HTMLURL,
+	}
+	if c.Commit != nil {
+		commit.Message = c.Commit.Message
+		commit.Author = c.Commit.Author.toGitUser()
+		commit.Committer = c.Commit.Committer.toGitUser()
+	}
+	if c.Author != nil {
+		commit.Author = toGiteaUser(c.Author)
+	}
+	if c.Committer != nil {
+		commit.Committer = toGiteaUser(c.Committer)
+	}
+	return commit
+}
+
+func (u *giteaCommitUser) toGitUser() *GitUser {
+	if u == nil {
+		return nil
+	}
+	return &GitUser{
+		Name:  u.Name,
+		Email: u.Email,
+	}
+}
+
+// apiRequest sends a request to the Gitea API for the endpoints which are not supported by the Gitea client, decoding
+// the JSON response into result
+func (p *GiteaProvider) apiRequest(method string, path string, body interface{}, result interface{}) error {
+	return util.CallJSON(util.GetClient(), method, strings.TrimSuffix(p.Server.URL, "/")+"/api/v1"+path, "token "+p.User.ApiToken, body, result)
+}
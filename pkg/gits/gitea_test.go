@@ -0,0 +1,185 @@
+package gits_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	giteaUserName = "testperson"
+	giteaOrgName  = "testorg"
+	giteaRepoName = "test-repo"
+)
+
+type GiteaProviderSuite struct {
+	suite.Suite
+	mux         *http.ServeMux
+	server      *httptest.Server
+	provider    *gits.GiteaProvider
+	updatedHook map[string]interface{}
+}
+
+func (suite *GiteaProviderSuite) SetupSuite() {
+	suite.mux = http.NewServeMux()
+	suite.configureGiteaMock()
+	suite.server = httptest.NewServer(suite.mux)
+
+	userAuth := &auth.UserAuth{
+		Username: giteaUserName,
+		ApiToken: "test",
+	}
+	authServer := &auth.AuthServer{
+		URL:   suite.server.URL,
+		Users: []*auth.UserAuth{userAuth},
+	}
+	provider, err := gits.NewGiteaProvider(authServer, userAuth, gits.NewGitCLI())
+	suite.Require().NoError(err)
+	suite.provider = provider.(*gits.GiteaProvider)
+}
+
+func (suite *GiteaProviderSuite) TearDownSuite() {
+	suite.server.Close()
+}
+
+func (suite *GiteaProviderSuite) configureGiteaMock() {
+	repoPath := fmt.Sprintf("/api/v1/repos/%s/%s", giteaOrgName, giteaRepoName)
+	giteaRouter := util.Router{
+		repoPath: util.MethodMap{
+			"PATCH": "repo-renamed.json",
+		},
+		repoPath + "/contents/README.md": util.MethodMap{
+			"GET": "contents.json",
+		},
+		repoPath + "/commits": util.MethodMap{
+			"GET": "commits.json",
+		},
+		repoPath + "/pulls/1": util.MethodMap{
+			"PATCH": "pull-updated.json",
+		},
+		repoPath + "/pulls/1/commits": util.MethodMap{
+			"GET": "pull-commits.json",
+		},
+		repoPath + "/hooks": util.MethodMap{
+			"GET": "hooks.json",
+		},
+	}
+	for path, methodMap := range giteaRouter {
+		suite.mux.HandleFunc(path, util.GetMockAPIResponseFromFile("test_data/gitea", methodMap))
+	}
+
+	suite.mux.HandleFunc(repoPath+"/hooks/3", func(w http.ResponseWriter, r *http.Request) {
+		suite.Require().Equal(http.MethodPatch, r.Method)
+		body, err := ioutil.ReadAll(r.Body)
+		suite.Require().NoError(err)
+		suite.updatedHook = map[string]interface{}{}
+		suite.Require().NoError(json.Unmarshal(body, &suite.updatedHook))
+
+		src, err := ioutil.ReadFile("test_data/gitea/hook-updated.json")
+		suite.Require().NoError(err)
+		w.Write(src)
+	})
+}
+
+func (suite *GiteaProviderSuite) TestGetContent() {
+	content, err := suite.provider.GetContent(giteaOrgName, giteaRepoName, "README.md", "master")
+
+	suite.Require().NoError(err)
+	suite.Require().Equal("README.md", content.Path)
+	suite.Require().Equal("base64", content.Encoding)
+	suite.Require().Equal("IyB0ZXN0LXJlcG8K", content.Content)
+	suite.Require().Equal(14, content.Size)
+	suite.Require().Equal("https://gitea.example.com/testorg/test-repo/raw/branch/master/README.md", content.DownloadUrl)
+}
+
+func (suite *GiteaProviderSuite) TestListCommits() {
+	commits, err := suite.provider.ListCommits(giteaOrgName, giteaRepoName, &gits.ListCommitsArguments{
+		SHA:     "master",
+		Page:    1,
+		PerPage: 10,
+	})
+
+	suite.Require().NoError(err)
+	suite.Require().Len(commits, 2)
+	suite.Require().Equal("c2ab1f3c1ca0b36bd6f0fe9a5b1e1b0a4be1e1b9", commits[0].SHA)
+	suite.Require().Equal("fix: handle empty values\n", commits[0].Message)
+	suite.Require().Equal("testperson", commits[0].Author.Login)
+	suite.Require().Equal("https://gitea.example.com/testorg/test-repo/commit/c2ab1f3c1ca0b36bd6f0fe9a5b1e1b0a4be1e1b9", commits[0].URL)
+
+	// commits by authors without Gitea accounts fall back to the git author
+	suite.Require().Equal("", commits[1].Author.Login)
+	suite.Require().Equal("contributor@example.org", commits[1].Author.Email)
+}
+
+func (suite *GiteaProviderSuite) TestGetPullRequestCommits() {
+	commits, err := suite.provider.GetPullRequestCommits(giteaOrgName, &gits.GitRepository{Name: giteaRepoName}, 1)
+
+	suite.Require().NoError(err)
+	suite.Require().Len(commits, 1)
+	suite.Require().Equal("5d3c8f2e1b0a9c8d7e6f5a4b3c2d1e0f9a8b7c6d", commits[0].SHA)
+	suite.Require().Equal("chore: bump dependencies\n", commits[0].Message)
+}
+
+func (suite *GiteaProviderSuite) TestUpdatePullRequest() {
+	pr, err := suite.provider.UpdatePullRequest(&gits.GitPullRequestArguments{
+		GitRepository: &gits.GitRepository{Name: giteaRepoName, Organisation: giteaOrgName},
+		Title:         "Updated title",
+		Body:          "Updated body",
+	}, 1)
+
+	suite.Require().NoError(err)
+	suite.Require().Equal(1, *pr.Number)
+	suite.Require().Equal("Updated title", pr.Title)
+	suite.Require().Equal("5d3c8f2e1b0a9c8d7e6f5a4b3c2d1e0f9a8b7c6d", pr.LastCommitSha)
+}
+
+func (suite *GiteaProviderSuite) TestListWebHooks() {
+	hooks, err := suite.provider.ListWebHooks(giteaOrgName, giteaRepoName)
+
+	suite.Require().NoError(err)
+	suite.Require().Len(hooks, 2)
+	suite.Require().Equal(int64(3), hooks[0].ID)
+	suite.Require().Equal("http://jenkins.jx.example.com/gitea-webhook/post", hooks[0].URL)
+}
+
+func (suite *GiteaProviderSuite) TestUpdateWebHook() {
+	err := suite.provider.UpdateWebHook(&gits.GitWebHookArguments{
+		Owner:       giteaOrgName,
+		Repo:        &gits.GitRepository{Name: giteaRepoName},
+		URL:         "http://hook.jx.example.com/hook",
+		ExistingURL: "http://jenkins.jx.example.com/gitea-webhook/post",
+		Secret:      "shh",
+	})
+
+	suite.Require().NoError(err)
+	suite.Require().Equal(map[string]interface{}{
+		"url":          "http://hook.jx.example.com/hook",
+		"content_type": "json",
+		"secret":       "shh",
+	}, suite.updatedHook["config"])
+}
+
+func (suite *GiteaProviderSuite) TestRenameRepository() {
+	repo, err := suite.provider.RenameRepository(giteaOrgName, giteaRepoName, "renamed-repo")
+
+	suite.Require().NoError(err)
+	suite.Require().Equal("renamed-repo", repo.Name)
+	suite.Require().Equal(giteaOrgName, repo.Organisation)
+	suite.Require().Equal("https://gitea.example.com/testorg/renamed-repo.git", repo.CloneURL)
+}
+
+func TestGiteaProviderSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping GiteaProviderSuite in short mode")
+	} else {
+		suite.Run(t, new(GiteaProviderSuite))
+	}
+}
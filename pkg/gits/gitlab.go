@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -18,6 +19,9 @@ import (
 	"github.com/xanzy/go-gitlab"
 )
 
+// gitlabPreReleaseMarker prefixes the release notes of tags which are pre-releases
+const gitlabPreReleaseMarker = "**Pre-release**\n\n"
+
 type GitlabProvider struct {
 	Username string
 	Client   *gitlab.Client
@@ -452,12 +456,41 @@ func (g *GitlabProvider) PullRequestLastCommitStatus(pr *GitPullRequest) (string
 
 	for _, result := range c {
 		if result.Status != "" {
+			if result.Status == "success" && pr.Number != nil {
+				approved, err := g.mergeRequestApproved(pid, *pr.Number)
+				if err != nil {
+					log.Logger().Debugf("Unable to get the approvals of merge request %d on %s: %s", *pr.Number, pid, err)
+				} else if !approved {
+					return "pending", nil
+				}
+			}
 			return result.Status, nil
 		}
 	}
 	return "", fmt.Errorf("could not find a status for repository %s with ref %s", pid, ref)
 }
 
+// mergeRequestApprovals is the approval state of a merge request
+type mergeRequestApprovals struct {
+	ApprovalsRequired int `json:"approvals_required"`
+	ApprovalsLeft     int `json:"approvals_left"`
+}
+
+// mergeRequestApproved returns false if the merge request still needs approvals before it can be merged. The
+// approvals API is not available on all GitLab editions, so callers should treat an error as no approvals required.
+func (g *GitlabProvider) mergeRequestApproved(pid string, number int) (bool, error) {
+	req, err := g.Client.NewRequest("GET", fmt.Sprintf("projects/%s/merge_requests/%d/approvals", url.QueryEscape(pid), number), nil, nil)
+	if err != nil {
+		return false, err
+	}
+	approvals := &mergeRequestApprovals{}
+	_, err = g.Client.Do(req, approvals)
+	if err != nil {
+		return false, err
+	}
+	return approvals.ApprovalsLeft <= 0, nil
+}
+
 func (g *GitlabProvider) ListCommitStatus(org string, repo string, sha string) ([]*GitRepoStatus, error) {
 	pid, err := g.projectId(org, g.Username, repo)
 	if err != nil {
@@ -787,8 +820,46 @@ func (g *GitlabProvider) UpdateRelease(owner string, repo string, tag string, re
 	return nil
 }
 
-// UpdateReleaseStatus is not supported for this git provider
+// UpdateReleaseStatus updates the pre-release state of the release for the tag. GitLab has no pre-release flag, so
+// the release notes of pre-releases are prefixed with gitlabPreReleaseMarker.
 func (g *GitlabProvider) UpdateReleaseStatus(owner string, repo string, tag string, releaseInfo *GitRelease) error {
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return err
+	}
+	t, r, err := g.Client.Tags.GetTag(pid, tag)
+	if r != nil && r.StatusCode == 404 && !strings.HasPrefix(tag, "v") {
+		// sometimes we prepend a v for example when using gh-release
+		vtag := "v" + tag
+		t2, r2, err2 := g.Client.Tags.GetTag(pid, vtag)
+		if r2 != nil && r2.StatusCode != 404 {
+			t, r, err, tag = t2, r2, err2, vtag
+		}
+	}
+	if r != nil && r.StatusCode == 404 {
+		log.Logger().Warnf("No release found for %s/%s and tag %s", owner, repo, tag)
+		return nil
+	}
+	if err != nil {
+		return errors2.Wrapf(err, "getting the tag %s of %s/%s", tag, owner, repo)
+	}
+
+	description := ""
+	if t.Release != nil {
+		description = t.Release.Description
+	}
+	updated := strings.TrimPrefix(description, gitlabPreReleaseMarker)
+	if releaseInfo.PreRelease {
+		updated = gitlabPreReleaseMarker + updated
+	}
+	if t.Release == nil {
+		_, _, err = g.Client.Tags.CreateRelease(pid, tag, &gitlab.CreateReleaseOptions{Description: &updated})
+	} else if updated != description {
+		_, _, err = g.Client.Tags.UpdateRelease(pid, tag, &gitlab.UpdateReleaseOptions{Description: &updated})
+	}
+	if err != nil {
+		return errors2.Wrapf(err, "updating the release for tag %s of %s/%s", tag, owner, repo)
+	}
 	return nil
 }
 
@@ -797,9 +868,31 @@ func (g *GitlabProvider) IssueURL(org string, name string, number int, isPull bo
 	return ""
 }
 
-// AddCollaborator adds a collaborator
+// AddCollaborator adds the user as a maintainer of the project, so that it can push to protected branches
 func (g *GitlabProvider) AddCollaborator(user string, organisation string, repo string) error {
-	log.Logger().Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for gitlab. Please add user: %v as a collaborator to this project.", user)
+	pid, err := g.projectId(organisation, g.Username, repo)
+	if err != nil {
+		return err
+	}
+	users, _, err := g.Client.Users.ListUsers(&gitlab.ListUsersOptions{Username: &user})
+	if err != nil {
+		return errors2.Wrapf(err, "finding the user %s", user)
+	}
+	if len(users) == 0 {
+		return fmt.Errorf("no user found with username %s", user)
+	}
+	options := &gitlab.AddProjectMemberOptions{
+		UserID:      &users[0].ID,
+		AccessLevel: gitlab.AccessLevel(gitlab.MaintainerPermissions),
+	}
+	_, r, err := g.Client.ProjectMembers.AddProjectMember(pid, options)
+	if r != nil && r.StatusCode == 409 {
+		log.Logger().Infof("User %s is already a member of %s/%s", user, organisation, repo)
+		return nil
+	}
+	if err != nil {
+		return errors2.Wrapf(err, "adding %s as a member of %s/%s", user, organisation, repo)
+	}
 	return nil
 }
 
@@ -815,9 +908,33 @@ func (g *GitlabProvider) AcceptInvitation(ID int64) (*github.Response, error) {
 	return &github.Response{}, nil
 }
 
-// GetContent returns the content of a file
+// GetContent returns the content of a file, using the default branch of the project if no ref is given
 func (g *GitlabProvider) GetContent(org string, name string, path string, ref string) (*GitFileContent, error) {
-	return nil, fmt.Errorf("Getting content not supported on gitlab")
+	pid, err := g.projectId(org, g.Username, name)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		project, _, err := g.Client.Projects.GetProject(pid)
+		if err != nil {
+			return nil, errors2.Wrapf(err, "getting the default branch of %s/%s", org, name)
+		}
+		ref = project.DefaultBranch
+	}
+	path = strings.TrimPrefix(path, "/")
+	file, _, err := g.Client.RepositoryFiles.GetFile(pid, path, &gitlab.GetFileOptions{Ref: &ref})
+	if err != nil {
+		return nil, errors2.Wrapf(err, "getting the content of %s in %s/%s", path, org, name)
+	}
+	return &GitFileContent{
+		Type:     "file",
+		Encoding: file.Encoding,
+		Size:     file.Size,
+		Name:     file.FileName,
+		Path:     file.FilePath,
+		Content:  file.Content,
+		Sha:      file.BlobID,
+	}, nil
 }
 
 // ShouldForkForPullReques treturns true if we should create a personal fork of this repository
@@ -835,15 +952,106 @@ func GitlabAccessTokenURL(url string) string {
 
 // ListCommits lists the commits for the specified repo and owner
 func (g *GitlabProvider) ListCommits(owner, repo string, opt *ListCommitsArguments) ([]*GitCommit, error) {
-	return nil, fmt.Errorf("Listing commits not supported on gitlab")
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return nil, err
+	}
+	options := &gitlab.ListCommitsOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    opt.Page,
+			PerPage: opt.PerPage,
+		},
+	}
+	if opt.SHA != "" {
+		options.RefName = &opt.SHA
+	}
+	if opt.Path != "" {
+		options.Path = &opt.Path
+	}
+	if !opt.Since.IsZero() {
+		options.Since = &opt.Since
+	}
+	if !opt.Until.IsZero() {
+		options.Until = &opt.Until
+	}
+	commits, _, err := g.Client.Commits.ListCommits(pid, options)
+	if err != nil {
+		return nil, errors2.Wrapf(err, "listing the commits of %s/%s", owner, repo)
+	}
+	var answer []*GitCommit
+	for _, commit := range commits {
+		if commit == nil {
+			continue
+		}
+		answer = append(answer, &GitCommit{
+			SHA:     commit.ID,
+			Message: commit.Message,
+			Author: &GitUser{
+				Name:  commit.AuthorName,
+				Email: commit.AuthorEmail,
+			},
+			Committer: &GitUser{
+				Name:  commit.CommitterName,
+				Email: commit.CommitterEmail,
+			},
+			URL: util.UrlJoin(g.projectURL(owner, repo), "commit", commit.ID),
+		})
+	}
+	return answer, nil
 }
 
-// AddLabelsToIssue adds labels to issues or pullrequests
+// projectURL returns the web URL of the project
+func (g *GitlabProvider) projectURL(owner, repo string) string {
+	serverURL := g.Server.URL
+	if IsGitLabServerURL(serverURL) {
+		serverURL = "https://gitlab.com"
+	}
+	return util.UrlJoin(serverURL, owner, repo)
+}
+
+// AddLabelsToIssue adds labels to merge requests or issues. Merge requests and issues are numbered separately in
+// GitLab, so the labels are added to the merge request with the number if there is one, otherwise to the issue.
 func (g *GitlabProvider) AddLabelsToIssue(owner, repo string, number int, labels []string) error {
-	log.Logger().Warnf("Adding labels not supported on gitlab yet for repo %s/%s issue %d labels %v", owner, repo, number, labels)
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return err
+	}
+	mr, r, err := g.Client.MergeRequests.GetMergeRequest(pid, number)
+	if err == nil {
+		newLabels := addMissingLabels(mr.Labels, labels)
+		_, _, err = g.Client.MergeRequests.UpdateMergeRequest(pid, number, &gitlab.UpdateMergeRequestOptions{Labels: newLabels})
+		if err != nil {
+			return errors2.Wrapf(err, "adding labels %v to merge request %d of %s/%s", labels, number, owner, repo)
+		}
+		return nil
+	}
+	if r == nil || r.StatusCode != 404 {
+		return errors2.Wrapf(err, "getting merge request %d of %s/%s", number, owner, repo)
+	}
+
+	issue, _, err := g.Client.Issues.GetIssue(pid, number)
+	if err != nil {
+		return errors2.Wrapf(err, "getting issue %d of %s/%s", number, owner, repo)
+	}
+	newLabels := addMissingLabels(issue.Labels, labels)
+	_, _, err = g.Client.Issues.UpdateIssue(pid, number, &gitlab.UpdateIssueOptions{Labels: newLabels})
+	if err != nil {
+		return errors2.Wrapf(err, "adding labels %v to issue %d of %s/%s", labels, number, owner, repo)
+	}
 	return nil
 }
 
+// addMissingLabels returns the existing labels with any of the new labels which are not already present appended
+func addMissingLabels(existing []string, labels []string) []string {
+	answer := append([]string{}, existing...)
+	for _, label := range labels {
+		if util.StringArrayIndex(answer, label) < 0 {
+			answer = append(answer, label)
+		}
+	}
+	return answer
+}
+
 // GetLatestRelease fetches the latest release from the git provider for org and name
 func (g *GitlabProvider) GetLatestRelease(org string, name string) (*GitRelease, error) {
 	// TODO
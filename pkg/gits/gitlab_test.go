@@ -21,6 +21,8 @@ const (
 	gitlabProjectName    = "test-project"
 	gitlabProjectID      = "5690870"
 	gitlabMergeRequestID = 12
+	gitlabIssueID        = 7
+	gitlabCommitSHA      = "8888888888888888888888888888888888888888"
 )
 
 type GitlabProviderSuite struct {
@@ -28,6 +30,9 @@ type GitlabProviderSuite struct {
 	mux      *http.ServeMux
 	server   *httptest.Server
 	provider *gits.GitlabProvider
+
+	updatedIssue   string
+	updatedRelease string
 }
 
 func (suite *GitlabProviderSuite) SetupSuite() {
@@ -105,10 +110,54 @@ func configureGitlabMock(suite *GitlabProviderSuite, mux *http.ServeMux) {
 		fmt.Sprintf("/api/v4/projects/%s/merge_requests", gitlabProjectID): util.MethodMap{
 			"POST": "create-merge-request.json",
 		},
+		fmt.Sprintf("/api/v4/projects/%s/merge_requests/%d/approvals", gitlabProjectID, gitlabMergeRequestID): util.MethodMap{
+			"GET": "approvals.json",
+		},
+		fmt.Sprintf("/api/v4/projects/%s/repository/files/README.md", gitlabProjectID): util.MethodMap{
+			"GET": "file.json",
+		},
+		fmt.Sprintf("/api/v4/projects/%s/repository/commits", gitlabProjectID): util.MethodMap{
+			"GET": "commits.json",
+		},
+		fmt.Sprintf("/api/v4/projects/%s/repository/commits/%s/statuses", gitlabProjectID, gitlabCommitSHA): util.MethodMap{
+			"GET": "statuses.json",
+		},
+		fmt.Sprintf("/api/v4/projects/%s/repository/tags/v1.0.0", gitlabProjectID): util.MethodMap{
+			"GET": "tag.json",
+		},
+		fmt.Sprintf("/api/v4/projects/%s/members", gitlabProjectID): util.MethodMap{
+			"POST": "member.json",
+		},
+		"/api/v4/users": util.MethodMap{
+			"GET": "users.json",
+		},
 	}
 	for path, methodMap := range gitlabRouter {
 		mux.HandleFunc(path, util.GetMockAPIResponseFromFile("test_data/gitlab", methodMap))
 	}
+
+	mux.HandleFunc(fmt.Sprintf("/api/v4/projects/%s/issues/%d", gitlabProjectID, gitlabIssueID), func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			body, err := ioutil.ReadAll(r.Body)
+			suite.Require().Nil(err)
+			suite.updatedIssue = string(body)
+		}
+		src, err := ioutil.ReadFile("test_data/gitlab/issue.json")
+
+		suite.Require().Nil(err)
+		w.Write(src)
+	})
+
+	mux.HandleFunc(fmt.Sprintf("/api/v4/projects/%s/repository/tags/v1.0.0/release", gitlabProjectID), func(w http.ResponseWriter, r *http.Request) {
+		suite.Require().Equal(http.MethodPut, r.Method)
+		body, err := ioutil.ReadAll(r.Body)
+		suite.Require().Nil(err)
+		suite.updatedRelease = string(body)
+		src, err := ioutil.ReadFile("test_data/gitlab/release.json")
+
+		suite.Require().Nil(err)
+		w.Write(src)
+	})
 }
 
 func (suite *GitlabProviderSuite) TestListOrganizations() {
@@ -163,7 +212,7 @@ func (suite *GitlabProviderSuite) TestGetRepository() {
 }
 
 func (suite *GitlabProviderSuite) TestAddCollaborator() {
-	err := suite.provider.AddCollaborator("derek", gitlabOrgName, "repo")
+	err := suite.provider.AddCollaborator("derek", gitlabUserName, gitlabProjectName)
 	suite.Require().Nil(err)
 }
 
@@ -227,6 +276,66 @@ func (suite *GitlabProviderSuite) TestUpdatePullRequest() {
 	suite.Require().Equal(pr.Owner, gitlabUserName)
 }
 
+func (suite *GitlabProviderSuite) TestGetContent() {
+	content, err := suite.provider.GetContent(gitlabUserName, gitlabProjectName, "README.md", "master")
+
+	suite.Require().Nil(err)
+	suite.Require().Equal("README.md", content.Path)
+	suite.Require().Equal("base64", content.Encoding)
+	suite.Require().Equal("IyB0ZXN0LXByb2plY3QK", content.Content)
+	suite.Require().Equal("79f7bbd25901e8334750839545a9bd021f0e4c83", content.Sha)
+}
+
+func (suite *GitlabProviderSuite) TestListCommits() {
+	commits, err := suite.provider.ListCommits(gitlabUserName, gitlabProjectName, &gits.ListCommitsArguments{
+		SHA:     "master",
+		Page:    1,
+		PerPage: 10,
+	})
+
+	suite.Require().Nil(err)
+	suite.Require().Len(commits, 2)
+	suite.Require().Equal("ed899a2f4b50b4370feeea94676502b42383c746", commits[0].SHA)
+	suite.Require().Equal("fix: handle empty values\n", commits[0].Message)
+	suite.Require().Equal("testperson@example.com", commits[0].Author.Email)
+	suite.Require().Equal("another@example.com", commits[1].Author.Email)
+	suite.Require().Equal("testperson@example.com", commits[1].Committer.Email)
+}
+
+func (suite *GitlabProviderSuite) TestPullRequestLastCommitStatusWaitsForApprovals() {
+	number := gitlabMergeRequestID
+	status, err := suite.provider.PullRequestLastCommitStatus(&gits.GitPullRequest{
+		Owner:         gitlabUserName,
+		Repo:          gitlabProjectName,
+		Number:        &number,
+		LastCommitSha: gitlabCommitSHA,
+	})
+
+	suite.Require().Nil(err)
+	suite.Require().Equal("pending", status)
+}
+
+func (suite *GitlabProviderSuite) TestAddLabelsToIssue() {
+	err := suite.provider.AddLabelsToIssue(gitlabUserName, gitlabProjectName, gitlabIssueID, []string{"bug", "help wanted"})
+
+	suite.Require().Nil(err)
+	suite.Require().Contains(suite.updatedIssue, "bug")
+	suite.Require().Contains(suite.updatedIssue, "help wanted")
+}
+
+func (suite *GitlabProviderSuite) TestAddLabelsToMergeRequest() {
+	err := suite.provider.AddLabelsToIssue(gitlabUserName, gitlabProjectName, gitlabMergeRequestID, []string{"updatebot"})
+
+	suite.Require().Nil(err)
+}
+
+func (suite *GitlabProviderSuite) TestUpdateReleaseStatus() {
+	err := suite.provider.UpdateReleaseStatus(gitlabUserName, gitlabProjectName, "1.0.0", &gits.GitRelease{PreRelease: true})
+
+	suite.Require().Nil(err)
+	suite.Require().Contains(suite.updatedRelease, `"description":"**Pre-release**\n\nInitial release"`)
+}
+
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
 func TestGitlabProviderSuite(t *testing.T) {
@@ -22,6 +22,8 @@ const (
 	VaultLocationKind SecretsLocationKind = "vault"
 	// KubeLocationKind inidcates that secrets location is in Kuberntes
 	KubeLocationKind SecretsLocationKind = "kube"
+	// SOPSLocationKind indicates that secrets location is SOPS encrypted files in the boot config repository
+	SOPSLocationKind SecretsLocationKind = "sops"
 	// AutoLocationKind indicates that secrets location needs to be dynamically determine
 	AutoLocationKind SecretsLocationKind = "auto"
 )
@@ -58,8 +60,11 @@ func (s *secretLocation) Location() SecretsLocationKind {
 		return s.location
 	}
 	value, ok := configMap[SecretsLocationKey]
-	if ok && value == string(VaultLocationKind) {
-		return VaultLocationKind
+	if ok {
+		switch location := ToSecretsLocation(value); location {
+		case VaultLocationKind, KubeLocationKind, SOPSLocationKind:
+			return location
+		}
 	}
 	return s.location
 }
@@ -96,8 +101,10 @@ func ToSecretsLocation(location string) SecretsLocationKind {
 		return FileSystemLocationKind
 	case "vault":
 		return VaultLocationKind
-	case "kube":
+	case "kube", "kubernetes":
 		return KubeLocationKind
+	case "sops":
+		return SOPSLocationKind
 	default:
 		return AutoLocationKind
 	}
@@ -88,6 +88,22 @@ func TestSecretsLocation_UpdateFromJxInstallConfigMap(t *testing.T) {
 	assert.Equal(t, string(VaultLocationKind), configMap.Data[SecretsLocationKey])
 }
 
+func TestSecretsLocation_KubeAndSOPS(t *testing.T) {
+	t.Parallel()
+
+	kubeClient := createMockCluster()
+	secretLocation := NewSecretLocation(kubeClient, ns)
+
+	for _, location := range []SecretsLocationKind{KubeLocationKind, SOPSLocationKind} {
+		err := secretLocation.SetLocation(location, true)
+		assert.NoError(t, err)
+		assert.Equal(t, location, NewSecretLocation(kubeClient, ns).Location())
+	}
+
+	assert.Equal(t, KubeLocationKind, ToSecretsLocation("kubernetes"))
+	assert.Equal(t, SOPSLocationKind, ToSecretsLocation("sops"))
+}
+
 func createMockCluster() *fake.Clientset {
 	namespace := &v1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
@@ -4,6 +4,8 @@ const (
 	Bugzilla = "bugzilla"
 	Jira     = "jira"
 	Trello   = "trello"
+	YouTrack = "youtrack"
+	Linear   = "linear"
 	Git      = "git"
 )
 
@@ -13,5 +15,5 @@ var (
 )
 
 var (
-	IssueTrackerKinds = []string{Bugzilla, Jira, Trello}
+	IssueTrackerKinds = []string{Bugzilla, Jira, Trello, YouTrack, Linear}
 )
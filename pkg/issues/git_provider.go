@@ -76,3 +76,11 @@ func (i *GitIssueProvider) CreateIssueComment(key string, comment string) error
 func (i *GitIssueProvider) HomeURL() string {
 	return util.UrlJoin(i.GitProvider.ServerURL(), i.Owner, i.Repository)
 }
+
+func (i *GitIssueProvider) TransitionIssue(key string, state string) error {
+	return fmt.Errorf("Cannot transition issue %s to %s as git issues do not have workflow states", key, state)
+}
+
+func (i *GitIssueProvider) AddIssueFixVersion(key string, version string) error {
+	return fmt.Errorf("Cannot add fix version %s to issue %s as git issues do not have fix versions", version, key)
+}
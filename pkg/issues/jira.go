@@ -20,6 +20,8 @@ type JiraService struct {
 	UserAuth   *auth.UserAuth
 	Project    string
 	Git        gits.Gitter
+
+	versions map[string]bool
 }
 
 func CreateJiraIssueProvider(server *auth.AuthServer, userAuth *auth.UserAuth, project string, batchMode bool, git gits.Gitter) (IssueProvider, error) {
@@ -108,7 +110,13 @@ func (i *JiraService) CreateIssue(issue *gits.GitIssue) (*gits.GitIssue, error)
 }
 
 func (i *JiraService) CreateIssueComment(key string, comment string) error {
-	return fmt.Errorf("TODO")
+	_, _, err := i.JiraClient.Issue.AddComment(key, &jira.Comment{
+		Body: comment,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to comment on issue %s: %s", key, err)
+	}
+	return nil
 }
 
 func (i *JiraService) IssueURL(key string) string {
@@ -126,6 +134,11 @@ func (i *JiraService) jiraToGitIssue(issue *jira.Issue) *gits.GitIssue {
 		answer.Body = fields.Description
 		answer.Labels = gits.ToGitLabels(fields.Labels)
 		answer.ClosedAt = jiraTimeToTimeP(fields.Resolutiondate)
+		state := IssueOpen
+		if !time.Time(fields.Resolutiondate).IsZero() {
+			state = IssueClosed
+		}
+		answer.State = &state
 		answer.User = jiraUserToGitUser(fields.Reporter)
 		assignee := jiraUserToGitUser(fields.Assignee)
 		if assignee != nil {
@@ -185,6 +198,11 @@ func (i *JiraService) gitToJiraIssue(issue *gits.GitIssue) *jira.Issue {
 	return answer
 }
 
+// Kind returns the kind of the issue tracker
+func (i *JiraService) Kind() string {
+	return Jira
+}
+
 func (i *JiraService) ServerName() string {
 	return i.Server.URL
 }
@@ -192,3 +210,131 @@ func (i *JiraService) ServerName() string {
 func (i *JiraService) HomeURL() string {
 	return util.UrlJoin(i.Server.URL, "browse", i.Project)
 }
+
+type jiraTransitions struct {
+	Transitions []jiraTransition `json:"transitions"`
+}
+
+type jiraTransition struct {
+	ID   string    `json:"id"`
+	Name string    `json:"name"`
+	To   jiraNamed `json:"to"`
+}
+
+type jiraNamed struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// TransitionIssue performs the transition of the issue into the status with the given name. The transition can also
+// be given by its own name in case the workflow has several transitions into the same status
+func (i *JiraService) TransitionIssue(key string, state string) error {
+	issue, _, err := i.JiraClient.Issue.Get(key, nil)
+	if err != nil {
+		return fmt.Errorf("Could not find issue %s: %s", key, err)
+	}
+	if issue.Fields != nil && issue.Fields.Status != nil && strings.EqualFold(issue.Fields.Status.Name, state) {
+		return nil
+	}
+	transitions := jiraTransitions{}
+	err = i.jiraRequest(http.MethodGet, "rest/api/2/issue/"+key+"/transitions", nil, &transitions)
+	if err != nil {
+		return fmt.Errorf("Failed to find the transitions of issue %s: %s", key, err)
+	}
+	var transition *jiraTransition
+	for k, t := range transitions.Transitions {
+		if strings.EqualFold(t.To.Name, state) || strings.EqualFold(t.Name, state) {
+			transition = &transitions.Transitions[k]
+			break
+		}
+	}
+	if transition == nil {
+		names := []string{}
+		for _, t := range transitions.Transitions {
+			names = append(names, t.To.Name)
+		}
+		return fmt.Errorf("Cannot transition issue %s to %s as the available states are: %s", key, state, strings.Join(names, ", "))
+	}
+	body := map[string]interface{}{
+		"transition": map[string]string{
+			"id": transition.ID,
+		},
+	}
+	err = i.jiraRequest(http.MethodPost, "rest/api/2/issue/"+key+"/transitions", body, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to transition issue %s to %s: %s", key, state, err)
+	}
+	return nil
+}
+
+// AddIssueFixVersion adds the version to the fix versions of the issue creating the version in the project of the
+// issue if it does not exist
+func (i *JiraService) AddIssueFixVersion(key string, version string) error {
+	project := i.Project
+	idx := strings.LastIndex(key, "-")
+	if idx > 0 {
+		project = key[0:idx]
+	}
+	err := i.getOrCreateVersion(project, version)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{
+		"update": map[string]interface{}{
+			"fixVersions": []interface{}{
+				map[string]interface{}{
+					"add": map[string]string{
+						"name": version,
+					},
+				},
+			},
+		},
+	}
+	err = i.jiraRequest(http.MethodPut, "rest/api/2/issue/"+key, body, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to add fix version %s to issue %s: %s", version, key, err)
+	}
+	return nil
+}
+
+// getOrCreateVersion lazily creates the version in the project, remembering the versions which exist so that
+// they are only looked up once per project
+func (i *JiraService) getOrCreateVersion(project string, version string) error {
+	if i.versions == nil {
+		i.versions = map[string]bool{}
+	}
+	versionKey := project + "/" + version
+	if i.versions[versionKey] {
+		return nil
+	}
+	versions := []jiraNamed{}
+	err := i.jiraRequest(http.MethodGet, "rest/api/2/project/"+project+"/versions", nil, &versions)
+	if err != nil {
+		return fmt.Errorf("Failed to find the versions of project %s: %s", project, err)
+	}
+	for _, v := range versions {
+		if v.Name == version {
+			i.versions[versionKey] = true
+			return nil
+		}
+	}
+	body := map[string]string{
+		"name":    version,
+		"project": project,
+	}
+	err = i.jiraRequest(http.MethodPost, "rest/api/2/version", body, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to create version %s in project %s: %s", version, project, err)
+	}
+	i.versions[versionKey] = true
+	return nil
+}
+
+func (i *JiraService) jiraRequest(method string, path string, body interface{}, result interface{}) error {
+	req, err := i.JiraClient.NewRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+	_, err = i.JiraClient.Do(req, result)
+	return err
+}
@@ -0,0 +1,73 @@
+package issues_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/issues"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJiraTransitionsAndFixVersions(t *testing.T) {
+	t.Parallel()
+	requests := map[string]map[string]interface{}{}
+	versionLookups := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := map[string]interface{}{}
+		data, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(data, &request)
+		requests[r.Method+" "+r.URL.Path] = request
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /rest/api/2/issue/ABC-1":
+			w.Write([]byte(`{"key":"ABC-1","fields":{"status":{"name":"In Progress"}}}`))
+		case "GET /rest/api/2/issue/ABC-2":
+			w.Write([]byte(`{"key":"ABC-2","fields":{"status":{"name":"In Staging"}}}`))
+		case "GET /rest/api/2/issue/ABC-1/transitions":
+			w.Write([]byte(`{"transitions":[{"id":"11","name":"Done","to":{"name":"Done"}},{"id":"21","name":"Deploy","to":{"name":"In Staging"}}]}`))
+		case "GET /rest/api/2/project/ABC/versions":
+			versionLookups++
+			w.Write([]byte(`[{"id":"1","name":"1.0.0"}]`))
+		case "POST /rest/api/2/issue/ABC-1/transitions", "POST /rest/api/2/version":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+		case "PUT /rest/api/2/issue/ABC-1", "PUT /rest/api/2/issue/ABC-2":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tracker, err := issues.CreateIssueProvider(issues.Jira, &auth.AuthServer{URL: server.URL}, nil, "ABC", true, nil)
+	require.NoError(t, err)
+
+	err = tracker.TransitionIssue("ABC-1", "in staging")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": "21"}, requests["POST /rest/api/2/issue/ABC-1/transitions"]["transition"])
+
+	// issues already in the state are not transitioned
+	err = tracker.TransitionIssue("ABC-2", "In Staging")
+	require.NoError(t, err)
+
+	err = tracker.TransitionIssue("ABC-1", "Released")
+	assert.Error(t, err)
+
+	err = tracker.AddIssueFixVersion("ABC-1", "1.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", requests["POST /rest/api/2/version"]["name"])
+	assert.Equal(t, "ABC", requests["POST /rest/api/2/version"]["project"])
+	update := requests["PUT /rest/api/2/issue/ABC-1"]["update"].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"add": map[string]interface{}{"name": "1.1.0"}}}, update["fixVersions"])
+
+	// the version is only created once
+	err = tracker.AddIssueFixVersion("ABC-2", "1.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, 1, versionLookups)
+}
@@ -0,0 +1,369 @@
+package issues
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+)
+
+const (
+	// LinearGraphQLURL is the URL of the GraphQL API of Linear
+	LinearGraphQLURL = "https://api.linear.app/graphql"
+
+	linearIssueFields = `identifier title description url createdAt updatedAt completedAt canceledAt
+state { type }
+labels { nodes { name } }
+creator { name displayName email avatarUrl }
+assignee { name displayName email avatarUrl }`
+)
+
+// LinearService is an issue provider for Linear and other trackers with a compatible GraphQL API
+type LinearService struct {
+	Server     *auth.AuthServer
+	UserAuth   *auth.UserAuth
+	Project    string
+	HTTPClient *http.Client
+	// GraphQLURL is the URL of the GraphQL API which defaults to LinearGraphQLURL
+	GraphQLURL string
+}
+
+type linearRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type linearResponse struct {
+	Data   interface{}   `json:"data"`
+	Errors []linearError `json:"errors,omitempty"`
+}
+
+type linearError struct {
+	Message string `json:"message"`
+}
+
+type linearIssue struct {
+	ID          string      `json:"id"`
+	Identifier  string      `json:"identifier"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	URL         string      `json:"url"`
+	CreatedAt   *time.Time  `json:"createdAt"`
+	UpdatedAt   *time.Time  `json:"updatedAt"`
+	CompletedAt *time.Time  `json:"completedAt"`
+	CanceledAt  *time.Time  `json:"canceledAt"`
+	State       *linearNode `json:"state"`
+	Labels      struct {
+		Nodes []linearNode `json:"nodes"`
+	} `json:"labels"`
+	Creator  *linearUser `json:"creator"`
+	Assignee *linearUser `json:"assignee"`
+}
+
+type linearNode struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type linearUser struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Email       string `json:"email"`
+	AvatarURL   string `json:"avatarUrl"`
+}
+
+type linearIssues struct {
+	Nodes []linearIssue `json:"nodes"`
+}
+
+// CreateLinearIssueProvider creates an issue provider for the team of a Linear workspace. The project is the key of
+// the team
+func CreateLinearIssueProvider(server *auth.AuthServer, userAuth *auth.UserAuth, project string, batchMode bool, git gits.Gitter) (IssueProvider, error) {
+	u := server.URL
+	if u == "" {
+		return nil, fmt.Errorf("No base URL for server!")
+	}
+	if project == "" {
+		return nil, fmt.Errorf("No Linear team specified for server %s", u)
+	}
+	if userAuth == nil || userAuth.ApiToken == "" {
+		return nil, fmt.Errorf("No API key found for Linear server %s", u)
+	}
+	if batchMode {
+		log.Logger().Infof("Using Linear server %s and API key %s", u, strings.Repeat("*", len(userAuth.ApiToken)))
+	}
+	return &LinearService{
+		Server:     server,
+		UserAuth:   userAuth,
+		Project:    project,
+		HTTPClient: util.GetClient(),
+		GraphQLURL: LinearGraphQLURL,
+	}, nil
+}
+
+func (i *LinearService) GetIssue(key string) (*gits.GitIssue, error) {
+	data := struct {
+		Issue *linearIssue `json:"issue"`
+	}{}
+	err := i.query(`query($id: String!) { issue(id: $id) { id `+linearIssueFields+` } }`,
+		map[string]interface{}{"id": key}, &data)
+	if err != nil {
+		return nil, err
+	}
+	if data.Issue == nil {
+		return nil, fmt.Errorf("Could not find issue %s", key)
+	}
+	return i.toGitIssue(data.Issue), nil
+}
+
+func (i *LinearService) SearchIssues(query string) ([]*gits.GitIssue, error) {
+	filter := map[string]interface{}{
+		"team":  map[string]interface{}{"key": map[string]interface{}{"eq": i.Project}},
+		"state": map[string]interface{}{"type": map[string]interface{}{"nin": []string{"completed", "canceled"}}},
+	}
+	if query != "" {
+		filter["title"] = map[string]interface{}{"containsIgnoreCase": query}
+	}
+	return i.search(filter)
+}
+
+func (i *LinearService) SearchIssuesClosedSince(t time.Time) ([]*gits.GitIssue, error) {
+	filter := map[string]interface{}{
+		"team":        map[string]interface{}{"key": map[string]interface{}{"eq": i.Project}},
+		"completedAt": map[string]interface{}{"gte": t.UTC().Format(time.RFC3339)},
+	}
+	issues, err := i.search(filter)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*gits.GitIssue{}
+	for _, issue := range issues {
+		if issue.IsClosedSince(t) {
+			answer = append(answer, issue)
+		}
+	}
+	return answer, nil
+}
+
+func (i *LinearService) CreateIssue(issue *gits.GitIssue) (*gits.GitIssue, error) {
+	teams := struct {
+		Teams struct {
+			Nodes []linearNode `json:"nodes"`
+		} `json:"teams"`
+	}{}
+	err := i.query(`query($key: String!) { teams(filter: { key: { eq: $key } }) { nodes { id name } } }`,
+		map[string]interface{}{"key": i.Project}, &teams)
+	if err != nil {
+		return nil, fmt.Errorf("Could not find team %s: %s", i.Project, err)
+	}
+	if len(teams.Teams.Nodes) == 0 {
+		return nil, fmt.Errorf("Could not find team %s", i.Project)
+	}
+	data := struct {
+		IssueCreate struct {
+			Success bool         `json:"success"`
+			Issue   *linearIssue `json:"issue"`
+		} `json:"issueCreate"`
+	}{}
+	input := map[string]interface{}{
+		"teamId":      teams.Teams.Nodes[0].ID,
+		"title":       issue.Title,
+		"description": issue.Body,
+	}
+	err = i.query(`mutation($input: IssueCreateInput!) { issueCreate(input: $input) { success issue { id `+linearIssueFields+` } } }`,
+		map[string]interface{}{"input": input}, &data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create issue: %s", err)
+	}
+	if !data.IssueCreate.Success || data.IssueCreate.Issue == nil {
+		return nil, fmt.Errorf("Failed to create issue in team %s", i.Project)
+	}
+	return i.toGitIssue(data.IssueCreate.Issue), nil
+}
+
+func (i *LinearService) CreateIssueComment(key string, comment string) error {
+	issue := struct {
+		Issue *linearIssue `json:"issue"`
+	}{}
+	err := i.query(`query($id: String!) { issue(id: $id) { id } }`, map[string]interface{}{"id": key}, &issue)
+	if err != nil {
+		return fmt.Errorf("Could not find issue %s: %s", key, err)
+	}
+	if issue.Issue == nil {
+		return fmt.Errorf("Could not find issue %s", key)
+	}
+	data := struct {
+		CommentCreate struct {
+			Success bool `json:"success"`
+		} `json:"commentCreate"`
+	}{}
+	input := map[string]interface{}{
+		"issueId": issue.Issue.ID,
+		"body":    comment,
+	}
+	err = i.query(`mutation($input: CommentCreateInput!) { commentCreate(input: $input) { success } }`,
+		map[string]interface{}{"input": input}, &data)
+	if err != nil {
+		return fmt.Errorf("Failed to comment on issue %s: %s", key, err)
+	}
+	if !data.CommentCreate.Success {
+		return fmt.Errorf("Failed to comment on issue %s", key)
+	}
+	return nil
+}
+
+// TransitionIssue moves the issue into the workflow state of its team with the given name
+func (i *LinearService) TransitionIssue(key string, state string) error {
+	issue := struct {
+		Issue *struct {
+			ID    string      `json:"id"`
+			State *linearNode `json:"state"`
+			Team  *struct {
+				States struct {
+					Nodes []linearNode `json:"nodes"`
+				} `json:"states"`
+			} `json:"team"`
+		} `json:"issue"`
+	}{}
+	err := i.query(`query($id: String!) { issue(id: $id) { id state { id name } team { states { nodes { id name } } } } }`,
+		map[string]interface{}{"id": key}, &issue)
+	if err != nil {
+		return fmt.Errorf("Could not find issue %s: %s", key, err)
+	}
+	if issue.Issue == nil || issue.Issue.Team == nil {
+		return fmt.Errorf("Could not find issue %s", key)
+	}
+	if issue.Issue.State != nil && strings.EqualFold(issue.Issue.State.Name, state) {
+		return nil
+	}
+	stateID := ""
+	names := []string{}
+	for _, s := range issue.Issue.Team.States.Nodes {
+		if strings.EqualFold(s.Name, state) {
+			stateID = s.ID
+			break
+		}
+		names = append(names, s.Name)
+	}
+	if stateID == "" {
+		return fmt.Errorf("Cannot transition issue %s to %s as the available states are: %s", key, state, strings.Join(names, ", "))
+	}
+	data := struct {
+		IssueUpdate struct {
+			Success bool `json:"success"`
+		} `json:"issueUpdate"`
+	}{}
+	err = i.query(`mutation($id: String!, $input: IssueUpdateInput!) { issueUpdate(id: $id, input: $input) { success } }`,
+		map[string]interface{}{"id": issue.Issue.ID, "input": map[string]interface{}{"stateId": stateID}}, &data)
+	if err != nil {
+		return fmt.Errorf("Failed to transition issue %s to %s: %s", key, state, err)
+	}
+	if !data.IssueUpdate.Success {
+		return fmt.Errorf("Failed to transition issue %s to %s", key, state)
+	}
+	return nil
+}
+
+// AddIssueFixVersion is not supported as Linear has no fix versions
+func (i *LinearService) AddIssueFixVersion(key string, version string) error {
+	return fmt.Errorf("Cannot add fix version %s to issue %s as Linear does not have fix versions", version, key)
+}
+
+func (i *LinearService) IssueURL(key string) string {
+	return util.UrlJoin(i.Server.URL, "issue", key)
+}
+
+func (i *LinearService) HomeURL() string {
+	return util.UrlJoin(i.Server.URL, "team", i.Project)
+}
+
+// Kind returns the kind of the issue tracker
+func (i *LinearService) Kind() string {
+	return Linear
+}
+
+func (i *LinearService) search(filter map[string]interface{}) ([]*gits.GitIssue, error) {
+	data := struct {
+		Issues linearIssues `json:"issues"`
+	}{}
+	err := i.query(`query($filter: IssueFilter) { issues(filter: $filter) { nodes { id `+linearIssueFields+` } } }`,
+		map[string]interface{}{"filter": filter}, &data)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*gits.GitIssue{}
+	for k := range data.Issues.Nodes {
+		answer = append(answer, i.toGitIssue(&data.Issues.Nodes[k]))
+	}
+	return answer, nil
+}
+
+// query invokes the GraphQL query and unmarshals its data into the result
+func (i *LinearService) query(query string, variables map[string]interface{}, result interface{}) error {
+	response := &linearResponse{Data: result}
+	err := util.CallJSON(i.HTTPClient, http.MethodPost, i.GraphQLURL, i.UserAuth.ApiToken, &linearRequest{
+		Query:     query,
+		Variables: variables,
+	}, response)
+	if err != nil {
+		return err
+	}
+	if len(response.Errors) > 0 {
+		messages := []string{}
+		for _, e := range response.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("%s", strings.Join(messages, ", "))
+	}
+	return nil
+}
+
+func (i *LinearService) toGitIssue(issue *linearIssue) *gits.GitIssue {
+	state := IssueOpen
+	answer := &gits.GitIssue{
+		Key:       issue.Identifier,
+		URL:       issue.URL,
+		Title:     issue.Title,
+		Body:      issue.Description,
+		CreatedAt: issue.CreatedAt,
+		UpdatedAt: issue.UpdatedAt,
+		User:      linearUserToGitUser(issue.Creator),
+	}
+	if answer.URL == "" {
+		answer.URL = i.IssueURL(issue.Identifier)
+	}
+	if issue.CompletedAt != nil {
+		state = IssueClosed
+		answer.ClosedAt = issue.CompletedAt
+	} else if issue.CanceledAt != nil {
+		state = IssueClosed
+		answer.ClosedAt = issue.CanceledAt
+	}
+	answer.State = &state
+	for _, label := range issue.Labels.Nodes {
+		answer.Labels = append(answer.Labels, gits.GitLabel{Name: label.Name})
+	}
+	assignee := linearUserToGitUser(issue.Assignee)
+	if assignee != nil {
+		answer.Assignees = []gits.GitUser{*assignee}
+	}
+	return answer
+}
+
+func linearUserToGitUser(user *linearUser) *gits.GitUser {
+	if user == nil {
+		return nil
+	}
+	return &gits.GitUser{
+		Login:     user.DisplayName,
+		Name:      user.Name,
+		Email:     user.Email,
+		AvatarURL: user.AvatarURL,
+	}
+}
@@ -0,0 +1,102 @@
+package issues_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/issues"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinearIssueProvider(t *testing.T) {
+	t.Parallel()
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "mykey" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		request := map[string]interface{}{}
+		data, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(data, &request)
+		requests = append(requests, request)
+		query := request["query"].(string)
+		switch {
+		case strings.Contains(query, "issueCreate"):
+			w.Write([]byte(`{"data":{"issueCreate":{"success":true,"issue":{"id":"i2","identifier":"ENG-2","title":"New widget"}}}}`))
+		case strings.Contains(query, "issueUpdate"):
+			w.Write([]byte(`{"data":{"issueUpdate":{"success":true}}}`))
+		case strings.Contains(query, "team { states"):
+			w.Write([]byte(`{"data":{"issue":{"id":"i1","state":{"id":"s1","name":"In Progress"},
+"team":{"states":{"nodes":[{"id":"s1","name":"In Progress"},{"id":"s2","name":"In Staging"}]}}}}}`))
+		case strings.Contains(query, "commentCreate"):
+			w.Write([]byte(`{"data":{"commentCreate":{"success":true}}}`))
+		case strings.Contains(query, "teams("):
+			w.Write([]byte(`{"data":{"teams":{"nodes":[{"id":"t1","name":"Engineering"}]}}}`))
+		case strings.Contains(query, "issues("):
+			w.Write([]byte(`{"data":{"issues":{"nodes":[{"id":"i3","identifier":"ENG-3","title":"Widget is slow","state":{"type":"started"}}]}}}`))
+		case strings.Contains(query, "issue("):
+			variables := request["variables"].(map[string]interface{})
+			if variables["id"] != "ENG-1" {
+				w.Write([]byte(`{"data":{"issue":null},"errors":[{"message":"Entity not found"}]}`))
+				return
+			}
+			w.Write([]byte(`{"data":{"issue":{"id":"i1","identifier":"ENG-1","title":"Fix the widget","url":"https://linear.app/myorg/issue/ENG-1",
+"createdAt":"2019-01-01T00:00:00Z","completedAt":"2019-01-02T00:00:00Z","state":{"type":"completed"},
+"labels":{"nodes":[{"name":"bug"}]},"creator":{"name":"Alice","displayName":"alice"}}}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	tracker, err := issues.CreateIssueProvider(issues.Linear, &auth.AuthServer{URL: "https://linear.app/myorg"}, &auth.UserAuth{ApiToken: "mykey"}, "ENG", true, nil)
+	require.NoError(t, err)
+	tracker.(*issues.LinearService).GraphQLURL = server.URL
+	assert.Equal(t, issues.Linear, issues.GetIssueProvider(tracker))
+
+	issue, err := tracker.GetIssue("ENG-1")
+	require.NoError(t, err)
+	assert.Equal(t, "ENG-1", issue.Key)
+	assert.Equal(t, "https://linear.app/myorg/issue/ENG-1", issue.URL)
+	assert.Equal(t, issues.IssueClosed, *issue.State)
+	assert.Equal(t, "alice", issue.User.Login)
+	assert.Equal(t, []gits.GitLabel{{Name: "bug"}}, issue.Labels)
+
+	found, err := tracker.SearchIssues("widget")
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, issues.IssueOpen, *found[0].State)
+	assert.Equal(t, "https://linear.app/myorg/issue/ENG-3", found[0].URL)
+
+	issue, err = tracker.CreateIssue(&gits.GitIssue{Title: "New widget", Body: "Please"})
+	require.NoError(t, err)
+	assert.Equal(t, "ENG-2", issue.Key)
+	input := requests[len(requests)-1]["variables"].(map[string]interface{})["input"].(map[string]interface{})
+	assert.Equal(t, "t1", input["teamId"])
+
+	err = tracker.CreateIssueComment("ENG-1", "deployed to staging")
+	require.NoError(t, err)
+	input = requests[len(requests)-1]["variables"].(map[string]interface{})["input"].(map[string]interface{})
+	assert.Equal(t, "i1", input["issueId"])
+	assert.Equal(t, "deployed to staging", input["body"])
+
+	err = tracker.TransitionIssue("ENG-1", "in staging")
+	require.NoError(t, err)
+	variables := requests[len(requests)-1]["variables"].(map[string]interface{})
+	assert.Equal(t, "i1", variables["id"])
+	assert.Equal(t, map[string]interface{}{"stateId": "s2"}, variables["input"])
+
+	err = tracker.TransitionIssue("ENG-1", "Released")
+	assert.Error(t, err)
+
+	_, err = tracker.GetIssue("ENG-404")
+	assert.Error(t, err)
+}
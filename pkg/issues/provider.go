@@ -8,6 +8,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/gits"
 )
 
+// IssueProvider represents an issue tracker
 type IssueProvider interface {
 	// GetIssue returns the issue of the given key
 	GetIssue(key string) (*gits.GitIssue, error)
@@ -29,32 +30,43 @@ type IssueProvider interface {
 
 	// HomeURL returns the home URL of the issue tracker
 	HomeURL() string
+
+	// TransitionIssue moves the given issue through the workflow of the issue tracker into the state of the given name
+	TransitionIssue(key string, state string) error
+
+	// AddIssueFixVersion adds the version to the versions which fix the given issue
+	AddIssueFixVersion(key string, version string) error
+}
+
+// KindProvider is implemented by issue providers which are not the issues of the git provider to return their kind
+type KindProvider interface {
+	// Kind returns the kind of the issue tracker
+	Kind() string
 }
 
+// CreateIssueProvider creates an issue provider for the project using the registered kind of issue tracker
 func CreateIssueProvider(kind string, server *auth.AuthServer, userAuth *auth.UserAuth, project string, batchMode bool, git gits.Gitter) (IssueProvider, error) {
-	switch kind {
-	case Jira:
-		return CreateJiraIssueProvider(server, userAuth, project, batchMode, git)
-	default:
+	trackerKind := FindIssueTrackerKind(kind)
+	if trackerKind == nil || trackerKind.Create == nil {
 		return nil, fmt.Errorf("Unsupported issue provider kind: %s", kind)
 	}
+	return trackerKind.Create(server, userAuth, project, batchMode, git)
 }
 
+// ProviderAccessTokenURL returns the URL to create an API token for the kind of issue tracker at the URL
 func ProviderAccessTokenURL(kind string, url string) string {
-	switch kind {
-	case Jira:
-		// TODO handle on premise servers too by detecting the URL is at atlassian.com
-		return "https://id.atlassian.com/manage/api-tokens"
-	default:
+	trackerKind := FindIssueTrackerKind(kind)
+	if trackerKind == nil || trackerKind.AccessTokenURL == nil {
 		return ""
 	}
+	return trackerKind.AccessTokenURL(url)
 }
 
 // GetIssueProvider returns the kind of issue provider
 func GetIssueProvider(tracker IssueProvider) string {
-	_, ok := tracker.(*JiraService)
+	kinded, ok := tracker.(KindProvider)
 	if ok {
-		return Jira
+		return kinded.Kind()
 	}
 	return Git
 }
@@ -0,0 +1,144 @@
+package issues
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// GitIssueKeyPattern matches the numbers of the issues of a git provider such as '#123'
+	GitIssueKeyPattern = `#(\d+)\b`
+
+	// ProjectIssueKeyPattern matches the keys of issues which are prefixed by the key of their project such as 'ABC-123'
+	ProjectIssueKeyPattern = `\b([A-Z][A-Z0-9]+-\d+)\b`
+)
+
+// IssueProviderFactory creates an IssueProvider for the project of an issue tracker
+type IssueProviderFactory func(server *auth.AuthServer, userAuth *auth.UserAuth, project string, batchMode bool, git gits.Gitter) (IssueProvider, error)
+
+// IssueTrackerKind is a kind of issue tracker which can be created by CreateIssueProvider
+type IssueTrackerKind struct {
+	// Kind is the kind used in the 'issueTracker' section of 'jenkins-x.yml'
+	Kind string
+	// Create creates the IssueProvider
+	Create IssueProviderFactory
+	// AccessTokenURL returns the URL to create an API token for the issue tracker at the given URL
+	AccessTokenURL func(url string) string
+	// IssueKeyPattern is the regular expression which finds the keys of issues in commit messages. If it has a
+	// capture group the group is the key
+	IssueKeyPattern string
+}
+
+var (
+	issueTrackerKindsLock sync.RWMutex
+	issueTrackerKinds     = []*IssueTrackerKind{
+		{
+			Kind:   Jira,
+			Create: CreateJiraIssueProvider,
+			AccessTokenURL: func(url string) string {
+				// TODO handle on premise servers too by detecting the URL is at atlassian.com
+				return "https://id.atlassian.com/manage/api-tokens"
+			},
+			IssueKeyPattern: ProjectIssueKeyPattern,
+		},
+		{
+			Kind:   YouTrack,
+			Create: CreateYouTrackIssueProvider,
+			AccessTokenURL: func(url string) string {
+				return util.UrlJoin(url, "users/me?tab=account-security")
+			},
+			IssueKeyPattern: ProjectIssueKeyPattern,
+		},
+		{
+			Kind:   Linear,
+			Create: CreateLinearIssueProvider,
+			AccessTokenURL: func(url string) string {
+				return "https://linear.app/settings/api"
+			},
+			IssueKeyPattern: ProjectIssueKeyPattern,
+		},
+	}
+)
+
+// RegisterIssueTrackerKind registers a kind of issue tracker replacing any existing registration of the same kind
+func RegisterIssueTrackerKind(kind *IssueTrackerKind) {
+	issueTrackerKindsLock.Lock()
+	defer issueTrackerKindsLock.Unlock()
+
+	for i, k := range issueTrackerKinds {
+		if k.Kind == kind.Kind {
+			issueTrackerKinds[i] = kind
+			return
+		}
+	}
+	issueTrackerKinds = append(issueTrackerKinds, kind)
+}
+
+// FindIssueTrackerKind returns the registered kind of issue tracker or nil if it is not registered
+func FindIssueTrackerKind(kind string) *IssueTrackerKind {
+	issueTrackerKindsLock.RLock()
+	defer issueTrackerKindsLock.RUnlock()
+
+	for _, k := range issueTrackerKinds {
+		if k.Kind == kind {
+			return k
+		}
+	}
+	return nil
+}
+
+// RegisteredIssueTrackerKinds returns the sorted names of the registered kinds of issue tracker
+func RegisteredIssueTrackerKinds() []string {
+	issueTrackerKindsLock.RLock()
+	defer issueTrackerKindsLock.RUnlock()
+
+	answer := []string{}
+	for _, k := range issueTrackerKinds {
+		answer = append(answer, k.Kind)
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// IssueKeyRegex returns the regular expression which finds the keys of the issues of the tracker in commit messages.
+// The pattern is used if it is not empty, otherwise the pattern of the kind of the tracker
+func IssueKeyRegex(tracker IssueProvider, pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		pattern = GitIssueKeyPattern
+		if tracker != nil {
+			trackerKind := FindIssueTrackerKind(GetIssueProvider(tracker))
+			if trackerKind != nil && trackerKind.IssueKeyPattern != "" {
+				pattern = trackerKind.IssueKeyPattern
+			}
+		}
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid issue key pattern %s", pattern)
+	}
+	return regex, nil
+}
+
+// FindIssueKeys returns the unique keys of the issues found by the regular expression in the text in the order they
+// are found
+func FindIssueKeys(regex *regexp.Regexp, text string) []string {
+	answer := []string{}
+	for _, match := range regex.FindAllStringSubmatch(text, -1) {
+		key := match[0]
+		if len(match) > 1 {
+			key = match[1]
+		}
+		key = strings.TrimPrefix(key, "#")
+		if key != "" && util.StringArrayIndex(answer, key) < 0 {
+			answer = append(answer, key)
+		}
+	}
+	return answer
+}
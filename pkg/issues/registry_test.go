@@ -0,0 +1,78 @@
+package issues_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/issues"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindIssueKeys(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		tracker  issues.IssueProvider
+		pattern  string
+		message  string
+		expected []string
+	}{
+		{
+			name:     "git",
+			message:  "fix: the widget #12 (#34)\n\ncloses #12",
+			expected: []string{"12", "34"},
+		},
+		{
+			name:     "jira",
+			tracker:  &issues.JiraService{},
+			message:  "fix: ABC-123 the widget\n\nsee also ABC-123 and XY2-7 but not abc-1",
+			expected: []string{"ABC-123", "XY2-7"},
+		},
+		{
+			name:     "linear",
+			tracker:  &issues.LinearService{},
+			message:  "ENG-42: fix the widget (#99)",
+			expected: []string{"ENG-42"},
+		},
+		{
+			name:     "custom pattern",
+			tracker:  &issues.YouTrackService{},
+			pattern:  `\[(WID-\d+)\]`,
+			message:  "[WID-5] fix the widget ABC-123",
+			expected: []string{"WID-5"},
+		},
+	}
+	for _, tc := range testCases {
+		regex, err := issues.IssueKeyRegex(tc.tracker, tc.pattern)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, issues.FindIssueKeys(regex, tc.message), tc.name)
+	}
+
+	_, err := issues.IssueKeyRegex(nil, "(")
+	assert.Error(t, err)
+}
+
+func TestRegisterIssueTrackerKind(t *testing.T) {
+	t.Parallel()
+	issues.RegisterIssueTrackerKind(&issues.IssueTrackerKind{
+		Kind: "test-tracker",
+		Create: func(server *auth.AuthServer, userAuth *auth.UserAuth, project string, batchMode bool, git gits.Gitter) (issues.IssueProvider, error) {
+			return &issues.YouTrackService{Server: server, Project: project}, nil
+		},
+		AccessTokenURL: func(url string) string {
+			return url + "/tokens"
+		},
+	})
+
+	assert.Contains(t, issues.RegisteredIssueTrackerKinds(), "test-tracker")
+	assert.Equal(t, "https://tracker.example.com/tokens", issues.ProviderAccessTokenURL("test-tracker", "https://tracker.example.com"))
+
+	tracker, err := issues.CreateIssueProvider("test-tracker", &auth.AuthServer{URL: "https://tracker.example.com"}, nil, "WID", true, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://tracker.example.com/issue/WID-1", tracker.IssueURL("WID-1"))
+
+	_, err = issues.CreateIssueProvider("does-not-exist", &auth.AuthServer{URL: "https://tracker.example.com"}, nil, "WID", true, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,251 @@
+package issues
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+)
+
+const (
+	youTrackIssueFields = "idReadable,summary,description,created,updated,resolved," +
+		"reporter(login,fullName,email,avatarUrl),tags(name)"
+	youTrackDateFormat = "2006-01-02"
+)
+
+// YouTrackService is an issue provider for JetBrains YouTrack using its REST API and a permanent token
+type YouTrackService struct {
+	Server     *auth.AuthServer
+	UserAuth   *auth.UserAuth
+	Project    string
+	HTTPClient *http.Client
+}
+
+type youTrackIssue struct {
+	ID          string           `json:"id,omitempty"`
+	IDReadable  string           `json:"idReadable,omitempty"`
+	Summary     string           `json:"summary,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Created     int64            `json:"created,omitempty"`
+	Updated     int64            `json:"updated,omitempty"`
+	Resolved    *int64           `json:"resolved,omitempty"`
+	Reporter    *youTrackUser    `json:"reporter,omitempty"`
+	Tags        []youTrackTag    `json:"tags,omitempty"`
+	Project     *youTrackProject `json:"project,omitempty"`
+}
+
+type youTrackUser struct {
+	Login     string `json:"login"`
+	FullName  string `json:"fullName"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatarUrl"`
+}
+
+type youTrackTag struct {
+	Name string `json:"name"`
+}
+
+type youTrackProject struct {
+	ID        string `json:"id,omitempty"`
+	ShortName string `json:"shortName,omitempty"`
+}
+
+type youTrackComment struct {
+	Text string `json:"text"`
+}
+
+type youTrackCommand struct {
+	Query  string          `json:"query"`
+	Issues []youTrackIssue `json:"issues"`
+}
+
+// CreateYouTrackIssueProvider creates an issue provider for the YouTrack project
+func CreateYouTrackIssueProvider(server *auth.AuthServer, userAuth *auth.UserAuth, project string, batchMode bool, git gits.Gitter) (IssueProvider, error) {
+	u := server.URL
+	if u == "" {
+		return nil, fmt.Errorf("No base URL for server!")
+	}
+	if project == "" {
+		return nil, fmt.Errorf("No YouTrack project specified for server %s", u)
+	}
+	if userAuth == nil || userAuth.ApiToken == "" {
+		if batchMode {
+			log.Logger().Warnf("No authentication found for YouTrack server %s so using anonymous access", u)
+		}
+		userAuth = &auth.UserAuth{}
+	}
+	return &YouTrackService{
+		Server:     server,
+		UserAuth:   userAuth,
+		Project:    project,
+		HTTPClient: util.GetClient(),
+	}, nil
+}
+
+func (i *YouTrackService) GetIssue(key string) (*gits.GitIssue, error) {
+	issue := &youTrackIssue{}
+	err := i.do(http.MethodGet, "issues/"+url.PathEscape(key)+"?fields="+youTrackIssueFields, nil, issue)
+	if err != nil {
+		return nil, err
+	}
+	return i.toGitIssue(issue), nil
+}
+
+func (i *YouTrackService) SearchIssues(query string) ([]*gits.GitIssue, error) {
+	q := fmt.Sprintf("project: {%s} #Unresolved", i.Project)
+	if query != "" {
+		q += " " + query
+	}
+	return i.search(q)
+}
+
+func (i *YouTrackService) SearchIssuesClosedSince(t time.Time) ([]*gits.GitIssue, error) {
+	q := fmt.Sprintf("project: {%s} resolved date: %s .. Today", i.Project, t.Format(youTrackDateFormat))
+	issues, err := i.search(q)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*gits.GitIssue{}
+	for _, issue := range issues {
+		if issue.IsClosedSince(t) {
+			answer = append(answer, issue)
+		}
+	}
+	return answer, nil
+}
+
+func (i *YouTrackService) CreateIssue(issue *gits.GitIssue) (*gits.GitIssue, error) {
+	projects := []youTrackProject{}
+	err := i.do(http.MethodGet, "admin/projects?fields=id,shortName&query="+url.QueryEscape(i.Project), nil, &projects)
+	if err != nil {
+		return nil, fmt.Errorf("Could not find project %s: %s", i.Project, err)
+	}
+	var project *youTrackProject
+	for k := range projects {
+		if strings.EqualFold(projects[k].ShortName, i.Project) {
+			project = &projects[k]
+			break
+		}
+	}
+	if project == nil {
+		return nil, fmt.Errorf("Could not find project %s", i.Project)
+	}
+	body := &youTrackIssue{
+		Project:     &youTrackProject{ID: project.ID},
+		Summary:     issue.Title,
+		Description: issue.Body,
+	}
+	created := &youTrackIssue{}
+	err = i.do(http.MethodPost, "issues?fields="+youTrackIssueFields, body, created)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create issue: %s", err)
+	}
+	return i.toGitIssue(created), nil
+}
+
+func (i *YouTrackService) CreateIssueComment(key string, comment string) error {
+	err := i.do(http.MethodPost, "issues/"+url.PathEscape(key)+"/comments", &youTrackComment{Text: comment}, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to comment on issue %s: %s", key, err)
+	}
+	return nil
+}
+
+// TransitionIssue sets the State field of the issue using a command
+func (i *YouTrackService) TransitionIssue(key string, state string) error {
+	err := i.command(key, fmt.Sprintf("State {%s}", state))
+	if err != nil {
+		return fmt.Errorf("Failed to transition issue %s to %s: %s", key, state, err)
+	}
+	return nil
+}
+
+// AddIssueFixVersion adds the version to the Fix versions field of the issue using a command. The version must
+// already exist in the versions of the project
+func (i *YouTrackService) AddIssueFixVersion(key string, version string) error {
+	err := i.command(key, fmt.Sprintf("add Fix versions {%s}", version))
+	if err != nil {
+		return fmt.Errorf("Failed to add fix version %s to issue %s: %s", version, key, err)
+	}
+	return nil
+}
+
+func (i *YouTrackService) IssueURL(key string) string {
+	return util.UrlJoin(i.Server.URL, "issue", key)
+}
+
+func (i *YouTrackService) HomeURL() string {
+	return util.UrlJoin(i.Server.URL, "issues", i.Project)
+}
+
+// Kind returns the kind of the issue tracker
+func (i *YouTrackService) Kind() string {
+	return YouTrack
+}
+
+func (i *YouTrackService) search(query string) ([]*gits.GitIssue, error) {
+	issues := []youTrackIssue{}
+	err := i.do(http.MethodGet, "issues?fields="+youTrackIssueFields+"&query="+url.QueryEscape(query), nil, &issues)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*gits.GitIssue{}
+	for k := range issues {
+		answer = append(answer, i.toGitIssue(&issues[k]))
+	}
+	return answer, nil
+}
+
+func (i *YouTrackService) command(key string, query string) error {
+	return i.do(http.MethodPost, "commands", &youTrackCommand{
+		Query:  query,
+		Issues: []youTrackIssue{{IDReadable: key}},
+	}, nil)
+}
+
+func (i *YouTrackService) do(method string, path string, body interface{}, result interface{}) error {
+	return util.CallJSON(i.HTTPClient, method, util.UrlJoin(i.Server.URL, "api", path), util.BearerAuthorization(i.UserAuth.ApiToken), body, result)
+}
+
+func (i *YouTrackService) toGitIssue(issue *youTrackIssue) *gits.GitIssue {
+	state := IssueOpen
+	answer := &gits.GitIssue{
+		Key:       issue.IDReadable,
+		URL:       i.IssueURL(issue.IDReadable),
+		Title:     issue.Summary,
+		Body:      issue.Description,
+		CreatedAt: millisToTimeP(issue.Created),
+		UpdatedAt: millisToTimeP(issue.Updated),
+	}
+	if issue.Resolved != nil {
+		state = IssueClosed
+		answer.ClosedAt = millisToTimeP(*issue.Resolved)
+	}
+	answer.State = &state
+	for _, tag := range issue.Tags {
+		answer.Labels = append(answer.Labels, gits.GitLabel{Name: tag.Name})
+	}
+	if issue.Reporter != nil {
+		answer.User = &gits.GitUser{
+			Login:     issue.Reporter.Login,
+			Name:      issue.Reporter.FullName,
+			Email:     issue.Reporter.Email,
+			AvatarURL: issue.Reporter.AvatarURL,
+		}
+	}
+	return answer
+}
+
+func millisToTimeP(millis int64) *time.Time {
+	if millis == 0 {
+		return nil
+	}
+	t := time.Unix(0, millis*int64(time.Millisecond))
+	return &t
+}
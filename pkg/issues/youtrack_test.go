@@ -0,0 +1,97 @@
+package issues_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/issues"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYouTrackIssueProvider(t *testing.T) {
+	t.Parallel()
+	var comment map[string]interface{}
+	var created map[string]interface{}
+	var commands []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer mytoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/issues/WID-1":
+			w.Write([]byte(`{"idReadable":"WID-1","summary":"Fix the widget","created":1546300800000,"resolved":1546387200000,
+"reporter":{"login":"alice","fullName":"Alice","email":"alice@example.com"},"tags":[{"name":"bug"}]}`))
+		case "GET /api/issues":
+			assert.Equal(t, "project: {WID} #Unresolved widget", r.URL.Query().Get("query"))
+			w.Write([]byte(`[{"idReadable":"WID-2","summary":"Widget is slow"}]`))
+		case "GET /api/admin/projects":
+			w.Write([]byte(`[{"id":"0-1","shortName":"OTHER"},{"id":"0-2","shortName":"WID"}]`))
+		case "POST /api/issues":
+			data, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(data, &created)
+			w.Write([]byte(`{"idReadable":"WID-3","summary":"New widget"}`))
+		case "POST /api/commands":
+			command := map[string]interface{}{}
+			data, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(data, &command)
+			commands = append(commands, command)
+			w.Write([]byte(`{}`))
+		case "POST /api/issues/WID-1/comments":
+			data, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(data, &comment)
+			w.Write([]byte(`{"id":"4-1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tracker, err := issues.CreateIssueProvider(issues.YouTrack, &auth.AuthServer{URL: server.URL}, &auth.UserAuth{ApiToken: "mytoken"}, "WID", true, nil)
+	require.NoError(t, err)
+	assert.Equal(t, issues.YouTrack, issues.GetIssueProvider(tracker))
+
+	issue, err := tracker.GetIssue("WID-1")
+	require.NoError(t, err)
+	assert.Equal(t, "WID-1", issue.Key)
+	assert.Equal(t, server.URL+"/issue/WID-1", issue.URL)
+	assert.Equal(t, "Fix the widget", issue.Title)
+	assert.Equal(t, issues.IssueClosed, *issue.State)
+	assert.Equal(t, "alice", issue.User.Login)
+	assert.Equal(t, []gits.GitLabel{{Name: "bug"}}, issue.Labels)
+	assert.Equal(t, time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC), issue.ClosedAt.UTC())
+
+	found, err := tracker.SearchIssues("widget")
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, issues.IssueOpen, *found[0].State)
+
+	issue, err = tracker.CreateIssue(&gits.GitIssue{Title: "New widget", Body: "Please"})
+	require.NoError(t, err)
+	assert.Equal(t, "WID-3", issue.Key)
+	assert.Equal(t, map[string]interface{}{"id": "0-2"}, created["project"])
+	assert.Equal(t, "New widget", created["summary"])
+
+	err = tracker.CreateIssueComment("WID-1", "deployed to staging")
+	require.NoError(t, err)
+	assert.Equal(t, "deployed to staging", comment["text"])
+
+	err = tracker.TransitionIssue("WID-1", "In Staging")
+	require.NoError(t, err)
+	err = tracker.AddIssueFixVersion("WID-1", "1.2.3")
+	require.NoError(t, err)
+	require.Len(t, commands, 2)
+	assert.Equal(t, "State {In Staging}", commands[0]["query"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"idReadable": "WID-1"}}, commands[0]["issues"])
+	assert.Equal(t, "add Fix versions {1.2.3}", commands[1]["query"])
+
+	_, err = tracker.GetIssue("WID-404")
+	assert.Error(t, err)
+}
@@ -16,6 +16,16 @@ import (
 
 // InitBuildPack initialises the build pack URL and git ref returning the packs dir or an error
 func InitBuildPack(gitter gits.Gitter, packURL string, packRef string) (string, error) {
+	dir, err := initGitRepository(gitter, packURL, packRef)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "packs"), nil
+}
+
+// initGitRepository clones or pulls the git repository into the draft dir and checks out the given git ref, returning
+// the dir of the clone
+func initGitRepository(gitter gits.Gitter, packURL string, packRef string) (string, error) {
 	u, err := url.Parse(strings.TrimSuffix(packURL, ".git"))
 	if err != nil {
 		return "", fmt.Errorf("Failed to parse build pack URL: %s: %s", packURL, err)
@@ -76,7 +86,7 @@ func InitBuildPack(gitter gits.Gitter, packURL string, packRef string) (string,
 		}
 
 	}
-	return filepath.Join(dir, "packs"), nil
+	return dir, nil
 }
 
 func ensureBranchTracksOrigin(dir string, packRef string, gitter gits.Gitter) error {
@@ -0,0 +1,40 @@
+package gitresolver
+
+import (
+	"path/filepath"
+
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/versionstream"
+	"github.com/pkg/errors"
+)
+
+// CreateTemplateResolver creates a resolver for the template files of a project. Files without a git URL are resolved
+// relative to the project dir, while files in git repositories are cloned at the git ref of the template source, or
+// at the version of the git repository in the version stream if no git ref is given.
+func CreateTemplateResolver(gitter gits.Gitter, versionResolver *versionstream.VersionResolver, projectDir string) config.TemplateFileResolver {
+	return func(source *config.TemplateSource) (string, string, error) {
+		if source.GitURL == "" {
+			if filepath.IsAbs(source.File) {
+				return source.File, "", nil
+			}
+			return filepath.Join(projectDir, source.File), "", nil
+		}
+		gitRef := source.GitRef
+		if gitRef == "" && versionResolver != nil {
+			var err error
+			gitRef, err = versionResolver.ResolveGitVersion(source.GitURL)
+			if err != nil {
+				return "", "", errors.Wrapf(err, "failed to resolve the version of %s in the version stream", source.GitURL)
+			}
+		}
+		if gitRef == "" {
+			return "", "", errors.Errorf("no gitRef is specified for the templates in %s and there is no version for it in the version stream", source.GitURL)
+		}
+		dir, err := initGitRepository(gitter, source.GitURL, gitRef)
+		if err != nil {
+			return "", "", err
+		}
+		return filepath.Join(dir, source.File), gitRef, nil
+	}
+}
@@ -487,6 +487,16 @@ func LoadPipelineConfig(fileName string, resolver ImportFileResolver, jenkinsfil
 
 // LoadPipelineConfigAndMaybeValidate returns the pipeline configuration, optionally after validating the YAML.
 func LoadPipelineConfigAndMaybeValidate(fileName string, resolver ImportFileResolver, jenkinsfileRunner bool, clearContainer bool, skipYamlValidation bool) (*PipelineConfig, error) {
+	return loadPipelineConfig(fileName, resolver, jenkinsfileRunner, clearContainer, skipYamlValidation, false)
+}
+
+// LoadPipelineConfigWithProvenance returns the pipeline configuration with the source of each step set to the file,
+// and override if any, it was defined in, including the files it extends.
+func LoadPipelineConfigWithProvenance(fileName string, resolver ImportFileResolver, jenkinsfileRunner bool, clearContainer bool) (*PipelineConfig, error) {
+	return loadPipelineConfig(fileName, resolver, jenkinsfileRunner, clearContainer, true, true)
+}
+
+func loadPipelineConfig(fileName string, resolver ImportFileResolver, jenkinsfileRunner bool, clearContainer bool, skipYamlValidation bool, trackSources bool) (*PipelineConfig, error) {
 	config := PipelineConfig{}
 	exists, err := util.FileExists(fileName)
 	if err != nil || !exists {
@@ -509,6 +519,9 @@ func LoadPipelineConfigAndMaybeValidate(fileName string, resolver ImportFileReso
 	if err != nil {
 		return &config, errors.Wrapf(err, "Failed to unmarshal file %s", fileName)
 	}
+	if trackSources {
+		config.SetStepSources(fileName)
+	}
 	pipelines := &config.Pipelines
 	pipelines.RemoveWhenStatements(jenkinsfileRunner)
 	if clearContainer {
@@ -541,7 +554,7 @@ func LoadPipelineConfigAndMaybeValidate(fileName string, resolver ImportFileReso
 	if !exists {
 		return &config, fmt.Errorf("base pipeline file does not exist %s", file)
 	}
-	basePipeline, err := LoadPipelineConfig(file, resolver, jenkinsfileRunner, clearContainer)
+	basePipeline, err := loadPipelineConfig(file, resolver, jenkinsfileRunner, clearContainer, true, trackSources)
 	if err != nil {
 		return &config, errors.Wrapf(err, "Failed to base pipeline file %s", file)
 	}
@@ -549,6 +562,33 @@ func LoadPipelineConfigAndMaybeValidate(fileName string, resolver ImportFileReso
 	return &config, err
 }
 
+// SetStepSources sets the source of each step in the pipeline configuration, including those in overrides, which does
+// not already have one
+func (c *PipelineConfig) SetStepSources(source string) {
+	pipelines := &c.Pipelines
+	for _, l := range pipelines.All() {
+		if l == nil {
+			continue
+		}
+		for _, n := range l.All() {
+			n.Lifecycle.setStepSources(source)
+		}
+		l.Pipeline.SetStepSources(source)
+	}
+	pipelines.Post.setStepSources(source)
+	pipelines.Default.SetStepSources(source)
+	for _, override := range pipelines.Overrides {
+		override.SetSources(source)
+	}
+}
+
+func (l *PipelineLifecycle) setStepSources(source string) {
+	if l != nil {
+		syntax.SetStepSources(l.PreSteps, source)
+		syntax.SetStepSources(l.Steps, source)
+	}
+}
+
 // PopulatePipelinesFromDefault sets the Release, PullRequest, and Feature pipelines, if unset, with the Default pipeline.
 func (c *PipelineConfig) PopulatePipelinesFromDefault() {
 	if c != nil && c.Pipelines.Default != nil {
@@ -845,6 +885,7 @@ func (c *PipelineConfig) createPipelineSteps(step *syntax.Step, prefixPath strin
 			stepName = "step" + strconv.Itoa(1+args.StepCounter)
 		}
 		s.Name = prefix + stepName
+		s.Source = step.Source
 		s.Command = replaceCommandText(step)
 		if args.CustomImage != "" {
 			s.Image = args.CustomImage
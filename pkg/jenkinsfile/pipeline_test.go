@@ -1,10 +1,12 @@
 package jenkinsfile_test
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/jenkins-x/jx/pkg/jenkinsfile"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetLifecycleReturnsSetup(t *testing.T) {
@@ -64,3 +66,26 @@ func TestGetLifecycleReturnsError(t *testing.T) {
 	_, err := lifecycles.GetLifecycle("something-else", false)
 	assert.Error(t, err)
 }
+
+func TestLoadPipelineConfigWithProvenance(t *testing.T) {
+	dir := filepath.Join("test_data", "provenance")
+	fileName := filepath.Join(dir, "pipeline.yaml")
+	config, err := jenkinsfile.LoadPipelineConfigWithProvenance(fileName, nil, false, false)
+	require.NoError(t, err)
+
+	sources := map[string]string{}
+	for _, s := range config.Pipelines.Release.Build.Steps {
+		sources[s.Name] = s.Source
+	}
+	assert.Equal(t, map[string]string{
+		"mvn-deploy":   filepath.Join(dir, "base.yaml"),
+		"helm-release": fileName,
+		"notify":       fileName + " (override after all steps in stage build of the release pipeline)",
+	}, sources)
+
+	config, err = jenkinsfile.LoadPipelineConfig(fileName, nil, false, false)
+	require.NoError(t, err)
+	for _, s := range config.Pipelines.Release.Build.Steps {
+		assert.Empty(t, s.Source, "step %s should not have a source", s.Name)
+	}
+}
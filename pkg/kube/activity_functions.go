@@ -13,9 +13,17 @@ func StartPromote(p *v1.PromoteActivityStep) error {
 			Time: time.Now(),
 		}
 	}
-	if p.Status == v1.ActivityStatusTypeNone {
+	if p.Status == v1.ActivityStatusTypeNone || p.Status == v1.ActivityStatusTypePending {
 		p.Status = v1.ActivityStatusTypeRunning
 	}
+	freeze := p.Freeze
+	if freeze != nil && freeze.Status == v1.ActivityStatusTypePending {
+		// the promotion was queued until the freeze window closed
+		freeze.Status = v1.ActivityStatusTypeSucceeded
+		freeze.CompletedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
 	return nil
 }
 
@@ -152,3 +160,89 @@ func FailedPromotionUpdate(a *v1.PipelineActivity, s *v1.PipelineActivityStep, p
 	p.Status = v1.ActivityStatusTypeFailed
 	return nil
 }
+
+func StartPromotionCanary(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteCanaryStep) error {
+	StartPromote(ps)
+	if p.StartedTimestamp == nil {
+		p.StartedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	if p.Status != v1.ActivityStatusTypeRunning {
+		p.Status = v1.ActivityStatusTypeRunning
+	}
+	return nil
+}
+
+func CompletePromotionCanary(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteCanaryStep) error {
+	if p.StartedTimestamp == nil {
+		p.StartedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	if p.CompletedTimestamp == nil {
+		p.CompletedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	p.Status = v1.ActivityStatusTypeSucceeded
+	return nil
+}
+
+func FailedPromotionCanary(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteCanaryStep) error {
+	FailedPromote(ps)
+	if p.StartedTimestamp == nil {
+		p.StartedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	if p.CompletedTimestamp == nil {
+		p.CompletedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+	}
+	p.Status = v1.ActivityStatusTypeFailed
+	return nil
+}
+
+// BlockedPromotionFreeze returns a function which records that the Promote is queued until the freeze window closes
+func BlockedPromotionFreeze(window string, reason string) PromoteFreezeFn {
+	return func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteFreezeStep) error {
+		if ps.StartedTimestamp == nil {
+			ps.StartedTimestamp = &metav1.Time{
+				Time: time.Now(),
+			}
+		}
+		ps.Status = v1.ActivityStatusTypePending
+		if p.StartedTimestamp == nil {
+			p.StartedTimestamp = &metav1.Time{
+				Time: time.Now(),
+			}
+		}
+		p.Status = v1.ActivityStatusTypePending
+		p.Window = window
+		p.Reason = reason
+		p.CompletedTimestamp = nil
+		return nil
+	}
+}
+
+// OverriddenPromotionFreeze returns a function which records that the Promote overrode the freeze window
+func OverriddenPromotionFreeze(window string, reason string, user string, overrideReason string) PromoteFreezeFn {
+	return func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteFreezeStep) error {
+		if p.StartedTimestamp == nil {
+			p.StartedTimestamp = &metav1.Time{
+				Time: time.Now(),
+			}
+		}
+		p.CompletedTimestamp = &metav1.Time{
+			Time: time.Now(),
+		}
+		p.Status = v1.ActivityStatusTypeSucceeded
+		p.Window = window
+		p.Reason = reason
+		p.OverriddenBy = user
+		p.OverrideReason = overrideReason
+		return nil
+	}
+}
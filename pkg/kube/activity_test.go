@@ -194,6 +194,140 @@ func TestCreateOrUpdateActivities(t *testing.T) {
 	//tests.Debugf("Has Promote %#v\n", promote)
 }
 
+func TestOnPromoteCanaryFailed(t *testing.T) {
+	t.Parallel()
+
+	const ns = "jx-testing"
+	mockKubeClient := kube_mocks.NewSimpleClientset()
+	jxClient := jxfake.NewSimpleClientset()
+
+	promoteKey := kube.PromoteStepActivityKey{
+		PipelineActivityKey: kube.PipelineActivityKey{
+			Name:     "demo-3",
+			Pipeline: "test-org/demo/master",
+			Build:    "3",
+			GitInfo: &gits.GitRepository{
+				Name:         "demo",
+				Organisation: "test-org",
+				URL:          "https://github.com/test-org/demo",
+			},
+		},
+		Environment: "production",
+	}
+
+	err := promoteKey.OnPromoteCanary(mockKubeClient, jxClient, ns, kube.StartPromotionCanary)
+	assert.NoError(t, err)
+
+	recordIteration := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteCanaryStep) error {
+		p.Phase = "Failed"
+		p.FailedChecks = 5
+		p.Iterations = append(p.Iterations, v1.CanaryAnalysisIteration{Phase: "Failed", FailedChecks: 5})
+		return kube.FailedPromotionCanary(a, s, ps, p)
+	}
+	err = promoteKey.OnPromoteCanary(mockKubeClient, jxClient, ns, recordIteration)
+	assert.NoError(t, err)
+
+	a, err := jxClient.JenkinsV1().PipelineActivities(ns).Get("demo-3", metav1.GetOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, a.Spec.Steps, 2) {
+		promote := a.Spec.Steps[1].Promote
+		if assert.NotNil(t, promote) && assert.NotNil(t, promote.Canary) {
+			assert.Equal(t, v1.ActivityStatusTypeFailed, promote.Status, "promote status")
+			assert.Equal(t, v1.ActivityStatusTypeFailed, promote.Canary.Status, "canary status")
+			assert.NotNil(t, promote.Canary.CompletedTimestamp)
+			assert.Len(t, promote.Canary.Iterations, 1)
+			assert.Equal(t, 5, promote.Canary.FailedChecks)
+		}
+	}
+}
+
+func TestOnPromoteFreezeQueuesUntilPromotionStarts(t *testing.T) {
+	t.Parallel()
+
+	const ns = "jx-testing"
+	mockKubeClient := kube_mocks.NewSimpleClientset()
+	jxClient := jxfake.NewSimpleClientset()
+
+	promoteKey := kube.PromoteStepActivityKey{
+		PipelineActivityKey: kube.PipelineActivityKey{
+			Name:     "demo-4",
+			Pipeline: "test-org/demo/master",
+			Build:    "4",
+			GitInfo: &gits.GitRepository{
+				Name:         "demo",
+				Organisation: "test-org",
+				URL:          "https://github.com/test-org/demo",
+			},
+		},
+		Environment: "production",
+	}
+
+	err := promoteKey.OnPromoteFreeze(mockKubeClient, jxClient, ns, kube.BlockedPromotionFreeze("weekend", "no deploys at the weekend"))
+	assert.NoError(t, err)
+
+	a, err := jxClient.JenkinsV1().PipelineActivities(ns).Get("demo-4", metav1.GetOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, a.Spec.Steps, 2) {
+		promote := a.Spec.Steps[1].Promote
+		if assert.NotNil(t, promote) && assert.NotNil(t, promote.Freeze) {
+			assert.Equal(t, v1.ActivityStatusTypePending, promote.Status, "promote status")
+			assert.Equal(t, v1.ActivityStatusTypePending, promote.Freeze.Status, "freeze status")
+			assert.Equal(t, "weekend", promote.Freeze.Window)
+			assert.Nil(t, promote.Freeze.CompletedTimestamp)
+		}
+	}
+
+	err = promoteKey.OnPromotePullRequest(mockKubeClient, jxClient, ns, kube.StartPromotionPullRequest)
+	assert.NoError(t, err)
+
+	a, err = jxClient.JenkinsV1().PipelineActivities(ns).Get("demo-4", metav1.GetOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, a.Spec.Steps, 2) {
+		promote := a.Spec.Steps[1].Promote
+		if assert.NotNil(t, promote) && assert.NotNil(t, promote.Freeze) {
+			assert.Equal(t, v1.ActivityStatusTypeRunning, promote.Status, "promote status")
+			assert.Equal(t, v1.ActivityStatusTypeSucceeded, promote.Freeze.Status, "freeze status")
+			assert.NotNil(t, promote.Freeze.CompletedTimestamp)
+		}
+	}
+}
+
+func TestOnPromoteFreezeOverridden(t *testing.T) {
+	t.Parallel()
+
+	const ns = "jx-testing"
+	mockKubeClient := kube_mocks.NewSimpleClientset()
+	jxClient := jxfake.NewSimpleClientset()
+
+	promoteKey := kube.PromoteStepActivityKey{
+		PipelineActivityKey: kube.PipelineActivityKey{
+			Name:     "demo-5",
+			Pipeline: "test-org/demo/master",
+			Build:    "5",
+			GitInfo: &gits.GitRepository{
+				Name:         "demo",
+				Organisation: "test-org",
+				URL:          "https://github.com/test-org/demo",
+			},
+		},
+		Environment: "production",
+	}
+
+	err := promoteKey.OnPromoteFreeze(mockKubeClient, jxClient, ns, kube.OverriddenPromotionFreeze("holidays", "", "alice", "security fix"))
+	assert.NoError(t, err)
+
+	a, err := jxClient.JenkinsV1().PipelineActivities(ns).Get("demo-5", metav1.GetOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, a.Spec.Steps, 2) {
+		promote := a.Spec.Steps[1].Promote
+		if assert.NotNil(t, promote) && assert.NotNil(t, promote.Freeze) {
+			assert.Equal(t, v1.ActivityStatusTypeSucceeded, promote.Freeze.Status, "freeze status")
+			assert.Equal(t, "alice", promote.Freeze.OverriddenBy)
+			assert.Equal(t, "security fix", promote.Freeze.OverrideReason)
+		}
+	}
+}
+
 func TestCreateOrUpdateActivityForBatchBuild(t *testing.T) {
 	t.Parallel()
 
@@ -136,6 +136,20 @@ const (
 	// ConfigMapNameJXInstallConfig is the ConfigMap containing the jx installation's CA and server url. Used by jx login
 	ConfigMapNameJXInstallConfig = "jx-install-config"
 
+	// ConfigMapLogMasking is the ConfigMap containing the rules used to mask secrets in pipeline logs
+	ConfigMapLogMasking = "jx-log-masking"
+
+	// ConfigMapChatNotifications is the ConfigMap containing the chat configuration used to post pipeline, promotion
+	// and release notifications
+	ConfigMapChatNotifications = "jx-chat-notifications"
+
+	// ConfigMapPipelineEvents is the ConfigMap containing the sinks the pipeline events are sent to
+	ConfigMapPipelineEvents = "jx-pipeline-events"
+
+	// ConfigMapPipelineEventsStatus is the ConfigMap recording the resourceVersions of the last PipelineActivity and
+	// Release resources sent to the pipeline events sinks
+	ConfigMapPipelineEventsStatus = "jx-pipeline-events-status"
+
 	// LocalHelmRepoName is the default name of the local chart repository where CI/CD releases go to
 	LocalHelmRepoName = "releases"
 
@@ -291,6 +305,18 @@ const (
 	// AnnotationReleaseName is the name of the annotation that stores the release name in the preview environment
 	AnnotationReleaseName = "jenkins.io/chart-release"
 
+	// AnnotationPreviewLastDeployed is the time a preview environment was last deployed or woken up
+	AnnotationPreviewLastDeployed = "jenkins.io/preview-last-deployed"
+
+	// AnnotationPreviewScaledToZero is the time a preview environment was scaled to zero by the garbage collector
+	AnnotationPreviewScaledToZero = "jenkins.io/preview-scaled-to-zero"
+
+	// AnnotationScaledToZeroReplicas is the number of replicas of a deployment before it was scaled to zero
+	AnnotationScaledToZeroReplicas = "jenkins.io/scaled-to-zero-replicas"
+
+	// AnnotationWakeupIngressSpec is the spec of an ingress before it was routed to the wakeup service
+	AnnotationWakeupIngressSpec = "jenkins.io/wakeup-ingress-spec"
+
 	// SecretDataUsername the username in a Secret/Credentials
 	SecretDataUsername = "username"
 
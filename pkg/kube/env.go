@@ -201,6 +201,7 @@ func CreateEnvironmentSurvey(batchMode bool, authConfigSvc auth.ConfigService, d
 			string(v1.PromotionStrategyTypeAutomatic),
 			string(v1.PromotionStrategyTypeManual),
 			string(v1.PromotionStrategyTypeNever),
+			string(v1.PromotionStrategyTypeCanary),
 		}
 		defaultValue := string(data.Spec.PromotionStrategy)
 		if defaultValue == "" {
@@ -210,7 +211,7 @@ func CreateEnvironmentSurvey(batchMode bool, authConfigSvc auth.ConfigService, d
 			Message: "Promotion Strategy:",
 			Options: promoteValues,
 			Default: defaultValue,
-			Help:    "Whether we promote to this Environment automatically, manually or never",
+			Help:    "Whether we promote to this Environment automatically, manually, never or automatically once the Flagger canary analysis succeeds",
 		}
 		textValue := ""
 		err := survey.AskOne(q, &textValue, survey.Required, surveyOpts)
@@ -1,19 +1,116 @@
 package kube
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
 	"strings"
+	"unicode"
 
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
+const (
+	// LogMaskingConfigKey the key of the LogMaskConfig in the ConfigMapLogMasking ConfigMap
+	LogMaskingConfigKey = "config.yaml"
+
+	// minEncodedLength is the minimum length of a secret value and of its encodings before the encodings are masked
+	// so that short values like 'true' do not mask unrelated text
+	minEncodedLength = 8
+
+	// minSecretWordLength is the minimum length of a word of a line of a multi-line secret value for the line to be
+	// masked on its own
+	minSecretWordLength = 16
+	// minSecretWordEntropy is the minimum Shannon entropy in bits per character of a word of a line of a multi-line
+	// secret value for the line to be masked on its own
+	minSecretWordEntropy = 3.0
+)
+
+// LogMaskConfig configures the rules used by a LogMasker in addition to the values of the Secrets
+type LogMaskConfig struct {
+	// DisableDefaultRules disables the DefaultLogMaskRules
+	DisableDefaultRules bool `json:"disableDefaultRules,omitempty"`
+	// Rules are the additional rules to mask text such as secrets which are not stored in Kubernetes Secrets
+	Rules []LogMaskRule `json:"rules,omitempty"`
+}
+
+// LogMaskRule is a rule which masks text matching a regular expression, or the text between a begin and an end
+// regular expression which may be on different lines such as a private key block
+type LogMaskRule struct {
+	// Name is the name of the rule
+	Name string `json:"name"`
+	// Pattern is the regular expression of the text to mask. If it has capture groups only the groups are masked
+	Pattern string `json:"pattern,omitempty"`
+	// Begin is the regular expression of the start of a block to mask
+	Begin string `json:"begin,omitempty"`
+	// End is the regular expression of the end of a block to mask
+	End string `json:"end,omitempty"`
+}
+
+// DefaultLogMaskRules are the rules used to mask well known kinds of credentials
+var DefaultLogMaskRules = []LogMaskRule{
+	{
+		Name:    "aws-access-key-id",
+		Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	},
+	{
+		Name:    "aws-secret-access-key",
+		Pattern: `(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`,
+	},
+	{
+		Name:    "jwt",
+		Pattern: `\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`,
+	},
+	{
+		Name:    "github-token",
+		Pattern: `\bgh[pousr]_[A-Za-z0-9]{36,}\b`,
+	},
+	{
+		Name:    "slack-token",
+		Pattern: `\bxox[abposr]-[A-Za-z0-9-]{10,}`,
+	},
+	{
+		Name:  "private-key",
+		Begin: `-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----`,
+		End:   `-----END [A-Z ]*PRIVATE KEY( BLOCK)?-----`,
+	},
+}
+
 // LogMasker replaces words in a log from a set of secrets
 type LogMasker struct {
 	ReplaceWords map[string]string
+
+	rules        []*logMaskRule
+	replacer     *strings.Replacer
+	replacerSize int
+}
+
+// logMaskRule is a compiled LogMaskRule
+type logMaskRule struct {
+	name    string
+	pattern *regexp.Regexp
+	begin   *regexp.Regexp
+	end     *regexp.Regexp
+}
+
+// LogStreamMasker masks the lines of a log one at a time, remembering any block such as a private key which is
+// still open at the end of a line so that the following lines are masked
+type LogStreamMasker struct {
+	masker *LogMasker
+	block  *logMaskRule
 }
 
-// NewLogMasker creates a new LogMasker loading secrets from the given namespace
+// NewLogMasker creates a new LogMasker loading secrets and the masking rules from the given namespace
 func NewLogMasker(kubeClient kubernetes.Interface, ns string) (*LogMasker, error) {
 	masker := &LogMasker{}
 	resourceList, err := kubeClient.CoreV1().Secrets(ns).List(metav1.ListOptions{})
@@ -23,7 +120,14 @@ func NewLogMasker(kubeClient kubernetes.Interface, ns string) (*LogMasker, error
 	for _, secret := range resourceList.Items {
 		masker.LoadSecret(&secret)
 	}
-	return masker, nil
+	config, err := LoadLogMaskConfig(kubeClient, ns)
+	if err != nil {
+		// lets still mask the well known credentials
+		masker.AddRules(DefaultLogMaskRules)
+		return masker, err
+	}
+	err = masker.LoadConfig(config)
+	return masker, err
 }
 
 // NewLogMaskerFromMap creates a new LogMasker with all the string values in a tree of map
@@ -35,6 +139,52 @@ func NewLogMaskerFromMap(m map[string]interface{}) *LogMasker {
 	return masker
 }
 
+// LoadLogMaskConfig loads the LogMaskConfig from the ConfigMapLogMasking ConfigMap in the namespace returning an empty
+// configuration if there is no ConfigMap
+func LoadLogMaskConfig(kubeClient kubernetes.Interface, ns string) (*LogMaskConfig, error) {
+	config := &LogMaskConfig{}
+	configMap, err := kubeClient.CoreV1().ConfigMaps(ns).Get(ConfigMapLogMasking, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return config, nil
+		}
+		return config, errors.Wrapf(err, "failed to get ConfigMap %s in namespace %s", ConfigMapLogMasking, ns)
+	}
+	err = yaml.Unmarshal([]byte(configMap.Data[LogMaskingConfigKey]), config)
+	if err != nil {
+		return config, errors.Wrapf(err, "failed to unmarshal the %s in ConfigMap %s in namespace %s", LogMaskingConfigKey, ConfigMapLogMasking, ns)
+	}
+	return config, nil
+}
+
+// LoadConfig adds the rules of the configuration to the log masker
+func (m *LogMasker) LoadConfig(config *LogMaskConfig) error {
+	if !config.DisableDefaultRules {
+		err := m.AddRules(DefaultLogMaskRules)
+		if err != nil {
+			return err
+		}
+	}
+	return m.AddRules(config.Rules)
+}
+
+// AddRules adds the rules to the log masker. Invalid rules are skipped and returned as an error
+func (m *LogMasker) AddRules(rules []LogMaskRule) error {
+	var errs []string
+	for _, rule := range rules {
+		compiled, err := compileLogMaskRule(rule)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		m.rules = append(m.rules, compiled)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid log mask rules: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
 // LoadSecrets loads the secrets into the log masker
 func (m *LogMasker) LoadSecrets(kubeClient kubernetes.Interface, ns string) error {
 	resourceList, err := kubeClient.CoreV1().Secrets(ns).List(metav1.ListOptions{})
@@ -49,29 +199,105 @@ func (m *LogMasker) LoadSecrets(kubeClient kubernetes.Interface, ns string) erro
 
 // LoadSecret loads the secret data into the log masker
 func (m *LogMasker) LoadSecret(secret *corev1.Secret) {
-	if m.ReplaceWords == nil {
-		m.ReplaceWords = map[string]string{}
-	}
-
 	if secret.Data != nil {
 		for _, v := range secret.Data {
 			if v != nil && len(v) > 0 {
-				// key := string(k)
-				value := string(v)
+				m.AddValue(string(v))
+			}
+		}
+	}
+}
 
-				m.ReplaceWords[value] = m.replaceValue(value)
+// AddValue adds a secret value to be masked along with its common encodings and, for a value which spans lines,
+// each of its lines which looks secret so that it is masked when a log is masked line by line
+func (m *LogMasker) AddValue(value string) {
+	if m.ReplaceWords == nil {
+		m.ReplaceWords = map[string]string{}
+	}
+	if value == "" {
+		return
+	}
+	m.ReplaceWords[value] = m.replaceValue(value)
+	if strings.Contains(value, "\n") {
+		for _, line := range strings.Split(value, "\n") {
+			line = strings.TrimSpace(line)
+			if isSecretLine(line) {
+				m.ReplaceWords[line] = m.replaceValue(line)
 			}
 		}
 	}
+	if len(value) < minEncodedLength {
+		return
+	}
+	for _, encoded := range encodings(value) {
+		if len(encoded) >= minEncodedLength {
+			m.ReplaceWords[encoded] = m.replaceValue(encoded)
+		}
+	}
+}
+
+// isSecretLine returns true if the line of a multi-line secret value looks secret, so that the lines of the value such
+// as the PEM armour '-----BEGIN CERTIFICATE-----' or the keys of YAML or JSON do not mask unrelated text. A line looks
+// secret if it has a long word, which mixes letters and digits or letter cases, with a high entropy
+func isSecretLine(line string) bool {
+	if strings.HasPrefix(line, "-----BEGIN ") || strings.HasPrefix(line, "-----END ") {
+		return false
+	}
+	words := strings.FieldsFunc(line, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`"',:{}[]`, r)
+	})
+	for _, word := range words {
+		if len(word) >= minSecretWordLength && characterClasses(word) >= 2 && entropy(word) >= minSecretWordEntropy {
+			return true
+		}
+	}
+	return false
+}
+
+// characterClasses returns how many of lower case letters, upper case letters and digits the word contains
+func characterClasses(word string) int {
+	lower, upper, digit := 0, 0, 0
+	for _, r := range word {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		}
+	}
+	return lower + upper + digit
+}
+
+// entropy returns the Shannon entropy of the word in bits per character
+func entropy(word string) float64 {
+	counts := map[rune]int{}
+	total := 0
+	for _, r := range word {
+		counts[r]++
+		total++
+	}
+	answer := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		answer -= p * math.Log2(p)
+	}
+	return answer
 }
 
 // MaskLog returns the text with all of the secrets masked out
 func (m *LogMasker) MaskLog(text string) string {
-	answer := text
-	for k, v := range m.ReplaceWords {
-		answer = strings.Replace(answer, k, v, -1)
+	answer := m.maskWords(text)
+	if len(m.rules) == 0 {
+		return answer
 	}
-	return answer
+	stream := m.NewStreamMasker()
+	lines := strings.Split(answer, "\n")
+	for i, line := range lines {
+		lines[i] = stream.maskRules(line)
+	}
+	return strings.Join(lines, "\n")
 }
 
 // MaskLogData masks the log data
@@ -80,6 +306,91 @@ func (m *LogMasker) MaskLogData(logData []byte) []byte {
 	return []byte(text)
 }
 
+// NewStreamMasker creates a masker for the lines of a log which are masked one at a time
+func (m *LogMasker) NewStreamMasker() *LogStreamMasker {
+	return &LogStreamMasker{
+		masker: m,
+	}
+}
+
+// MaskLine masks a single line of a log, which must not contain a new line
+func (s *LogStreamMasker) MaskLine(line string) string {
+	return s.maskRules(s.masker.maskWords(line))
+}
+
+// Reset forgets any open block such as when a log stream ends
+func (s *LogStreamMasker) Reset() {
+	s.block = nil
+}
+
+func (s *LogStreamMasker) maskRules(line string) string {
+	prefix := ""
+	if s.block != nil {
+		loc := s.block.end.FindStringIndex(line)
+		if loc == nil {
+			return maskText(line)
+		}
+		s.block = nil
+		prefix = maskText(line[:loc[0]])
+		line = line[loc[0]:]
+	}
+	for _, rule := range s.masker.rules {
+		if rule.pattern != nil {
+			line = maskMatches(rule.pattern, line)
+		}
+	}
+	for _, rule := range s.masker.rules {
+		if rule.pattern != nil {
+			continue
+		}
+		start := 0
+		for start < len(line) {
+			loc := rule.begin.FindStringIndex(line[start:])
+			if loc == nil {
+				break
+			}
+			blockStart := start + loc[1]
+			endLoc := rule.end.FindStringIndex(line[blockStart:])
+			if endLoc == nil {
+				// the block continues on the following lines
+				s.block = rule
+				return prefix + line[:blockStart] + maskText(line[blockStart:])
+			}
+			blockEnd := blockStart + endLoc[0]
+			line = line[:blockStart] + maskText(line[blockStart:blockEnd]) + line[blockEnd:]
+			start = blockStart + endLoc[1]
+		}
+	}
+	return prefix + line
+}
+
+// maskWords replaces the words with their masks, replacing the longest words first so that a word containing
+// another word is completely masked
+func (m *LogMasker) maskWords(text string) string {
+	if len(m.ReplaceWords) == 0 {
+		return text
+	}
+	if m.replacer == nil || m.replacerSize != len(m.ReplaceWords) {
+		words := make([]string, 0, len(m.ReplaceWords))
+		for k := range m.ReplaceWords {
+			words = append(words, k)
+		}
+		sort.Slice(words, func(i, j int) bool {
+			if len(words[i]) != len(words[j]) {
+				return len(words[i]) > len(words[j])
+			}
+			return words[i] < words[j]
+		})
+		oldnew := make([]string, 0, len(words)*2)
+		for _, word := range words {
+			oldnew = append(oldnew, word, m.ReplaceWords[word])
+		}
+		m.replacer = strings.NewReplacer(oldnew...)
+		m.replacerSize = len(m.ReplaceWords)
+	}
+	return m.replacer.Replace(text)
+}
+
 // replaceMapValues adds all the string values in the given map to the replacer words
 func (m *LogMasker) replaceMapValues(values map[string]interface{}) {
 	for _, value := range values {
@@ -96,5 +407,90 @@ func (m *LogMasker) replaceMapValues(values map[string]interface{}) {
 }
 
 func (m *LogMasker) replaceValue(value string) string {
-	return strings.Repeat("*", len(value))
+	return maskText(value)
+}
+
+func maskText(text string) string {
+	return strings.Repeat("*", len(text))
+}
+
+// maskMatches masks the matches of the regular expression, or only its capture groups if it has any
+func maskMatches(r *regexp.Regexp, text string) string {
+	matches := r.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	masked := []byte(text)
+	for _, match := range matches {
+		groups := [][]int{{match[0], match[1]}}
+		if len(match) > 2 {
+			groups = nil
+			for i := 2; i+1 < len(match); i += 2 {
+				if match[i] >= 0 {
+					groups = append(groups, []int{match[i], match[i+1]})
+				}
+			}
+		}
+		for _, group := range groups {
+			for i := group[0]; i < group[1]; i++ {
+				masked[i] = '*'
+			}
+		}
+	}
+	return string(masked)
+}
+
+func compileLogMaskRule(rule LogMaskRule) (*logMaskRule, error) {
+	compiled := &logMaskRule{
+		name: rule.Name,
+	}
+	var err error
+	switch {
+	case rule.Pattern != "":
+		compiled.pattern, err = regexp.Compile(rule.Pattern)
+	case rule.Begin != "" && rule.End != "":
+		compiled.begin, err = regexp.Compile(rule.Begin)
+		if err == nil {
+			compiled.end, err = regexp.Compile(rule.End)
+		}
+	default:
+		err = errors.New("either a pattern or a begin and end must be specified")
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "rule %s", rule.Name)
+	}
+	return compiled, nil
+}
+
+// encodings returns the common encodings of a value which may appear in a log such as its base64 encoding. As a
+// base64 encoded value is often part of a larger encoded text, such as the auth of a docker config, the parts of its
+// encoding which do not depend on the surrounding text at each of the 3 possible alignments are returned too
+func encodings(value string) []string {
+	answer := []string{
+		url.QueryEscape(value),
+		url.PathEscape(value),
+	}
+	data, err := json.Marshal(value)
+	if err == nil {
+		answer = append(answer, strings.Trim(string(data), `"`))
+	}
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding} {
+		answer = append(answer, encoding.EncodeToString([]byte(value)))
+		for offset := 0; offset < 3; offset++ {
+			encoded := encoding.EncodeToString([]byte(strings.Repeat("\x00", offset) + value))
+			// skip the characters which depend on the preceding bytes and the ones which depend on the following bytes
+			start := (offset*8 + 5) / 6
+			end := (offset + len(value)) * 8 / 6
+			if start < end {
+				answer = append(answer, encoded[start:end])
+			}
+		}
+	}
+	var unique []string
+	for _, encoded := range answer {
+		if encoded != value && util.StringArrayIndex(unique, encoded) < 0 {
+			unique = append(unique, encoded)
+		}
+	}
+	return unique
 }
@@ -293,6 +293,12 @@ func (t TektonLogger) GetRunningBuildLogs(pa *v1.PipelineActivity, buildName str
 					break
 				}
 			}
+			if foundLogs {
+				err = t.logSkippedStages(structure, buildName)
+				if err != nil {
+					return errors.Wrapf(err, "failed to log the skipped stages for build %s", buildName)
+				}
+			}
 		}
 		if !foundLogs {
 			break
@@ -382,14 +388,27 @@ func writeStreamLines(reader io.Reader, logCh chan<- LogLine) error {
 	if buffReader == nil {
 		return errors.New("there was a problem obtaining a buffered reader")
 	}
+	// a long line is returned in fragments which are joined so that a secret is never split before being masked
+	var fragments []byte
 	for {
-		line, _, err := buffReader.ReadLine()
+		line, isPrefix, err := buffReader.ReadLine()
 		if err != nil {
 			if err == io.EOF {
+				if len(fragments) > 0 {
+					logCh <- LogLine{Line: string(fragments), ShouldMask: true}
+				}
 				return nil
 			}
 			return errors.Wrap(err, "failed to read stream")
 		}
+		if isPrefix {
+			fragments = append(fragments, line...)
+			continue
+		}
+		if len(fragments) > 0 {
+			line = append(fragments, line...)
+			fragments = nil
+		}
 		logCh <- LogLine{Line: string(line), ShouldMask: true}
 	}
 }
@@ -506,6 +525,28 @@ func (t *TektonLogger) streamPipedLogs(scanner *bufio.Scanner, logsURL string) e
 	return nil
 }
 
+// write a line for each stage of the pipeline which was skipped because its when conditions were not met
+func (t *TektonLogger) logSkippedStages(structure *v1.PipelineStructure, buildName string) error {
+	skippedStages := structure.GetSkippedStages()
+	if len(skippedStages) == 0 {
+		return nil
+	}
+	infoColor := color.New(color.FgGreen)
+	infoColor.EnableColor()
+	t.initializeLoggingRoutine()
+	for _, stage := range skippedStages {
+		err := t.LogWriter.WriteLog(LogLine{
+			Line: fmt.Sprintf("\nBuild %v stage %s was skipped", infoColor.Sprintf(buildName), infoColor.Sprintf(stage.Name)),
+		}, t.logsChannel)
+		if err != nil {
+			return errors.Wrapf(err, "there was a problem writing a single line into the logs writer")
+		}
+	}
+	t.closeLoggingChannels()
+	t.wg.Wait()
+	return nil
+}
+
 // create the logs and errors channels and the waitgroup for this TektonLogger instance
 // assign a pointer to the waitgroup to TektonLogger which will be used by all other methods
 // then start the log writing goroutine, which calls the implementation of StreamLogs of the given LogWriter
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/acarl005/stripansi"
@@ -611,6 +612,23 @@ func getFakeClientsAndNs(t *testing.T) (versioned.Interface, tektonclient.Interf
 	return jxClient, tektonClient, kubeClient, commonOpts, ns
 }
 
+func TestWriteStreamLinesJoinsLongLines(t *testing.T) {
+	longLine := strings.Repeat("x", 10000) + "secret"
+	reader := strings.NewReader("first\n" + longLine + "\nlast")
+	logCh := make(chan LogLine, 10)
+
+	err := writeStreamLines(reader, logCh)
+	assert.NoError(t, err)
+	close(logCh)
+
+	var lines []string
+	for l := range logCh {
+		assert.True(t, l.ShouldMask)
+		lines = append(lines, l.Line)
+	}
+	assert.Equal(t, []string{"first", longLine, "last"}, lines)
+}
+
 func (w *TestWriter) WriteLog(logLine LogLine, lch chan<- LogLine) error {
 	w.SingleLinesLogged = append(w.SingleLinesLogged, logLine.Line)
 	lch <- logLine
@@ -0,0 +1,47 @@
+package pipline_events
+
+import (
+	"net/http"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/util"
+)
+
+// CloudEventsSpecVersion is the version of the CloudEvents specification of the events
+const CloudEventsSpecVersion = "1.0"
+
+// CloudEventsProvider implements PipelineEventsProvider interface by posting CloudEvents in the HTTP binary content
+// mode to a URL such as a Knative Eventing broker
+type CloudEventsProvider struct {
+	Client *http.Client
+	Sink   *SinkConfig
+}
+
+// NewCloudEventsProvider creates a provider posting CloudEvents to the URL of the sink
+func NewCloudEventsProvider(sink *SinkConfig) PipelineEventsProvider {
+	return &CloudEventsProvider{
+		Client: util.GetClient(),
+		Sink:   sink,
+	}
+}
+
+func (c *CloudEventsProvider) SendActivity(a *v1.PipelineActivity) error {
+	return c.send(NewActivityEvent(a))
+}
+
+func (c *CloudEventsProvider) SendRelease(r *v1.Release) error {
+	return c.send(NewReleaseEvent(r))
+}
+
+func (c *CloudEventsProvider) send(event *Event) error {
+	headers := map[string]string{
+		"ce-specversion": CloudEventsSpecVersion,
+		"ce-id":          event.ID,
+		"ce-type":        event.Type,
+		"ce-source":      event.Source,
+		"ce-subject":     event.Subject,
+		"ce-time":        event.Time.Format(time.RFC3339),
+	}
+	return post(c.Client, c.Sink, c.Sink.URL, event.Data, headers, nil)
+}
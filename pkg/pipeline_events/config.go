@@ -0,0 +1,125 @@
+package pipline_events
+
+import (
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// PipelineEventsConfigKey is the key of the PipelineEventsConfig in the ConfigMapPipelineEvents ConfigMap
+	PipelineEventsConfigKey = "config.yaml"
+
+	// DefaultBufferSize is the default number of events buffered for each sink
+	DefaultBufferSize = 1000
+	// DefaultRetryTimeout is the default time spent retrying to send an event to a sink before it is dropped
+	DefaultRetryTimeout = 30 * time.Second
+	// DefaultStatsInterval is the default interval between the log lines reporting the delivery metrics of the sinks
+	DefaultStatsInterval = 5 * time.Minute
+)
+
+// PipelineEventsConfig configures the sinks the pipeline events are sent to
+type PipelineEventsConfig struct {
+	// BufferSize is the number of events buffered for each sink while they are sent or retried
+	BufferSize int `json:"bufferSize,omitempty"`
+	// RetryTimeout is how long to retry sending an event to a sink such as '30s'. The events of a sink are sent in
+	// order, so while an event is retried the later events of the sink wait in its buffer and are dropped once it is
+	// full. A longer timeout loses fewer events during a short outage of the sink at the cost of delaying the others
+	RetryTimeout string `json:"retryTimeout,omitempty"`
+	// StatsInterval is how often the numbers of events sent, failed and dropped by each sink are logged such as '5m'
+	StatsInterval string `json:"statsInterval,omitempty"`
+	// Sinks are the sinks the events are sent to
+	Sinks []SinkConfig `json:"sinks,omitempty"`
+}
+
+// SinkConfig configures a sink of pipeline events
+type SinkConfig struct {
+	// Name of the sink which defaults to its kind
+	Name string `json:"name,omitempty"`
+	// Kind of the sink such as cloudevents, elasticsearch, kafka or webhook
+	Kind string `json:"kind"`
+	// URL of the sink. For Kafka this is the URL of the Kafka REST Proxy
+	URL string `json:"url"`
+	// Topic is the Kafka topic
+	Topic string `json:"topic,omitempty"`
+	// Headers are added to the HTTP requests sent to the sink
+	Headers map[string]string `json:"headers,omitempty"`
+	// Secret is the name of the Secret containing the 'username' and 'password' or the 'token' used to authenticate
+	// with the sink
+	Secret string `json:"secret,omitempty"`
+
+	Username string `json:"-"`
+	Password string `json:"-"`
+	Token    string `json:"-"`
+}
+
+// GetRetryTimeout returns the retry timeout or the default if it is not configured
+func (c *PipelineEventsConfig) GetRetryTimeout() (time.Duration, error) {
+	if c.RetryTimeout == "" {
+		return DefaultRetryTimeout, nil
+	}
+	d, err := time.ParseDuration(c.RetryTimeout)
+	if err != nil {
+		return d, errors.Wrapf(err, "invalid retryTimeout %s", c.RetryTimeout)
+	}
+	return d, nil
+}
+
+// GetStatsInterval returns the stats interval or the default if it is not configured
+func (c *PipelineEventsConfig) GetStatsInterval() (time.Duration, error) {
+	if c.StatsInterval == "" {
+		return DefaultStatsInterval, nil
+	}
+	d, err := time.ParseDuration(c.StatsInterval)
+	if err != nil {
+		return d, errors.Wrapf(err, "invalid statsInterval %s", c.StatsInterval)
+	}
+	return d, nil
+}
+
+// GetBufferSize returns the buffer size or the default if it is not configured
+func (c *PipelineEventsConfig) GetBufferSize() int {
+	if c.BufferSize <= 0 {
+		return DefaultBufferSize
+	}
+	return c.BufferSize
+}
+
+// LoadPipelineEventsConfig loads the PipelineEventsConfig from the ConfigMapPipelineEvents ConfigMap in the namespace
+// along with the credentials of its sinks returning nil if there is no ConfigMap
+func LoadPipelineEventsConfig(kubeClient kubernetes.Interface, ns string) (*PipelineEventsConfig, error) {
+	configMap, err := kubeClient.CoreV1().ConfigMaps(ns).Get(kube.ConfigMapPipelineEvents, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get ConfigMap %s in namespace %s", kube.ConfigMapPipelineEvents, ns)
+	}
+	eventsConfig := &PipelineEventsConfig{}
+	err = yaml.Unmarshal([]byte(configMap.Data[PipelineEventsConfigKey]), eventsConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the %s in ConfigMap %s in namespace %s", PipelineEventsConfigKey, kube.ConfigMapPipelineEvents, ns)
+	}
+	for i := range eventsConfig.Sinks {
+		sink := &eventsConfig.Sinks[i]
+		if sink.Name == "" {
+			sink.Name = sink.Kind
+		}
+		if sink.Secret == "" {
+			continue
+		}
+		secret, err := kubeClient.CoreV1().Secrets(ns).Get(sink.Secret, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get Secret %s of pipeline events sink %s in namespace %s", sink.Secret, sink.Name, ns)
+		}
+		sink.Username = string(secret.Data["username"])
+		sink.Password = string(secret.Data["password"])
+		sink.Token = string(secret.Data["token"])
+	}
+	return eventsConfig, nil
+}
@@ -0,0 +1,182 @@
+package pipline_events
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+)
+
+// DefaultRetryInitialDelay is the default delay before the first retry of sending an event to a sink
+const DefaultRetryInitialDelay = time.Second
+
+// Dispatcher implements PipelineEventsProvider interface by buffering the events of each of its sinks and sending them
+// in the background, retrying failures with an exponential backoff, so that a slow or unavailable sink neither blocks
+// the caller nor the other sinks. If the buffer of a sink is full its new events are dropped.
+//
+// The events of a sink are sent in order, so while an event is retried for up to RetryTimeout the later events of that
+// sink wait behind it
+type Dispatcher struct {
+	BufferSize        int
+	RetryInitialDelay time.Duration
+	RetryTimeout      time.Duration
+	// StatsInterval is the interval between the log lines reporting the Stats of the sinks, or zero to not log them
+	StatsInterval time.Duration
+	// Delivered is invoked with the *v1.PipelineActivity or *v1.Release of an event once it has been sent to every
+	// sink. It is not invoked for an event which was dropped or failed to be sent by any sink
+	Delivered func(resource interface{})
+
+	sinks []*sinkQueue
+}
+
+// SinkStats are the delivery metrics of a sink
+type SinkStats struct {
+	Sent    int64
+	Failed  int64
+	Dropped int64
+}
+
+type sinkQueue struct {
+	name     string
+	provider PipelineEventsProvider
+	events   chan *event
+	stats    SinkStats
+}
+
+// event is an event buffered for each of the sinks which tracks its delivery to all of them
+type event struct {
+	resource  interface{}
+	send      func(PipelineEventsProvider) error
+	remaining int32
+	failed    int32
+}
+
+// NewDispatcher creates a dispatcher buffering the given number of events for each sink
+func NewDispatcher(bufferSize int, retryTimeout time.Duration) *Dispatcher {
+	return &Dispatcher{
+		BufferSize:        bufferSize,
+		RetryInitialDelay: DefaultRetryInitialDelay,
+		RetryTimeout:      retryTimeout,
+		StatsInterval:     DefaultStatsInterval,
+	}
+}
+
+// AddSink adds the provider of a sink. Sinks must be added before the dispatcher is started
+func (d *Dispatcher) AddSink(name string, provider PipelineEventsProvider) {
+	d.sinks = append(d.sinks, &sinkQueue{
+		name:     name,
+		provider: provider,
+		events:   make(chan *event, d.BufferSize),
+	})
+}
+
+// Start sends the buffered events to the sinks until the stop channel is closed
+func (d *Dispatcher) Start(stop <-chan struct{}) {
+	for _, q := range d.sinks {
+		go d.run(q, stop)
+	}
+	if d.StatsInterval > 0 {
+		go d.logStats(stop)
+	}
+}
+
+func (d *Dispatcher) SendActivity(a *v1.PipelineActivity) error {
+	return d.dispatch("PipelineActivity "+a.Name, a, func(p PipelineEventsProvider) error {
+		return p.SendActivity(a)
+	})
+}
+
+func (d *Dispatcher) SendRelease(r *v1.Release) error {
+	return d.dispatch("Release "+r.Name, r, func(p PipelineEventsProvider) error {
+		return p.SendRelease(r)
+	})
+}
+
+// Stats returns the delivery metrics of the sinks by their names
+func (d *Dispatcher) Stats() map[string]SinkStats {
+	answer := map[string]SinkStats{}
+	for _, q := range d.sinks {
+		answer[q.name] = SinkStats{
+			Sent:    atomic.LoadInt64(&q.stats.Sent),
+			Failed:  atomic.LoadInt64(&q.stats.Failed),
+			Dropped: atomic.LoadInt64(&q.stats.Dropped),
+		}
+	}
+	return answer
+}
+
+// dispatch buffers the event for each sink returning an error naming the sinks whose buffers are full
+func (d *Dispatcher) dispatch(name string, resource interface{}, send func(PipelineEventsProvider) error) error {
+	e := &event{
+		resource:  resource,
+		send:      send,
+		remaining: int32(len(d.sinks)),
+	}
+	dropped := []string{}
+	for _, q := range d.sinks {
+		select {
+		case q.events <- e:
+		default:
+			atomic.AddInt64(&q.stats.Dropped, 1)
+			dropped = append(dropped, q.name)
+			d.completed(e, false)
+		}
+	}
+	if len(dropped) > 0 {
+		return fmt.Errorf("dropped the event of %s as the buffers of the pipeline events sinks %s are full", name, strings.Join(dropped, ", "))
+	}
+	return nil
+}
+
+func (d *Dispatcher) run(q *sinkQueue, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case e := <-q.events:
+			err := util.RetryWithInitialDelay(d.RetryInitialDelay, d.RetryTimeout, func() error {
+				return e.send(q.provider)
+			})
+			if err != nil {
+				atomic.AddInt64(&q.stats.Failed, 1)
+				log.Logger().Warnf("failed to send the pipeline event to sink %s: %s", q.name, err)
+				d.completed(e, false)
+				continue
+			}
+			atomic.AddInt64(&q.stats.Sent, 1)
+			d.completed(e, true)
+		}
+	}
+}
+
+// completed records that a sink is done with the event, invoking Delivered once every sink has sent it
+func (d *Dispatcher) completed(e *event, sent bool) {
+	if !sent {
+		atomic.StoreInt32(&e.failed, 1)
+	}
+	if atomic.AddInt32(&e.remaining, -1) == 0 && atomic.LoadInt32(&e.failed) == 0 && d.Delivered != nil {
+		d.Delivered(e.resource)
+	}
+}
+
+// logStats logs the delivery metrics of the sinks every StatsInterval until the stop channel is closed
+func (d *Dispatcher) logStats(stop <-chan struct{}) {
+	ticker := time.NewTicker(d.StatsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			stats := d.Stats()
+			for _, q := range d.sinks {
+				s := stats[q.name]
+				log.Logger().Infof("pipeline events sink %s: sent %d, failed %d, dropped %d, buffered %d", q.name, s.Sent, s.Failed, s.Dropped, len(q.events))
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package pipline_events_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/pipeline_events"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeProvider struct {
+	lock       sync.Mutex
+	failures   int
+	activities []string
+	releases   []string
+}
+
+func (f *fakeProvider) SendActivity(a *v1.PipelineActivity) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return fmt.Errorf("sink unavailable")
+	}
+	f.activities = append(f.activities, a.Name)
+	return nil
+}
+
+func (f *fakeProvider) SendRelease(r *v1.Release) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.releases = append(f.releases, r.Name)
+	return nil
+}
+
+func (f *fakeProvider) sent() ([]string, []string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]string{}, f.activities...), append([]string{}, f.releases...)
+}
+
+func waitForStats(t *testing.T, dispatcher *pipline_events.Dispatcher, name string, expected pipline_events.SinkStats) {
+	for i := 0; i < 200; i++ {
+		if dispatcher.Stats()[name] == expected {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, expected, dispatcher.Stats()[name])
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	t.Parallel()
+	flaky := &fakeProvider{failures: 2}
+	healthy := &fakeProvider{}
+	dispatcher := pipline_events.NewDispatcher(10, time.Second)
+	dispatcher.RetryInitialDelay = time.Millisecond
+	dispatcher.AddSink("flaky", flaky)
+	dispatcher.AddSink("healthy", healthy)
+	stop := make(chan struct{})
+	defer close(stop)
+	dispatcher.Start(stop)
+
+	err := dispatcher.SendActivity(&v1.PipelineActivity{ObjectMeta: metav1.ObjectMeta{Name: "a1"}})
+	assert.NoError(t, err)
+	err = dispatcher.SendRelease(&v1.Release{ObjectMeta: metav1.ObjectMeta{Name: "r1"}})
+	assert.NoError(t, err)
+
+	waitForStats(t, dispatcher, "flaky", pipline_events.SinkStats{Sent: 2})
+	waitForStats(t, dispatcher, "healthy", pipline_events.SinkStats{Sent: 2})
+	activities, releases := flaky.sent()
+	assert.Equal(t, []string{"a1"}, activities)
+	assert.Equal(t, []string{"r1"}, releases)
+}
+
+func TestDispatcherDropsWhenBufferFull(t *testing.T) {
+	t.Parallel()
+	provider := &fakeProvider{}
+	dispatcher := pipline_events.NewDispatcher(1, time.Second)
+	dispatcher.AddSink("slow", provider)
+
+	// the dispatcher is not started so the first event fills the buffer and the second is dropped
+	assert.NoError(t, dispatcher.SendActivity(&v1.PipelineActivity{ObjectMeta: metav1.ObjectMeta{Name: "a1"}}))
+	assert.Error(t, dispatcher.SendActivity(&v1.PipelineActivity{ObjectMeta: metav1.ObjectMeta{Name: "a2"}}))
+
+	stop := make(chan struct{})
+	defer close(stop)
+	dispatcher.Start(stop)
+	waitForStats(t, dispatcher, "slow", pipline_events.SinkStats{Sent: 1, Dropped: 1})
+	activities, _ := provider.sent()
+	assert.Equal(t, []string{"a1"}, activities)
+}
+
+func TestDispatcherDelivered(t *testing.T) {
+	t.Parallel()
+	failing := &fakeProvider{failures: 1000}
+	healthy := &fakeProvider{}
+	dispatcher := pipline_events.NewDispatcher(1, time.Millisecond)
+	dispatcher.RetryInitialDelay = time.Millisecond
+	dispatcher.AddSink("failing", failing)
+	dispatcher.AddSink("healthy", healthy)
+	delivered := make(chan string, 10)
+	dispatcher.Delivered = func(resource interface{}) {
+		switch r := resource.(type) {
+		case *v1.PipelineActivity:
+			delivered <- r.Name
+		case *v1.Release:
+			delivered <- r.Name
+		}
+	}
+
+	// the dispatcher is not started so the second event is dropped
+	assert.NoError(t, dispatcher.SendActivity(&v1.PipelineActivity{ObjectMeta: metav1.ObjectMeta{Name: "a1"}}))
+	assert.Error(t, dispatcher.SendActivity(&v1.PipelineActivity{ObjectMeta: metav1.ObjectMeta{Name: "a2"}}))
+
+	stop := make(chan struct{})
+	defer close(stop)
+	dispatcher.Start(stop)
+	waitForStats(t, dispatcher, "failing", pipline_events.SinkStats{Failed: 1, Dropped: 1})
+	waitForStats(t, dispatcher, "healthy", pipline_events.SinkStats{Sent: 1, Dropped: 1})
+
+	assert.NoError(t, dispatcher.SendRelease(&v1.Release{ObjectMeta: metav1.ObjectMeta{Name: "r1"}}))
+	select {
+	case name := <-delivered:
+		assert.Equal(t, "r1", name)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the release to be delivered")
+	}
+	assert.Empty(t, delivered)
+}
@@ -0,0 +1,114 @@
+package pipline_events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// EventTypeActivity is the type of the events of PipelineActivity changes
+	EventTypeActivity = "io.jenkins-x.pipelineactivity"
+	// EventTypeRelease is the type of the events of Release changes
+	EventTypeRelease = "io.jenkins-x.release"
+)
+
+// Event is the envelope of a change of a PipelineActivity or Release sent to webhook and Kafka sinks. Its fields
+// match the attributes of a CloudEvent
+type Event struct {
+	ID      string      `json:"id"`
+	Type    string      `json:"type"`
+	Source  string      `json:"source"`
+	Subject string      `json:"subject"`
+	Time    time.Time   `json:"time"`
+	Data    interface{} `json:"data"`
+}
+
+// NewActivityEvent creates the event of the change of the PipelineActivity
+func NewActivityEvent(a *v1.PipelineActivity) *Event {
+	return newEvent(EventTypeActivity, &a.ObjectMeta, a)
+}
+
+// NewReleaseEvent creates the event of the change of the Release
+func NewReleaseEvent(r *v1.Release) *Event {
+	return newEvent(EventTypeRelease, &r.ObjectMeta, r)
+}
+
+func newEvent(eventType string, meta *metav1.ObjectMeta, data interface{}) *Event {
+	id := string(meta.UID)
+	if id == "" {
+		id = meta.Namespace + "/" + meta.Name
+	}
+	if meta.ResourceVersion != "" {
+		id += "-" + meta.ResourceVersion
+	}
+	return &Event{
+		ID:      id,
+		Type:    eventType,
+		Source:  "/jenkins-x/namespaces/" + meta.Namespace,
+		Subject: meta.Name,
+		Time:    time.Now().UTC(),
+		Data:    data,
+	}
+}
+
+// post sends the body to the URL of the sink with its headers and credentials
+func post(client *http.Client, sink *SinkConfig, u string, body interface{}, headers map[string]string, result interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range sink.Headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if sink.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+sink.Token)
+	} else if sink.Username != "" {
+		req.Header.Set("Authorization", "Basic "+util.BasicAuth(sink.Username, sink.Password))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error POSTing to pipeline events sink %s: %v", sink.Name, err)
+	}
+	defer resp.Body.Close()
+	respData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err = fmt.Errorf("error response POSTing to pipeline events sink %s: %s %s", sink.Name, resp.Status, string(respData))
+		if isPermanentFailure(resp.StatusCode) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}
+	if result == nil || len(respData) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respData, result)
+}
+
+// isPermanentFailure returns true if the status code is a client error which fails again if the event is resent, so
+// that the event is not retried
+func isPermanentFailure(statusCode int) bool {
+	if statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests {
+		return false
+	}
+	return statusCode >= 400 && statusCode < 500
+}
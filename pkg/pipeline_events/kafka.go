@@ -0,0 +1,82 @@
+package pipline_events
+
+import (
+	"fmt"
+	"net/http"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/util"
+)
+
+const kafkaJSONContentType = "application/vnd.kafka.json.v2+json"
+
+// KafkaProvider implements PipelineEventsProvider interface by producing each Event to a topic using the v2 API of
+// the Kafka REST Proxy. The records are keyed by the name of the resource so that the changes of a resource stay in
+// order on the same partition
+type KafkaProvider struct {
+	Client *http.Client
+	Sink   *SinkConfig
+}
+
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key"`
+	Value *Event `json:"value"`
+}
+
+type kafkaOffsets struct {
+	Offsets []struct {
+		Partition int    `json:"partition"`
+		Offset    int64  `json:"offset"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// NewKafkaProvider creates a provider producing the events to the topic of the sink
+func NewKafkaProvider(sink *SinkConfig) (PipelineEventsProvider, error) {
+	if sink.Topic == "" {
+		return nil, fmt.Errorf("no topic for Kafka pipeline events sink %s", sink.Name)
+	}
+	return &KafkaProvider{
+		Client: util.GetClient(),
+		Sink:   sink,
+	}, nil
+}
+
+func (k *KafkaProvider) SendActivity(a *v1.PipelineActivity) error {
+	return k.send(NewActivityEvent(a))
+}
+
+func (k *KafkaProvider) SendRelease(r *v1.Release) error {
+	return k.send(NewReleaseEvent(r))
+}
+
+func (k *KafkaProvider) send(event *Event) error {
+	headers := map[string]string{
+		"Content-Type": kafkaJSONContentType,
+		"Accept":       "application/vnd.kafka.v2+json",
+	}
+	body := &kafkaRecords{
+		Records: []kafkaRecord{
+			{
+				Key:   event.Subject,
+				Value: event,
+			},
+		},
+	}
+	offsets := &kafkaOffsets{}
+	err := post(k.Client, k.Sink, util.UrlJoin(k.Sink.URL, "topics", k.Sink.Topic), body, headers, offsets)
+	if err != nil {
+		return err
+	}
+	for _, o := range offsets.Offsets {
+		if o.ErrorCode != nil {
+			return fmt.Errorf("failed to produce event %s to Kafka topic %s: %d %s", event.ID, k.Sink.Topic, *o.ErrorCode, o.Error)
+		}
+	}
+	return nil
+}
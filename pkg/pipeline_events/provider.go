@@ -1,8 +1,51 @@
 package pipline_events
 
-import v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+import (
+	"fmt"
 
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/auth"
+)
+
+const (
+	// Elasticsearch indexes the events in Elasticsearch
+	Elasticsearch = "elasticsearch"
+	// CloudEvents posts the events as CloudEvents in the HTTP binary content mode
+	CloudEvents = "cloudevents"
+	// Kafka produces the events to a topic using the Kafka REST Proxy
+	Kafka = "kafka"
+	// Webhook posts the events as JSON to a URL
+	Webhook = "webhook"
+)
+
+// SinkKinds are the kinds of pipeline events sink
+var SinkKinds = []string{CloudEvents, Elasticsearch, Kafka, Webhook}
+
+// PipelineEventsProvider sends the changes of PipelineActivity and Release resources to a sink. The promotions of a
+// pipeline are part of its PipelineActivity
 type PipelineEventsProvider interface {
 	SendActivity(a *v1.PipelineActivity) error
 	SendRelease(a *v1.Release) error
 }
+
+// CreatePipelineEventsProvider creates the provider for the sink
+func CreatePipelineEventsProvider(sink *SinkConfig) (PipelineEventsProvider, error) {
+	if sink.URL == "" {
+		return nil, fmt.Errorf("no URL for pipeline events sink %s", sink.Name)
+	}
+	switch sink.Kind {
+	case Elasticsearch:
+		return NewElasticsearchProvider(&auth.AuthServer{URL: sink.URL}, &auth.UserAuth{
+			Username: sink.Username,
+			Password: sink.Password,
+		})
+	case CloudEvents:
+		return NewCloudEventsProvider(sink), nil
+	case Kafka:
+		return NewKafkaProvider(sink)
+	case Webhook:
+		return NewWebhookProvider(sink), nil
+	default:
+		return nil, fmt.Errorf("unsupported kind %s of pipeline events sink %s. Supported kinds are: %v", sink.Kind, sink.Name, SinkKinds)
+	}
+}
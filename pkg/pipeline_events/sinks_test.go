@@ -0,0 +1,184 @@
+package pipline_events_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/pipeline_events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type recordedRequest struct {
+	path   string
+	header http.Header
+	body   map[string]interface{}
+}
+
+func newSinkServer(t *testing.T, response string, requests *[]recordedRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		data, _ := ioutil.ReadAll(r.Body)
+		err := json.Unmarshal(data, &body)
+		assert.NoError(t, err)
+		*requests = append(*requests, recordedRequest{path: r.URL.Path, header: r.Header, body: body})
+		w.Write([]byte(response))
+	}))
+}
+
+func testActivity() *v1.PipelineActivity {
+	return &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "myorg-myapp-master-1",
+			Namespace:       "jx",
+			UID:             "abc",
+			ResourceVersion: "7",
+		},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline: "myorg/myapp/master",
+			Build:    "1",
+		},
+	}
+}
+
+func TestCloudEventsProvider(t *testing.T) {
+	t.Parallel()
+	var requests []recordedRequest
+	server := newSinkServer(t, "", &requests)
+	defer server.Close()
+
+	provider, err := pipline_events.CreatePipelineEventsProvider(&pipline_events.SinkConfig{
+		Name:    "broker",
+		Kind:    pipline_events.CloudEvents,
+		URL:     server.URL,
+		Headers: map[string]string{"X-Team": "platform"},
+	})
+	require.NoError(t, err)
+
+	err = provider.SendActivity(testActivity())
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	header := requests[0].header
+	assert.Equal(t, "1.0", header.Get("ce-specversion"))
+	assert.Equal(t, "abc-7", header.Get("ce-id"))
+	assert.Equal(t, pipline_events.EventTypeActivity, header.Get("ce-type"))
+	assert.Equal(t, "/jenkins-x/namespaces/jx", header.Get("ce-source"))
+	assert.Equal(t, "myorg-myapp-master-1", header.Get("ce-subject"))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "platform", header.Get("X-Team"))
+	spec := requests[0].body["spec"].(map[string]interface{})
+	assert.Equal(t, "myorg/myapp/master", spec["pipeline"])
+}
+
+func TestWebhookProvider(t *testing.T) {
+	t.Parallel()
+	var requests []recordedRequest
+	server := newSinkServer(t, "", &requests)
+	defer server.Close()
+
+	provider, err := pipline_events.CreatePipelineEventsProvider(&pipline_events.SinkConfig{
+		Name:  "data-platform",
+		Kind:  pipline_events.Webhook,
+		URL:   server.URL + "/hooks/jx",
+		Token: "mytoken",
+	})
+	require.NoError(t, err)
+
+	err = provider.SendRelease(&v1.Release{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-1.0.0", Namespace: "jx-staging"},
+		Spec:       v1.ReleaseSpec{Name: "myapp", Version: "1.0.0"},
+	})
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/hooks/jx", requests[0].path)
+	assert.Equal(t, "Bearer mytoken", requests[0].header.Get("Authorization"))
+	assert.Equal(t, pipline_events.EventTypeRelease, requests[0].body["type"])
+	assert.Equal(t, "jx-staging/myapp-1.0.0", requests[0].body["id"])
+	assert.Equal(t, "myapp-1.0.0", requests[0].body["subject"])
+	data := requests[0].body["data"].(map[string]interface{})
+	assert.Equal(t, "1.0.0", data["spec"].(map[string]interface{})["version"])
+}
+
+func TestKafkaProvider(t *testing.T) {
+	t.Parallel()
+	var requests []recordedRequest
+	server := newSinkServer(t, `{"offsets":[{"partition":0,"offset":12}]}`, &requests)
+	defer server.Close()
+
+	_, err := pipline_events.CreatePipelineEventsProvider(&pipline_events.SinkConfig{Kind: pipline_events.Kafka, URL: server.URL})
+	assert.Error(t, err)
+
+	provider, err := pipline_events.CreatePipelineEventsProvider(&pipline_events.SinkConfig{
+		Name:     "kafka",
+		Kind:     pipline_events.Kafka,
+		URL:      server.URL,
+		Topic:    "jx-pipeline-events",
+		Username: "jx",
+		Password: "secret",
+	})
+	require.NoError(t, err)
+
+	err = provider.SendActivity(testActivity())
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/topics/jx-pipeline-events", requests[0].path)
+	assert.Equal(t, "application/vnd.kafka.json.v2+json", requests[0].header.Get("Content-Type"))
+	assert.Contains(t, requests[0].header.Get("Authorization"), "Basic ")
+	records := requests[0].body["records"].([]interface{})
+	require.Len(t, records, 1)
+	record := records[0].(map[string]interface{})
+	assert.Equal(t, "myorg-myapp-master-1", record["key"])
+	assert.Equal(t, pipline_events.EventTypeActivity, record["value"].(map[string]interface{})["type"])
+}
+
+func TestCreateUnsupportedSink(t *testing.T) {
+	t.Parallel()
+	_, err := pipline_events.CreatePipelineEventsProvider(&pipline_events.SinkConfig{Kind: "carrier-pigeon", URL: "http://example.com"})
+	assert.Error(t, err)
+}
+
+func TestSinkRetriesOnlyServerErrors(t *testing.T) {
+	t.Parallel()
+	for status, expectedRequests := range map[int]int32{
+		http.StatusBadRequest:         1,
+		http.StatusServiceUnavailable: 3,
+	} {
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) >= 3 {
+				return
+			}
+			w.WriteHeader(status)
+		}))
+
+		provider, err := pipline_events.CreatePipelineEventsProvider(&pipline_events.SinkConfig{
+			Name: "data-platform",
+			Kind: pipline_events.Webhook,
+			URL:  server.URL,
+		})
+		require.NoError(t, err)
+		dispatcher := pipline_events.NewDispatcher(10, 10*time.Second)
+		dispatcher.RetryInitialDelay = time.Millisecond
+		dispatcher.AddSink("data-platform", provider)
+		stop := make(chan struct{})
+		dispatcher.Start(stop)
+
+		err = dispatcher.SendActivity(testActivity())
+		require.NoError(t, err)
+		expected := pipline_events.SinkStats{Sent: 1}
+		if status < 500 {
+			expected = pipline_events.SinkStats{Failed: 1}
+		}
+		waitForStats(t, dispatcher, "data-platform", expected)
+		assert.Equal(t, expectedRequests, atomic.LoadInt32(&requests), "requests for status %d", status)
+		close(stop)
+		server.Close()
+	}
+}
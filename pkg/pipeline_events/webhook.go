@@ -0,0 +1,30 @@
+package pipline_events
+
+import (
+	"net/http"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/util"
+)
+
+// WebhookProvider implements PipelineEventsProvider interface by posting each Event as JSON to a URL
+type WebhookProvider struct {
+	Client *http.Client
+	Sink   *SinkConfig
+}
+
+// NewWebhookProvider creates a provider posting the events to the URL of the sink
+func NewWebhookProvider(sink *SinkConfig) PipelineEventsProvider {
+	return &WebhookProvider{
+		Client: util.GetClient(),
+		Sink:   sink,
+	}
+}
+
+func (w *WebhookProvider) SendActivity(a *v1.PipelineActivity) error {
+	return post(w.Client, w.Sink, w.Sink.URL, NewActivityEvent(a), nil, nil)
+}
+
+func (w *WebhookProvider) SendRelease(r *v1.Release) error {
+	return post(w.Client, w.Sink, w.Sink.URL, NewReleaseEvent(r), nil, nil)
+}
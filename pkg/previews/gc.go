@@ -0,0 +1,104 @@
+package previews
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/pkg/errors"
+)
+
+// Action is what the garbage collector does with a Preview Environment
+type Action string
+
+const (
+	// ActionNone leaves the Preview Environment running
+	ActionNone Action = ""
+	// ActionDelete deletes the Preview Environment
+	ActionDelete Action = "delete"
+	// ActionScaleToZero scales the deployments of the Preview Environment down to zero replicas
+	ActionScaleToZero Action = "scale-to-zero"
+)
+
+// Decision is what the garbage collector does with a Preview Environment and why
+type Decision struct {
+	Action Action
+	Reason string
+}
+
+// RequestMetrics counts the requests made to the applications in a namespace via their ingresses
+type RequestMetrics interface {
+	// Requests returns the number of requests to the namespace within the window of time up to now
+	Requests(namespace string, window time.Duration) (float64, error)
+}
+
+// LastDeployed returns when the Preview Environment was last deployed or woken up, defaulting to when it was created
+func LastDeployed(env *v1.Environment) time.Time {
+	text := env.Annotations[kube.AnnotationPreviewLastDeployed]
+	if text != "" {
+		t, err := time.Parse(time.RFC3339, text)
+		if err == nil {
+			return t
+		}
+	}
+	return env.CreationTimestamp.Time
+}
+
+// IsScaledToZero returns true if the Preview Environment has been scaled to zero by the garbage collector
+func IsScaledToZero(env *v1.Environment) bool {
+	return env.Annotations[kube.AnnotationPreviewScaledToZero] != ""
+}
+
+// Evaluate applies the garbage collection policy of the team to the Preview Environment at the given time. The
+// metrics are only used if the policy has an idle timeout
+func Evaluate(env *v1.Environment, policy *v1.PreviewGCPolicy, metrics RequestMetrics, now time.Time) (*Decision, error) {
+	if policy == nil {
+		return &Decision{}, nil
+	}
+	action := ActionDelete
+	if policy.ScaleToZero {
+		if IsScaledToZero(env) {
+			return &Decision{Reason: "already scaled to zero"}, nil
+		}
+		action = ActionScaleToZero
+	}
+	age := now.Sub(LastDeployed(env))
+
+	if policy.TTL != "" {
+		ttl, err := time.ParseDuration(policy.TTL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid TTL %s", policy.TTL)
+		}
+		if age >= ttl {
+			return &Decision{
+				Action: action,
+				Reason: fmt.Sprintf("last deployed %s ago which is longer than the TTL of %s", age.Round(time.Minute).String(), policy.TTL),
+			}, nil
+		}
+	}
+	if policy.IdleTimeout != "" {
+		idleTimeout, err := time.ParseDuration(policy.IdleTimeout)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid idle timeout %s", policy.IdleTimeout)
+		}
+		// a preview deployed within the idle timeout cannot have been idle for long enough
+		if age < idleTimeout {
+			return &Decision{}, nil
+		}
+		if metrics == nil {
+			return nil, errors.Errorf("no metrics URL in the preview garbage collection policy so cannot find whether Preview Environment %s is idle", env.Name)
+		}
+		requests, err := metrics.Requests(env.Spec.Namespace, idleTimeout)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find the requests to namespace %s", env.Spec.Namespace)
+		}
+		if requests == 0 {
+			return &Decision{
+				Action: action,
+				Reason: fmt.Sprintf("no requests in the last %s", policy.IdleTimeout),
+			}, nil
+		}
+	}
+	return &Decision{}, nil
+}
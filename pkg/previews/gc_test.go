@@ -0,0 +1,103 @@
+package previews_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/previews"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeMetrics map[string]float64
+
+func (m fakeMetrics) Requests(namespace string, window time.Duration) (float64, error) {
+	return m[namespace], nil
+}
+
+func previewEnvironment(name string, lastDeployed time.Time, annotations map[string]string) *v1.Environment {
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[kube.AnnotationPreviewLastDeployed] = lastDeployed.Format(time.RFC3339)
+	return &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: annotations,
+		},
+		Spec: v1.EnvironmentSpec{
+			Namespace: "jx-" + name,
+			Kind:      v1.EnvironmentKindTypePreview,
+		},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2019, time.December, 20, 12, 0, 0, 0, time.UTC)
+	metrics := fakeMetrics{"jx-busy": 42}
+	ttl := &v1.PreviewGCPolicy{TTL: "72h"}
+	idle := &v1.PreviewGCPolicy{IdleTimeout: "24h", ScaleToZero: true}
+	scaled := map[string]string{kube.AnnotationPreviewScaledToZero: now.Format(time.RFC3339)}
+
+	testCases := []struct {
+		name     string
+		env      *v1.Environment
+		policy   *v1.PreviewGCPolicy
+		expected previews.Action
+	}{
+		{"no policy", previewEnvironment("old", now.AddDate(-1, 0, 0), nil), nil, previews.ActionNone},
+		{"within ttl", previewEnvironment("recent", now.Add(-71*time.Hour), nil), ttl, previews.ActionNone},
+		{"ttl expired", previewEnvironment("old", now.Add(-72*time.Hour), nil), ttl, previews.ActionDelete},
+		{"recently deployed", previewEnvironment("recent", now.Add(-time.Hour), nil), idle, previews.ActionNone},
+		{"busy", previewEnvironment("busy", now.Add(-48*time.Hour), nil), idle, previews.ActionNone},
+		{"idle", previewEnvironment("idle", now.Add(-48*time.Hour), nil), idle, previews.ActionScaleToZero},
+		{"already scaled", previewEnvironment("idle", now.Add(-48*time.Hour), scaled), idle, previews.ActionNone},
+	}
+	for _, tc := range testCases {
+		decision, err := previews.Evaluate(tc.env, tc.policy, metrics, now)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, decision.Action, tc.name)
+	}
+
+	_, err := previews.Evaluate(previewEnvironment("idle", now.Add(-48*time.Hour), nil), idle, nil, now)
+	assert.Error(t, err, "idle timeout without metrics")
+	_, err = previews.Evaluate(previewEnvironment("idle", now, nil), &v1.PreviewGCPolicy{TTL: "3 days"}, nil, now)
+	assert.Error(t, err, "invalid TTL")
+}
+
+func TestLastDeployed(t *testing.T) {
+	t.Parallel()
+	created := time.Date(2019, time.December, 1, 0, 0, 0, 0, time.UTC)
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(created),
+		},
+	}
+	assert.Equal(t, created, previews.LastDeployed(env))
+
+	deployed := created.AddDate(0, 0, 5)
+	env.Annotations = map[string]string{kube.AnnotationPreviewLastDeployed: deployed.Format(time.RFC3339)}
+	assert.Equal(t, deployed, previews.LastDeployed(env))
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		assert.Equal(t, `sum(increase(nginx_ingress_controller_requests{exported_namespace="jx-preview"}[86400s]))`, query)
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1576843200,"12"]}]}}`)
+	}))
+	defer server.Close()
+
+	metrics := &previews.PrometheusMetrics{URL: server.URL}
+	requests, err := metrics.Requests("jx-preview", 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, float64(12), requests)
+}
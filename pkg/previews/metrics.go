@@ -0,0 +1,67 @@
+package previews
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PrometheusMetrics counts the requests to a namespace using the metrics of the nginx ingress controller scraped by
+// Prometheus
+type PrometheusMetrics struct {
+	URL    string
+	Client *http.Client
+}
+
+type prometheusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Data   struct {
+		Result []struct {
+			Value []interface{} `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// Requests returns the number of requests to the namespace within the window of time up to now
+func (m *PrometheusMetrics) Requests(namespace string, window time.Duration) (float64, error) {
+	query := fmt.Sprintf(`sum(increase(nginx_ingress_controller_requests{exported_namespace="%s"}[%ds]))`, namespace, int64(window.Seconds()))
+	u := strings.TrimSuffix(m.URL, "/") + "/api/v1/query?query=" + url.QueryEscape(query)
+	client := m.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(u)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to query %s", m.URL)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read the response from %s", m.URL)
+	}
+	result := prometheusResponse{}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse the response from %s with status %d", m.URL, resp.StatusCode)
+	}
+	if result.Status != "success" {
+		return 0, errors.Errorf("query %s failed: %s", query, result.Error)
+	}
+	// no series means there have been no requests
+	if len(result.Data.Result) == 0 || len(result.Data.Result[0].Value) < 2 {
+		return 0, nil
+	}
+	text, ok := result.Data.Result[0].Value[1].(string)
+	if !ok {
+		return 0, errors.Errorf("unexpected value %v for query %s", result.Data.Result[0].Value[1], query)
+	}
+	return strconv.ParseFloat(text, 64)
+}
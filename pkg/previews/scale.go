@@ -0,0 +1,261 @@
+package previews
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// WakeupServiceName is the name of the service in the namespace of a Preview Environment scaled to zero which its
+// ingresses are routed to
+const WakeupServiceName = "preview-wakeup"
+
+// ScaleToZero scales the deployments of the Preview Environment down to zero replicas, recording their replicas so
+// that WakeUp can restore them. If there is a wakeup service the ingresses of the Preview Environment are routed to it
+// so that the environment is woken up by its first request
+func ScaleToZero(kubeClient kubernetes.Interface, jxClient versioned.Interface, devNs string, env *v1.Environment, wakeupService string) error {
+	ns := env.Spec.Namespace
+	deployments := kubeClient.AppsV1().Deployments(ns)
+	list, err := deployments.List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the deployments in namespace %s", ns)
+	}
+	zero := int32(0)
+	for i := range list.Items {
+		deployment := &list.Items[i]
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		if replicas == 0 {
+			continue
+		}
+		if deployment.Annotations == nil {
+			deployment.Annotations = map[string]string{}
+		}
+		deployment.Annotations[kube.AnnotationScaledToZeroReplicas] = strconv.Itoa(int(replicas))
+		deployment.Spec.Replicas = &zero
+		_, err = deployments.Update(deployment)
+		if err != nil {
+			return errors.Wrapf(err, "failed to scale deployment %s in namespace %s to zero", deployment.Name, ns)
+		}
+	}
+	if wakeupService != "" {
+		err = routeToWakeupService(kubeClient, ns, wakeupService)
+		if err != nil {
+			return err
+		}
+	}
+	return annotateEnvironment(jxClient, devNs, env.Name, map[string]string{
+		kube.AnnotationPreviewScaledToZero: time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// WakeUp scales the deployments of a Preview Environment which was scaled to zero back up and restores the routes of
+// its ingresses. The time it was last deployed is reset so that the TTL of the garbage collection policy starts again
+// from the wake up. It does nothing if the Preview Environment has not been scaled to zero
+func WakeUp(kubeClient kubernetes.Interface, jxClient versioned.Interface, devNs string, env *v1.Environment) error {
+	if !IsScaledToZero(env) {
+		return nil
+	}
+	ns := env.Spec.Namespace
+	deployments := kubeClient.AppsV1().Deployments(ns)
+	list, err := deployments.List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the deployments in namespace %s", ns)
+	}
+	for i := range list.Items {
+		deployment := &list.Items[i]
+		text := deployment.Annotations[kube.AnnotationScaledToZeroReplicas]
+		if text == "" {
+			continue
+		}
+		value, err := strconv.Atoi(text)
+		if err != nil {
+			return errors.Wrapf(err, "invalid annotation %s on deployment %s", kube.AnnotationScaledToZeroReplicas, deployment.Name)
+		}
+		replicas := int32(value)
+		deployment.Spec.Replicas = &replicas
+		delete(deployment.Annotations, kube.AnnotationScaledToZeroReplicas)
+		_, err = deployments.Update(deployment)
+		if err != nil {
+			return errors.Wrapf(err, "failed to scale up deployment %s in namespace %s", deployment.Name, ns)
+		}
+	}
+
+	ingresses := kubeClient.ExtensionsV1beta1().Ingresses(ns)
+	ingressList, err := ingresses.List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the ingresses in namespace %s", ns)
+	}
+	for i := range ingressList.Items {
+		ingress := &ingressList.Items[i]
+		text := ingress.Annotations[kube.AnnotationWakeupIngressSpec]
+		if text == "" {
+			continue
+		}
+		spec := v1beta1.IngressSpec{}
+		err = json.Unmarshal([]byte(text), &spec)
+		if err != nil {
+			return errors.Wrapf(err, "invalid annotation %s on ingress %s", kube.AnnotationWakeupIngressSpec, ingress.Name)
+		}
+		ingress.Spec = spec
+		delete(ingress.Annotations, kube.AnnotationWakeupIngressSpec)
+		_, err = ingresses.Update(ingress)
+		if err != nil {
+			return errors.Wrapf(err, "failed to restore ingress %s in namespace %s", ingress.Name, ns)
+		}
+	}
+	err = kubeClient.CoreV1().Services(ns).Delete(WakeupServiceName, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.Logger().Warnf("Failed to delete service %s in namespace %s: %s", WakeupServiceName, ns, err)
+	}
+	return annotateEnvironment(jxClient, devNs, env.Name, map[string]string{
+		kube.AnnotationPreviewScaledToZero: "",
+		kube.AnnotationPreviewLastDeployed: time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// FindByHost returns the Preview Environment scaled to zero which has an ingress for the given host or nil if there
+// is none
+func FindByHost(kubeClient kubernetes.Interface, jxClient versioned.Interface, devNs string, host string) (*v1.Environment, error) {
+	if idx := strings.Index(host, ":"); idx > 0 {
+		host = host[0:idx]
+	}
+	envs, err := jxClient.JenkinsV1().Environments(devNs).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the Environments in namespace %s", devNs)
+	}
+	for i := range envs.Items {
+		env := &envs.Items[i]
+		if env.Spec.Kind != v1.EnvironmentKindTypePreview || !IsScaledToZero(env) {
+			continue
+		}
+		ingresses, err := kubeClient.ExtensionsV1beta1().Ingresses(env.Spec.Namespace).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the ingresses in namespace %s", env.Spec.Namespace)
+		}
+		for _, ingress := range ingresses.Items {
+			for _, rule := range ingress.Spec.Rules {
+				if rule.Host == host {
+					return env, nil
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// routeToWakeupService routes all the ingresses in the namespace to the wakeup service, recording their original
+// spec so that it can be restored
+func routeToWakeupService(kubeClient kubernetes.Interface, ns string, wakeupService string) error {
+	services := kubeClient.CoreV1().Services(ns)
+	_, err := services.Get(WakeupServiceName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: WakeupServiceName,
+			},
+			Spec: corev1.ServiceSpec{
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: wakeupService,
+				Ports: []corev1.ServicePort{
+					{
+						Name: "http",
+						Port: 80,
+					},
+				},
+			},
+		}
+		_, err = services.Create(svc)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to create service %s in namespace %s", WakeupServiceName, ns)
+	}
+
+	backend := v1beta1.IngressBackend{
+		ServiceName: WakeupServiceName,
+		ServicePort: intstr.FromInt(80),
+	}
+	ingresses := kubeClient.ExtensionsV1beta1().Ingresses(ns)
+	list, err := ingresses.List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the ingresses in namespace %s", ns)
+	}
+	for i := range list.Items {
+		ingress := &list.Items[i]
+		if ingress.Annotations[kube.AnnotationWakeupIngressSpec] != "" {
+			continue
+		}
+		data, err := json.Marshal(&ingress.Spec)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal the spec of ingress %s", ingress.Name)
+		}
+		if ingress.Annotations == nil {
+			ingress.Annotations = map[string]string{}
+		}
+		ingress.Annotations[kube.AnnotationWakeupIngressSpec] = string(data)
+		if ingress.Spec.Backend != nil {
+			ingress.Spec.Backend = backend.DeepCopy()
+		}
+		for j := range ingress.Spec.Rules {
+			http := ingress.Spec.Rules[j].HTTP
+			if http == nil {
+				continue
+			}
+			for k := range http.Paths {
+				http.Paths[k].Backend = backend
+			}
+		}
+		_, err = ingresses.Update(ingress)
+		if err != nil {
+			return errors.Wrapf(err, "failed to route ingress %s in namespace %s to the wakeup service", ingress.Name, ns)
+		}
+	}
+	return nil
+}
+
+// annotateEnvironment sets or, if the value is empty, removes the annotations on the latest version of the Environment
+func annotateEnvironment(jxClient versioned.Interface, devNs string, name string, annotations map[string]string) error {
+	environments := jxClient.JenkinsV1().Environments(devNs)
+	env, err := environments.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get Environment %s", name)
+	}
+	changed := false
+	for annotation, value := range annotations {
+		if env.Annotations[annotation] == value {
+			continue
+		}
+		if value == "" {
+			delete(env.Annotations, annotation)
+		} else {
+			if env.Annotations == nil {
+				env.Annotations = map[string]string{}
+			}
+			env.Annotations[annotation] = value
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	_, err = environments.Update(env)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update Environment %s", name)
+	}
+	return nil
+}
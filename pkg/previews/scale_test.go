@@ -0,0 +1,113 @@
+package previews_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/previews"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestScaleToZeroAndWakeUp(t *testing.T) {
+	t.Parallel()
+	devNs := "jx"
+	deployed := time.Now().Add(-48 * time.Hour)
+	env := previewEnvironment("pr-1", deployed, nil)
+	env.Namespace = devNs
+	ns := env.Spec.Namespace
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myapp",
+			Namespace: ns,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+		},
+	}
+	ingress := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myapp",
+			Namespace: ns,
+		},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{
+				{
+					Host: "myapp.jx-pr-1.example.com",
+					IngressRuleValue: v1beta1.IngressRuleValue{
+						HTTP: &v1beta1.HTTPIngressRuleValue{
+							Paths: []v1beta1.HTTPIngressPath{
+								{
+									Backend: v1beta1.IngressBackend{
+										ServiceName: "myapp",
+										ServicePort: intstr.FromInt(8080),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	kubeClient := kubefake.NewSimpleClientset(deployment, ingress)
+	jxClient := fake.NewSimpleClientset(env)
+
+	err := previews.ScaleToZero(kubeClient, jxClient, devNs, env, "jx-preview-wakeup.jx.svc.cluster.local")
+	require.NoError(t, err)
+
+	scaledDeployment, err := kubeClient.AppsV1().Deployments(ns).Get("myapp", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), *scaledDeployment.Spec.Replicas)
+	assert.Equal(t, "2", scaledDeployment.Annotations[kube.AnnotationScaledToZeroReplicas])
+
+	routedIngress, err := kubeClient.ExtensionsV1beta1().Ingresses(ns).Get("myapp", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, previews.WakeupServiceName, routedIngress.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName)
+
+	svc, err := kubeClient.CoreV1().Services(ns).Get(previews.WakeupServiceName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "jx-preview-wakeup.jx.svc.cluster.local", svc.Spec.ExternalName)
+
+	scaledEnv, err := jxClient.JenkinsV1().Environments(devNs).Get(env.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, previews.IsScaledToZero(scaledEnv))
+
+	found, err := previews.FindByHost(kubeClient, jxClient, devNs, "myapp.jx-pr-1.example.com:80")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, env.Name, found.Name)
+
+	err = previews.WakeUp(kubeClient, jxClient, devNs, scaledEnv)
+	require.NoError(t, err)
+
+	wokenDeployment, err := kubeClient.AppsV1().Deployments(ns).Get("myapp", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), *wokenDeployment.Spec.Replicas)
+	assert.Empty(t, wokenDeployment.Annotations[kube.AnnotationScaledToZeroReplicas])
+
+	restoredIngress, err := kubeClient.ExtensionsV1beta1().Ingresses(ns).Get("myapp", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, ingress.Spec, restoredIngress.Spec)
+	assert.Empty(t, restoredIngress.Annotations[kube.AnnotationWakeupIngressSpec])
+
+	_, err = kubeClient.CoreV1().Services(ns).Get(previews.WakeupServiceName, metav1.GetOptions{})
+	assert.Error(t, err)
+
+	wokenEnv, err := jxClient.JenkinsV1().Environments(devNs).Get(env.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.False(t, previews.IsScaledToZero(wokenEnv))
+	assert.True(t, previews.LastDeployed(wokenEnv).After(deployed.Add(time.Hour)), "the TTL should start again when woken up")
+
+	found, err = previews.FindByHost(kubeClient, jxClient, devNs, "myapp.jx-pr-1.example.com")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
@@ -0,0 +1,230 @@
+package rotation
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// SecretDockerConfig the name of the Secret containing the docker config.json used by the pipelines
+	SecretDockerConfig = "jenkins-docker-cfg"
+
+	// dockerConfigKey the key of the docker config.json in SecretDockerConfig
+	dockerConfigKey = "config.json"
+)
+
+var challengeParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// RegistryHost returns the host of the API of a docker registry which may be a URL such as https://index.docker.io/v1/
+func RegistryHost(registry string) string {
+	host := registry
+	if u, err := url.Parse(registry); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	host = strings.Split(host, "/")[0]
+	// Docker Hub is referred to by several host names
+	switch host {
+	case "docker.io", "index.docker.io", "registry-1.docker.io":
+		return "registry-1.docker.io"
+	}
+	return host
+}
+
+// FindDockerAuth returns the registry and user name of the single auth in the docker config.json used by the pipelines
+func FindDockerAuth(kubeClient kubernetes.Interface, ns string) (string, string, error) {
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get(SecretDockerConfig, metav1.GetOptions{})
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to get the secret %s in namespace %s", SecretDockerConfig, ns)
+	}
+	auths, _, err := dockerConfigAuths(secret.Data[dockerConfigKey])
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to parse the secret %s in namespace %s", SecretDockerConfig, ns)
+	}
+	if len(auths) != 1 {
+		return "", "", fmt.Errorf("the secret %s in namespace %s has %d registries so the registry must be specified", SecretDockerConfig, ns, len(auths))
+	}
+	for registry, value := range auths {
+		auth, _ := value.(map[string]interface{})
+		username, _ := auth["username"].(string)
+		if encoded, ok := auth["auth"].(string); ok && username == "" {
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err == nil {
+				username = strings.SplitN(string(decoded), ":", 2)[0]
+			}
+		}
+		return registry, username, nil
+	}
+	return "", "", nil
+}
+
+// UpdateDockerConfigSecrets updates the auth of the registry in the docker config.json used by the pipelines and in the
+// image pull Secrets in the namespace which contain the registry. It returns the names of the Secrets which were updated
+func UpdateDockerConfigSecrets(kubeClient kubernetes.Interface, ns string, registry string, username string, password string) ([]string, error) {
+	secrets := kubeClient.CoreV1().Secrets(ns)
+	list, err := secrets.List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the secrets in namespace %s", ns)
+	}
+	answer := []string{}
+	for i := range list.Items {
+		secret := &list.Items[i]
+		key := ""
+		switch {
+		case secret.Name == SecretDockerConfig:
+			key = dockerConfigKey
+		case secret.Type == corev1.SecretTypeDockerConfigJson:
+			key = corev1.DockerConfigJsonKey
+		default:
+			continue
+		}
+		data, updated, err := updateDockerConfig(secret.Data[key], registry, username, password, secret.Name == SecretDockerConfig)
+		if err != nil {
+			return answer, errors.Wrapf(err, "failed to update the secret %s in namespace %s", secret.Name, ns)
+		}
+		if !updated {
+			continue
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[key] = data
+		_, err = secrets.Update(secret)
+		if err != nil {
+			return answer, errors.Wrapf(err, "failed to update the secret %s in namespace %s", secret.Name, ns)
+		}
+		answer = append(answer, secret.Name)
+	}
+	sort.Strings(answer)
+	return answer, nil
+}
+
+// updateDockerConfig updates the auth of the registry in the docker config, keeping any other fields, adding it if
+// create is true. It returns false if the registry is not in the config and create is false
+func updateDockerConfig(data []byte, registry string, username string, password string, create bool) ([]byte, bool, error) {
+	auths, config, err := dockerConfigAuths(data)
+	if err != nil {
+		return nil, false, err
+	}
+	host := RegistryHost(registry)
+	found := false
+	for name, value := range auths {
+		if RegistryHost(name) != host {
+			continue
+		}
+		auth, ok := value.(map[string]interface{})
+		if !ok {
+			auth = map[string]interface{}{}
+		}
+		setDockerAuth(auth, username, password)
+		auths[name] = auth
+		found = true
+	}
+	if !found {
+		if !create {
+			return data, false, nil
+		}
+		auth := map[string]interface{}{}
+		setDockerAuth(auth, username, password)
+		auths[registry] = auth
+	}
+	config["auths"] = auths
+	answer, err := json.Marshal(config)
+	return answer, true, err
+}
+
+func setDockerAuth(auth map[string]interface{}, username string, password string) {
+	auth["auth"] = base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	if _, ok := auth["username"]; ok {
+		auth["username"] = username
+	}
+	if _, ok := auth["password"]; ok {
+		auth["password"] = password
+	}
+}
+
+func dockerConfigAuths(data []byte) (map[string]interface{}, map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	if len(data) > 0 {
+		err := json.Unmarshal(data, &config)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	auths, ok := config["auths"].(map[string]interface{})
+	if !ok {
+		auths = map[string]interface{}{}
+	}
+	return auths, config, nil
+}
+
+// VerifyRegistry verifies that the user name and password can log in to the docker registry using the registry API,
+// following the token authentication of registries such as Docker Hub
+func VerifyRegistry(httpClient *http.Client, registry string, username string, password string) error {
+	scheme := "https"
+	if u, err := url.Parse(registry); err == nil && u.Scheme == "http" {
+		scheme = u.Scheme
+	}
+	host := RegistryHost(registry)
+	endpoint := fmt.Sprintf("%s://%s/v2/", scheme, host)
+	resp, err := basicAuthGet(httpClient, endpoint, username, password)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if resp.StatusCode != http.StatusUnauthorized || !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return fmt.Errorf("failed to log in to %s: %s", endpoint, resp.Status)
+	}
+	params := map[string]string{}
+	for _, match := range challengeParamRegex.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+	realm := params["realm"]
+	if realm == "" {
+		return fmt.Errorf("no realm in the authentication challenge %q of %s", challenge, endpoint)
+	}
+	tokenURL, err := url.Parse(realm)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse the realm %s of %s", realm, endpoint)
+	}
+	query := tokenURL.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	tokenURL.RawQuery = query.Encode()
+	resp, err = basicAuthGet(httpClient, tokenURL.String(), username, password)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to log in to %s: %s", tokenURL.String(), resp.Status)
+	}
+	return nil
+}
+
+func basicAuthGet(httpClient *http.Client, u string, username string, password string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create a request for %s", u)
+	}
+	req.SetBasicAuth(username, password)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %s", u)
+	}
+	resp.Body.Close()
+	return resp, nil
+}
@@ -0,0 +1,81 @@
+package rotation
+
+import (
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ConfigMapRotationHistory the name of the ConfigMap recording the history of secret rotations
+	ConfigMapRotationHistory = "jx-secret-rotations"
+
+	// historyKey the key of the rotation history in ConfigMapRotationHistory
+	historyKey = "history.yaml"
+)
+
+// Record records a rotation of a secret. It never contains the credential itself, only its fingerprint
+type Record struct {
+	Kind                 string    `json:"kind"`
+	Path                 string    `json:"path,omitempty"`
+	Key                  string    `json:"key,omitempty"`
+	Fingerprint          string    `json:"fingerprint"`
+	Generated            bool      `json:"generated,omitempty"`
+	Verified             bool      `json:"verified"`
+	RotatedAt            time.Time `json:"rotatedAt"`
+	RotatedBy            string    `json:"rotatedBy,omitempty"`
+	Secrets              []string  `json:"secrets,omitempty"`
+	RestartedDeployments []string  `json:"restartedDeployments,omitempty"`
+	Error                string    `json:"error,omitempty"`
+}
+
+// LoadHistory loads the history of secret rotations in the namespace, oldest first
+func LoadHistory(kubeClient kubernetes.Interface, ns string) ([]Record, error) {
+	configMap, err := kubeClient.CoreV1().ConfigMaps(ns).Get(ConfigMapRotationHistory, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return []Record{}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get the ConfigMap %s in namespace %s", ConfigMapRotationHistory, ns)
+	}
+	return parseHistory(configMap.Data[historyKey])
+}
+
+// AddRecord appends the record to the history of secret rotations in the namespace
+func AddRecord(kubeClient kubernetes.Interface, ns string, record *Record) error {
+	_, err := kube.DefaultModifyConfigMap(kubeClient, ns, ConfigMapRotationHistory, func(configMap *corev1.ConfigMap) error {
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		history, err := parseHistory(configMap.Data[historyKey])
+		if err != nil {
+			return err
+		}
+		history = append(history, *record)
+		data, err := yaml.Marshal(history)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the secret rotation history")
+		}
+		configMap.Data[historyKey] = string(data)
+		return nil
+	}, nil)
+	return err
+}
+
+func parseHistory(text string) ([]Record, error) {
+	history := []Record{}
+	if text == "" {
+		return history, nil
+	}
+	err := yaml.Unmarshal([]byte(text), &history)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the secret rotation history in ConfigMap %s", ConfigMapRotationHistory)
+	}
+	return history, nil
+}
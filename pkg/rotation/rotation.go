@@ -0,0 +1,295 @@
+package rotation
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/secreturl"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// KindGit the token of the pipeline user of the git provider
+	KindGit = "git"
+	// KindDocker the password of the docker registry
+	KindDocker = "docker"
+	// KindChat the token of the chat bot
+	KindChat = "chat"
+	// KindHMAC the HMAC token used to sign the webhooks of the git provider
+	KindHMAC = "hmac"
+
+	// AnnotationRestartedAt is the pod template annotation used to restart a deployment. It is the same annotation
+	// used by 'kubectl rollout restart'
+	AnnotationRestartedAt = "kubectl.kubernetes.io/restartedAt"
+)
+
+// SecretKey is a key of a Kubernetes Secret
+type SecretKey struct {
+	Name string
+	Key  string
+}
+
+// SecretKind describes where jx boot stores a kind of secret
+type SecretKind struct {
+	// Name is the name of the kind
+	Name string
+	// Description describes the credential
+	Description string
+	// Path is the path of the secret in the secret store relative to the secret base path
+	Path string
+	// Key is the key of the credential in the secret at Path
+	Key string
+	// Generated indicates that the credential is a random value which can be generated rather than being issued by a service
+	Generated bool
+	// Secrets are the well known Kubernetes Secrets the credential is copied into
+	Secrets []SecretKey
+}
+
+// SecretKinds are the kinds of secrets which can be rotated
+var SecretKinds = []SecretKind{
+	{
+		Name:        KindGit,
+		Description: "the token of the pipeline user of the git provider",
+		Path:        "pipelineUser",
+		Key:         "token",
+		Secrets: []SecretKey{
+			{Name: "oauth-token", Key: "oauth"},
+			{Name: "lighthouse-oauth-token", Key: "oauth"},
+		},
+	},
+	{
+		Name:        KindDocker,
+		Description: "the password of the docker registry",
+		Path:        "docker",
+		Key:         "password",
+	},
+	{
+		Name:        KindChat,
+		Description: "the token of the chat bot",
+		Path:        "chat",
+		Key:         "token",
+	},
+	{
+		Name:        KindHMAC,
+		Description: "the HMAC token used to sign the webhooks of the git provider",
+		Path:        "prow",
+		Key:         "hmacToken",
+		Generated:   true,
+		Secrets: []SecretKey{
+			{Name: "hmac-token", Key: "hmac"},
+			{Name: "lighthouse-hmac-token", Key: "hmac"},
+		},
+	},
+}
+
+// SecretKindNames returns the names of the kinds of secrets which can be rotated
+func SecretKindNames() []string {
+	answer := []string{}
+	for _, kind := range SecretKinds {
+		answer = append(answer, kind.Name)
+	}
+	return answer
+}
+
+// FindSecretKind returns the kind of secret with the given name
+func FindSecretKind(name string) (*SecretKind, error) {
+	for i := range SecretKinds {
+		if SecretKinds[i].Name == name {
+			return &SecretKinds[i], nil
+		}
+	}
+	return nil, util.InvalidArg(name, SecretKindNames())
+}
+
+// GenerateCredential generates a new random credential of 32 bytes from a cryptographically secure source, hex encoded
+func GenerateCredential() (string, error) {
+	data := make([]byte, 32)
+	_, err := rand.Read(data)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read random bytes")
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// Fingerprint returns a fingerprint of a credential which identifies it in the rotation history without revealing it
+func Fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// SecretPath returns the path in the secret store of the kind of secret below the given base path
+func SecretPath(basePath string, kind *SecretKind) string {
+	if basePath == "" {
+		return kind.Path
+	}
+	return strings.TrimSuffix(basePath, "/") + "/" + kind.Path
+}
+
+// WriteSecret upserts the credential into the secret at the path in the secret store, keeping the other keys of the secret
+func WriteSecret(client secreturl.Client, path string, key string, value string) error {
+	data, err := client.Read(path)
+	if err != nil && !secreturl.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to read the secret %s", path)
+	}
+	if err != nil || data == nil {
+		data = map[string]interface{}{}
+	}
+	data[key] = value
+	_, err = client.Write(path, data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the secret %s", path)
+	}
+	return nil
+}
+
+// UpdateSecretKeys sets the given keys of the Kubernetes Secrets which exist in the namespace to the value and
+// returns the names of the Secrets which were updated
+func UpdateSecretKeys(kubeClient kubernetes.Interface, ns string, keys []SecretKey, value string) ([]string, error) {
+	answer := []string{}
+	secrets := kubeClient.CoreV1().Secrets(ns)
+	for _, key := range keys {
+		secret, err := secrets.Get(key.Name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return answer, errors.Wrapf(err, "failed to get the secret %s in namespace %s", key.Name, ns)
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[key.Key] = []byte(value)
+		_, err = secrets.Update(secret)
+		if err != nil {
+			return answer, errors.Wrapf(err, "failed to update the secret %s in namespace %s", key.Name, ns)
+		}
+		answer = append(answer, key.Name)
+	}
+	return answer, nil
+}
+
+// UpdateAuthSecrets sets the token of the given user in the auth Secrets of the given kind, such as git or chat, and
+// returns the names of the Secrets which were updated. The auth Secrets may be derived from a secret store rather
+// than being saved by the auth config service, so they are updated here so that the restarted deployments use the
+// new token. It fails without updating any Secret if one of them is managed by a controller, such as an
+// ExternalSecret, as the controller would replace the token
+func UpdateAuthSecrets(kubeClient kubernetes.Interface, ns string, kind string, username string, value string) ([]string, error) {
+	secrets := kubeClient.CoreV1().Secrets(ns)
+	list, err := secrets.List(metav1.ListOptions{
+		LabelSelector: kube.LabelKind + "=" + kind,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the %s secrets in namespace %s", kind, ns)
+	}
+	matches := []*corev1.Secret{}
+	for i := range list.Items {
+		secret := &list.Items[i]
+		if username != "" && string(secret.Data[kube.SecretDataUsername]) != username {
+			continue
+		}
+		if len(secret.OwnerReferences) > 0 {
+			owner := secret.OwnerReferences[0]
+			return nil, errors.Errorf("the secret %s in namespace %s is managed by %s %s so the %s token must be rotated where it is defined",
+				secret.Name, ns, owner.Kind, owner.Name, kind)
+		}
+		matches = append(matches, secret)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Name < matches[j].Name
+	})
+	answer := []string{}
+	for _, secret := range matches {
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		if string(secret.Data[kube.SecretDataPassword]) != value {
+			secret.Data[kube.SecretDataPassword] = []byte(value)
+			_, err = secrets.Update(secret)
+			if err != nil {
+				return answer, errors.Wrapf(err, "failed to update the secret %s in namespace %s", secret.Name, ns)
+			}
+		}
+		answer = append(answer, secret.Name)
+	}
+	return answer, nil
+}
+
+// RestartDeployments restarts the deployments in the namespace whose pods use any of the given Secrets and returns
+// their names
+func RestartDeployments(kubeClient kubernetes.Interface, ns string, secretNames []string) ([]string, error) {
+	answer := []string{}
+	if len(secretNames) == 0 {
+		return answer, nil
+	}
+	deployments := kubeClient.AppsV1().Deployments(ns)
+	list, err := deployments.List(metav1.ListOptions{})
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to list the deployments in namespace %s", ns)
+	}
+	restartedAt := time.Now().UTC().Format(time.RFC3339)
+	for i := range list.Items {
+		deployment := &list.Items[i]
+		if !UsesSecrets(&deployment.Spec.Template.Spec, secretNames) {
+			continue
+		}
+		if deployment.Spec.Template.Annotations == nil {
+			deployment.Spec.Template.Annotations = map[string]string{}
+		}
+		deployment.Spec.Template.Annotations[AnnotationRestartedAt] = restartedAt
+		_, err = deployments.Update(deployment)
+		if err != nil {
+			return answer, errors.Wrapf(err, "failed to restart deployment %s in namespace %s", deployment.Name, ns)
+		}
+		answer = append(answer, deployment.Name)
+	}
+	return answer, nil
+}
+
+// UsesSecrets returns true if the pod spec mounts or references any of the given Secrets
+func UsesSecrets(podSpec *corev1.PodSpec, secretNames []string) bool {
+	uses := func(name string) bool {
+		return name != "" && util.StringArrayIndex(secretNames, name) >= 0
+	}
+	for _, volume := range podSpec.Volumes {
+		if volume.Secret != nil && uses(volume.Secret.SecretName) {
+			return true
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil && uses(source.Secret.Name) {
+					return true
+				}
+			}
+		}
+	}
+	for _, ref := range podSpec.ImagePullSecrets {
+		if uses(ref.Name) {
+			return true
+		}
+	}
+	containers := append([]corev1.Container{}, podSpec.InitContainers...)
+	containers = append(containers, podSpec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil && uses(envFrom.SecretRef.Name) {
+				return true
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && uses(env.ValueFrom.SecretKeyRef.Name) {
+				return true
+			}
+		}
+	}
+	return false
+}
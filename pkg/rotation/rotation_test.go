@@ -0,0 +1,343 @@
+package rotation_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/rotation"
+	"github.com/jenkins-x/jx/pkg/secreturl"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func deployment(name string, ns string, spec corev1.PodSpec) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: spec,
+			},
+		},
+	}
+}
+
+func TestGenerateCredential(t *testing.T) {
+	t.Parallel()
+	first, err := rotation.GenerateCredential()
+	require.NoError(t, err)
+	second, err := rotation.GenerateCredential()
+	require.NoError(t, err)
+
+	assert.Regexp(t, "^[0-9a-f]{64}$", first)
+	assert.NotEqual(t, first, second)
+}
+
+func TestUpdateSecretKeysAndRestartDeployments(t *testing.T) {
+	t.Parallel()
+	ns := "jx"
+	hmac := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hmac-token",
+			Namespace: ns,
+		},
+		Data: map[string][]byte{
+			"hmac": []byte("old"),
+		},
+	}
+	hook := deployment("hook", ns, corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{
+				Name: "hmac",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: "hmac-token"},
+				},
+			},
+		},
+	})
+	tide := deployment("tide", ns, corev1.PodSpec{
+		Containers: []corev1.Container{
+			{
+				Name: "tide",
+				Env: []corev1.EnvVar{
+					{
+						Name: "TOKEN",
+						ValueFrom: &corev1.EnvVarSource{
+							SecretKeyRef: &corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "oauth-token"},
+								Key:                  "oauth",
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	kubeClient := kubefake.NewSimpleClientset(hmac, hook, tide)
+
+	kind, err := rotation.FindSecretKind(rotation.KindHMAC)
+	require.NoError(t, err)
+	names, err := rotation.UpdateSecretKeys(kubeClient, ns, kind.Secrets, "new")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hmac-token"}, names)
+
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get("hmac-token", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(secret.Data["hmac"]))
+
+	restarted, err := rotation.RestartDeployments(kubeClient, ns, names)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hook"}, restarted)
+
+	updated, err := kubeClient.AppsV1().Deployments(ns).Get("hook", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, updated.Spec.Template.Annotations[rotation.AnnotationRestartedAt])
+	updated, err = kubeClient.AppsV1().Deployments(ns).Get("tide", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, updated.Spec.Template.Annotations[rotation.AnnotationRestartedAt])
+}
+
+func TestUpdateAuthSecrets(t *testing.T) {
+	t.Parallel()
+	ns := "jx"
+	secret := func(name string, kind string, username string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				Labels:    map[string]string{kube.LabelKind: kind},
+			},
+			Data: map[string][]byte{
+				kube.SecretDataUsername: []byte(username),
+				kube.SecretDataPassword: []byte("old"),
+			},
+		}
+	}
+	kubeClient := kubefake.NewSimpleClientset(
+		secret("jx-pipeline-git-github-github", kube.ValueKindGit, "bot"),
+		secret("jx-pipeline-git-github-other", kube.ValueKindGit, "someone"),
+		secret("jx-pipeline-chat-slack", kube.ValueKindChat, "bot"),
+	)
+
+	names, err := rotation.UpdateAuthSecrets(kubeClient, ns, kube.ValueKindGit, "bot", "new")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jx-pipeline-git-github-github"}, names)
+
+	expected := map[string]string{
+		"jx-pipeline-git-github-github": "new",
+		"jx-pipeline-git-github-other":  "old",
+		"jx-pipeline-chat-slack":        "old",
+	}
+	for name, password := range expected {
+		actual, err := kubeClient.CoreV1().Secrets(ns).Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, password, string(actual.Data[kube.SecretDataPassword]), "password of secret %s", name)
+	}
+}
+
+func TestUpdateAuthSecretsManagedExternally(t *testing.T) {
+	t.Parallel()
+	ns := "jx"
+	kubeClient := kubefake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jx-pipeline-git-github-github",
+			Namespace: ns,
+			Labels:    map[string]string{kube.LabelKind: kube.ValueKindGit},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "kubernetes-client.io/v1",
+					Kind:       "ExternalSecret",
+					Name:       "jx-pipeline-git-github-github",
+				},
+			},
+		},
+		Data: map[string][]byte{
+			kube.SecretDataUsername: []byte("bot"),
+			kube.SecretDataPassword: []byte("old"),
+		},
+	})
+
+	_, err := rotation.UpdateAuthSecrets(kubeClient, ns, kube.ValueKindGit, "bot", "new")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ExternalSecret")
+
+	actual, err := kubeClient.CoreV1().Secrets(ns).Get("jx-pipeline-git-github-github", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(actual.Data[kube.SecretDataPassword]))
+}
+
+func TestUpdateDockerConfigSecrets(t *testing.T) {
+	t.Parallel()
+	ns := "jx"
+	auth := func(username string, password string) string {
+		return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	}
+	config := `{"auths":{"https://index.docker.io/v1/":{"auth":"` + auth("myuser", "old") + `","email":"me@example.com"}}}`
+	dockerCfg := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rotation.SecretDockerConfig,
+			Namespace: ns,
+		},
+		Data: map[string][]byte{
+			"config.json": []byte(config),
+		},
+	}
+	pullSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pull-secret",
+			Namespace: ns,
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths":{"index.docker.io":{"username":"myuser","password":"old"}}}`),
+		},
+	}
+	otherSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "other-registry",
+			Namespace: ns,
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths":{"gcr.io":{"auth":"` + auth("_json_key", "key") + `"}}}`),
+		},
+	}
+	kubeClient := kubefake.NewSimpleClientset(dockerCfg, pullSecret, otherSecret)
+
+	registry, username, err := rotation.FindDockerAuth(kubeClient, ns)
+	require.NoError(t, err)
+	assert.Equal(t, "https://index.docker.io/v1/", registry)
+	assert.Equal(t, "myuser", username)
+
+	names, err := rotation.UpdateDockerConfigSecrets(kubeClient, ns, "docker.io", "myuser", "new")
+	require.NoError(t, err)
+	assert.Equal(t, []string{rotation.SecretDockerConfig, "pull-secret"}, names)
+
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get(rotation.SecretDockerConfig, metav1.GetOptions{})
+	require.NoError(t, err)
+	actual := map[string]map[string]map[string]string{}
+	require.NoError(t, json.Unmarshal(secret.Data["config.json"], &actual))
+	assert.Equal(t, map[string]string{"auth": auth("myuser", "new"), "email": "me@example.com"}, actual["auths"]["https://index.docker.io/v1/"])
+
+	secret, err = kubeClient.CoreV1().Secrets(ns).Get("pull-secret", metav1.GetOptions{})
+	require.NoError(t, err)
+	actual = map[string]map[string]map[string]string{}
+	require.NoError(t, json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &actual))
+	assert.Equal(t, map[string]string{"auth": auth("myuser", "new"), "username": "myuser", "password": "new"}, actual["auths"]["index.docker.io"])
+}
+
+func TestVerifyRegistry(t *testing.T) {
+	t.Parallel()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry.example.com"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/token":
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "myuser" || password != "secret" || r.URL.Query().Get("service") != "registry.example.com" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"token":"abc"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	err := rotation.VerifyRegistry(server.Client(), server.URL, "myuser", "secret")
+	assert.NoError(t, err)
+
+	err = rotation.VerifyRegistry(server.Client(), server.URL, "myuser", "wrong")
+	assert.Error(t, err)
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+	ns := "jx"
+	kubeClient := kubefake.NewSimpleClientset()
+
+	history, err := rotation.LoadHistory(kubeClient, ns)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	first := &rotation.Record{
+		Kind:        rotation.KindGit,
+		Fingerprint: rotation.Fingerprint("first"),
+		Verified:    true,
+		Secrets:     []string{"jx-pipeline-git-github-github"},
+	}
+	second := &rotation.Record{
+		Kind:        rotation.KindHMAC,
+		Fingerprint: rotation.Fingerprint("second"),
+		Generated:   true,
+	}
+	require.NoError(t, rotation.AddRecord(kubeClient, ns, first))
+	require.NoError(t, rotation.AddRecord(kubeClient, ns, second))
+
+	history, err = rotation.LoadHistory(kubeClient, ns)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, rotation.KindGit, history[0].Kind)
+	assert.Equal(t, []string{"jx-pipeline-git-github-github"}, history[0].Secrets)
+	assert.Equal(t, rotation.KindHMAC, history[1].Kind)
+	assert.NotEqual(t, history[0].Fingerprint, history[1].Fingerprint)
+	assert.NotContains(t, history[0].Fingerprint, "first")
+}
+
+type fakeSecretClient struct {
+	secreturl.Client
+	data    map[string]map[string]interface{}
+	readErr error
+}
+
+func (c *fakeSecretClient) Read(secretName string) (map[string]interface{}, error) {
+	if c.readErr != nil {
+		return nil, c.readErr
+	}
+	data, ok := c.data[secretName]
+	if !ok {
+		return nil, errors.Wrapf(secreturl.ErrNotFound, "no secret %s", secretName)
+	}
+	return data, nil
+}
+
+func (c *fakeSecretClient) Write(secretName string, data map[string]interface{}) (map[string]interface{}, error) {
+	c.data[secretName] = data
+	return data, nil
+}
+
+func TestWriteSecret(t *testing.T) {
+	t.Parallel()
+	client := &fakeSecretClient{
+		data: map[string]map[string]interface{}{
+			"cluster/pipelineUser": {"username": "bot", "token": "old"},
+		},
+	}
+
+	err := rotation.WriteSecret(client, "cluster/pipelineUser", "token", "new")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"username": "bot", "token": "new"}, client.data["cluster/pipelineUser"])
+
+	err = rotation.WriteSecret(client, "cluster/hmacToken", "token", "hmac")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"token": "hmac"}, client.data["cluster/hmacToken"])
+
+	client.readErr = errors.New("connection refused")
+	err = rotation.WriteSecret(client, "cluster/pipelineUser", "token", "newer")
+	require.Error(t, err)
+	assert.Equal(t, map[string]interface{}{"username": "bot", "token": "new"}, client.data["cluster/pipelineUser"])
+}
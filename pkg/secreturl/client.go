@@ -1,5 +1,15 @@
 package secreturl
 
+import "github.com/pkg/errors"
+
+// ErrNotFound is the cause of the error returned by Client.Read when the named secret does not exist
+var ErrNotFound = errors.New("secret not found")
+
+// IsNotFound returns true if the error was caused by reading a secret which does not exist
+func IsNotFound(err error) bool {
+	return errors.Cause(err) == ErrNotFound
+}
+
 // Client is a simple interface for acessing vault-like secret storage URLs such as `vault.Client` or a file system we can use to
 // access secret files and values in helm.
 //go:generate pegomock generate github.com/jenkins-x/jx/pkg/secreturl Client -o mocks/secreturl_client.go
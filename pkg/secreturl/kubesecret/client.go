@@ -0,0 +1,159 @@
+package kubesecret
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/kube/naming"
+	"github.com/jenkins-x/jx/pkg/secreturl"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Scheme the URL scheme of secrets stored in Kubernetes Secrets such as `kubesecret:jx/my-secret:token`
+const Scheme = "kubesecret:"
+
+var kubeSecretURIRegex = regexp.MustCompile(`:[\s"]*kubesecret:[-_.\w\/:]*`)
+
+// Client a secret URL client which reads and writes native Kubernetes Secrets. Secret names are either
+// `namespace/name` or a `name` in the default namespace. Deeper paths such as `namespace/a/b` are stored in the
+// Secret `a-b` and names are converted to valid Kubernetes resource names
+type Client struct {
+	KubeClient kubernetes.Interface
+	Namespace  string
+}
+
+// NewClient creates a new Kubernetes Secrets based client using the namespace for secret names without a namespace
+func NewClient(kubeClient kubernetes.Interface, namespace string) secreturl.Client {
+	return &Client{
+		KubeClient: kubeClient,
+		Namespace:  namespace,
+	}
+}
+
+// Read reads the data of a named Kubernetes Secret as strings
+func (c *Client) Read(secretName string) (map[string]interface{}, error) {
+	ns, name, err := c.parseName(secretName)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := c.KubeClient.CoreV1().Secrets(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(secreturl.ErrNotFound, "no Secret %s in namespace %s", name, ns)
+		}
+		return nil, errors.Wrapf(err, "failed to get Secret %s in namespace %s", name, ns)
+	}
+	answer := map[string]interface{}{}
+	for k, v := range secret.Data {
+		answer[k] = string(v)
+	}
+	for k, v := range secret.StringData {
+		answer[k] = v
+	}
+	return answer, nil
+}
+
+// ReadObject reads a generic named object from a Kubernetes Secret. Values which were written as JSON objects or
+// arrays by WriteObject are decoded
+func (c *Client) ReadObject(secretName string, secret interface{}) error {
+	m, err := c.Read(secretName)
+	if err != nil {
+		return errors.Wrapf(err, "reading the secret %q from Kubernetes", secretName)
+	}
+	for k, v := range m {
+		text := v.(string)
+		if strings.HasPrefix(text, "{") || strings.HasPrefix(text, "[") {
+			var value interface{}
+			if json.Unmarshal([]byte(text), &value) == nil {
+				m[k] = value
+			}
+		}
+	}
+	err = util.ToStructFromMapStringInterface(m, &secret)
+	if err != nil {
+		return errors.Wrapf(err, "deserializing the secret %q from Kubernetes", secretName)
+	}
+	return nil
+}
+
+// Write creates or replaces the data of the named Kubernetes Secret. Values which are not strings are stored as JSON
+func (c *Client) Write(secretName string, data map[string]interface{}) (map[string]interface{}, error) {
+	ns, name, err := c.parseName(secretName)
+	if err != nil {
+		return nil, err
+	}
+	secretData := map[string][]byte{}
+	for k, v := range data {
+		if text, ok := v.(string); ok {
+			secretData[k] = []byte(text)
+			continue
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal the value of %s in secret %s", k, secretName)
+		}
+		secretData[k] = value
+	}
+	secrets := c.KubeClient.CoreV1().Secrets(ns)
+	secret, err := secrets.Get(name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Data: secretData,
+		}
+		_, err = secrets.Create(secret)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create Secret %s in namespace %s", name, ns)
+		}
+		return c.Read(secretName)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get Secret %s in namespace %s", name, ns)
+	}
+	secret.Data = secretData
+	secret.StringData = nil
+	_, err = secrets.Update(secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update Secret %s in namespace %s", name, ns)
+	}
+	return c.Read(secretName)
+}
+
+// WriteObject writes a generic named object to a Kubernetes Secret.
+// The secret _must_ be serializable to JSON.
+func (c *Client) WriteObject(secretName string, secret interface{}) (map[string]interface{}, error) {
+	m, err := util.ToMapStringInterfaceFromStruct(secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "serializing the secret %q", secretName)
+	}
+	return c.Write(secretName, m)
+}
+
+// ReplaceURIs will replace any kubesecret: URIs in a string
+func (c *Client) ReplaceURIs(s string) (string, error) {
+	return secreturl.ReplaceURIs(s, c, kubeSecretURIRegex, Scheme)
+}
+
+func (c *Client) parseName(secretName string) (string, string, error) {
+	ns := c.Namespace
+	parts := strings.Split(strings.Trim(secretName, "/"), "/")
+	if len(parts) > 1 {
+		ns = parts[0]
+		parts = parts[1:]
+	}
+	name := naming.ToValidNameWithDots(strings.Join(parts, "-"))
+	if ns == "" || name == "" {
+		return "", "", fmt.Errorf("invalid Kubernetes Secret name %q, expected 'namespace/name' or 'name'", secretName)
+	}
+	return ns, name, nil
+}
@@ -0,0 +1,79 @@
+package kubesecret_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/secreturl/kubesecret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReplaceURIs(t *testing.T) {
+	t.Parallel()
+	kubeClient := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jenkins-x-docker-registry",
+			Namespace: "jx",
+		},
+		Data: map[string][]byte{
+			"config.json": []byte("{}"),
+		},
+	}, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pipeline-user",
+			Namespace: "jx-staging",
+		},
+		Data: map[string][]byte{
+			"token": []byte("mytoken"),
+		},
+	})
+	client := kubesecret.NewClient(kubeClient, "jx")
+
+	text, err := client.ReplaceURIs(`token: kubesecret:jx-staging/pipeline-user:token
+config: "kubesecret:jenkins-x-docker-registry:config.json"`)
+	require.NoError(t, err)
+	assert.Equal(t, `token: mytoken
+config: "{}"`, text)
+
+	_, err = client.ReplaceURIs("token: kubesecret:jx-staging/pipeline-user:password")
+	assert.Error(t, err, "missing key")
+	_, err = client.ReplaceURIs("token: kubesecret:jx/missing:token")
+	assert.Error(t, err, "missing secret")
+}
+
+func TestWriteAndReadObject(t *testing.T) {
+	t.Parallel()
+	type userAuth struct {
+		Username string            `json:"username"`
+		Token    string            `json:"token"`
+		Labels   map[string]string `json:"labels"`
+	}
+	kubeClient := fake.NewSimpleClientset()
+	client := kubesecret.NewClient(kubeClient, "jx")
+
+	auth := userAuth{
+		Username: "jenkins-x-bot",
+		Token:    "mytoken",
+		Labels:   map[string]string{"kind": "git"},
+	}
+	_, err := client.WriteObject("jx/pipelineUser", &auth)
+	require.NoError(t, err)
+
+	secret, err := kubeClient.CoreV1().Secrets("jx").Get("pipelineuser", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "mytoken", string(secret.Data["token"]))
+
+	actual := userAuth{}
+	err = client.ReadObject("jx/pipelineUser", &actual)
+	require.NoError(t, err)
+	assert.Equal(t, auth, actual)
+
+	_, err = client.Write("jx/pipelineUser", map[string]interface{}{"token": "newtoken"})
+	require.NoError(t, err)
+	values, err := client.Read("jx/pipelineUser")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"token": "newtoken"}, values)
+}
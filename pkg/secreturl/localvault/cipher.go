@@ -0,0 +1,293 @@
+package localvault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// KeyFileEnvVar the environment variable for the key file used to encrypt the local secrets
+	KeyFileEnvVar = "JX_LOCAL_SECRETS_KEY_FILE"
+	// PassphraseEnvVar the environment variable for the passphrase used to encrypt the local secrets
+	PassphraseEnvVar = "JX_LOCAL_SECRETS_PASSPHRASE"
+
+	// encryptedHeader is the prefix of the first line of an encrypted secret file
+	encryptedHeader = "JXENC:v1:"
+
+	keySize  = 32
+	saltSize = 16
+)
+
+// Cipher encrypts and decrypts the content of local secret files
+type Cipher interface {
+	// ID identifies the cipher and key used to encrypt a file so that files encrypted with another key are detected
+	ID() string
+	// Encrypt encrypts the plain text
+	Encrypt(plaintext []byte) ([]byte, error)
+	// Decrypt decrypts the cipher text created by Encrypt
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// keyCipher encrypts with AES-256-GCM using a key held in a local key file
+type keyCipher struct {
+	key []byte
+}
+
+// NewKeyCipher creates a cipher which encrypts with AES-256-GCM using the 32 byte key
+func NewKeyCipher(key []byte) (Cipher, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("the key must be %d bytes but was %d bytes", keySize, len(key))
+	}
+	return &keyCipher{key: key}, nil
+}
+
+// ID returns the id of the cipher including a fingerprint of the key
+func (c *keyCipher) ID() string {
+	hash := sha256.Sum256(c.key)
+	return "aes256gcm:" + hex.EncodeToString(hash[:4])
+}
+
+// Encrypt encrypts the plain text
+func (c *keyCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	return seal(c.key, nil, plaintext)
+}
+
+// Decrypt decrypts the cipher text
+func (c *keyCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	return open(c.key, ciphertext)
+}
+
+// passphraseCipher encrypts with AES-256-GCM using a key derived from a passphrase with scrypt and a random salt
+// per file
+type passphraseCipher struct {
+	passphrase []byte
+}
+
+// NewPassphraseCipher creates a cipher which encrypts with AES-256-GCM using keys derived from the passphrase
+func NewPassphraseCipher(passphrase string) (Cipher, error) {
+	if passphrase == "" {
+		return nil, errors.New("the passphrase must not be empty")
+	}
+	return &passphraseCipher{passphrase: []byte(passphrase)}, nil
+}
+
+// ID returns the id of the cipher
+func (c *passphraseCipher) ID() string {
+	return "scrypt-aes256gcm"
+}
+
+// Encrypt encrypts the plain text prefixing it with the salt of the derived key
+func (c *passphraseCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	_, err := io.ReadFull(rand.Reader, salt)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating salt")
+	}
+	key, err := c.deriveKey(salt)
+	if err != nil {
+		return nil, err
+	}
+	return seal(key, salt, plaintext)
+}
+
+// Decrypt decrypts the cipher text
+func (c *passphraseCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < saltSize {
+		return nil, errors.New("the cipher text is too short")
+	}
+	key, err := c.deriveKey(ciphertext[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	return open(key, ciphertext[saltSize:])
+}
+
+func (c *passphraseCipher) deriveKey(salt []byte) ([]byte, error) {
+	key, err := scrypt.Key(c.passphrase, salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "deriving the key from the passphrase")
+	}
+	return key, nil
+}
+
+// seal encrypts the plain text with AES-256-GCM returning the prefix, the random nonce and the sealed text
+func seal(key []byte, prefix []byte, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating nonce")
+	}
+	answer := append(append([]byte{}, prefix...), nonce...)
+	return gcm.Seal(answer, nonce, plaintext, nil), nil
+}
+
+// open decrypts the nonce and sealed text created by seal
+func open(key []byte, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("the cipher text is too short")
+	}
+	nonce := ciphertext[:gcm.NonceSize()]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting, the key or passphrase may be wrong")
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating the AES cipher")
+	}
+	return cipher.NewGCM(block)
+}
+
+// IsEncrypted returns true if the content of a secret file is encrypted
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedHeader))
+}
+
+// Encrypt encrypts the content of a secret file adding a header which identifies the cipher
+func Encrypt(c Cipher, plaintext []byte) ([]byte, error) {
+	ciphertext, err := c.Encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(encryptedHeader + c.ID() + "\n" + base64.StdEncoding.EncodeToString(ciphertext) + "\n"), nil
+}
+
+// Decrypt decrypts the content of a secret file returning it unchanged if it is not encrypted
+func Decrypt(c Cipher, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	text := strings.TrimPrefix(string(data), encryptedHeader)
+	idx := strings.Index(text, "\n")
+	if idx < 0 {
+		return nil, errors.New("missing encrypted content")
+	}
+	id := text[:idx]
+	if c == nil {
+		return nil, fmt.Errorf("the secret is encrypted with %s but no key file or passphrase is configured via $%s or $%s", id, KeyFileEnvVar, PassphraseEnvVar)
+	}
+	if id != c.ID() {
+		return nil, fmt.Errorf("the secret is encrypted with %s but the configured key is %s", id, c.ID())
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text[idx+1:]))
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding encrypted content")
+	}
+	return c.Decrypt(ciphertext)
+}
+
+// GenerateKey generates a random key for NewKeyCipher
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	_, err := io.ReadFull(rand.Reader, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating key")
+	}
+	return key, nil
+}
+
+// SaveKeyFile saves the key to a file which is only readable by the current user
+func SaveKeyFile(fileName string, key []byte) error {
+	err := os.MkdirAll(filepath.Dir(fileName), util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to ensure that parent directory exists %s", fileName)
+	}
+	err = ioutil.WriteFile(fileName, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save key file %s", fileName)
+	}
+	return nil
+}
+
+// LoadKeyFile loads a cipher from a key file created by SaveKeyFile
+func LoadKeyFile(fileName string) (Cipher, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read key file %s", fileName)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode key file %s", fileName)
+	}
+	return NewKeyCipher(key)
+}
+
+// RetireKeyFile renames the key file with an '.old' suffix so that it is no longer used to encrypt or decrypt the
+// local secrets, returning its new name or an empty string if there is no key file
+func RetireKeyFile(fileName string) (string, error) {
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if !exists {
+		return "", nil
+	}
+	oldFileName := fileName + ".old"
+	err = os.Rename(fileName, oldFileName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to rename key file %s to %s", fileName, oldFileName)
+	}
+	return oldFileName, nil
+}
+
+// DefaultKeyFile returns the default location of the key file used to encrypt the local secrets
+func DefaultKeyFile() (string, error) {
+	dir, err := util.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "localSecrets.key"), nil
+}
+
+// CipherFromEnvironment returns the cipher configured by $JX_LOCAL_SECRETS_KEY_FILE or $JX_LOCAL_SECRETS_PASSPHRASE,
+// falling back to the default key file if it exists. It returns nil if no cipher is configured and the secrets
+// are stored as plain text
+func CipherFromEnvironment() (Cipher, error) {
+	keyFile := os.Getenv(KeyFileEnvVar)
+	if keyFile != "" {
+		return LoadKeyFile(keyFile)
+	}
+	passphrase := os.Getenv(PassphraseEnvVar)
+	if passphrase != "" {
+		return NewPassphraseCipher(passphrase)
+	}
+	keyFile, err := DefaultKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	exists, err := util.FileExists(keyFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", keyFile)
+	}
+	if !exists {
+		return nil, nil
+	}
+	return LoadKeyFile(keyFile)
+}
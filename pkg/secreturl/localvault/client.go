@@ -1,12 +1,13 @@
 package localvault
 
 import (
-	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/ghodss/yaml"
 	"github.com/jenkins-x/jx/pkg/helm"
 	"github.com/jenkins-x/jx/pkg/secreturl"
 	"github.com/jenkins-x/jx/pkg/util"
@@ -21,6 +22,8 @@ const CanonicalClusterPath = "currentCluster"
 // FileSystemClient a local file system based client loading/saving content from the given URL
 type FileSystemClient struct {
 	Dir string
+	// Cipher encrypts the secret files if specified. Plain text files can still be read so that they can be migrated
+	Cipher Cipher
 }
 
 // NewFileSystemClient create a new local file system based client loading content from the given URL
@@ -30,6 +33,15 @@ func NewFileSystemClient(dir string) secreturl.Client {
 	}
 }
 
+// NewEncryptedFileSystemClient create a new local file system based client which encrypts the secret files with the
+// given cipher
+func NewEncryptedFileSystemClient(dir string, cipher Cipher) secreturl.Client {
+	return &FileSystemClient{
+		Dir:    dir,
+		Cipher: cipher,
+	}
+}
+
 // Read reads a named secret from the vault
 func (c *FileSystemClient) Read(secretName string) (map[string]interface{}, error) {
 	name := c.fileName(secretName)
@@ -48,13 +60,13 @@ func (c *FileSystemClient) Read(secretName string) (map[string]interface{}, erro
 				return nil, errors.Wrapf(err, "failed to check if file exists %s", name)
 			}
 			if !exists {
-				return nil, errors.Wrapf(err, "the canonical path %s doesn't exist", name)
+				return nil, errors.Wrapf(secreturl.ErrNotFound, "the canonical path %s doesn't exist", name)
 			}
-			return helm.LoadValuesFile(name)
+			return c.loadValuesFile(name)
 		}
-		return nil, fmt.Errorf("local vault file does not exist: %s", name)
+		return nil, errors.Wrapf(secreturl.ErrNotFound, "local vault file does not exist: %s", name)
 	}
-	return helm.LoadValuesFile(name)
+	return c.loadValuesFile(name)
 }
 
 // ReadObject reads a generic named object from vault.
@@ -80,7 +92,7 @@ func (c *FileSystemClient) Write(secretName string, data map[string]interface{})
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to ensure that parent directory exists %s", dir)
 	}
-	err = helm.SaveFile(path, data)
+	err = c.saveFile(path, data)
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +108,7 @@ func (c *FileSystemClient) WriteObject(secretName string, secret interface{}) (m
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to ensure that parent directory exists %s", dir)
 	}
-	err = helm.SaveFile(path, secret)
+	err = c.saveFile(path, secret)
 	if err != nil {
 		return nil, err
 	}
@@ -108,6 +120,88 @@ func (c *FileSystemClient) ReplaceURIs(s string) (string, error) {
 	return secreturl.ReplaceURIs(s, c, localURIRegex, "local:")
 }
 
+// LoadFile loads the content of a secret file decrypting it if required
+func (c *FileSystemClient) LoadFile(fileName string) ([]byte, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", fileName)
+	}
+	data, err = Decrypt(c.Cipher, data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decrypting %s", fileName)
+	}
+	return data, nil
+}
+
+// Rekey re-encrypts all the secret files with the new cipher, or stores them as plain text if it is nil, returning
+// the names of the files which were changed. All the files are decrypted before any are written so that a wrong key
+// does not leave the files encrypted with different keys
+func (c *FileSystemClient) Rekey(newCipher Cipher) ([]string, error) {
+	contents := map[string][]byte{}
+	fileNames := []string{}
+	err := filepath.Walk(c.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".yaml") {
+			return nil
+		}
+		data, err := c.LoadFile(path)
+		if err != nil {
+			return err
+		}
+		contents[path] = data
+		fileNames = append(fileNames, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, fileName := range fileNames {
+		err = writeFile(newCipher, fileName, contents[fileName])
+		if err != nil {
+			return nil, err
+		}
+	}
+	c.Cipher = newCipher
+	return fileNames, nil
+}
+
+func (c *FileSystemClient) loadValuesFile(fileName string) (map[string]interface{}, error) {
+	data, err := c.LoadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	v, err := helm.LoadValues(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshaling %s", fileName)
+	}
+	return v, nil
+}
+
+func (c *FileSystemClient) saveFile(fileName string, contents interface{}) error {
+	data, err := yaml.Marshal(contents)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal secret file %s", fileName)
+	}
+	return writeFile(c.Cipher, fileName, data)
+}
+
+func writeFile(cipher Cipher, fileName string, data []byte) error {
+	var err error
+	if cipher != nil {
+		data, err = Encrypt(cipher, data)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encrypt secret file %s", fileName)
+		}
+	}
+	err = ioutil.WriteFile(fileName, data, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save secret file %s", fileName)
+	}
+	return nil
+}
+
 func (c *FileSystemClient) fileName(secretName string) string {
 	return filepath.Join(c.Dir, secretName+".yaml")
 }
@@ -0,0 +1,142 @@
+package localvault_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/secreturl/localvault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKeyCipher(t *testing.T) localvault.Cipher {
+	key, err := localvault.GenerateKey()
+	require.NoError(t, err)
+	cipher, err := localvault.NewKeyCipher(key)
+	require.NoError(t, err)
+	return cipher
+}
+
+func TestEncryptedFileSystemClient(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "test-local-vault-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	passphraseCipher, err := localvault.NewPassphraseCipher("my passphrase")
+	require.NoError(t, err)
+	for _, cipher := range []localvault.Cipher{newKeyCipher(t), passphraseCipher} {
+		client := localvault.NewEncryptedFileSystemClient(dir, cipher)
+		_, err = client.Write("mycluster/pipelineUser", map[string]interface{}{"token": "mytoken"})
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "mycluster", "pipelineUser.yaml"))
+		require.NoError(t, err)
+		assert.True(t, localvault.IsEncrypted(data))
+		assert.NotContains(t, string(data), "mytoken")
+
+		secret, err := client.Read("mycluster/pipelineUser")
+		require.NoError(t, err)
+		assert.Equal(t, "mytoken", secret["token"])
+
+		text, err := client.ReplaceURIs("token: local:mycluster/pipelineUser:token")
+		require.NoError(t, err)
+		assert.Equal(t, "token: mytoken", text)
+	}
+
+	_, err = localvault.NewEncryptedFileSystemClient(dir, newKeyCipher(t)).Read("mycluster/pipelineUser")
+	assert.Error(t, err, "wrong key")
+	_, err = localvault.NewFileSystemClient(dir).Read("mycluster/pipelineUser")
+	assert.Error(t, err, "no key")
+}
+
+func TestRekey(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "test-local-vault-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	plainClient := localvault.NewFileSystemClient(dir)
+	_, err = plainClient.Write("mycluster/pipelineUser", map[string]interface{}{"token": "mytoken"})
+	require.NoError(t, err)
+	_, err = plainClient.Write("mycluster/adminUser", map[string]interface{}{"password": "mypassword"})
+	require.NoError(t, err)
+
+	oldCipher := newKeyCipher(t)
+	client := &localvault.FileSystemClient{Dir: dir}
+	fileNames, err := client.Rekey(oldCipher)
+	require.NoError(t, err)
+	assert.Len(t, fileNames, 2)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "mycluster", "adminUser.yaml"))
+	require.NoError(t, err)
+	assert.True(t, localvault.IsEncrypted(data))
+
+	newCipher := newKeyCipher(t)
+	_, err = (&localvault.FileSystemClient{Dir: dir, Cipher: newCipher}).Rekey(nil)
+	assert.Error(t, err, "the secrets cannot be decrypted with the wrong key")
+
+	_, err = client.Rekey(newCipher)
+	require.NoError(t, err)
+	secret, err := localvault.NewEncryptedFileSystemClient(dir, newCipher).Read("mycluster/adminUser")
+	require.NoError(t, err)
+	assert.Equal(t, "mypassword", secret["password"])
+
+	_, err = client.Rekey(nil)
+	require.NoError(t, err)
+	secret, err = plainClient.Read("mycluster/pipelineUser")
+	require.NoError(t, err)
+	assert.Equal(t, "mytoken", secret["token"])
+}
+
+func TestKeyFile(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "test-local-vault-key-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	key, err := localvault.GenerateKey()
+	require.NoError(t, err)
+	keyFile := filepath.Join(dir, "localSecrets.key")
+	err = localvault.SaveKeyFile(keyFile, key)
+	require.NoError(t, err)
+
+	cipher, err := localvault.LoadKeyFile(keyFile)
+	require.NoError(t, err)
+	expected, err := localvault.NewKeyCipher(key)
+	require.NoError(t, err)
+	assert.Equal(t, expected.ID(), cipher.ID())
+
+	_, err = localvault.NewKeyCipher([]byte("too short"))
+	assert.Error(t, err)
+}
+
+func TestRetireKeyFile(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "test-local-vault-key-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "localSecrets.key")
+	oldKeyFile, err := localvault.RetireKeyFile(keyFile)
+	require.NoError(t, err)
+	assert.Empty(t, oldKeyFile, "no key file")
+
+	key, err := localvault.GenerateKey()
+	require.NoError(t, err)
+	err = localvault.SaveKeyFile(keyFile, key)
+	require.NoError(t, err)
+
+	oldKeyFile, err = localvault.RetireKeyFile(keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, keyFile+".old", oldKeyFile)
+	_, err = os.Stat(keyFile)
+	assert.True(t, os.IsNotExist(err))
+	cipher, err := localvault.LoadKeyFile(oldKeyFile)
+	require.NoError(t, err)
+	expected, err := localvault.NewKeyCipher(key)
+	require.NoError(t, err)
+	assert.Equal(t, expected.ID(), cipher.ID())
+}
@@ -0,0 +1,136 @@
+package sops
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/secreturl"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// Scheme the URL scheme of secrets stored in SOPS encrypted files such as `sops:pipelineUser:token`
+const Scheme = "sops:"
+
+// DefaultDir the default directory of the SOPS encrypted files relative to the requirements file
+const DefaultDir = "secrets"
+
+var sopsURIRegex = regexp.MustCompile(`:[\s"]*sops:[-_\w\/:]*`)
+
+// Client a secret URL client which reads and writes YAML files encrypted with SOPS (https://github.com/mozilla/sops)
+// so that they can be committed to the boot config repository. The keys used to encrypt new files are configured by
+// the creation rules in the `.sops.yaml` file of the repository
+type Client struct {
+	Dir    string
+	Runner util.Commander
+}
+
+// NewClient creates a new SOPS based client for the encrypted files in the given directory
+func NewClient(dir string) secreturl.Client {
+	return NewClientWithCommander(dir, &util.Command{})
+}
+
+// NewClientWithCommander creates a new SOPS based client which runs the sops binary with the given commander
+func NewClientWithCommander(dir string, runner util.Commander) secreturl.Client {
+	return &Client{
+		Dir:    dir,
+		Runner: runner,
+	}
+}
+
+// Read decrypts a named secret file
+func (c *Client) Read(secretName string) (map[string]interface{}, error) {
+	fileName := c.fileName(secretName)
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if !exists {
+		return nil, errors.Wrapf(secreturl.ErrNotFound, "SOPS file does not exist: %s", fileName)
+	}
+	out, err := c.sops("--decrypt", fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decrypt %s", fileName)
+	}
+	values, err := helm.LoadValues([]byte(out))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshaling %s", fileName)
+	}
+	return values, nil
+}
+
+// ReadObject reads a generic named object from a SOPS file.
+// The secret _must_ be serializable to JSON.
+func (c *Client) ReadObject(secretName string, secret interface{}) error {
+	m, err := c.Read(secretName)
+	if err != nil {
+		return errors.Wrapf(err, "reading the secret %q from SOPS", secretName)
+	}
+	err = util.ToStructFromMapStringInterface(m, &secret)
+	if err != nil {
+		return errors.Wrapf(err, "deserializing the secret %q from SOPS", secretName)
+	}
+	return nil
+}
+
+// Write writes a named secret to an encrypted file with the data provided
+func (c *Client) Write(secretName string, data map[string]interface{}) (map[string]interface{}, error) {
+	return c.WriteObject(secretName, data)
+}
+
+// WriteObject writes a generic named object to an encrypted file.
+// The secret _must_ be serializable to JSON.
+func (c *Client) WriteObject(secretName string, secret interface{}) (map[string]interface{}, error) {
+	fileName := c.fileName(secretName)
+	err := os.MkdirAll(filepath.Dir(fileName), util.DefaultWritePermissions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to ensure that parent directory exists %s", fileName)
+	}
+	data, err := yaml.Marshal(secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal secret %s", secretName)
+	}
+	// the plain text is only written to a temporary file next to the encrypted file so that the path based creation
+	// rules apply and the encrypted file is never left unencrypted
+	tmpFile, err := ioutil.TempFile(filepath.Dir(fileName), ".sops-*.yaml")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create temporary file for %s", fileName)
+	}
+	tmpFileName := tmpFile.Name()
+	defer os.Remove(tmpFileName)
+	_, err = tmpFile.Write(data)
+	tmpFile.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to write temporary file for %s", fileName)
+	}
+	out, err := c.sops("--encrypt", "--input-type", "yaml", "--output-type", "yaml", tmpFileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encrypt %s", fileName)
+	}
+	err = ioutil.WriteFile(fileName, []byte(out+"\n"), util.DefaultWritePermissions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to save SOPS file %s", fileName)
+	}
+	return c.Read(secretName)
+}
+
+// ReplaceURIs will replace any sops: URIs in a string
+func (c *Client) ReplaceURIs(s string) (string, error) {
+	return secreturl.ReplaceURIs(s, c, sopsURIRegex, Scheme)
+}
+
+// sops runs the sops binary in the directory of the files so that it finds the `.sops.yaml` file of the repository
+func (c *Client) sops(args ...string) (string, error) {
+	c.Runner.SetName("sops")
+	c.Runner.SetDir(c.Dir)
+	c.Runner.SetArgs(args)
+	return c.Runner.RunWithoutRetry()
+}
+
+func (c *Client) fileName(secretName string) string {
+	return filepath.Join(c.Dir, secretName+".yaml")
+}
@@ -0,0 +1,64 @@
+package sops_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/secreturl/sops"
+	mocks "github.com/jenkins-x/jx/pkg/util/mocks"
+	. "github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceURIs(t *testing.T) {
+	RegisterMockTestingT(t)
+	dir, err := ioutil.TempDir("", "test-sops-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "mycluster", "pipelineUser.yaml")
+	err = os.MkdirAll(filepath.Dir(fileName), os.FileMode(0755))
+	require.NoError(t, err)
+	err = ioutil.WriteFile(fileName, []byte("token: ENC[AES256_GCM,data:abc]\n"), 0644)
+	require.NoError(t, err)
+
+	runner := mocks.NewMockCommander()
+	When(runner.RunWithoutRetry()).ThenReturn("token: mytoken\n", nil)
+	client := sops.NewClientWithCommander(dir, runner)
+
+	text, err := client.ReplaceURIs("token: sops:mycluster/pipelineUser:token")
+	require.NoError(t, err)
+	assert.Equal(t, "token: mytoken", text)
+	runner.VerifyWasCalledOnce().SetName("sops")
+	runner.VerifyWasCalledOnce().SetDir(dir)
+	runner.VerifyWasCalledOnce().SetArgs([]string{"--decrypt", fileName})
+
+	_, err = client.ReplaceURIs("token: sops:mycluster/adminUser:password")
+	assert.Error(t, err, "missing file")
+}
+
+func TestWrite(t *testing.T) {
+	RegisterMockTestingT(t)
+	dir, err := ioutil.TempDir("", "test-sops-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	encrypted := "token: ENC[AES256_GCM,data:abc]\nsops:\n  version: 3.3.1"
+	runner := mocks.NewMockCommander()
+	When(runner.RunWithoutRetry()).ThenReturn(encrypted, nil)
+	client := sops.NewClientWithCommander(dir, runner)
+
+	_, err = client.Write("mycluster/pipelineUser", map[string]interface{}{"token": "mytoken"})
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "mycluster", "pipelineUser.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, encrypted+"\n", string(data))
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "mycluster"))
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "the plain text file should be removed")
+}
@@ -58,6 +58,13 @@ type StageInfo struct {
 	CreatedTime    time.Time
 	Pod            *corev1.Pod
 
+	// Skipped is true if the stage is not run because its when conditions were not met
+	Skipped bool
+
+	// RetriedPods holds the Pods for earlier, failed attempts at running this stage's TaskRun when it has been retried,
+	// oldest first
+	RetriedPods []*corev1.Pod
+
 	// These fields will only be populated for appropriate parent stages
 	Parallel []*StageInfo
 	Stages   []*StageInfo
@@ -351,9 +358,21 @@ func (si *StageInfo) SetPodsForStageInfo(podList *corev1.PodList, prName string)
 			return nil
 		}
 		if len(podListItems) > 1 {
-			return errors.New(fmt.Sprintf("Too many Pods (%d) found for PipelineRun %s and Stage %s", len(podListItems), prName, si.Name))
+			// A retried TaskRun has a Pod for each attempt, so the most recent is the current attempt
+			for _, p := range podListItems {
+				if p.Labels[builds.LabelTaskRunName] != podListItems[0].Labels[builds.LabelTaskRunName] {
+					return errors.New(fmt.Sprintf("Too many Pods (%d) found for PipelineRun %s and Stage %s", len(podListItems), prName, si.Name))
+				}
+			}
+			sort.Slice(podListItems, func(i, j int) bool {
+				return podListItems[i].CreationTimestamp.Before(&podListItems[j].CreationTimestamp)
+			})
+			si.RetriedPods = nil
+			for i := range podListItems[:len(podListItems)-1] {
+				si.RetriedPods = append(si.RetriedPods, &podListItems[i])
+			}
 		}
-		pod := podListItems[0]
+		pod := podListItems[len(podListItems)-1]
 		si.PodName = pod.Name
 		si.Task = pod.Labels[builds.LabelTaskName]
 		si.TaskRun = pod.Labels[builds.LabelTaskRunName]
@@ -435,6 +454,7 @@ func stageAndChildrenToStageInfo(psc *v1.PipelineStageAndChildren, parents []str
 	si := &StageInfo{
 		Name:    psc.Stage.Name,
 		Parents: parents,
+		Skipped: psc.Stage.Skipped,
 	}
 	if psc.Stage.TaskRef != nil {
 		si.Task = *psc.Stage.TaskRef
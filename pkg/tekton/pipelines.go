@@ -8,6 +8,7 @@ import (
 	"time"
 
 	jenkinsio "github.com/jenkins-x/jx/pkg/apis/jenkins.io"
+	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	v1 "github.com/jenkins-x/jx/pkg/client/clientset/versioned/typed/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/kube/naming"
 	"k8s.io/apimachinery/pkg/labels"
@@ -486,6 +487,60 @@ func ApplyPipeline(jxClient versioned.Interface, tektonClient tektonclient.Inter
 	return nil
 }
 
+// ApplySkippedPipeline records a pipeline whose stages are all skipped as their when conditions are not met. As there is
+// no PipelineRun for the build controller to follow, it creates the PipelineStructure of the skipped stages and marks the
+// PipelineActivity as succeeded with each of its stages skipped.
+func ApplySkippedPipeline(jxClient versioned.Interface, structure *jenkinsv1.PipelineStructure, ns string, activityKey *kube.PromoteStepActivityKey) error {
+	info := util.ColorInfo
+
+	activity, _, err := activityKey.GetOrCreate(jxClient, ns)
+	if err != nil {
+		return err
+	}
+
+	structure.OwnerReferences = []metav1.OwnerReference{
+		{
+			APIVersion: jenkinsio.GroupAndVersion,
+			Kind:       "PipelineActivity",
+			Name:       activity.Name,
+			UID:        activity.UID,
+		},
+	}
+	_, err = jxClient.JenkinsV1().PipelineStructures(ns).Create(structure)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the PipelineStructure in namespace %s", ns)
+	}
+	log.Logger().Infof("created PipelineStructure %s", info(structure.Name))
+
+	for _, psc := range structure.GetAllStagesAndChildren() {
+		markStagesSkipped(stageAndChildrenToStageInfo(psc, []string{}), activity)
+	}
+	now := metav1.Now()
+	if activity.Spec.StartedTimestamp == nil {
+		activity.Spec.StartedTimestamp = &now
+	}
+	activity.Spec.CompletedTimestamp = &now
+	activity.Spec.Status = jenkinsv1.ActivityStatusTypeSucceeded
+
+	_, err = jxClient.JenkinsV1().PipelineActivities(ns).PatchUpdate(activity)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update the PipelineActivity %s in namespace %s", activity.Name, ns)
+	}
+	log.Logger().Infof("marked PipelineActivity %s as succeeded with every stage skipped", info(activity.Name))
+	return nil
+}
+
+func markStagesSkipped(si *StageInfo, activity *jenkinsv1.PipelineActivity) {
+	_, stage, _ := kube.GetOrCreateStage(activity, si.GetStageNameIncludingParents())
+	stage.Status = jenkinsv1.ActivityStatusTypeSkipped
+	for _, nested := range si.Parallel {
+		markStagesSkipped(nested, activity)
+	}
+	for _, nested := range si.Stages {
+		markStagesSkipped(nested, activity)
+	}
+}
+
 // PipelineRunIsNotPending returns true if the PipelineRun has completed or has running steps.
 func PipelineRunIsNotPending(pr *pipelineapi.PipelineRun) bool {
 	if pr.Status.CompletionTime != nil {
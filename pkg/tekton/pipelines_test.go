@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	jxfake "github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
 	"github.com/jenkins-x/jx/pkg/cmd/clients/fake"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
@@ -13,6 +14,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/tekton"
 	"github.com/jenkins-x/jx/pkg/tekton/tekton_helpers_test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -207,3 +209,52 @@ func TestGenerateNextBuildNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestApplySkippedPipeline(t *testing.T) {
+	jxClient := jxfake.NewSimpleClientset()
+
+	repo := &gits.GitRepository{
+		Name:         "jx",
+		Host:         "github.com",
+		Organisation: "jenkins-x",
+	}
+	activityKey := tekton.GeneratePipelineActivity("1", "PR-1", repo, "", nil)
+
+	checks := "Checks"
+	structure := &v1.PipelineStructure{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "jenkins-x-jx-pr-1-1",
+		},
+		Stages: []v1.PipelineStructureStage{
+			{Name: "Checks", Stages: []string{"Lint"}, Skipped: true},
+			{Name: "Lint", Depth: 1, Parent: &checks, Skipped: true},
+			{Name: "Deploy", Skipped: true},
+		},
+	}
+
+	err := tekton.ApplySkippedPipeline(jxClient, structure, ns, activityKey)
+	require.NoError(t, err)
+
+	activity, err := jxClient.JenkinsV1().PipelineActivities(ns).Get("jenkins-x-jx-pr-1-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.ActivityStatusTypeSucceeded, activity.Spec.Status)
+	assert.NotNil(t, activity.Spec.CompletedTimestamp)
+
+	stages := map[string]v1.ActivityStatusType{}
+	for _, step := range activity.Spec.Steps {
+		if step.Stage != nil {
+			stages[step.Stage.Name] = step.Stage.Status
+		}
+	}
+	assert.Equal(t, map[string]v1.ActivityStatusType{
+		"Checks":        v1.ActivityStatusTypeSkipped,
+		"Checks / Lint": v1.ActivityStatusTypeSkipped,
+		"Deploy":        v1.ActivityStatusTypeSkipped,
+	}, stages)
+
+	created, err := jxClient.JenkinsV1().PipelineStructures(ns).Get("jenkins-x-jx-pr-1-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, created.GetSkippedStages(), 3)
+	require.Len(t, created.OwnerReferences, 1)
+	assert.Equal(t, activity.Name, created.OwnerReferences[0].Name)
+}
@@ -0,0 +1,244 @@
+package syntax
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/knative/pkg/apis"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// MaxMatrixCells is the maximum number of stages a matrix can be expanded into, after exclusions
+	MaxMatrixCells = 32
+)
+
+var validMatrixAxisName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`).MatchString
+
+// Matrix defines the axes of values a stage is run across. The stage is expanded into parallel stages, one for each
+// combination of values which is not excluded, with an environment variable set for each axis.
+type Matrix struct {
+	// Axes maps the name of each axis to its values, such as "go: [1.12, 1.13]". The environment variable for an axis
+	// is its name in upper case with hyphens replaced by underscores, so the values of "os-image" are in OS_IMAGE.
+	Axes map[string][]string `json:"axes"`
+	// Exclude lists combinations of axis values which are not run. A combination is excluded if it matches all the
+	// values in any one of the exclusions.
+	Exclude []map[string]string `json:"exclude,omitempty"`
+}
+
+// matrixStructure records the matrix stages and the stages they were expanded into, so that they can be grouped in
+// the PipelineStructure.
+type matrixStructure struct {
+	// axes holds the sorted axis names for each matrix stage, keyed by stage name
+	axes map[string][]string
+	// values holds the axis values for each expanded stage, keyed by stage name
+	values map[string]map[string]string
+}
+
+func validateMatrix(m *Matrix) *apis.FieldError {
+	if m == nil {
+		return nil
+	}
+	if len(m.Axes) == 0 {
+		return apis.ErrMissingField("axes")
+	}
+	for _, name := range m.axisNames() {
+		if !validMatrixAxisName(name) {
+			return (&apis.FieldError{
+				Message: "matrix axis names must start with a letter and contain only letters, digits, '-' and '_'",
+				Paths:   []string{name},
+			}).ViaField("axes")
+		}
+		values := m.Axes[name]
+		if len(values) == 0 {
+			return apis.ErrMissingField(name).ViaField("axes")
+		}
+		seen := make(map[string]bool)
+		for _, v := range values {
+			if seen[v] {
+				return (&apis.FieldError{
+					Message: "matrix axis values must be unique",
+					Details: fmt.Sprintf("The value %s is used more than once in the axis %s", v, name),
+					Paths:   []string{name},
+				}).ViaField("axes")
+			}
+			seen[v] = true
+		}
+	}
+	for i, ex := range m.Exclude {
+		if len(ex) == 0 {
+			return (&apis.FieldError{
+				Message: "matrix exclusions must specify at least one axis value",
+			}).ViaFieldIndex("exclude", i)
+		}
+		for name, value := range ex {
+			values, exists := m.Axes[name]
+			if !exists {
+				return (&apis.FieldError{
+					Message: "matrix exclusions must refer to axes of the matrix",
+					Details: fmt.Sprintf("The axis %s is not defined", name),
+					Paths:   []string{name},
+				}).ViaFieldIndex("exclude", i)
+			}
+			if !containsAny(values, []string{value}) {
+				return (&apis.FieldError{
+					Message: "matrix exclusions must refer to values of the axes",
+					Details: fmt.Sprintf("The value %s is not in the axis %s", value, name),
+					Paths:   []string{name},
+				}).ViaFieldIndex("exclude", i)
+			}
+		}
+	}
+	cells := m.cells()
+	if len(cells) == 0 {
+		return &apis.FieldError{
+			Message: "matrix exclusions must not exclude every combination of values",
+			Paths:   []string{"exclude"},
+		}
+	}
+	if len(cells) > MaxMatrixCells {
+		return &apis.FieldError{
+			Message: "matrix has too many combinations of values",
+			Details: fmt.Sprintf("The matrix has %d combinations of values, but at most %d are allowed", len(cells), MaxMatrixCells),
+			Paths:   []string{"axes"},
+		}
+	}
+	return nil
+}
+
+// axisNames returns the names of the axes in the matrix, sorted so that expansion is deterministic.
+func (m *Matrix) axisNames() []string {
+	var names []string
+	for name := range m.Axes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// cells returns every combination of axis values which is not excluded, varying the last axis fastest.
+func (m *Matrix) cells() []map[string]string {
+	cells := []map[string]string{{}}
+	for _, name := range m.axisNames() {
+		var expanded []map[string]string
+		for _, c := range cells {
+			for _, v := range m.Axes[name] {
+				cell := make(map[string]string)
+				for k, existing := range c {
+					cell[k] = existing
+				}
+				cell[name] = v
+				expanded = append(expanded, cell)
+			}
+		}
+		cells = expanded
+	}
+
+	var included []map[string]string
+	for _, c := range cells {
+		if !m.isExcluded(c) {
+			included = append(included, c)
+		}
+	}
+	return included
+}
+
+func (m *Matrix) isExcluded(cell map[string]string) bool {
+	for _, ex := range m.Exclude {
+		if len(ex) == 0 {
+			continue
+		}
+		matches := true
+		for name, value := range ex {
+			if cell[name] != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// cellName returns the RFC1035 label used as the name of the stage for a cell of the matrix on the given stage.
+func (m *Matrix) cellName(stageName string, cell map[string]string) string {
+	parts := []string{stageName}
+	for _, name := range m.axisNames() {
+		parts = append(parts, cell[name])
+	}
+	return MangleToRfc1035Label(strings.Join(parts, " "), "")
+}
+
+// MatrixEnvVarName returns the name of the environment variable which holds the value of the given matrix axis.
+func MatrixEnvVarName(axis string) string {
+	return strings.ToUpper(strings.Replace(axis, "-", "_", -1))
+}
+
+// expandMatrices replaces each stage with a matrix with a stage containing a parallel stage for each cell of the
+// matrix. The when conditions, agent and options of the original stage are kept on the enclosing stage, while
+// everything else is copied to each of the parallel stages, along with the environment variables for the cell. The
+// cells inherit the agent, container options and volumes from the enclosing stage, and keep their own copy of the
+// other options such as the timeout and unstash.
+func expandMatrices(stages []Stage, ms *matrixStructure) []Stage {
+	var expanded []Stage
+	for _, s := range stages {
+		if s.Matrix == nil {
+			s.Stages = expandMatrices(s.Stages, ms)
+			s.Parallel = expandMatrices(s.Parallel, ms)
+			expanded = append(expanded, s)
+			continue
+		}
+
+		matrixStage := Stage{
+			Name:    s.Name,
+			When:    s.When,
+			Agent:   s.Agent.DeepCopy(),
+			Options: s.Options.DeepCopy(),
+		}
+		if matrixStage.Options != nil {
+			matrixStage.Options.Unstash = nil
+		}
+		for _, cell := range s.Matrix.cells() {
+			cellStage := *s.DeepCopy()
+			cellStage.Name = s.Matrix.cellName(s.Name, cell)
+			cellStage.Matrix = nil
+			cellStage.When = nil
+			cellStage.Agent = nil
+			if o := cellStage.Options; o != nil && o.RootOptions != nil {
+				o.ContainerOptions = nil
+				o.Volumes = nil
+			}
+			cellStage.Env = cellStage.GetEnv()
+			cellStage.Environment = nil
+			for _, name := range s.Matrix.axisNames() {
+				cellStage.Env = append(cellStage.Env, corev1.EnvVar{
+					Name:  MatrixEnvVarName(name),
+					Value: cell[name],
+				})
+			}
+			matrixStage.Parallel = append(matrixStage.Parallel, cellStage)
+			ms.values[cellStage.Name] = cell
+		}
+		ms.axes[s.Name] = s.Matrix.axisNames()
+		expanded = append(expanded, matrixStage)
+	}
+	return expanded
+}
+
+// annotate sets the matrix axes and values on the PipelineStructureStages for the matrix stages and their cells.
+func (ms *matrixStructure) annotate(structure *v1.PipelineStructure) {
+	for i := range structure.Stages {
+		s := &structure.Stages[i]
+		if axes, ok := ms.axes[s.Name]; ok {
+			s.MatrixAxes = axes
+		}
+		if values, ok := ms.values[s.Name]; ok {
+			s.MatrixValues = values
+		}
+	}
+}
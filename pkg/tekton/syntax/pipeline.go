@@ -34,6 +34,32 @@ const (
 
 	// WorkingDirRoot is the root directory for working directories.
 	WorkingDirRoot = "/workspace"
+
+	// PipelinePostStageName is the name of the stage generated to run the post steps defined at the top level of a
+	// pipeline.
+	PipelinePostStageName = "post"
+
+	// stageFailureMarkerFile records the name of the failed step in a stage with post conditions. It lives outside the
+	// source directory, so it is only visible to the stage's own steps.
+	stageFailureMarkerFile = ".jx-stage-failed"
+
+	// StashClassifier is the storage classifier used for stashes passed between stages.
+	StashClassifier = "stash"
+
+	// pipelineFailureMarkerFile records the name of the failed step in a pipeline with post conditions. It lives in the
+	// source directory, so it is passed along to later stages with the workspace.
+	pipelineFailureMarkerFile = ".jx-pipeline-failed"
+
+	// stageDeadlineFile records the time, in seconds since the epoch, by which a stage with a timeout must complete. It
+	// is written by whichever of the stage's steps runs first.
+	stageDeadlineFile = ".jx-stage-deadline"
+
+	// StepAttemptsMessagePrefix prefixes the number of times a retried step was run in the termination message of its
+	// container.
+	StepAttemptsMessagePrefix = "jx-step-attempts="
+
+	// stepTerminationMessageFile is the default path of the termination message of a container.
+	stepTerminationMessageFile = "/dev/termination-log"
 )
 
 // ParsedPipeline is the internal representation of the Pipeline, used to validate and create CRDs
@@ -95,7 +121,10 @@ type Timeout struct {
 func (t *Timeout) ToDuration() (*metav1.Duration, error) {
 	durationStr := ""
 	// TODO: Populate a default timeout unit, most likely seconds.
-	if t.Unit != "" {
+	if t.Unit == TimeoutUnitDays {
+		// time.ParseDuration has no unit for days
+		durationStr = fmt.Sprintf("%dh", t.Time*24)
+	} else if t.Unit != "" {
 		durationStr = fmt.Sprintf("%d%c", t.Time, t.Unit[0])
 	} else {
 		durationStr = fmt.Sprintf("%ds", t.Time)
@@ -141,7 +170,8 @@ type Unstash struct {
 type StageOptions struct {
 	*RootOptions `json:",inline"`
 
-	// TODO: Not yet implemented in build-pipeline
+	// Stash and Unstash are implemented with "jx step stash" and "jx step unstash" steps, which store the files in the
+	// team's storage location for the "stash" classifier.
 	Stash   *Stash   `json:"stash,omitempty"`
 	Unstash *Unstash `json:"unstash,omitempty"`
 
@@ -177,6 +207,11 @@ type Step struct {
 	// env allows defining per-step environment variables
 	Env []corev1.EnvVar `json:"env,omitempty"`
 
+	// timeout is optional, but only allowed with command. The command is killed if it runs for longer than this.
+	Timeout *Timeout `json:"timeout,omitempty"`
+	// retry is optional, but only allowed with command. The command is run again, up to this many times, if it fails.
+	Retry int8 `json:"retry,omitempty"`
+
 	// Legacy fields from jenkinsfile.PipelineStep before it was eliminated.
 	Comment   string  `json:"comment,omitempty"`
 	Groovy    string  `json:"groovy,omitempty"`
@@ -184,6 +219,10 @@ type Step struct {
 	When      string  `json:"when,omitempty"`
 	Container string  `json:"container,omitempty"`
 	Sh        string  `json:"sh,omitempty"`
+
+	// Source describes where the step was defined. It is only set when tracking the provenance of a pipeline, and is
+	// never serialized.
+	Source string `json:"-"`
 }
 
 // Loop is a special step that defines a variable, a list of possible values for that variable, and a set of steps to
@@ -210,6 +249,10 @@ type Stage struct {
 	Parallel   []Stage         `json:"parallel,omitempty"`
 	Post       []Post          `json:"post,omitempty"`
 	WorkingDir *string         `json:"dir,omitempty"`
+	When       *When           `json:"when,omitempty"`
+	Matrix     *Matrix         `json:"matrix,omitempty"`
+	// Template includes a stage template in place of this stage
+	Template *StageTemplateRef `json:"template,omitempty"`
 
 	// Replaced by Env, retained for backwards compatibility
 	Environment []corev1.EnvVar `json:"environment,omitempty"`
@@ -225,11 +268,27 @@ const (
 	PostConditionAlways  PostCondition = "always"
 )
 
-// Post contains a PostCondition and one more actions to be executed after a pipeline or stage if the condition is met.
+// All possible post conditions, used for validation
+var allPostConditions = []PostCondition{PostConditionSuccess, PostConditionFailure, PostConditionAlways}
+
+func allPostConditionsAsStrings() []string {
+	pc := make([]string, len(allPostConditions))
+
+	for i, c := range allPostConditions {
+		pc[i] = string(c)
+	}
+
+	return pc
+}
+
+// Post contains a PostCondition and one or more actions or steps to be executed after a pipeline or stage if the
+// condition is met.
 type Post struct {
-	// TODO: Conditional execution of something after a Task or Pipeline completes is not yet implemented
 	Condition PostCondition `json:"condition"`
-	Actions   []PostAction  `json:"actions"`
+	Actions   []PostAction  `json:"actions,omitempty"`
+	// Steps are run after the stage's own steps, or in a final Task after all stages for the pipeline, if the
+	// condition is met.
+	Steps []Step `json:"steps,omitempty"`
 }
 
 // PostAction contains the name of a built-in post action and options to pass to that action.
@@ -263,6 +322,10 @@ type PipelineOverride struct {
 	Agent            *Agent            `json:"agent,omitempty"`
 	ContainerOptions *corev1.Container `json:"containerOptions,omitempty"`
 	Volumes          []*corev1.Volume  `json:"volumes,omitempty"`
+
+	// Source is the file the override was defined in. It is only set when tracking the provenance of a pipeline, and
+	// is never serialized.
+	Source string `json:"-"`
 }
 
 var _ apis.Validatable = (*ParsedPipeline)(nil)
@@ -538,10 +601,28 @@ func (j *ParsedPipeline) Validate(context context.Context) *apis.FieldError {
 		return err
 	}
 
+	if err := validateStashes(j); err != nil {
+		return err
+	}
+
 	if err := validateRootOptions(j.Options).ViaField("options"); err != nil {
 		return err
 	}
 
+	if len(j.Post) > 0 {
+		if j.Agent == nil {
+			return &apis.FieldError{
+				Message: "post at the top level requires an agent for the pipeline",
+				Paths:   []string{"agent"},
+			}
+		}
+		for i, p := range j.Post {
+			if err := validatePost(p).ViaFieldIndex("post", i); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -577,6 +658,18 @@ func validateAgent(a *Agent) *apis.FieldError {
 var containsASCIILetter = regexp.MustCompile(`[a-zA-Z]`).MatchString
 
 func validateStage(s Stage, parentAgent *Agent) *apis.FieldError {
+	if s.Template != nil {
+		// The stage is replaced by the stage from the template when the effective pipeline is created, so only the
+		// reference to the template can be validated here.
+		if len(s.Steps) > 0 || len(s.Stages) > 0 || len(s.Parallel) > 0 {
+			return apis.ErrMultipleOneOf("steps", "stages", "parallel", "template")
+		}
+		if s.Template.Name == "" {
+			return apis.ErrMissingField("name").ViaField("template")
+		}
+		return nil
+	}
+
 	if len(s.Steps) == 0 && len(s.Stages) == 0 && len(s.Parallel) == 0 {
 		return apis.ErrMissingOneOf("steps", "stages", "parallel")
 	}
@@ -653,6 +746,43 @@ func validateStage(s Stage, parentAgent *Agent) *apis.FieldError {
 		}
 	}
 
+	if len(s.Post) > 0 {
+		if len(s.Steps) == 0 {
+			return &apis.FieldError{
+				Message: "post can only be used on stages with steps",
+				Paths:   []string{"post"},
+			}
+		}
+		for i, p := range s.Post {
+			if err := validatePost(p).ViaFieldIndex("post", i); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := validateWhen(s.When).ViaField("when"); err != nil {
+		return err
+	}
+
+	if s.Matrix != nil {
+		if len(s.Steps) == 0 {
+			return &apis.FieldError{
+				Message: "matrix can only be used on stages with steps",
+				Paths:   []string{"matrix"},
+			}
+		}
+		if s.Options != nil && s.Options.Stash != nil {
+			return &apis.FieldError{
+				Message: "stash cannot be used on stages with a matrix",
+				Details: "The stages for each combination of values run in parallel, so they would overwrite the same stash",
+				Paths:   []string{"stash"},
+			}
+		}
+		if err := validateMatrix(s.Matrix).ViaField("matrix"); err != nil {
+			return err
+		}
+	}
+
 	return validateStageOptions(s.Options).ViaField("options")
 }
 
@@ -723,6 +853,26 @@ func validateStep(s Step) *apis.FieldError {
 		}
 	}
 
+	if s.GetCommand() == "" && (s.Timeout != nil || s.Retry != 0) {
+		return &apis.FieldError{
+			Message: "Cannot set timeout or retry for a step or a loop",
+			Paths:   []string{"timeout", "retry"},
+		}
+	}
+
+	if s.Timeout != nil {
+		if err := validateTimeout(s.Timeout); err != nil {
+			return err.ViaField("timeout")
+		}
+	}
+
+	if s.Retry < 0 {
+		return &apis.FieldError{
+			Message: "Retry count cannot be negative",
+			Paths:   []string{"retry"},
+		}
+	}
+
 	if err := validateLoop(s.Loop); err != nil {
 		return err.ViaField("loop")
 	}
@@ -757,6 +907,43 @@ func validateLoop(l *Loop) *apis.FieldError {
 	return nil
 }
 
+func validatePost(p Post) *apis.FieldError {
+	isAllowed := false
+	for _, allowed := range allPostConditions {
+		if p.Condition == allowed {
+			isAllowed = true
+		}
+	}
+
+	if !isAllowed {
+		return &apis.FieldError{
+			Message: fmt.Sprintf("%s is not a valid post condition. Valid post conditions are %s", string(p.Condition),
+				strings.Join(allPostConditionsAsStrings(), ", ")),
+			Paths: []string{"condition"},
+		}
+	}
+
+	if len(p.Actions) > 0 {
+		return &apis.FieldError{
+			Message: "post actions are not yet supported",
+			Details: "Please use post steps instead",
+			Paths:   []string{"actions"},
+		}
+	}
+
+	if len(p.Steps) == 0 {
+		return apis.ErrMissingField("steps")
+	}
+
+	for i, step := range p.Steps {
+		if err := validateStep(step).ViaFieldIndex("steps", i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func validateStages(stages []Stage, parentAgent *Agent) *apis.FieldError {
 	if len(stages) == 0 {
 		return apis.ErrMissingField("stages")
@@ -919,7 +1106,7 @@ func validateTimeout(t *Timeout) *apis.FieldError {
 
 func validateUnstash(u *Unstash) *apis.FieldError {
 	if u != nil {
-		// TODO: Check to make sure the corresponding stash is defined somewhere
+		// validateStashes checks that the corresponding stash is defined in an earlier stage.
 		if u.Name == "" {
 			return &apis.FieldError{
 				Message: "The unstash name must be provided",
@@ -950,6 +1137,35 @@ func validateStash(s *Stash) *apis.FieldError {
 	return nil
 }
 
+// validateStashes checks that every unstash refers to a stash from an earlier stage in the pipeline.
+func validateStashes(j *ParsedPipeline) *apis.FieldError {
+	stashed := make(map[string]bool)
+
+	var validate func(stages []Stage) *apis.FieldError
+	validate = func(stages []Stage) *apis.FieldError {
+		for _, s := range stages {
+			if s.Options != nil && s.Options.Unstash != nil && !stashed[s.Options.Unstash.Name] {
+				return &apis.FieldError{
+					Message: "unstash must refer to a stash from an earlier stage",
+					Details: fmt.Sprintf("The stage %s unstashes %s, which is not stashed by any earlier stage", s.Name, s.Options.Unstash.Name),
+				}
+			}
+			if err := validate(s.Stages); err != nil {
+				return err
+			}
+			if err := validate(s.Parallel); err != nil {
+				return err
+			}
+			if s.Options != nil && s.Options.Stash != nil {
+				stashed[s.Options.Stash.Name] = true
+			}
+		}
+		return nil
+	}
+
+	return validate(j.Stages)
+}
+
 func validateWorkspace(w string) *apis.FieldError {
 	if w == "" {
 		return &apis.FieldError{
@@ -1207,11 +1423,86 @@ type stageToTaskParams struct {
 	depth                int8
 	enclosingStage       *transformedStage
 	previousSiblingStage *transformedStage
+	// pipelineFailureMarker is the marker file for the whole pipeline, and is only set if the pipeline has post steps
+	pipelineFailureMarker string
+	// isPipelinePost is true for the stage generated to run the post steps defined at the top level of the pipeline
+	isPipelinePost bool
+}
+
+// postMarkers holds the paths of the files used to record a failed step when a stage or the pipeline has post
+// conditions, so that the remaining steps can be skipped and the post steps run conditionally, rather than the Task
+// halting on the first failed step.
+type postMarkers struct {
+	stage    string
+	pipeline string
+}
+
+// getPostMarkers returns the post markers to use for the stage, or nil if neither the stage nor the pipeline has post
+// conditions.
+func (params stageToTaskParams) getPostMarkers() *postMarkers {
+	if params.isPipelinePost {
+		return &postMarkers{stage: params.pipelineFailureMarker}
+	}
+	m := &postMarkers{pipeline: params.pipelineFailureMarker}
+	if len(params.stage.Post) > 0 {
+		m.stage = filepath.Join(WorkingDirRoot, stageFailureMarkerFile)
+	}
+	if m.stage == "" && m.pipeline == "" {
+		return nil
+	}
+	return m
+}
+
+// wrapCommand makes the command a no-op if a failure has already been recorded, and records the step name as the
+// failure rather than exiting with an error if the command fails.
+func (m *postMarkers) wrapCommand(cmd string, stepName string) string {
+	var checks []string
+	for _, f := range []string{m.stage, m.pipeline} {
+		if f != "" {
+			checks = append(checks, fmt.Sprintf("[ ! -f %s ]", f))
+		}
+	}
+	marker := m.stage
+	if marker == "" {
+		marker = m.pipeline
+	}
+	return fmt.Sprintf("if %s; then\n(\n%s\n) || echo %s > %s\nfi", strings.Join(checks, " && "), cmd, stepName, marker)
+}
+
+// condition returns the shell test deciding whether post steps with the given condition should run, or an empty
+// string if they should always run.
+func (m *postMarkers) condition(c PostCondition) string {
+	var checks []string
+	if m.pipeline != "" {
+		// If an earlier stage failed, this stage's steps never ran, so neither should its post steps.
+		checks = append(checks, fmt.Sprintf("[ ! -f %s ]", m.pipeline))
+	}
+	switch c {
+	case PostConditionSuccess:
+		checks = append(checks, fmt.Sprintf("[ ! -f %s ]", m.stage))
+	case PostConditionFailure:
+		checks = append(checks, fmt.Sprintf("[ -f %s ]", m.stage))
+	}
+	return strings.Join(checks, " && ")
+}
+
+// checkCommand returns the command run after all post steps, which fails the Task if a step failed, or hands the
+// failure on to the pipeline marker if the pipeline has post conditions of its own.
+func (m *postMarkers) checkCommand() string {
+	if m.pipeline != "" {
+		return fmt.Sprintf("if [ -f %s ]; then cp %s %s; fi", m.stage, m.stage, m.pipeline)
+	}
+	return fmt.Sprintf("if [ -f %s ]; then echo \"step $(cat %s) failed\"; exit 1; fi", m.stage, m.stage)
 }
 
 func stageToTask(params stageToTaskParams) (*transformedStage, error) {
-	if len(params.stage.Post) != 0 {
-		return nil, errors.New("post on stages not yet supported")
+	for _, p := range params.stage.Post {
+		if len(p.Actions) > 0 {
+			return nil, errors.New("post actions are not yet supported - please use post steps instead")
+		}
+	}
+	if len(params.stage.Post) > 0 && params.parentParams.InterpretMode {
+		return nil, errors.New("post is not supported in interpret mode")
 	}
 
 	stageContainer := &corev1.Container{}
@@ -1222,20 +1513,14 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 		if o.RootOptions == nil {
 			o.RootOptions = &RootOptions{}
 		} else {
-			if o.Timeout != nil {
-				return nil, errors.New("Timeout on stage not yet supported")
+			if o.Timeout != nil && params.parentParams.InterpretMode {
+				return nil, errors.New("timeout on stage is not supported in interpret mode")
 			}
 			if o.ContainerOptions != nil {
 				stageContainer = o.ContainerOptions
 			}
 			stageVolumes = o.Volumes
 		}
-		if o.Stash != nil {
-			return nil, errors.New("Stash on stage not yet supported")
-		}
-		if o.Unstash != nil {
-			return nil, errors.New("Unstash on stage not yet supported")
-		}
 	}
 
 	// Don't overwrite the inherited working dir if we don't have one specified here.
@@ -1266,7 +1551,7 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 		return nil, err
 	}
 
-	if len(params.stage.Steps) > 0 {
+	if len(params.stage.Steps) > 0 || params.isPipelinePost {
 		t := &tektonv1alpha1.Task{
 			TypeMeta: metav1.TypeMeta{
 				APIVersion: TektonAPIVersion,
@@ -1312,7 +1597,14 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 			volumes[v.Name] = *v
 		}
 
-		for _, step := range params.stage.Steps {
+		markers := params.getPostMarkers()
+
+		var stageTimeout *Timeout
+		if params.stage.Options != nil && params.stage.Options.RootOptions != nil {
+			stageTimeout = params.stage.Options.Timeout
+		}
+
+		addSteps := func(step Step, m *postMarkers, condition string, timeout *Timeout) error {
 			actualSteps, stepVolumes, newCounter, err := generateSteps(generateStepsParams{
 				stageParams:     params,
 				step:            step,
@@ -1320,9 +1612,12 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 				env:             env,
 				parentContainer: stageContainer,
 				stepCounter:     stepCounter,
+				postMarkers:     m,
+				condition:       condition,
+				stageTimeout:    timeout,
 			})
 			if err != nil {
-				return nil, err
+				return err
 			}
 
 			stepCounter = newCounter
@@ -1331,6 +1626,58 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 			for k, v := range stepVolumes {
 				volumes[k] = v
 			}
+			return nil
+		}
+
+		var stashStep, unstashStep *Step
+		if o := params.stage.Options; o != nil && (o.Stash != nil || o.Unstash != nil) {
+			jxImage, err := getJxImage(params.parentParams.DefaultImage, params.parentParams.VersionsDir)
+			if err != nil {
+				return nil, err
+			}
+			stashPath := filepath.Join("jenkins-x", StashClassifier, params.parentParams.PipelineIdentifier, params.parentParams.BuildIdentifier)
+			if o.Stash != nil {
+				stashStep = o.Stash.toStep(jxImage, stashPath)
+			}
+			if o.Unstash != nil {
+				unstashStep = o.Unstash.toStep(jxImage, stashPath)
+			}
+		}
+
+		if unstashStep != nil {
+			if err := addSteps(*unstashStep, markers, "", stageTimeout); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, step := range params.stage.Steps {
+			if err := addSteps(step, markers, "", stageTimeout); err != nil {
+				return nil, err
+			}
+		}
+
+		if stashStep != nil {
+			if err := addSteps(*stashStep, markers, "", stageTimeout); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(params.stage.Post) > 0 {
+			for _, p := range params.stage.Post {
+				for i, step := range p.Steps {
+					if step.Name == "" {
+						step.Name = fmt.Sprintf("post-%s-%d", p.Condition, i+1)
+					} else {
+						step.Name = fmt.Sprintf("post-%s-%s", p.Condition, step.Name)
+					}
+					if err := addSteps(step, nil, markers.condition(p.Condition), nil); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if err := addSteps(Step{Name: "post-check", Command: markers.checkCommand()}, nil, "", nil); err != nil {
+				return nil, err
+			}
 		}
 
 		// Avoid nondeterministic results by sorting the keys and appending volumes in that order.
@@ -1359,17 +1706,18 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 				nestedPreviousSibling = tasks[i-1]
 			}
 			nestedTask, err := stageToTask(stageToTaskParams{
-				parentParams:         params.parentParams,
-				stage:                nested,
-				baseWorkingDir:       params.baseWorkingDir,
-				parentEnv:            env,
-				parentAgent:          agent,
-				parentWorkspace:      *ts.Stage.Options.Workspace,
-				parentContainer:      stageContainer,
-				parentVolumes:        stageVolumes,
-				depth:                params.depth + 1,
-				enclosingStage:       &ts,
-				previousSiblingStage: nestedPreviousSibling,
+				parentParams:          params.parentParams,
+				stage:                 nested,
+				baseWorkingDir:        params.baseWorkingDir,
+				parentEnv:             env,
+				parentAgent:           agent,
+				parentWorkspace:       *ts.Stage.Options.Workspace,
+				parentContainer:       stageContainer,
+				parentVolumes:         stageVolumes,
+				depth:                 params.depth + 1,
+				enclosingStage:        &ts,
+				previousSiblingStage:  nestedPreviousSibling,
+				pipelineFailureMarker: params.pipelineFailureMarker,
 			})
 			if err != nil {
 				return nil, err
@@ -1388,16 +1736,17 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 
 		for _, nested := range params.stage.Parallel {
 			nestedTask, err := stageToTask(stageToTaskParams{
-				parentParams:    params.parentParams,
-				stage:           nested,
-				baseWorkingDir:  params.baseWorkingDir,
-				parentEnv:       env,
-				parentAgent:     agent,
-				parentWorkspace: *ts.Stage.Options.Workspace,
-				parentContainer: stageContainer,
-				parentVolumes:   stageVolumes,
-				depth:           params.depth + 1,
-				enclosingStage:  &ts,
+				parentParams:          params.parentParams,
+				stage:                 nested,
+				baseWorkingDir:        params.baseWorkingDir,
+				parentEnv:             env,
+				parentAgent:           agent,
+				parentWorkspace:       *ts.Stage.Options.Workspace,
+				parentContainer:       stageContainer,
+				parentVolumes:         stageVolumes,
+				depth:                 params.depth + 1,
+				enclosingStage:        &ts,
+				pipelineFailureMarker: params.pipelineFailureMarker,
 			})
 			if err != nil {
 				return nil, err
@@ -1484,6 +1833,12 @@ type generateStepsParams struct {
 	env             []corev1.EnvVar
 	parentContainer *corev1.Container
 	stepCounter     int
+	// postMarkers, if set, makes command steps record failures rather than halting the Task
+	postMarkers *postMarkers
+	// condition, if set, is a shell test which must pass for command steps to run
+	condition string
+	// stageTimeout, if set, is the timeout for the enclosing stage, which command steps must complete within
+	stageTimeout *Timeout
 }
 
 func generateSteps(params generateStepsParams) ([]corev1.Container, map[string]corev1.Volume, int, error) {
@@ -1546,10 +1901,12 @@ func generateSteps(params generateStepsParams) ([]corev1.Container, map[string]c
 		} else {
 			c.Image = resolvedImage
 		}
+		isShellCommand := true
 		// Special-casing for commands starting with /kaniko/warmer, which doesn't have sh at all
 		if strings.HasPrefix(params.step.GetCommand(), "/kaniko/warmer") {
 			c.Command = append(targetDirPrefix, params.step.GetCommand())
 			c.Args = params.step.Arguments
+			isShellCommand = false
 		} else {
 			// If it's /kaniko/executor, use /busybox/sh instead of /bin/sh, and use the debug image
 			if strings.HasPrefix(params.step.GetCommand(), "/kaniko/executor") && strings.Contains(c.Image, "gcr.io/kaniko-project") {
@@ -1579,6 +1936,19 @@ func generateSteps(params generateStepsParams) ([]corev1.Container, map[string]c
 			c.Name = "step" + strconv.Itoa(1+params.stepCounter)
 		}
 
+		if isShellCommand {
+			wrapped, err := wrapWithRetryAndTimeout(c.Args[0], c.Name, params.step.Retry, params.step.Timeout, params.stageTimeout)
+			if err != nil {
+				return nil, nil, params.stepCounter, errors.Wrapf(err, "failed to apply the timeout for step %s", c.Name)
+			}
+			c.Args = []string{wrapped}
+			if params.condition != "" {
+				c.Args = []string{fmt.Sprintf("if %s; then\n%s\nfi", params.condition, c.Args[0])}
+			} else if params.postMarkers != nil {
+				c.Args = []string{params.postMarkers.wrapCommand(c.Args[0], c.Name)}
+			}
+		}
+
 		c.Stdin = false
 		c.TTY = false
 		c.Env = scopedEnv(params.step.Env, scopedEnv(params.env, c.Env))
@@ -1599,6 +1969,9 @@ func generateSteps(params generateStepsParams) ([]corev1.Container, map[string]c
 					env:             loopEnv,
 					parentContainer: params.parentContainer,
 					stepCounter:     params.stepCounter,
+					postMarkers:     params.postMarkers,
+					condition:       params.condition,
+					stageTimeout:    params.stageTimeout,
 				})
 				if loopErr != nil {
 					return nil, nil, loopCounter, loopErr
@@ -1641,24 +2014,64 @@ type CRDsFromPipelineParams struct {
 	Labels             map[string]string
 	DefaultImage       string
 	InterpretMode      bool
+	// When is the context which the when conditions on stages are evaluated against. If nil, the conditions are not
+	// evaluated and all stages are run.
+	When *WhenContext
 }
 
-// GenerateCRDs translates the Pipeline structure into the corresponding Pipeline and Task CRDs
+// GenerateCRDs translates the Pipeline structure into the corresponding Pipeline and Task CRDs. If the when conditions
+// of every stage are not met, it returns ErrNoStagesToRun along with a PipelineStructure holding the skipped stages.
 func (j *ParsedPipeline) GenerateCRDs(params CRDsFromPipelineParams) (*tektonv1alpha1.Pipeline, []*tektonv1alpha1.Task, *v1.PipelineStructure, error) {
+	pipelineFailureMarker := ""
+
+	matrices := &matrixStructure{
+		axes:   make(map[string][]string),
+		values: make(map[string]map[string]string),
+	}
+	stages := expandMatrices(j.Stages, matrices)
+
+	var skippedStages []v1.PipelineStructureStage
+	if params.When != nil {
+		allStages := stages
+		stages, skippedStages = skipStages(allStages, *params.When, j.GetEnv(), 0, nil)
+		if len(stages) == 0 {
+			structure := &v1.PipelineStructure{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: PipelineRunName(params.PipelineIdentifier, params.BuildIdentifier),
+				},
+				Stages: skippedStages,
+			}
+			if len(params.Labels) > 0 {
+				structure.Labels = util.MergeMaps(params.Labels)
+			}
+			return nil, nil, structure, ErrNoStagesToRun
+		}
+		if err := validateSkippedStashes(allStages, stages); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
 	if len(j.Post) != 0 {
-		return nil, nil, nil, errors.New("Post at top level not yet supported")
+		if params.InterpretMode {
+			return nil, nil, nil, errors.New("post is not supported in interpret mode")
+		}
+		pipelineFailureMarker = filepath.Join(WorkingDirRoot, params.SourceDir, pipelineFailureMarkerFile)
+		stages = append(append([]Stage{}, stages...), Stage{
+			Name: PipelinePostStageName,
+			Post: j.Post,
+		})
 	}
 
 	var parentContainer *corev1.Container
 	var parentVolumes []*corev1.Volume
 
+	var pipelineRetry int8
+
 	baseWorkingDir := j.WorkingDir
 
 	if j.Options != nil {
 		o := j.Options
-		if o.Retry > 0 {
-			return nil, nil, nil, errors.New("Retry at top level not yet supported")
-		}
+		pipelineRetry = o.Retry
 		parentContainer = o.ContainerOptions
 		parentVolumes = o.Volumes
 	}
@@ -1701,20 +2114,22 @@ func (j *ParsedPipeline) GenerateCRDs(params CRDsFromPipelineParams) (*tektonv1a
 
 	baseEnv := j.GetEnv()
 
-	for i, s := range j.Stages {
-		isLastStage := i == len(j.Stages)-1
+	for i, s := range stages {
+		isLastStage := i == len(stages)-1
 
 		stage, err := stageToTask(stageToTaskParams{
-			parentParams:         params,
-			stage:                s,
-			baseWorkingDir:       baseWorkingDir,
-			parentEnv:            baseEnv,
-			parentAgent:          j.Agent,
-			parentWorkspace:      "default",
-			parentContainer:      parentContainer,
-			parentVolumes:        parentVolumes,
-			depth:                0,
-			previousSiblingStage: previousStage,
+			parentParams:          params,
+			stage:                 s,
+			baseWorkingDir:        baseWorkingDir,
+			parentEnv:             baseEnv,
+			parentAgent:           j.Agent,
+			parentWorkspace:       "default",
+			parentContainer:       parentContainer,
+			parentVolumes:         parentVolumes,
+			depth:                 0,
+			previousSiblingStage:  previousStage,
+			pipelineFailureMarker: pipelineFailureMarker,
+			isPipelinePost:        pipelineFailureMarker != "" && isLastStage,
 		})
 		if err != nil {
 			return nil, nil, nil, err
@@ -1729,11 +2144,21 @@ func (j *ParsedPipeline) GenerateCRDs(params CRDsFromPipelineParams) (*tektonv1a
 		}
 		previousStage = stage
 
-		pipelineTasks := createPipelineTasks(stage, p.Spec.Resources[0].Name)
+		pipelineTasks := createPipelineTasks(stage, p.Spec.Resources[0].Name, pipelineRetry)
+		if pipelineFailureMarker != "" && isLastStage {
+			// Parallel stages don't pass their workspace on, so the post stage takes the workspace from the end of
+			// every parallel branch as well, to see a failure recorded in any of them.
+			input := &pipelineTasks[0].Resources.Inputs[0]
+			input.From = append(input.From, findParallelWorkspaceProviders(stage, input.From)...)
+		}
 
 		linearTasks := stage.getLinearTasks()
 
 		for index, lt := range linearTasks {
+			if pipelineFailureMarker != "" && stage.isParallel() {
+				// Keep the workspace of each parallel branch for the post stage
+				continue
+			}
 			if shouldRemoveWorkspaceOutput(stage, lt.Name, index, len(linearTasks), isLastStage) {
 				pipelineTasks[index].Resources.Outputs = nil
 				lt.Spec.Outputs = nil
@@ -1748,9 +2173,33 @@ func (j *ParsedPipeline) GenerateCRDs(params CRDsFromPipelineParams) (*tektonv1a
 		structure.Stages = append(structure.Stages, stage.getAllAsPipelineStructureStages()...)
 	}
 
+	addSkippedStages(structure, skippedStages)
+	matrices.annotate(structure)
+
 	return p, tasks, structure, nil
 }
 
+// addSkippedStages adds the skipped stages to the PipelineStructure, including them in the nested stages of the
+// enclosing stages which were run.
+func addSkippedStages(structure *v1.PipelineStructure, skippedStages []v1.PipelineStructureStage) {
+	for _, skipped := range skippedStages {
+		if skipped.Parent != nil {
+			for i := range structure.Stages {
+				parent := &structure.Stages[i]
+				if parent.Name != *skipped.Parent || parent.Skipped {
+					continue
+				}
+				if len(parent.Parallel) > 0 {
+					parent.Parallel = append(parent.Parallel, skipped.Name)
+				} else {
+					parent.Stages = append(parent.Stages, skipped.Name)
+				}
+			}
+		}
+		structure.Stages = append(structure.Stages, skipped)
+	}
+}
+
 func shouldRemoveWorkspaceOutput(stage *transformedStage, taskName string, index int, tasksLen int, isLastStage bool) bool {
 	if stage.isParallel() {
 		parallelStages := stage.Parallel
@@ -1773,17 +2222,23 @@ func shouldRemoveWorkspaceOutput(stage *transformedStage, taskName string, index
 	return false
 }
 
-func createPipelineTasks(stage *transformedStage, resourceName string) []tektonv1alpha1.PipelineTask {
+// createPipelineTasks creates the PipelineTasks for the stage and any nested stages. Stages which don't set their own
+// retry count inherit the one from their enclosing stage, or from the pipeline.
+func createPipelineTasks(stage *transformedStage, resourceName string, defaultRetry int8) []tektonv1alpha1.PipelineTask {
+	retry := defaultRetry
+	if stage.Stage.Options.Retry > 0 {
+		retry = stage.Stage.Options.Retry
+	}
 	if stage.isSequential() {
 		var pTasks []tektonv1alpha1.PipelineTask
 		for _, nestedStage := range stage.Sequential {
-			pTasks = append(pTasks, createPipelineTasks(nestedStage, resourceName)...)
+			pTasks = append(pTasks, createPipelineTasks(nestedStage, resourceName, retry)...)
 		}
 		return pTasks
 	} else if stage.isParallel() {
 		var pTasks []tektonv1alpha1.PipelineTask
 		for _, nestedStage := range stage.Parallel {
-			pTasks = append(pTasks, createPipelineTasks(nestedStage, resourceName)...)
+			pTasks = append(pTasks, createPipelineTasks(nestedStage, resourceName, retry)...)
 		}
 		return pTasks
 	} else {
@@ -1792,7 +2247,7 @@ func createPipelineTasks(stage *transformedStage, resourceName string) []tektonv
 			TaskRef: tektonv1alpha1.TaskRef{
 				Name: stage.Task.Name,
 			},
-			Retries: int(stage.Stage.Options.Retry),
+			Retries: int(retry),
 		}
 
 		_, provider := findWorkspaceProvider(stage, stage.getEnclosing(0))
@@ -1865,6 +2320,41 @@ func findWorkspaceProvider(stage, sibling *transformedStage) (bool, []string) {
 	return false, nil
 }
 
+// findParallelWorkspaceProviders returns the names of the Tasks at the end of every parallel branch run before the
+// stage which use the same workspace as it, other than those in existing.
+func findParallelWorkspaceProviders(stage *transformedStage, existing []string) []string {
+	var providers []string
+	var collect func(ts *transformedStage)
+	collect = func(ts *transformedStage) {
+		if ts.isSequential() {
+			for _, nested := range ts.Sequential {
+				collect(nested)
+			}
+		} else if ts.isParallel() {
+			for _, nested := range ts.Parallel {
+				for _, end := range findEndStages(*nested) {
+					if *end.Stage.Options.Workspace != *stage.Stage.Options.Workspace {
+						continue
+					}
+					name := end.PipelineTask.Name
+					if util.StringArrayIndex(existing, name) < 0 && util.StringArrayIndex(providers, name) < 0 {
+						providers = append(providers, name)
+					}
+				}
+				collect(nested)
+			}
+		}
+	}
+	var previous []*transformedStage
+	for sibling := stage.PreviousSiblingStage; sibling != nil; sibling = sibling.PreviousSiblingStage {
+		previous = append([]*transformedStage{sibling}, previous...)
+	}
+	for _, sibling := range previous {
+		collect(sibling)
+	}
+	return providers
+}
+
 // Find the end tasks for this stage, traversing down to the end stages of any
 // nested sequential or parallel stages as well.
 func findEndStages(stage transformedStage) []*transformedStage {
@@ -1950,6 +2440,12 @@ func validateStageNames(j *ParsedPipeline) (err *apis.FieldError) {
 
 		for _, stage := range stages {
 			*stageNames = append(*stageNames, stage.Name)
+			if stage.Matrix != nil {
+				// The stage is expanded into a stage for each cell of the matrix, all of which need unique names too.
+				for _, cell := range stage.Matrix.cells() {
+					*stageNames = append(*stageNames, stage.Matrix.cellName(stage.Name, cell))
+				}
+			}
 			if len(stage.Stages) > 0 {
 				validate(stage.Stages, stageNames)
 			}
@@ -1960,23 +2456,111 @@ func validateStageNames(j *ParsedPipeline) (err *apis.FieldError) {
 
 	validate(j.Stages, &names)
 
+	// The top level post steps run in a stage of their own, so make sure nothing else is using its name.
+	if len(j.Post) > 0 {
+		names = append(names, PipelinePostStageName)
+	}
+
 	err = findDuplicates(names)
 
 	return
 }
 
+// getJxImage returns the image to use for steps which run jx itself, such as the git merge and stash steps.
+func getJxImage(defaultImage string, versionsDir string) (string, error) {
+	if defaultImage != "" {
+		return defaultImage, nil
+	}
+	if image := os.Getenv("BUILDER_JX_IMAGE"); image != "" {
+		return image, nil
+	}
+	return versionstream.ResolveDockerImage(versionsDir, GitMergeImage)
+}
+
+// toStep returns the step which stashes the files for this stash, at the end of the stage.
+func (s *Stash) toStep(image string, stashPath string) *Step {
+	name := MangleToRfc1035Label(s.Name, "")
+	return &Step{
+		Name:    "stash-" + name,
+		Image:   image,
+		Command: "jx",
+		Arguments: []string{"step", "stash", "--classifier", StashClassifier, "--pattern", shellQuote(s.Files),
+			"--name", name, "--to-path", stashPath},
+	}
+}
+
+// toStep returns the step which unstashes the files for this unstash, at the start of the stage.
+func (u *Unstash) toStep(image string, stashPath string) *Step {
+	name := MangleToRfc1035Label(u.Name, "")
+	dir := u.Dir
+	if dir == "" {
+		dir = "."
+	}
+	return &Step{
+		Name:    "unstash-" + name,
+		Image:   image,
+		Command: "jx",
+		Arguments: []string{"step", "unstash", "--classifier", StashClassifier, "--name", name, "--to-path", stashPath,
+			"--output", shellQuote(dir)},
+	}
+}
+
+// shellQuote wraps the string in single quotes so that globs and spaces are passed through the shell untouched.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'"'"'`, -1) + "'"
+}
+
+// wrapWithRetryAndTimeout wraps the shell command so that it is killed if it exceeds the step's timeout, and run again
+// if it fails, up to the step's retry count, recording the number of attempts in the termination message of the
+// container. If the stage has a timeout, the command is also killed once the stage's deadline, recorded by whichever
+// step runs first, has passed. Only shell builtins, sleep and date are used, so that it works in minimal images.
+func wrapWithRetryAndTimeout(cmd string, stepName string, retry int8, stepTimeout *Timeout, stageTimeout *Timeout) (string, error) {
+	if stepTimeout != nil {
+		d, err := stepTimeout.ToDuration()
+		if err != nil {
+			return "", err
+		}
+		cmd = wrapWithTimeout(cmd, strconv.FormatInt(int64(d.Seconds()), 10))
+	}
+	if retry > 0 {
+		attempts := retry + 1
+		cmd = fmt.Sprintf("attempt=1\nwhile true; do\n(\n%s\n)\nstatus=$?\nif [ $status -eq 0 ] || [ $attempt -ge %d ]; then break; fi\n"+
+			"attempt=$((attempt + 1))\necho \"step %s failed, retrying (attempt $attempt of %d)\"\ndone\n"+
+			"if [ $attempt -gt 1 ]; then { echo %s$attempt > %s; } 2>/dev/null; fi\nexit $status",
+			cmd, attempts, stepName, attempts, StepAttemptsMessagePrefix, stepTerminationMessageFile)
+	}
+	if stageTimeout != nil {
+		d, err := stageTimeout.ToDuration()
+		if err != nil {
+			return "", err
+		}
+		deadline := filepath.Join(WorkingDirRoot, stageDeadlineFile)
+		cmd = fmt.Sprintf("[ -f %s ] || echo $(($(date +%%s) + %d)) > %s\nread deadline < %s\nremaining=$((deadline - $(date +%%s)))\n"+
+			"if [ $remaining -le 0 ]; then echo \"stage timed out before step %s\"; exit 124; fi\n%s",
+			deadline, int64(d.Seconds()), deadline, deadline, stepName, wrapWithTimeout(cmd, "$remaining"))
+	}
+	return cmd, nil
+}
+
+// wrapWithTimeout runs the shell command in the background in its own process group, killing the whole group if it
+// is still running after the given number of seconds and exiting with 124 as timeout(1) does. The process group is
+// created with setsid if it is available, otherwise with job control which needs a shell such as bash that supports it
+// without a terminal. If the wrapper is itself killed by an enclosing timeout it kills the process group too.
+func wrapWithTimeout(cmd string, seconds string) string {
+	return fmt.Sprintf("if command -v setsid >/dev/null 2>&1; then process_group=setsid; else set -m 2>/dev/null; fi\n"+
+		"$process_group sh -c %s &\npid=$!\n"+
+		"trap 'kill -TERM -$pid 2>/dev/null || kill -TERM $pid 2>/dev/null' TERM\n"+
+		"(sleep %s; trap '' TERM; kill -TERM -$pid 2>/dev/null || kill -TERM $pid) 2>/dev/null &\nwatchdog=$!\n"+
+		"wait $pid\nstatus=$?\n"+
+		"kill -TERM -$watchdog 2>/dev/null || kill -TERM $watchdog 2>/dev/null\n"+
+		"if wait $watchdog; then status=124; fi\nexit $status", shellQuote(cmd), seconds)
+}
+
 // todo JR lets remove this when we switch tekton to using git merge type pipelineresources
 func getDefaultTaskSpec(envs []corev1.EnvVar, parentContainer *corev1.Container, defaultImage string, versionsDir string) (tektonv1alpha1.TaskSpec, error) {
-	var err error
-	image := defaultImage
-	if image == "" {
-		image = os.Getenv("BUILDER_JX_IMAGE")
-		if image == "" {
-			image, err = versionstream.ResolveDockerImage(versionsDir, GitMergeImage)
-			if err != nil {
-				return tektonv1alpha1.TaskSpec{}, err
-			}
-		}
+	image, err := getJxImage(defaultImage, versionsDir)
+	if err != nil {
+		return tektonv1alpha1.TaskSpec{}, err
 	}
 
 	childContainer := &corev1.Container{
@@ -3,6 +3,8 @@ package syntax
 import (
 	"strings"
 	"testing"
+
+	corev1 "k8s.io/api/core/v1"
 )
 
 func TestFindDuplicates(t *testing.T) {
@@ -57,3 +59,54 @@ func TestFindDuplicates(t *testing.T) {
 		})
 	}
 }
+
+func TestExpandMatricesKeepsAgentAndOptions(t *testing.T) {
+	workspace := "custom"
+	stages := []Stage{{
+		Name:  "Test",
+		Agent: &Agent{Image: "golang"},
+		Matrix: &Matrix{
+			Axes: map[string][]string{"go": {"1.12", "1.13"}},
+		},
+		Options: &StageOptions{
+			RootOptions: &RootOptions{
+				Retry:   2,
+				Volumes: []*corev1.Volume{{Name: "cache"}},
+			},
+			Unstash:   &Unstash{Name: "sources"},
+			Workspace: &workspace,
+		},
+		Steps: []Step{{Command: "make"}},
+	}}
+	ms := &matrixStructure{
+		axes:   map[string][]string{},
+		values: map[string]map[string]string{},
+	}
+
+	expanded := expandMatrices(stages, ms)
+
+	if len(expanded) != 1 || len(expanded[0].Parallel) != 2 {
+		t.Fatalf("expected one stage with two parallel stages but got %#v", expanded)
+	}
+	matrixStage := expanded[0]
+	if matrixStage.Agent == nil || matrixStage.Agent.Image != "golang" {
+		t.Errorf("expected the agent on the matrix stage but got %#v", matrixStage.Agent)
+	}
+	if matrixStage.Options == nil || len(matrixStage.Options.Volumes) != 1 || matrixStage.Options.Retry != 2 {
+		t.Errorf("expected the options on the matrix stage but got %#v", matrixStage.Options)
+	}
+	if matrixStage.Options.Unstash != nil {
+		t.Errorf("expected no unstash on the matrix stage but got %#v", matrixStage.Options.Unstash)
+	}
+	for _, cell := range matrixStage.Parallel {
+		if cell.Agent != nil {
+			t.Errorf("expected stage %s to inherit the agent but got %#v", cell.Name, cell.Agent)
+		}
+		if len(cell.Options.Volumes) != 0 {
+			t.Errorf("expected stage %s to inherit the volumes but got %#v", cell.Name, cell.Options.Volumes)
+		}
+		if cell.Options.Unstash == nil || cell.Options.Retry != 2 || *cell.Options.Workspace != workspace {
+			t.Errorf("expected stage %s to keep its options but got %#v", cell.Name, cell.Options)
+		}
+	}
+}
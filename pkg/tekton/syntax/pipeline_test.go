@@ -9,8 +9,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/api/resource"
 
+	"fmt"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/jenkins-x/jx/pkg/config"
@@ -45,6 +47,7 @@ func TestParseJenkinsfileYaml(t *testing.T) {
 		expectedErrorMsg   string
 		validationErrorMsg string
 		structure          *v1.PipelineStructure
+		whenContext        *syntax.WhenContext
 	}{
 		{
 			name: "simple_jenkinsfile",
@@ -602,7 +605,207 @@ func TestParseJenkinsfileYaml(t *testing.T) {
 					),
 				),
 			),
-			expectedErrorMsg: "post on stages not yet supported",
+			validationErrorMsg: "Please use post steps instead",
+			expectedErrorMsg:   "post actions are not yet supported - please use post steps instead",
+		},
+		{
+			name: "stage_post_steps",
+			expected: sh.ParsedPipeline(
+				sh.PipelineAgent("some-image"),
+				sh.PipelineStage("A Working Stage",
+					sh.StageStep(sh.StepCmd("echo"), sh.StepArg("hello"), sh.StepArg("world")),
+					sh.StagePost(syntax.PostConditionFailure,
+						sh.PostStep(sh.StepCmd("echo"), sh.StepArg("failed"))),
+					sh.StagePost(syntax.PostConditionAlways,
+						sh.PostStep(sh.StepName("cleanup"), sh.StepCmd("rm"), sh.StepArg("-rf"), sh.StepArg("tmp"))),
+				),
+			),
+			pipeline: tb.Pipeline("somepipeline-1", "jx", tb.PipelineSpec(
+				tb.PipelineTask("a-working-stage", "somepipeline-a-working-stage-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline"),
+				),
+				tb.PipelineDeclaredResource("somepipeline", tektonv1alpha1.PipelineResourceTypeGit))),
+			tasks: []*tektonv1alpha1.Task{
+				tb.Task("somepipeline-a-working-stage-1", "jx", sh.TaskStageLabel("A Working Stage"),
+					tb.TaskSpec(
+						tb.TaskInputs(
+							tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+								tb.ResourceTargetPath("source"))),
+						tb.Step("git-merge", resolvedGitMergeImage, tb.Command("jx"), tb.Args("step", "git", "merge", "--verbose"), workingDir("/workspace/source")),
+						tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"),
+							tb.Args("if [ ! -f /workspace/.jx-stage-failed ]; then\n(\necho hello world\n) || echo step2 > /workspace/.jx-stage-failed\nfi"),
+							workingDir("/workspace/source")),
+						tb.Step("post-failure-1", "some-image:0.0.1", tb.Command("/bin/sh", "-c"),
+							tb.Args("if [ -f /workspace/.jx-stage-failed ]; then\necho failed\nfi"), workingDir("/workspace/source")),
+						tb.Step("post-always-cleanup", "some-image:0.0.1", tb.Command("/bin/sh", "-c"), tb.Args("rm -rf tmp"), workingDir("/workspace/source")),
+						tb.Step("post-check", "some-image:0.0.1", tb.Command("/bin/sh", "-c"),
+							tb.Args("if [ -f /workspace/.jx-stage-failed ]; then echo \"step $(cat /workspace/.jx-stage-failed) failed\"; exit 1; fi"),
+							workingDir("/workspace/source")),
+					)),
+			},
+			structure: sh.PipelineStructure("somepipeline-1",
+				sh.StructureStage("A Working Stage", sh.StructureStageTaskRef("somepipeline-a-working-stage-1")),
+			),
+		},
+		{
+			name: "top_level_post",
+			expected: sh.ParsedPipeline(
+				sh.PipelineAgent("some-image"),
+				sh.PipelineStage("Build",
+					sh.StageStep(sh.StepCmd("make")),
+				),
+				sh.PipelineStage("Test",
+					sh.StageStep(sh.StepCmd("make"), sh.StepArg("test")),
+				),
+				sh.PipelinePost(syntax.PostConditionSuccess,
+					sh.PostStep(sh.StepCmd("echo"), sh.StepArg("passed"))),
+				sh.PipelinePost(syntax.PostConditionFailure,
+					sh.PostStep(sh.StepCmd("echo"), sh.StepArg("failed"))),
+			),
+			pipeline: tb.Pipeline("somepipeline-1", "jx", tb.PipelineSpec(
+				tb.PipelineTask("build", "somepipeline-build-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline"),
+					tb.PipelineTaskOutputResource("workspace", "somepipeline")),
+				tb.PipelineTask("test", "somepipeline-test-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline",
+						tb.From("build")),
+					tb.PipelineTaskOutputResource("workspace", "somepipeline"),
+					tb.RunAfter("build")),
+				tb.PipelineTask("post", "somepipeline-post-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline",
+						tb.From("test")),
+					tb.RunAfter("test")),
+				tb.PipelineDeclaredResource("somepipeline", tektonv1alpha1.PipelineResourceTypeGit))),
+			tasks: []*tektonv1alpha1.Task{
+				tb.Task("somepipeline-build-1", "jx", sh.TaskStageLabel("Build"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.TaskOutputs(tb.OutputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit)),
+					tb.Step("git-merge", resolvedGitMergeImage, tb.Command("jx"), tb.Args("step", "git", "merge", "--verbose"), workingDir("/workspace/source")),
+					tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"),
+						tb.Args("if [ ! -f /workspace/source/.jx-pipeline-failed ]; then\n(\nmake\n) || echo step2 > /workspace/source/.jx-pipeline-failed\nfi"),
+						workingDir("/workspace/source")),
+				)),
+				tb.Task("somepipeline-test-1", "jx", sh.TaskStageLabel("Test"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.TaskOutputs(tb.OutputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit)),
+					tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"),
+						tb.Args("if [ ! -f /workspace/source/.jx-pipeline-failed ]; then\n(\nmake test\n) || echo step2 > /workspace/source/.jx-pipeline-failed\nfi"),
+						workingDir("/workspace/source")),
+				)),
+				tb.Task("somepipeline-post-1", "jx", sh.TaskStageLabel("post"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.Step("post-success-1", "some-image:0.0.1", tb.Command("/bin/sh", "-c"),
+						tb.Args("if [ ! -f /workspace/source/.jx-pipeline-failed ]; then\necho passed\nfi"), workingDir("/workspace/source")),
+					tb.Step("post-failure-1", "some-image:0.0.1", tb.Command("/bin/sh", "-c"),
+						tb.Args("if [ -f /workspace/source/.jx-pipeline-failed ]; then\necho failed\nfi"), workingDir("/workspace/source")),
+					tb.Step("post-check", "some-image:0.0.1", tb.Command("/bin/sh", "-c"),
+						tb.Args("if [ -f /workspace/source/.jx-pipeline-failed ]; then echo \"step $(cat /workspace/source/.jx-pipeline-failed) failed\"; exit 1; fi"),
+						workingDir("/workspace/source")),
+				)),
+			},
+			structure: sh.PipelineStructure("somepipeline-1",
+				sh.StructureStage("Build", sh.StructureStageTaskRef("somepipeline-build-1")),
+				sh.StructureStage("Test", sh.StructureStageTaskRef("somepipeline-test-1"), sh.StructureStagePrevious("Build")),
+				sh.StructureStage("post", sh.StructureStageTaskRef("somepipeline-post-1"), sh.StructureStagePrevious("Test")),
+			),
+		},
+		{
+			name: "top_level_post_with_parallel_stages",
+			expected: sh.ParsedPipeline(
+				sh.PipelineAgent("some-image"),
+				sh.PipelineStage("Build",
+					sh.StageStep(sh.StepCmd("make")),
+				),
+				sh.PipelineStage("Checks",
+					sh.StageParallel("Lint",
+						sh.StageStep(sh.StepCmd("make"), sh.StepArg("lint"))),
+					sh.StageParallel("Test",
+						sh.StageStep(sh.StepCmd("make"), sh.StepArg("test"))),
+				),
+				sh.PipelinePost(syntax.PostConditionFailure,
+					sh.PostStep(sh.StepCmd("echo"), sh.StepArg("failed"))),
+			),
+			pipeline: tb.Pipeline("somepipeline-1", "jx", tb.PipelineSpec(
+				tb.PipelineTask("build", "somepipeline-build-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline"),
+					tb.PipelineTaskOutputResource("workspace", "somepipeline")),
+				tb.PipelineTask("lint", "somepipeline-lint-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline",
+						tb.From("build")),
+					tb.PipelineTaskOutputResource("workspace", "somepipeline"),
+					tb.RunAfter("build")),
+				tb.PipelineTask("test", "somepipeline-test-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline",
+						tb.From("build")),
+					tb.PipelineTaskOutputResource("workspace", "somepipeline"),
+					tb.RunAfter("build")),
+				tb.PipelineTask("post", "somepipeline-post-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline",
+						tb.From("build", "lint", "test")),
+					tb.RunAfter("lint", "test")),
+				tb.PipelineDeclaredResource("somepipeline", tektonv1alpha1.PipelineResourceTypeGit))),
+			tasks: []*tektonv1alpha1.Task{
+				tb.Task("somepipeline-build-1", "jx", sh.TaskStageLabel("Build"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.TaskOutputs(tb.OutputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit)),
+					tb.Step("git-merge", resolvedGitMergeImage, tb.Command("jx"), tb.Args("step", "git", "merge", "--verbose"), workingDir("/workspace/source")),
+					tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"),
+						tb.Args("if [ ! -f /workspace/source/.jx-pipeline-failed ]; then\n(\nmake\n) || echo step2 > /workspace/source/.jx-pipeline-failed\nfi"),
+						workingDir("/workspace/source")),
+				)),
+				tb.Task("somepipeline-lint-1", "jx", sh.TaskStageLabel("Lint"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.TaskOutputs(tb.OutputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit)),
+					tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"),
+						tb.Args("if [ ! -f /workspace/source/.jx-pipeline-failed ]; then\n(\nmake lint\n) || echo step2 > /workspace/source/.jx-pipeline-failed\nfi"),
+						workingDir("/workspace/source")),
+				)),
+				tb.Task("somepipeline-test-1", "jx", sh.TaskStageLabel("Test"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.TaskOutputs(tb.OutputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit)),
+					tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"),
+						tb.Args("if [ ! -f /workspace/source/.jx-pipeline-failed ]; then\n(\nmake test\n) || echo step2 > /workspace/source/.jx-pipeline-failed\nfi"),
+						workingDir("/workspace/source")),
+				)),
+				tb.Task("somepipeline-post-1", "jx", sh.TaskStageLabel("post"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.Step("post-failure-1", "some-image:0.0.1", tb.Command("/bin/sh", "-c"),
+						tb.Args("if [ -f /workspace/source/.jx-pipeline-failed ]; then\necho failed\nfi"), workingDir("/workspace/source")),
+					tb.Step("post-check", "some-image:0.0.1", tb.Command("/bin/sh", "-c"),
+						tb.Args("if [ -f /workspace/source/.jx-pipeline-failed ]; then echo \"step $(cat /workspace/source/.jx-pipeline-failed) failed\"; exit 1; fi"),
+						workingDir("/workspace/source")),
+				)),
+			},
+			structure: sh.PipelineStructure("somepipeline-1",
+				sh.StructureStage("Build", sh.StructureStageTaskRef("somepipeline-build-1")),
+				sh.StructureStage("Checks",
+					sh.StructureStageParallel("Lint", "Test"),
+					sh.StructureStagePrevious("Build"),
+				),
+				sh.StructureStage("Lint", sh.StructureStageTaskRef("somepipeline-lint-1"),
+					sh.StructureStageDepth(1),
+					sh.StructureStageParent("Checks"),
+				),
+				sh.StructureStage("Test", sh.StructureStageTaskRef("somepipeline-test-1"),
+					sh.StructureStageDepth(1),
+					sh.StructureStageParent("Checks"),
+				),
+				sh.StructureStage("post", sh.StructureStageTaskRef("somepipeline-post-1"), sh.StructureStagePrevious("Checks")),
+			),
 		},
 		{
 			name: "top_level_and_stage_options",
@@ -622,7 +825,267 @@ func TestParseJenkinsfileYaml(t *testing.T) {
 					sh.StageStep(sh.StepCmd("echo"), sh.StepArg("hello"), sh.StepArg("world")),
 				),
 			),
-			expectedErrorMsg: "Retry at top level not yet supported",
+			validationErrorMsg: "The stage A Working Stage unstashes Earlier Files, which is not stashed by any earlier stage",
+		},
+		{
+			name: "retry_and_timeout",
+			expected: sh.ParsedPipeline(
+				sh.PipelineAgent("some-image"),
+				sh.PipelineOptions(
+					sh.PipelineOptionsRetry(2),
+				),
+				sh.PipelineStage("Build",
+					sh.StageOptions(
+						sh.StageOptionsTimeout(10, "minutes"),
+						sh.StageOptionsRetry(1),
+					),
+					sh.StageStep(sh.StepCmd("make"), sh.StepArg("build"), sh.StepTimeout(30, "seconds"), sh.StepRetry(2)),
+				),
+				sh.PipelineStage("Test",
+					sh.StageStep(sh.StepCmd("make"), sh.StepArg("test")),
+				),
+			),
+			pipeline: tb.Pipeline("somepipeline-1", "jx", tb.PipelineSpec(
+				tb.PipelineTask("build", "somepipeline-build-1",
+					sh.PipelineTaskRetries(1),
+					tb.PipelineTaskInputResource("workspace", "somepipeline"),
+					tb.PipelineTaskOutputResource("workspace", "somepipeline")),
+				tb.PipelineTask("test", "somepipeline-test-1",
+					sh.PipelineTaskRetries(2),
+					tb.PipelineTaskInputResource("workspace", "somepipeline",
+						tb.From("build")),
+					tb.RunAfter("build")),
+				tb.PipelineDeclaredResource("somepipeline", tektonv1alpha1.PipelineResourceTypeGit))),
+			tasks: []*tektonv1alpha1.Task{
+				tb.Task("somepipeline-build-1", "jx", sh.TaskStageLabel("Build"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.TaskOutputs(tb.OutputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit)),
+					tb.Step("git-merge", resolvedGitMergeImage, tb.Command("jx"), tb.Args("step", "git", "merge", "--verbose"), workingDir("/workspace/source")),
+					tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"),
+						tb.Args("[ -f /workspace/.jx-stage-deadline ] || echo $(($(date +%s) + 600)) > /workspace/.jx-stage-deadline\nread deadline < /workspace/.jx-stage-deadline\nremaining=$((deadline - $(date +%s)))\nif [ $remaining -le 0 ]; then echo \"stage timed out before step step2\"; exit 124; fi\nif command -v setsid >/dev/null 2>&1; then process_group=setsid; else set -m 2>/dev/null; fi\n$process_group sh -c 'attempt=1\nwhile true; do\n(\nif command -v setsid >/dev/null 2>&1; then process_group=setsid; else set -m 2>/dev/null; fi\n$process_group sh -c '\"'\"'make build'\"'\"' &\npid=$!\ntrap '\"'\"'kill -TERM -$pid 2>/dev/null || kill -TERM $pid 2>/dev/null'\"'\"' TERM\n(sleep 30; trap '\"'\"''\"'\"' TERM; kill -TERM -$pid 2>/dev/null || kill -TERM $pid) 2>/dev/null &\nwatchdog=$!\nwait $pid\nstatus=$?\nkill -TERM -$watchdog 2>/dev/null || kill -TERM $watchdog 2>/dev/null\nif wait $watchdog; then status=124; fi\nexit $status\n)\nstatus=$?\nif [ $status -eq 0 ] || [ $attempt -ge 3 ]; then break; fi\nattempt=$((attempt + 1))\necho \"step step2 failed, retrying (attempt $attempt of 3)\"\ndone\nif [ $attempt -gt 1 ]; then { echo jx-step-attempts=$attempt > /dev/termination-log; } 2>/dev/null; fi\nexit $status' &\npid=$!\ntrap 'kill -TERM -$pid 2>/dev/null || kill -TERM $pid 2>/dev/null' TERM\n(sleep $remaining; trap '' TERM; kill -TERM -$pid 2>/dev/null || kill -TERM $pid) 2>/dev/null &\nwatchdog=$!\nwait $pid\nstatus=$?\nkill -TERM -$watchdog 2>/dev/null || kill -TERM $watchdog 2>/dev/null\nif wait $watchdog; then status=124; fi\nexit $status"),
+						workingDir("/workspace/source")),
+				)),
+				tb.Task("somepipeline-test-1", "jx", sh.TaskStageLabel("Test"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"), tb.Args("make test"), workingDir("/workspace/source")),
+				)),
+			},
+			structure: sh.PipelineStructure("somepipeline-1",
+				sh.StructureStage("Build", sh.StructureStageTaskRef("somepipeline-build-1")),
+				sh.StructureStage("Test", sh.StructureStageTaskRef("somepipeline-test-1"),
+					sh.StructureStagePrevious("Build")),
+			),
+		},
+		{
+			name: "stash_and_unstash",
+			expected: sh.ParsedPipeline(
+				sh.PipelineAgent("some-image"),
+				sh.PipelineStage("Build",
+					sh.StageOptions(
+						sh.StageOptionsStash("Build Output", "target/**/*"),
+					),
+					sh.StageStep(sh.StepCmd("make")),
+				),
+				sh.PipelineStage("Test",
+					sh.StageOptions(
+						sh.StageOptionsUnstash("Build Output", "target"),
+					),
+					sh.StageStep(sh.StepCmd("make"), sh.StepArg("test")),
+				),
+			),
+			pipeline: tb.Pipeline("somepipeline-1", "jx", tb.PipelineSpec(
+				tb.PipelineTask("build", "somepipeline-build-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline"),
+					tb.PipelineTaskOutputResource("workspace", "somepipeline")),
+				tb.PipelineTask("test", "somepipeline-test-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline",
+						tb.From("build")),
+					tb.RunAfter("build")),
+				tb.PipelineDeclaredResource("somepipeline", tektonv1alpha1.PipelineResourceTypeGit))),
+			tasks: []*tektonv1alpha1.Task{
+				tb.Task("somepipeline-build-1", "jx", sh.TaskStageLabel("Build"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.TaskOutputs(tb.OutputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit)),
+					tb.Step("git-merge", resolvedGitMergeImage, tb.Command("jx"), tb.Args("step", "git", "merge", "--verbose"), workingDir("/workspace/source")),
+					tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"), tb.Args("make"), workingDir("/workspace/source")),
+					tb.Step("stash-build-output", resolvedGitMergeImage, tb.Command("/bin/sh", "-c"),
+						tb.Args("jx step stash --classifier stash --pattern 'target/**/*' --name build-output --to-path jenkins-x/stash/somepipeline/1"),
+						workingDir("/workspace/source")),
+				)),
+				tb.Task("somepipeline-test-1", "jx", sh.TaskStageLabel("Test"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.Step("unstash-build-output", resolvedGitMergeImage, tb.Command("/bin/sh", "-c"),
+						tb.Args("jx step unstash --classifier stash --name build-output --to-path jenkins-x/stash/somepipeline/1 --output 'target'"),
+						workingDir("/workspace/source")),
+					tb.Step("step3", "some-image:0.0.1", tb.Command("/bin/sh", "-c"), tb.Args("make test"), workingDir("/workspace/source")),
+				)),
+			},
+			structure: sh.PipelineStructure("somepipeline-1",
+				sh.StructureStage("Build", sh.StructureStageTaskRef("somepipeline-build-1")),
+				sh.StructureStage("Test", sh.StructureStageTaskRef("somepipeline-test-1"),
+					sh.StructureStagePrevious("Build")),
+			),
+		},
+		{
+			name: "when_conditions",
+			expected: sh.ParsedPipeline(
+				sh.PipelineAgent("some-image"),
+				sh.PipelineStage("Build",
+					sh.StageStep(sh.StepCmd("make")),
+				),
+				sh.PipelineStage("Deploy",
+					sh.StageWhen(sh.WhenBranch("master")),
+					sh.StageStep(sh.StepCmd("make"), sh.StepArg("deploy")),
+				),
+				sh.PipelineStage("Lint",
+					sh.StageWhen(sh.WhenLabels("lint")),
+					sh.StageStep(sh.StepCmd("make"), sh.StepArg("lint")),
+				),
+			),
+			whenContext: &syntax.WhenContext{
+				Branch: "PR-1",
+				Labels: []string{"lint", "ok-to-test"},
+			},
+			pipeline: tb.Pipeline("somepipeline-1", "jx", tb.PipelineSpec(
+				tb.PipelineTask("build", "somepipeline-build-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline"),
+					tb.PipelineTaskOutputResource("workspace", "somepipeline")),
+				tb.PipelineTask("lint", "somepipeline-lint-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline",
+						tb.From("build")),
+					tb.RunAfter("build")),
+				tb.PipelineDeclaredResource("somepipeline", tektonv1alpha1.PipelineResourceTypeGit))),
+			tasks: []*tektonv1alpha1.Task{
+				tb.Task("somepipeline-build-1", "jx", sh.TaskStageLabel("Build"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.TaskOutputs(tb.OutputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit)),
+					tb.Step("git-merge", resolvedGitMergeImage, tb.Command("jx"), tb.Args("step", "git", "merge", "--verbose"), workingDir("/workspace/source")),
+					tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"), tb.Args("make"), workingDir("/workspace/source")),
+				)),
+				tb.Task("somepipeline-lint-1", "jx", sh.TaskStageLabel("Lint"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"), tb.Args("make lint"), workingDir("/workspace/source")),
+				)),
+			},
+			structure: sh.PipelineStructure("somepipeline-1",
+				sh.StructureStage("Build", sh.StructureStageTaskRef("somepipeline-build-1")),
+				sh.StructureStage("Lint", sh.StructureStageTaskRef("somepipeline-lint-1"),
+					sh.StructureStagePrevious("Build")),
+				sh.StructureStage("Deploy", sh.StructureStageSkipped()),
+			),
+		},
+		{
+			name:             "when_all_skipped",
+			whenContext:      &syntax.WhenContext{Branch: "PR-1"},
+			expectedErrorMsg: "nothing to run as the when conditions of every stage are not met",
+			structure: sh.PipelineStructure("somepipeline-1",
+				sh.StructureStage("Deploy", sh.StructureStageSkipped()),
+			),
+		},
+		{
+			name:             "when_skipped_stash",
+			whenContext:      &syntax.WhenContext{Branch: "PR-1"},
+			expectedErrorMsg: "stage Test unstashes binaries, which is stashed by stage Build whose when conditions are not met. Please add the same when conditions to stage Test",
+		},
+		{
+			name: "matrix",
+			expected: sh.ParsedPipeline(
+				sh.PipelineAgent("some-image"),
+				sh.PipelineStage("Build",
+					sh.StageStep(sh.StepCmd("make")),
+				),
+				sh.PipelineStage("Test",
+					sh.StageMatrix(
+						sh.MatrixAxis("go", "1.12", "1.13"),
+						sh.MatrixAxis("os", "alpine", "ubuntu"),
+						sh.MatrixExclude(map[string]string{"go": "1.12", "os": "alpine"}),
+					),
+					sh.StageStep(sh.StepCmd("make"), sh.StepArg("test")),
+				),
+			),
+			pipeline: tb.Pipeline("somepipeline-1", "jx", tb.PipelineSpec(
+				tb.PipelineTask("build", "somepipeline-build-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline"),
+					tb.PipelineTaskOutputResource("workspace", "somepipeline")),
+				tb.PipelineTask("test-1-12-ubuntu", "somepipeline-test-1-12-ubuntu-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline",
+						tb.From("build")),
+					tb.RunAfter("build")),
+				tb.PipelineTask("test-1-13-alpine", "somepipeline-test-1-13-alpine-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline",
+						tb.From("build")),
+					tb.RunAfter("build")),
+				tb.PipelineTask("test-1-13-ubuntu", "somepipeline-test-1-13-ubuntu-1",
+					tb.PipelineTaskInputResource("workspace", "somepipeline",
+						tb.From("build")),
+					tb.RunAfter("build")),
+				tb.PipelineDeclaredResource("somepipeline", tektonv1alpha1.PipelineResourceTypeGit))),
+			tasks: []*tektonv1alpha1.Task{
+				tb.Task("somepipeline-build-1", "jx", sh.TaskStageLabel("Build"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.TaskOutputs(tb.OutputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit)),
+					tb.Step("git-merge", resolvedGitMergeImage, tb.Command("jx"), tb.Args("step", "git", "merge", "--verbose"), workingDir("/workspace/source")),
+					tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"), tb.Args("make"), workingDir("/workspace/source")),
+				)),
+				tb.Task("somepipeline-test-1-12-ubuntu-1", "jx", sh.TaskStageLabel("test-1-12-ubuntu"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"), tb.Args("make test"), workingDir("/workspace/source"),
+						tb.EnvVar("GO", "1.12"), tb.EnvVar("OS", "ubuntu")),
+				)),
+				tb.Task("somepipeline-test-1-13-alpine-1", "jx", sh.TaskStageLabel("test-1-13-alpine"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"), tb.Args("make test"), workingDir("/workspace/source"),
+						tb.EnvVar("GO", "1.13"), tb.EnvVar("OS", "alpine")),
+				)),
+				tb.Task("somepipeline-test-1-13-ubuntu-1", "jx", sh.TaskStageLabel("test-1-13-ubuntu"), tb.TaskSpec(
+					tb.TaskInputs(
+						tb.InputsResource("workspace", tektonv1alpha1.PipelineResourceTypeGit,
+							tb.ResourceTargetPath("source"))),
+					tb.Step("step2", "some-image:0.0.1", tb.Command("/bin/sh", "-c"), tb.Args("make test"), workingDir("/workspace/source"),
+						tb.EnvVar("GO", "1.13"), tb.EnvVar("OS", "ubuntu")),
+				)),
+			},
+			structure: sh.PipelineStructure("somepipeline-1",
+				sh.StructureStage("Build", sh.StructureStageTaskRef("somepipeline-build-1")),
+				sh.StructureStage("Test",
+					sh.StructureStageParallel("test-1-12-ubuntu", "test-1-13-alpine", "test-1-13-ubuntu"),
+					sh.StructureStagePrevious("Build"),
+					sh.StructureStageMatrixAxes("go", "os"),
+				),
+				sh.StructureStage("test-1-12-ubuntu", sh.StructureStageTaskRef("somepipeline-test-1-12-ubuntu-1"),
+					sh.StructureStageDepth(1),
+					sh.StructureStageParent("Test"),
+					sh.StructureStageMatrixValues(map[string]string{"go": "1.12", "os": "ubuntu"}),
+				),
+				sh.StructureStage("test-1-13-alpine", sh.StructureStageTaskRef("somepipeline-test-1-13-alpine-1"),
+					sh.StructureStageDepth(1),
+					sh.StructureStageParent("Test"),
+					sh.StructureStageMatrixValues(map[string]string{"go": "1.13", "os": "alpine"}),
+				),
+				sh.StructureStage("test-1-13-ubuntu", sh.StructureStageTaskRef("somepipeline-test-1-13-ubuntu-1"),
+					sh.StructureStageDepth(1),
+					sh.StructureStageParent("Test"),
+					sh.StructureStageMatrixValues(map[string]string{"go": "1.13", "os": "ubuntu"}),
+				),
+			),
 		},
 		{
 			name: "stage_and_step_agent",
@@ -1407,6 +1870,7 @@ func TestParseJenkinsfileYaml(t *testing.T) {
 				SourceDir:          "source",
 				DefaultImage:       "",
 				InterpretMode:      false,
+				When:               tt.whenContext,
 			}
 			pipeline, tasks, structure, err := parsed.GenerateCRDs(crdParams)
 
@@ -1415,6 +1879,11 @@ func TestParseJenkinsfileYaml(t *testing.T) {
 					if d := cmp.Diff(tt.expectedErrorMsg, err.Error()); d != "" {
 						t.Fatalf("CRD generation error did not meet expectation: %s", d)
 					}
+					if tt.structure != nil {
+						if d := cmp.Diff(tt.structure, structure); d != "" {
+							t.Errorf("Generated PipelineStructure did not match expected: %s", d)
+						}
+					}
 				} else {
 					t.Fatalf("Error generating CRDs: %s", err)
 				}
@@ -1519,6 +1988,27 @@ func TestFailedValidation(t *testing.T) {
 				Paths:   []string{"args"},
 			}).ViaFieldIndex("steps", 0).ViaFieldIndex("stages", 0),
 		},
+		{
+			name: "step_with_step_and_timeout",
+			expectedError: (&apis.FieldError{
+				Message: "Cannot set timeout or retry for a step or a loop",
+				Paths:   []string{"timeout", "retry"},
+			}).ViaFieldIndex("steps", 0).ViaFieldIndex("stages", 0),
+		},
+		{
+			name: "step_retry_with_invalid_count",
+			expectedError: (&apis.FieldError{
+				Message: "Retry count cannot be negative",
+				Paths:   []string{"retry"},
+			}).ViaFieldIndex("steps", 0).ViaFieldIndex("stages", 0),
+		},
+		{
+			name: "step_timeout_with_invalid_unit",
+			expectedError: (&apis.FieldError{
+				Message: "years is not a valid time unit. Valid time units are seconds, minutes, hours, days",
+				Paths:   []string{"unit"},
+			}).ViaField("timeout").ViaFieldIndex("steps", 0).ViaFieldIndex("stages", 0),
+		},
 		{
 			name: "step_with_loop_and_options",
 			expectedError: (&apis.FieldError{
@@ -1667,6 +2157,37 @@ func TestFailedValidation(t *testing.T) {
 			name:          "loop_without_steps",
 			expectedError: apis.ErrMissingField("steps").ViaField("loop").ViaFieldIndex("steps", 0).ViaFieldIndex("stages", 0),
 		},
+		{
+			name: "matrix_without_steps",
+			expectedError: (&apis.FieldError{
+				Message: "matrix can only be used on stages with steps",
+				Paths:   []string{"matrix"},
+			}).ViaFieldIndex("stages", 0),
+		},
+		{
+			name: "matrix_with_stash",
+			expectedError: (&apis.FieldError{
+				Message: "stash cannot be used on stages with a matrix",
+				Details: "The stages for each combination of values run in parallel, so they would overwrite the same stash",
+				Paths:   []string{"stash"},
+			}).ViaFieldIndex("stages", 1),
+		},
+		{
+			name: "matrix_exclude_unknown_axis",
+			expectedError: (&apis.FieldError{
+				Message: "matrix exclusions must refer to axes of the matrix",
+				Details: "The axis os is not defined",
+				Paths:   []string{"os"},
+			}).ViaFieldIndex("exclude", 0).ViaField("matrix").ViaFieldIndex("stages", 0),
+		},
+		{
+			name: "matrix_too_many_combinations",
+			expectedError: (&apis.FieldError{
+				Message: "matrix has too many combinations of values",
+				Details: "The matrix has 48 combinations of values, but at most 32 are allowed",
+				Paths:   []string{"axes"},
+			}).ViaField("matrix").ViaFieldIndex("stages", 0),
+		},
 		{
 			name:          "loop_without_values",
 			expectedError: apis.ErrMissingField("values").ViaField("loop").ViaFieldIndex("steps", 0).ViaFieldIndex("stages", 0),
@@ -1739,10 +2260,40 @@ func TestFailedValidation(t *testing.T) {
 				Paths:   []string{"steps"},
 			}).ViaFieldIndex("stages", 0),
 		},
+		{
+			name: "post_with_invalid_condition",
+			expectedError: (&apis.FieldError{
+				Message: "sometimes is not a valid post condition. Valid post conditions are success, failure, always",
+				Paths:   []string{"condition"},
+			}).ViaFieldIndex("post", 0).ViaFieldIndex("stages", 0),
+		},
+		{
+			name:          "post_without_steps",
+			expectedError: apis.ErrMissingField("steps").ViaFieldIndex("post", 0).ViaFieldIndex("stages", 0),
+		},
+		{
+			name: "post_with_actions",
+			expectedError: (&apis.FieldError{
+				Message: "post actions are not yet supported",
+				Details: "Please use post steps instead",
+				Paths:   []string{"actions"},
+			}).ViaFieldIndex("post", 0),
+		},
+		{
+			name: "unstash_without_stash",
+			expectedError: &apis.FieldError{
+				Message: "unstash must refer to a stash from an earlier stage",
+				Details: "The stage Test unstashes Build Output, which is not stashed by any earlier stage",
+			},
+		},
 		{
 			name:          "volume_missing_name",
 			expectedError: apis.ErrMissingField("name").ViaFieldIndex("volumes", 0).ViaField("options"),
 		},
+		{
+			name:          "when_without_conditions",
+			expectedError: apis.ErrMissingOneOf("branch", "labels", "changedPaths", "env").ViaField("when").ViaFieldIndex("stages", 0),
+		},
 	}
 
 	for _, tt := range tests {
@@ -2284,3 +2835,24 @@ func TestParsedPipelineHelpers(t *testing.T) {
 		t.Fatalf("ParsedPipeline diff -want, +got: %v", d)
 	}
 }
+
+func TestTimeoutToDuration(t *testing.T) {
+	tests := []struct {
+		timeout  syntax.Timeout
+		expected time.Duration
+	}{
+		{timeout: syntax.Timeout{Time: 30}, expected: 30 * time.Second},
+		{timeout: syntax.Timeout{Time: 30, Unit: syntax.TimeoutUnitSeconds}, expected: 30 * time.Second},
+		{timeout: syntax.Timeout{Time: 10, Unit: syntax.TimeoutUnitMinutes}, expected: 10 * time.Minute},
+		{timeout: syntax.Timeout{Time: 2, Unit: syntax.TimeoutUnitHours}, expected: 2 * time.Hour},
+		{timeout: syntax.Timeout{Time: 3, Unit: syntax.TimeoutUnitDays}, expected: 72 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d %s", tt.timeout.Time, tt.timeout.Unit), func(t *testing.T) {
+			d, err := tt.timeout.ToDuration()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, d.Duration)
+		})
+	}
+}
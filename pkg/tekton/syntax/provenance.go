@@ -0,0 +1,91 @@
+package syntax
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Describe returns a short description of the rule applied by the override, such as "override after step build in
+// stage ci of the release pipeline"
+func (p *PipelineOverride) Describe() string {
+	overrideType := StepOverrideReplace
+	if p.Type != nil {
+		overrideType = *p.Type
+	}
+	parts := []string{"override", string(overrideType)}
+	if p.Name != "" {
+		parts = append(parts, "step", p.Name)
+	} else {
+		parts = append(parts, "all steps")
+	}
+	if p.Stage != "" {
+		parts = append(parts, "in stage", p.Stage)
+	}
+	if p.Pipeline != "" {
+		parts = append(parts, "of the", p.Pipeline, "pipeline")
+	}
+	return strings.Join(parts, " ")
+}
+
+// StepSource returns the source of the steps added by the override
+func (p *PipelineOverride) StepSource() string {
+	if p.Source == "" {
+		return p.Describe()
+	}
+	return fmt.Sprintf("%s (%s)", p.Source, p.Describe())
+}
+
+// SetSources sets the source of the override and of its steps, if they are not already set
+func (p *PipelineOverride) SetSources(source string) {
+	if p.Source == "" {
+		p.Source = source
+	}
+	SetStepSources(p.AsStepsSlice(), p.StepSource())
+}
+
+// SetStepSources sets the source of each of the steps, and their child steps, which does not already have one
+func SetStepSources(steps []*Step, source string) {
+	for _, s := range steps {
+		if s != nil {
+			s.setSource(source)
+		}
+	}
+}
+
+func (s *Step) setSource(source string) {
+	if s.Source == "" {
+		s.Source = source
+	}
+	SetStepSources(s.Steps, source)
+}
+
+// SetStageStepSources sets the source of each step in the stages, their child stages and their post conditions, which
+// does not already have one
+func SetStageStepSources(stages []Stage, source string) {
+	for i := range stages {
+		stage := &stages[i]
+		for j := range stage.Steps {
+			stage.Steps[j].setSource(source)
+		}
+		setPostStepSources(stage.Post, source)
+		SetStageStepSources(stage.Stages, source)
+		SetStageStepSources(stage.Parallel, source)
+	}
+}
+
+func setPostStepSources(posts []Post, source string) {
+	for i := range posts {
+		for j := range posts[i].Steps {
+			posts[i].Steps[j].setSource(source)
+		}
+	}
+}
+
+// SetStepSources sets the source of each step in the pipeline which does not already have one
+func (j *ParsedPipeline) SetStepSources(source string) {
+	if j == nil {
+		return
+	}
+	SetStageStepSources(j.Stages, source)
+	setPostStepSources(j.Post, source)
+}
@@ -108,6 +108,27 @@ func StructureStageParallel(stages ...string) PipelineStructureStageOp {
 	}
 }
 
+// StructureStageSkipped marks the stage as skipped
+func StructureStageSkipped() PipelineStructureStageOp {
+	return func(stage *v1.PipelineStructureStage) {
+		stage.Skipped = true
+	}
+}
+
+// StructureStageMatrixAxes sets the matrix axes for the stage
+func StructureStageMatrixAxes(axes ...string) PipelineStructureStageOp {
+	return func(stage *v1.PipelineStructureStage) {
+		stage.MatrixAxes = append(stage.MatrixAxes, axes...)
+	}
+}
+
+// StructureStageMatrixValues sets the matrix axis values for the stage
+func StructureStageMatrixValues(values map[string]string) PipelineStructureStageOp {
+	return func(stage *v1.PipelineStructureStage) {
+		stage.MatrixValues = values
+	}
+}
+
 // PipelineOp is an operation on a ParsedPipeline
 type PipelineOp func(*syntax.ParsedPipeline)
 
@@ -123,6 +144,12 @@ type StageOp func(*syntax.Stage)
 // StageOptionsOp is an operation on StageOptions
 type StageOptionsOp func(*syntax.StageOptions)
 
+// WhenOp is an operation on When
+type WhenOp func(*syntax.When)
+
+// MatrixOp is an operation on a Matrix
+type MatrixOp func(*syntax.Matrix)
+
 // StepOp is an operation on a step
 type StepOp func(*syntax.Step)
 
@@ -352,6 +379,19 @@ func PostAction(name string, options map[string]string) PipelinePostOp {
 	}
 }
 
+// PostStep adds a step to a post condition
+func PostStep(ops ...StepOp) PipelinePostOp {
+	return func(post *syntax.Post) {
+		step := syntax.Step{}
+
+		for _, op := range ops {
+			op(&step)
+		}
+
+		post.Steps = append(post.Steps, step)
+	}
+}
+
 // StageAgent sets the image/agent for a stage
 func StageAgent(image string) StageOp {
 	return func(stage *syntax.Stage) {
@@ -449,6 +489,75 @@ func StagePost(condition syntax.PostCondition, ops ...PipelinePostOp) StageOp {
 	}
 }
 
+// StageWhen sets the when conditions for the stage
+func StageWhen(ops ...WhenOp) StageOp {
+	return func(stage *syntax.Stage) {
+		stage.When = &syntax.When{}
+
+		for _, op := range ops {
+			op(stage.When)
+		}
+	}
+}
+
+// StageMatrix sets the matrix for the stage
+func StageMatrix(ops ...MatrixOp) StageOp {
+	return func(stage *syntax.Stage) {
+		stage.Matrix = &syntax.Matrix{
+			Axes: make(map[string][]string),
+		}
+
+		for _, op := range ops {
+			op(stage.Matrix)
+		}
+	}
+}
+
+// MatrixAxis adds an axis, with the specified name and values, to the matrix
+func MatrixAxis(name string, values ...string) MatrixOp {
+	return func(matrix *syntax.Matrix) {
+		matrix.Axes[name] = values
+	}
+}
+
+// MatrixExclude adds an exclusion to the matrix
+func MatrixExclude(values map[string]string) MatrixOp {
+	return func(matrix *syntax.Matrix) {
+		matrix.Exclude = append(matrix.Exclude, values)
+	}
+}
+
+// WhenBranch sets the branch pattern for the when conditions
+func WhenBranch(branch string) WhenOp {
+	return func(when *syntax.When) {
+		when.Branch = branch
+	}
+}
+
+// WhenLabels adds labels to the when conditions
+func WhenLabels(labels ...string) WhenOp {
+	return func(when *syntax.When) {
+		when.Labels = append(when.Labels, labels...)
+	}
+}
+
+// WhenChangedPaths adds changed path patterns to the when conditions
+func WhenChangedPaths(paths ...string) WhenOp {
+	return func(when *syntax.When) {
+		when.ChangedPaths = append(when.ChangedPaths, paths...)
+	}
+}
+
+// WhenEnvVar adds an environment variable, with specified name and value, to the when conditions
+func WhenEnvVar(name, value string) WhenOp {
+	return func(when *syntax.When) {
+		when.Env = append(when.Env, corev1.EnvVar{
+			Name:  name,
+			Value: value,
+		})
+	}
+}
+
 // StepAgent sets the agent for a step
 func StepAgent(image string) StepOp {
 	return func(step *syntax.Step) {
@@ -486,6 +595,23 @@ func StepArg(arg string) StepOp {
 	}
 }
 
+// StepTimeout sets the timeout for a step
+func StepTimeout(time int64, unit syntax.TimeoutUnit) StepOp {
+	return func(step *syntax.Step) {
+		step.Timeout = &syntax.Timeout{
+			Time: time,
+			Unit: unit,
+		}
+	}
+}
+
+// StepRetry sets the retry count for a step
+func StepRetry(count int8) StepOp {
+	return func(step *syntax.Step) {
+		step.Retry = count
+	}
+}
+
 // StepStep sets the alias step for a step
 func StepStep(s string) StepOp {
 	return func(step *syntax.Step) {
@@ -585,6 +711,13 @@ func StageSequential(name string, ops ...StageOp) StageOp {
 	}
 }
 
+// PipelineTaskRetries sets the number of retries for a PipelineTask
+func PipelineTaskRetries(retries int) builder.PipelineTaskOp {
+	return func(pt *v1alpha1.PipelineTask) {
+		pt.Retries = retries
+	}
+}
+
 // TaskStageLabel sets the stage label on the task
 func TaskStageLabel(value string) builder.TaskOp {
 	return func(t *v1alpha1.Task) {
@@ -0,0 +1,237 @@
+package syntax
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TemplateParameterType is the type of a parameter of a stage template
+type TemplateParameterType string
+
+// Template parameter types
+const (
+	TemplateParameterTypeString TemplateParameterType = "string"
+	TemplateParameterTypeInt    TemplateParameterType = "int"
+	TemplateParameterTypeBool   TemplateParameterType = "bool"
+)
+
+var templateParamReference = regexp.MustCompile(`\$\{params\.([a-zA-Z0-9_-]+)\}`)
+
+// PipelineTemplates is the contents of a file of stage templates which can be included in pipelines
+type PipelineTemplates struct {
+	Templates []StageTemplate `json:"templates"`
+}
+
+// StageTemplate is a reusable, named stage which can be included in pipelines. Any "${params.<name>}" in the stage is
+// replaced with the value of the parameter when the template is included.
+type StageTemplate struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Parameters  []TemplateParameter `json:"parameters,omitempty"`
+	Stage       Stage               `json:"stage"`
+}
+
+// TemplateParameter is a parameter of a stage template
+type TemplateParameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Type is one of string, int or bool, and defaults to string
+	Type TemplateParameterType `json:"type,omitempty"`
+	// Default is the value used if the parameter is not given when the template is included. The parameter is
+	// required if there is no default.
+	Default *string `json:"default,omitempty"`
+}
+
+// StageTemplateRef includes a stage template in a pipeline
+type StageTemplateRef struct {
+	Name   string            `json:"name"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// Validate checks the parameters of the template are well formed
+func (t *StageTemplate) Validate() error {
+	if t.Name == "" {
+		return errors.New("stage templates must have a name")
+	}
+	seen := make(map[string]bool)
+	for _, p := range t.Parameters {
+		if p.Name == "" {
+			return errors.Errorf("the parameters of stage template %s must have names", t.Name)
+		}
+		if seen[p.Name] {
+			return errors.Errorf("the parameter %s of stage template %s is defined more than once", p.Name, t.Name)
+		}
+		seen[p.Name] = true
+		if p.Default != nil {
+			if err := p.checkValue(*p.Default); err != nil {
+				return errors.Wrapf(err, "invalid default for stage template %s", t.Name)
+			}
+		} else if err := p.checkType(); err != nil {
+			return errors.Wrapf(err, "invalid parameter for stage template %s", t.Name)
+		}
+	}
+	for _, m := range templateParamReference.FindAllStringSubmatch(t.stageJSON(), -1) {
+		if !seen[m[1]] {
+			return errors.Errorf("stage template %s refers to the parameter %s, which is not defined", t.Name, m[1])
+		}
+	}
+	return nil
+}
+
+func (p *TemplateParameter) checkType() error {
+	switch p.Type {
+	case "", TemplateParameterTypeString, TemplateParameterTypeInt, TemplateParameterTypeBool:
+		return nil
+	default:
+		return errors.Errorf("the parameter %s has type %s but must be one of %s, %s or %s", p.Name, p.Type,
+			TemplateParameterTypeString, TemplateParameterTypeInt, TemplateParameterTypeBool)
+	}
+}
+
+func (p *TemplateParameter) checkValue(value string) error {
+	if err := p.checkType(); err != nil {
+		return err
+	}
+	switch p.Type {
+	case TemplateParameterTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return errors.Errorf("the parameter %s must be an int but was %s", p.Name, value)
+		}
+	case TemplateParameterTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return errors.Errorf("the parameter %s must be a bool but was %s", p.Name, value)
+		}
+	}
+	return nil
+}
+
+func (t *StageTemplate) stageJSON() string {
+	data, _ := json.Marshal(t.Stage)
+	return string(data)
+}
+
+// Instantiate returns the stage for the template with the given parameter values substituted.
+func (t *StageTemplate) Instantiate(params map[string]string) (*Stage, error) {
+	values := make(map[string]string)
+	defined := make(map[string]bool)
+	for _, p := range t.Parameters {
+		defined[p.Name] = true
+		value, ok := params[p.Name]
+		if !ok {
+			if p.Default == nil {
+				return nil, errors.Errorf("the parameter %s of stage template %s is required", p.Name, t.Name)
+			}
+			value = *p.Default
+		}
+		if err := p.checkValue(value); err != nil {
+			return nil, errors.Wrapf(err, "invalid parameter for stage template %s", t.Name)
+		}
+		values[p.Name] = value
+	}
+	var unknown []string
+	for name := range params {
+		if !defined[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, errors.Errorf("stage template %s has no parameters named %s", t.Name, strings.Join(unknown, ", "))
+	}
+
+	// Substitute the values into the JSON form of the stage, so that every string field is covered, quoting the values
+	// so that they remain valid JSON strings.
+	stageJSON := templateParamReference.ReplaceAllStringFunc(t.stageJSON(), func(ref string) string {
+		name := templateParamReference.FindStringSubmatch(ref)[1]
+		quoted, _ := json.Marshal(values[name])
+		return string(quoted[1 : len(quoted)-1])
+	})
+	stage := &Stage{}
+	err := json.Unmarshal([]byte(stageJSON), stage)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to substitute the parameters of stage template %s", t.Name)
+	}
+	return stage, nil
+}
+
+// ExpandStageTemplates replaces each stage which includes a template with the stage from the template. The name of
+// the including stage is kept, along with its agent, env, options, dir, when and matrix if set, which take precedence
+// over those of the template. It returns the expanded stages and the name of the template for each stage which was
+// expanded, keyed by stage name.
+func ExpandStageTemplates(stages []Stage, templates map[string]*StageTemplate) ([]Stage, map[string]string, error) {
+	included := make(map[string]string)
+	expanded, err := expandStageTemplates(stages, templates, included)
+	return expanded, included, err
+}
+
+func expandStageTemplates(stages []Stage, templates map[string]*StageTemplate, included map[string]string) ([]Stage, error) {
+	var expanded []Stage
+	for _, s := range stages {
+		if s.Template == nil {
+			var err error
+			s.Stages, err = expandStageTemplates(s.Stages, templates, included)
+			if err != nil {
+				return nil, err
+			}
+			s.Parallel, err = expandStageTemplates(s.Parallel, templates, included)
+			if err != nil {
+				return nil, err
+			}
+			expanded = append(expanded, s)
+			continue
+		}
+
+		if len(s.Steps) > 0 || len(s.Stages) > 0 || len(s.Parallel) > 0 {
+			return nil, errors.Errorf("stage %s includes the template %s so cannot also have steps, stages or parallel", s.Name, s.Template.Name)
+		}
+		template, ok := templates[s.Template.Name]
+		if !ok {
+			return nil, errors.Errorf("stage %s includes the template %s, which is not defined", s.Name, s.Template.Name)
+		}
+		stage, err := template.Instantiate(s.Template.Params)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to include the template %s in stage %s", s.Template.Name, s.Name)
+		}
+		if s.Name != "" {
+			stage.Name = s.Name
+		}
+		if s.Agent != nil {
+			stage.Agent = s.Agent
+		}
+		if len(s.GetEnv()) > 0 {
+			stage.Env = scopedEnv(s.GetEnv(), stage.GetEnv())
+			stage.Environment = nil
+		}
+		if s.Options != nil {
+			stage.Options = s.Options
+		}
+		if s.WorkingDir != nil {
+			stage.WorkingDir = s.WorkingDir
+		}
+		if s.When != nil {
+			stage.When = s.When
+		}
+		if s.Matrix != nil {
+			stage.Matrix = s.Matrix
+		}
+		included[stage.Name] = template.Name
+		expanded = append(expanded, *stage)
+	}
+	return expanded, nil
+}
+
+// ExpandStageTemplates expands the stages of the pipeline which include templates, returning the name of the template
+// for each stage which was expanded, keyed by stage name.
+func (j *ParsedPipeline) ExpandStageTemplates(templates map[string]*StageTemplate) (map[string]string, error) {
+	stages, included, err := ExpandStageTemplates(j.Stages, templates)
+	if err != nil {
+		return nil, err
+	}
+	j.Stages = stages
+	return included, nil
+}
@@ -0,0 +1,138 @@
+package syntax_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStageTemplateInstantiate(t *testing.T) {
+	t.Parallel()
+
+	defaultVersion := "1.13"
+	defaultRace := "false"
+	template := &syntax.StageTemplate{
+		Name: "go-build",
+		Parameters: []syntax.TemplateParameter{
+			{Name: "version", Default: &defaultVersion},
+			{Name: "target"},
+			{Name: "race", Type: syntax.TemplateParameterTypeBool, Default: &defaultRace},
+		},
+		Stage: syntax.Stage{
+			Name: "build",
+			Agent: &syntax.Agent{
+				Image: "golang:${params.version}",
+			},
+			Steps: []syntax.Step{{
+				Command: "make ${params.target} RACE=${params.race}",
+			}},
+		},
+	}
+	assert.NoError(t, template.Validate())
+
+	tests := []struct {
+		name          string
+		params        map[string]string
+		expectedImage string
+		expectedCmd   string
+		expectedErr   string
+	}{
+		{
+			name:          "defaults",
+			params:        map[string]string{"target": "build"},
+			expectedImage: "golang:1.13",
+			expectedCmd:   "make build RACE=false",
+		},
+		{
+			name:          "quoted_value",
+			params:        map[string]string{"target": `"all"`, "version": "1.12", "race": "true"},
+			expectedImage: "golang:1.12",
+			expectedCmd:   `make "all" RACE=true`,
+		},
+		{
+			name:        "missing_required",
+			params:      map[string]string{},
+			expectedErr: "the parameter target of stage template go-build is required",
+		},
+		{
+			name:        "wrong_type",
+			params:      map[string]string{"target": "build", "race": "maybe"},
+			expectedErr: "invalid parameter for stage template go-build: the parameter race must be a bool but was maybe",
+		},
+		{
+			name:        "unknown_param",
+			params:      map[string]string{"target": "build", "os": "linux"},
+			expectedErr: "stage template go-build has no parameters named os",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage, err := template.Instantiate(tt.params)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedImage, stage.Agent.Image)
+			assert.Equal(t, tt.expectedCmd, stage.Steps[0].Command)
+		})
+	}
+}
+
+func TestStageTemplateValidate(t *testing.T) {
+	t.Parallel()
+
+	template := &syntax.StageTemplate{
+		Name: "deploy",
+		Stage: syntax.Stage{
+			Steps: []syntax.Step{{
+				Command: "deploy ${params.environment}",
+			}},
+		},
+	}
+	assert.EqualError(t, template.Validate(), "stage template deploy refers to the parameter environment, which is not defined")
+}
+
+func TestExpandStageTemplates(t *testing.T) {
+	t.Parallel()
+
+	templates := map[string]*syntax.StageTemplate{
+		"lint": {
+			Name:       "lint",
+			Parameters: []syntax.TemplateParameter{{Name: "dir"}},
+			Stage: syntax.Stage{
+				Name:  "lint",
+				Steps: []syntax.Step{{Command: "golint ${params.dir}"}},
+			},
+		},
+	}
+	stages := []syntax.Stage{{
+		Name: "checks",
+		Parallel: []syntax.Stage{{
+			Name: "lint-pkg",
+			Template: &syntax.StageTemplateRef{
+				Name:   "lint",
+				Params: map[string]string{"dir": "./pkg/..."},
+			},
+		}, {
+			Name:  "test",
+			Steps: []syntax.Step{{Command: "make test"}},
+		}},
+	}}
+
+	expanded, included, err := syntax.ExpandStageTemplates(stages, templates)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"lint-pkg": "lint"}, included)
+	assert.Equal(t, "lint-pkg", expanded[0].Parallel[0].Name)
+	assert.Nil(t, expanded[0].Parallel[0].Template)
+	assert.Equal(t, "golint ./pkg/...", expanded[0].Parallel[0].Steps[0].Command)
+	assert.Equal(t, "make test", expanded[0].Parallel[1].Steps[0].Command)
+
+	_, _, err = syntax.ExpandStageTemplates([]syntax.Stage{{
+		Name:     "missing",
+		Template: &syntax.StageTemplateRef{Name: "unknown"},
+	}}, templates)
+	assert.EqualError(t, err, "stage missing includes the template unknown, which is not defined")
+}
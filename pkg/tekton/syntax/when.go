@@ -0,0 +1,263 @@
+package syntax
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/knative/pkg/apis"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ErrNoStagesToRun is returned by GenerateCRDs when the when conditions of every stage are not met, so that there is
+// nothing to run. The PipelineStructure of the skipped stages is still returned so that the build can be recorded.
+var ErrNoStagesToRun = errors.New("nothing to run as the when conditions of every stage are not met")
+
+// When defines the conditions under which a stage is run. A stage with a when block is only run if all of the
+// conditions which are set are met, otherwise it is skipped along with any stages nested within it.
+type When struct {
+	// Branch is a glob pattern which the name of the branch being built must match, such as "release-*"
+	Branch string `json:"branch,omitempty"`
+	// Labels is a list of labels, at least one of which must be on the pull request being built
+	Labels []string `json:"labels,omitempty"`
+	// ChangedPaths is a list of glob patterns, at least one of which must match a file changed by the pull request
+	// being built, such as "services/frontend/**"
+	ChangedPaths []string `json:"changedPaths,omitempty"`
+	// Env is a list of environment variables, each of which must be set to the given value
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// WhenContext holds the details of the build which the when conditions on stages are evaluated against.
+type WhenContext struct {
+	// Branch is the name of the branch being built
+	Branch string
+	// Labels are the labels on the pull request being built, if any
+	Labels []string
+	// ChangedFiles are the paths of the files changed compared with the base SHA of the pull request being built. If
+	// nil, the changes are not known, such as when building a branch rather than a pull request, and changedPaths
+	// conditions are always met.
+	ChangedFiles []string
+	// Env holds the environment variables of the build. The env defined on the pipeline and its stages takes precedence.
+	Env map[string]string
+}
+
+func validateWhen(w *When) *apis.FieldError {
+	if w == nil {
+		return nil
+	}
+	if w.Branch == "" && len(w.Labels) == 0 && len(w.ChangedPaths) == 0 && len(w.Env) == 0 {
+		return apis.ErrMissingOneOf("branch", "labels", "changedPaths", "env")
+	}
+	for i, e := range w.Env {
+		if e.Name == "" {
+			return apis.ErrMissingField("name").ViaFieldIndex("env", i)
+		}
+		if e.ValueFrom != nil {
+			return (&apis.FieldError{
+				Message: "valueFrom cannot be used in when conditions",
+				Paths:   []string{"valueFrom"},
+			}).ViaFieldIndex("env", i)
+		}
+	}
+	return nil
+}
+
+// Matches returns true if all of the conditions which are set are met in the given context. If not, it also returns a
+// description of the first condition which was not met.
+func (w *When) Matches(ctx WhenContext) (bool, string) {
+	if w.Branch != "" && !globToRegexp(w.Branch).MatchString(ctx.Branch) {
+		return false, fmt.Sprintf("branch %s does not match %s", ctx.Branch, w.Branch)
+	}
+	if len(w.Labels) > 0 && !containsAny(ctx.Labels, w.Labels) {
+		return false, fmt.Sprintf("none of the labels %s are on the pull request", strings.Join(w.Labels, ", "))
+	}
+	if len(w.ChangedPaths) > 0 && ctx.ChangedFiles != nil && !anyFileMatches(ctx.ChangedFiles, w.ChangedPaths) {
+		return false, fmt.Sprintf("no changed files match %s", strings.Join(w.ChangedPaths, ", "))
+	}
+	for _, e := range w.Env {
+		if ctx.Env[e.Name] != e.Value {
+			return false, fmt.Sprintf("environment variable %s is not %s", e.Name, e.Value)
+		}
+	}
+	return true, ""
+}
+
+func containsAny(values []string, wanted []string) bool {
+	for _, v := range values {
+		for _, w := range wanted {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func anyFileMatches(files []string, patterns []string) bool {
+	for _, p := range patterns {
+		re := globToRegexp(p)
+		for _, f := range files {
+			if re.MatchString(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// globToRegexp converts a glob pattern into a regular expression. "*" and "?" match within a single path segment,
+// while "**" matches across path segments.
+func globToRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '*' && strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case c == '*' && strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// skipStages removes the stages whose when conditions are not met in the given context, returning the stages to run
+// and the PipelineStructureStages for the skipped stages. A stage whose nested stages are all skipped is skipped too.
+func skipStages(stages []Stage, ctx WhenContext, parentEnv []corev1.EnvVar, depth int8, parent *string) ([]Stage, []v1.PipelineStructureStage) {
+	var toRun []Stage
+	var skipped []v1.PipelineStructureStage
+
+	for _, s := range stages {
+		env := scopedEnv(s.GetEnv(), parentEnv)
+		if s.When != nil {
+			stageCtx := ctx
+			stageCtx.Env = make(map[string]string)
+			for k, v := range ctx.Env {
+				stageCtx.Env[k] = v
+			}
+			for _, e := range env {
+				if e.ValueFrom == nil {
+					stageCtx.Env[e.Name] = e.Value
+				}
+			}
+			if matches, reason := s.When.Matches(stageCtx); !matches {
+				log.Logger().Infof("Skipping stage %s as %s", s.Name, reason)
+				skipped = append(skipped, skippedStructureStages(s, depth, parent)...)
+				continue
+			}
+		}
+
+		if len(s.Stages) > 0 || len(s.Parallel) > 0 {
+			name := s.Name
+			nestedStages, skippedStages := skipStages(s.Stages, ctx, env, depth+1, &name)
+			nestedParallel, skippedParallel := skipStages(s.Parallel, ctx, env, depth+1, &name)
+			if len(nestedStages) == 0 && len(nestedParallel) == 0 {
+				log.Logger().Infof("Skipping stage %s as all of its nested stages are skipped", s.Name)
+				skipped = append(skipped, skippedStructureStages(s, depth, parent)...)
+				continue
+			}
+			s.Stages = nestedStages
+			s.Parallel = nestedParallel
+			skipped = append(skipped, skippedStages...)
+			skipped = append(skipped, skippedParallel...)
+		}
+		toRun = append(toRun, s)
+	}
+
+	return toRun, skipped
+}
+
+// validateSkippedStashes returns an error if one of the stages to run unstashes files which are only stashed by stages
+// which were skipped, as the unstash would fail at runtime.
+func validateSkippedStashes(all []Stage, toRun []Stage) error {
+	stashedBy := make(map[string]string)
+	collectStashes(all, stashedBy)
+	stillStashed := make(map[string]string)
+	collectStashes(toRun, stillStashed)
+	return checkUnstashes(toRun, func(s Stage, name string) error {
+		if _, ok := stillStashed[name]; ok {
+			return nil
+		}
+		if skippedStage, ok := stashedBy[name]; ok {
+			return errors.Errorf("stage %s unstashes %s, which is stashed by stage %s whose when conditions are not met. "+
+				"Please add the same when conditions to stage %s", s.Name, name, skippedStage, s.Name)
+		}
+		return nil
+	})
+}
+
+// collectStashes records the name of the stage which stashes each stash in the stages, including nested stages.
+func collectStashes(stages []Stage, stashes map[string]string) {
+	for _, s := range stages {
+		if s.Options != nil && s.Options.Stash != nil {
+			stashes[s.Options.Stash.Name] = s.Name
+		}
+		collectStashes(s.Stages, stashes)
+		collectStashes(s.Parallel, stashes)
+	}
+}
+
+func checkUnstashes(stages []Stage, check func(s Stage, name string) error) error {
+	for _, s := range stages {
+		if s.Options != nil && s.Options.Unstash != nil {
+			if err := check(s, s.Options.Unstash.Name); err != nil {
+				return err
+			}
+		}
+		if err := checkUnstashes(s.Stages, check); err != nil {
+			return err
+		}
+		if err := checkUnstashes(s.Parallel, check); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skippedStructureStages returns the PipelineStructureStages for a skipped stage and all the stages nested within it.
+func skippedStructureStages(s Stage, depth int8, parent *string) []v1.PipelineStructureStage {
+	structureStage := v1.PipelineStructureStage{
+		Name:    s.Name,
+		Depth:   depth,
+		Parent:  parent,
+		Skipped: true,
+	}
+	var nested []v1.PipelineStructureStage
+	name := s.Name
+	for _, n := range s.Stages {
+		structureStage.Stages = append(structureStage.Stages, n.Name)
+		nested = append(nested, skippedStructureStages(n, depth+1, &name)...)
+	}
+	for _, n := range s.Parallel {
+		structureStage.Parallel = append(structureStage.Parallel, n.Name)
+		nested = append(nested, skippedStructureStages(n, depth+1, &name)...)
+	}
+	return append([]v1.PipelineStructureStage{structureStage}, nested...)
+}
+
+// HasWhenConditions returns true if any of the stages of the pipeline, including nested stages, has a when block.
+func (j *ParsedPipeline) HasWhenConditions() bool {
+	return stagesHaveWhen(j.Stages)
+}
+
+func stagesHaveWhen(stages []Stage) bool {
+	for _, s := range stages {
+		if s.When != nil || stagesHaveWhen(s.Stages) || stagesHaveWhen(s.Parallel) {
+			return true
+		}
+	}
+	return false
+}
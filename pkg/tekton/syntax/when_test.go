@@ -0,0 +1,105 @@
+package syntax_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	sh "github.com/jenkins-x/jx/pkg/tekton/syntax/syntax_helpers_test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhenMatches(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		when           []sh.WhenOp
+		ctx            syntax.WhenContext
+		expected       bool
+		expectedReason string
+	}{
+		{
+			name:     "branch_matches",
+			when:     []sh.WhenOp{sh.WhenBranch("release-*")},
+			ctx:      syntax.WhenContext{Branch: "release-1.0"},
+			expected: true,
+		},
+		{
+			name:           "branch_does_not_match",
+			when:           []sh.WhenOp{sh.WhenBranch("release-*")},
+			ctx:            syntax.WhenContext{Branch: "master"},
+			expectedReason: "branch master does not match release-*",
+		},
+		{
+			name:     "label_matches",
+			when:     []sh.WhenOp{sh.WhenLabels("deploy", "preview")},
+			ctx:      syntax.WhenContext{Labels: []string{"preview"}},
+			expected: true,
+		},
+		{
+			name:           "label_missing",
+			when:           []sh.WhenOp{sh.WhenLabels("deploy", "preview")},
+			ctx:            syntax.WhenContext{Labels: []string{"lgtm"}},
+			expectedReason: "none of the labels deploy, preview are on the pull request",
+		},
+		{
+			name:     "changed_path_matches",
+			when:     []sh.WhenOp{sh.WhenChangedPaths("services/frontend/**")},
+			ctx:      syntax.WhenContext{ChangedFiles: []string{"README.md", "services/frontend/src/app.js"}},
+			expected: true,
+		},
+		{
+			name:     "changed_path_matches_any_directory",
+			when:     []sh.WhenOp{sh.WhenChangedPaths("**/*.go")},
+			ctx:      syntax.WhenContext{ChangedFiles: []string{"main.go"}},
+			expected: true,
+		},
+		{
+			name:           "changed_path_single_segment",
+			when:           []sh.WhenOp{sh.WhenChangedPaths("docs/*.md")},
+			ctx:            syntax.WhenContext{ChangedFiles: []string{"docs/images/logo.md"}},
+			expectedReason: "no changed files match docs/*.md",
+		},
+		{
+			name:     "changed_paths_unknown",
+			when:     []sh.WhenOp{sh.WhenChangedPaths("services/frontend/**")},
+			ctx:      syntax.WhenContext{},
+			expected: true,
+		},
+		{
+			name:           "no_changed_files",
+			when:           []sh.WhenOp{sh.WhenChangedPaths("services/frontend/**")},
+			ctx:            syntax.WhenContext{ChangedFiles: []string{}},
+			expectedReason: "no changed files match services/frontend/**",
+		},
+		{
+			name:     "env_matches",
+			when:     []sh.WhenOp{sh.WhenEnvVar("DEPLOY", "true")},
+			ctx:      syntax.WhenContext{Env: map[string]string{"DEPLOY": "true"}},
+			expected: true,
+		},
+		{
+			name:           "env_does_not_match",
+			when:           []sh.WhenOp{sh.WhenEnvVar("DEPLOY", "true")},
+			ctx:            syntax.WhenContext{},
+			expectedReason: "environment variable DEPLOY is not true",
+		},
+		{
+			name:           "all_conditions_must_match",
+			when:           []sh.WhenOp{sh.WhenBranch("master"), sh.WhenLabels("deploy")},
+			ctx:            syntax.WhenContext{Branch: "master", Labels: []string{"lgtm"}},
+			expectedReason: "none of the labels deploy are on the pull request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage := &syntax.Stage{}
+			sh.StageWhen(tt.when...)(stage)
+
+			matches, reason := stage.When.Matches(tt.ctx)
+			assert.Equal(t, tt.expected, matches)
+			assert.Equal(t, tt.expectedReason, reason)
+		})
+	}
+}
@@ -52,6 +52,15 @@ func (in *CRDsFromPipelineParams) DeepCopyInto(out *CRDsFromPipelineParams) {
 			(*out)[key] = val
 		}
 	}
+	if in.When != nil {
+		in, out := &in.When, &out.When
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(WhenContext)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -93,6 +102,50 @@ func (in *Loop) DeepCopy() *Loop {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Matrix) DeepCopyInto(out *Matrix) {
+	*out = *in
+	if in.Axes != nil {
+		in, out := &in.Axes, &out.Axes
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]map[string]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Matrix.
+func (in *Matrix) DeepCopy() *Matrix {
+	if in == nil {
+		return nil
+	}
+	out := new(Matrix)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ParsedPipeline) DeepCopyInto(out *ParsedPipeline) {
 	*out = *in
@@ -240,6 +293,29 @@ func (in *PipelineOverride) DeepCopy() *PipelineOverride {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineTemplates) DeepCopyInto(out *PipelineTemplates) {
+	*out = *in
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make([]StageTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineTemplates.
+func (in *PipelineTemplates) DeepCopy() *PipelineTemplates {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineTemplates)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Post) DeepCopyInto(out *Post) {
 	*out = *in
@@ -250,6 +326,13 @@ func (in *Post) DeepCopyInto(out *Post) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]Step, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -411,6 +494,33 @@ func (in *Stage) DeepCopyInto(out *Stage) {
 			**out = **in
 		}
 	}
+	if in.When != nil {
+		in, out := &in.When, &out.When
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(When)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Matrix != nil {
+		in, out := &in.Matrix, &out.Matrix
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(Matrix)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Template != nil {
+		in, out := &in.Template, &out.Template
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(StageTemplateRef)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	if in.Environment != nil {
 		in, out := &in.Environment, &out.Environment
 		*out = make([]v1.EnvVar, len(*in))
@@ -483,6 +593,53 @@ func (in *StageOptions) DeepCopy() *StageOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StageTemplate) DeepCopyInto(out *StageTemplate) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]TemplateParameter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Stage.DeepCopyInto(&out.Stage)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StageTemplate.
+func (in *StageTemplate) DeepCopy() *StageTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(StageTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StageTemplateRef) DeepCopyInto(out *StageTemplateRef) {
+	*out = *in
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StageTemplateRef.
+func (in *StageTemplateRef) DeepCopy() *StageTemplateRef {
+	if in == nil {
+		return nil
+	}
+	out := new(StageTemplateRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Stash) DeepCopyInto(out *Stash) {
 	*out = *in
@@ -539,6 +696,15 @@ func (in *Step) DeepCopyInto(out *Step) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(Timeout)
+			**out = **in
+		}
+	}
 	if in.Steps != nil {
 		in, out := &in.Steps, &out.Steps
 		*out = make([]*Step, len(*in))
@@ -564,6 +730,31 @@ func (in *Step) DeepCopy() *Step {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateParameter) DeepCopyInto(out *TemplateParameter) {
+	*out = *in
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(string)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateParameter.
+func (in *TemplateParameter) DeepCopy() *TemplateParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Timeout) DeepCopyInto(out *Timeout) {
 	*out = *in
@@ -595,3 +786,69 @@ func (in *Unstash) DeepCopy() *Unstash {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *When) DeepCopyInto(out *When) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ChangedPaths != nil {
+		in, out := &in.ChangedPaths, &out.ChangedPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new When.
+func (in *When) DeepCopy() *When {
+	if in == nil {
+		return nil
+	}
+	out := new(When)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WhenContext) DeepCopyInto(out *WhenContext) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ChangedFiles != nil {
+		in, out := &in.ChangedFiles, &out.ChangedFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WhenContext.
+func (in *WhenContext) DeepCopy() *WhenContext {
+	if in == nil {
+		return nil
+	}
+	out := new(WhenContext)
+	in.DeepCopyInto(out)
+	return out
+}
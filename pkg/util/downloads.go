@@ -344,3 +344,45 @@ func UnTarFile(header *tar.Header, target string, tarReader io.Reader) error {
 	_, err = io.Copy(file, tarReader)
 	return err
 }
+
+// Targz writes a gzipped tarball of the given files to the writer, with each entry named relative to the base dir
+func Targz(files []string, basedir string, writer io.Writer) error {
+	zwriter := gzip.NewWriter(writer)
+	tarWriter := tar.NewWriter(zwriter)
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			continue
+		}
+		name, err := filepath.Rel(basedir, file)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find the path of %s relative to %s", file, basedir)
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		err = tarWriter.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tarWriter, f)
+		f.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed to add %s to the tarball", file)
+		}
+	}
+	err := tarWriter.Close()
+	if err != nil {
+		return err
+	}
+	return zwriter.Close()
+}
@@ -157,3 +157,39 @@ func Test_UnTargzAll_NestedNoDir(t *testing.T) {
 	}))
 	require.Equal(t, expected, found, "wrong files extracted")
 }
+
+func Test_Targz_RoundTrip(t *testing.T) {
+	srcDir, err := ioutil.TempDir("", "targz_src")
+	require.NoError(t, err)
+	defer os.RemoveAll(srcDir)
+
+	files := map[string]string{
+		"file1.txt":          "file1\n",
+		"target/file2.txt":   "file2\n",
+		"target/a/file3.txt": "file3\n",
+	}
+	paths := []string{}
+	for name, content := range files {
+		p := filepath.Join(srcDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, ioutil.WriteFile(p, []byte(content), 0644))
+		paths = append(paths, p)
+	}
+
+	tarball := filepath.Join(srcDir, "archive.tar.gz")
+	f, err := os.Create(tarball)
+	require.NoError(t, err)
+	require.NoError(t, Targz(paths, srcDir, f))
+	require.NoError(t, f.Close())
+
+	dir, err := ioutil.TempDir("", "targz_dest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, UnTargzAll(tarball, dir))
+	for name, content := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err, "Error reading file %s", name)
+		assert.Equal(t, content, string(data), "wrong content for %s", name)
+	}
+}
@@ -2,7 +2,9 @@ package util
 
 import (
 	"bytes"
-
+	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -118,6 +120,55 @@ func CallWithExponentialBackOff(url string, auth string, httpMethod string, reqB
 	return respBody, nil
 }
 
+// CallJSON sends the request body as JSON with the authorization header, if any, and unmarshals the JSON response
+// into the result, returning an error if the response status is not successful
+func CallJSON(httpClient *http.Client, method string, u string, authorization string, body interface{}, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s returned status %d: %s", method, u, resp.StatusCode, string(data))
+	}
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
+
+// BearerAuthorization returns the value of the Authorization header for the bearer token or an empty string if there
+// is no token
+func BearerAuthorization(token string) string {
+	if token == "" {
+		return ""
+	}
+	return "Bearer " + token
+}
+
 func GetBasicAuthUserAndPassword(auth string) (string, string) {
 	if auth != "" {
 		creds := strings.Fields(strings.Replace(auth, ":", " ", -1))
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -73,3 +74,31 @@ func TestGetClient(t *testing.T) {
 	myClient2 := GetClient()
 	assert.Equal(t, myClient, myClient2)
 }
+
+func TestCallJSON(t *testing.T) {
+	t.Parallel()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer mytoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"unauthorized"}`))
+			return
+		}
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		w.Write([]byte(`{"echo":` + string(body) + `}`))
+	}))
+	defer backend.Close()
+
+	result := struct {
+		Echo map[string]string `json:"echo"`
+	}{}
+	err := CallJSON(backend.Client(), http.MethodPost, backend.URL, BearerAuthorization("mytoken"), map[string]string{"name": "jx"}, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "jx"}, result.Echo)
+
+	err = CallJSON(backend.Client(), http.MethodGet, backend.URL, BearerAuthorization(""), nil, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "returned status 401")
+}
@@ -69,7 +69,7 @@ func (f FakeVaultClient) List(path string) ([]string, error) {
 // Read a secret from vault
 func (f FakeVaultClient) Read(secretName string) (map[string]interface{}, error) {
 	if answer, ok := f.Data[secretName]; !ok {
-		return nil, errors.Errorf("secret does not exist at key %s", secretName)
+		return nil, errors.Wrapf(secreturl.ErrNotFound, "secret does not exist at key %s", secretName)
 	} else {
 		return answer, nil
 	}
@@ -82,7 +82,7 @@ func (v *client) Read(secretName string) (map[string]interface{}, error) {
 	}
 
 	if secret == nil {
-		return nil, fmt.Errorf("no secret %q not found in vault", secretName)
+		return nil, errors.Wrapf(secreturl.ErrNotFound, "no secret %q found in vault", secretName)
 	}
 
 	if secret.Data != nil {
@@ -0,0 +1,267 @@
+package workflow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// StepName returns the name of the step of the workflow at the given index, defaulting it from the kind of the step
+// if it has no name
+func StepName(step *v1.WorkflowStep, index int) string {
+	if step.Name != "" {
+		return step.Name
+	}
+	kind := StepKind(step)
+	if kind == v1.WorkflowStepKindTypePromote && step.Promote != nil && step.Promote.Environment != "" {
+		return "promote-" + step.Promote.Environment
+	}
+	return strings.ToLower(string(kind)) + "-" + strconv.Itoa(index+1)
+}
+
+// StepKind returns the kind of the step, defaulting it from the step which is populated if no kind is specified
+func StepKind(step *v1.WorkflowStep) v1.WorkflowStepKindType {
+	if step.Kind != v1.WorkflowStepKindTypeNone {
+		return step.Kind
+	}
+	switch {
+	case step.Approve != nil:
+		return v1.WorkflowStepKindTypeApprove
+	case step.Wait != nil:
+		return v1.WorkflowStepKindTypeWait
+	case step.Verify != nil:
+		return v1.WorkflowStepKindTypeVerify
+	case step.Parallel != nil:
+		return v1.WorkflowStepKindTypeParallel
+	case step.Promote != nil:
+		return v1.WorkflowStepKindTypePromote
+	}
+	return v1.WorkflowStepKindTypeNone
+}
+
+// StepEnvironments returns the environments a promote or parallel step promotes to
+func StepEnvironments(step *v1.WorkflowStep) []string {
+	answer := []string{}
+	if step.Promote != nil && step.Promote.Environment != "" {
+		answer = append(answer, step.Promote.Environment)
+	}
+	if step.Parallel != nil {
+		for _, envName := range step.Parallel.Environments {
+			if envName != "" && util.StringArrayIndex(answer, envName) < 0 {
+				answer = append(answer, envName)
+			}
+		}
+	}
+	return answer
+}
+
+// FindStep returns the step of the workflow with the given name along with its index or nil if it cannot be found
+func FindStep(flow *v1.Workflow, name string) (*v1.WorkflowStep, int) {
+	for i := range flow.Spec.Steps {
+		step := &flow.Spec.Steps[i]
+		if StepName(step, i) == name {
+			return step, i
+		}
+	}
+	return nil, -1
+}
+
+// GetStepStatus returns the status of the named workflow step of the activity or nil if the step has not started
+func GetStepStatus(activity *v1.PipelineActivity, name string) *v1.WorkflowStepStatus {
+	for i := range activity.Spec.WorkflowSteps {
+		status := &activity.Spec.WorkflowSteps[i]
+		if status.Name == name {
+			return status
+		}
+	}
+	return nil
+}
+
+// GetOrCreateStepStatus returns the status of the named workflow step of the activity, lazily creating a pending
+// status if the step has not started
+func GetOrCreateStepStatus(activity *v1.PipelineActivity, name string, kind v1.WorkflowStepKindType) *v1.WorkflowStepStatus {
+	status := GetStepStatus(activity, name)
+	if status != nil {
+		return status
+	}
+	now := metav1.Now()
+	activity.Spec.WorkflowSteps = append(activity.Spec.WorkflowSteps, v1.WorkflowStepStatus{
+		Name:             name,
+		Kind:             kind,
+		Status:           v1.WorkflowStatusTypePending,
+		StartedTimestamp: &now,
+	})
+	return &activity.Spec.WorkflowSteps[len(activity.Spec.WorkflowSteps)-1]
+}
+
+// CompleteStep marks the workflow step as terminated with the given status and message
+func CompleteStep(status *v1.WorkflowStepStatus, result v1.WorkflowStatusType, message string) {
+	now := metav1.Now()
+	status.Status = result
+	status.Message = message
+	status.CompletedTimestamp = &now
+}
+
+// FindApprovalStep returns the name of the approval step of the activity which is waiting for approval or an empty
+// string if there is none
+func FindApprovalStep(activity *v1.PipelineActivity) string {
+	for _, status := range activity.Spec.WorkflowSteps {
+		if status.Kind == v1.WorkflowStepKindTypeApprove && status.Status == v1.WorkflowStatusTypeWaitingForApproval {
+			return status.Name
+		}
+	}
+	return ""
+}
+
+// Approve approves or rejects the approval step of the workflow which the activity is waiting on. If the step name
+// is blank the step waiting for approval is used.
+func Approve(activity *v1.PipelineActivity, flow *v1.Workflow, stepName string, user string, approved bool) error {
+	if stepName == "" {
+		stepName = FindApprovalStep(activity)
+		if stepName == "" {
+			return errors.Errorf("pipeline activity %s is not waiting for approval", activity.Name)
+		}
+	}
+	step, _ := FindStep(flow, stepName)
+	if step == nil {
+		return errors.Errorf("workflow %s has no step %s", flow.Name, stepName)
+	}
+	if StepKind(step) != v1.WorkflowStepKindTypeApprove {
+		return errors.Errorf("step %s of workflow %s is not an approval step", stepName, flow.Name)
+	}
+	status := GetStepStatus(activity, stepName)
+	if status == nil || status.Status != v1.WorkflowStatusTypeWaitingForApproval {
+		return errors.Errorf("step %s of pipeline activity %s is not waiting for approval", stepName, activity.Name)
+	}
+	approvers := []string{}
+	if step.Approve != nil {
+		approvers = step.Approve.Approvers
+	}
+	if len(approvers) > 0 && util.StringArrayIndex(approvers, user) < 0 {
+		return errors.Errorf("user %s cannot approve step %s as they are not one of the approvers: %s", user, stepName, strings.Join(approvers, ", "))
+	}
+
+	status.ApprovedBy = user
+	spec := &activity.Spec
+	if approved {
+		CompleteStep(status, v1.WorkflowStatusTypeSucceeded, fmt.Sprintf("approved by %s", user))
+		spec.WorkflowStatus = v1.ActivityStatusTypeRunning
+	} else {
+		CompleteStep(status, v1.WorkflowStatusTypeFailed, fmt.Sprintf("rejected by %s", user))
+		spec.WorkflowStatus = v1.ActivityStatusTypeFailed
+	}
+	spec.WorkflowMessage = fmt.Sprintf("step %s %s", stepName, status.Message)
+	return nil
+}
+
+// InTimeWindow returns true if the given time is inside the time window. A window whose end is before its start is
+// open over midnight, in which case the days refer to the day on which the window opened.
+func InTimeWindow(window *v1.TimeWindow, t time.Time) (bool, error) {
+	if window == nil {
+		return true, nil
+	}
+	loc := time.UTC
+	if window.TimeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(window.TimeZone)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid time zone %s", window.TimeZone)
+		}
+	}
+	t = t.In(loc)
+	start, err := parseClock(window.Start, 0)
+	if err != nil {
+		return false, err
+	}
+	end, err := parseClock(window.End, 24*60)
+	if err != nil {
+		return false, err
+	}
+	days := map[int]bool{}
+	for _, day := range window.Days {
+		idx := -1
+		name := strings.ToLower(strings.TrimSpace(day))
+		if len(name) >= 3 {
+			idx = util.StringArrayIndex(weekdays, name[0:3])
+		}
+		if idx < 0 {
+			return false, errors.Errorf("invalid day of the week %s", day)
+		}
+		days[idx] = true
+	}
+	dayMatches := func(day time.Weekday) bool {
+		return len(days) == 0 || days[int(day)]
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return minute >= start && minute < end && dayMatches(t.Weekday()), nil
+	}
+	if minute >= start {
+		return dayMatches(t.Weekday()), nil
+	}
+	if minute < end {
+		return dayMatches(t.AddDate(0, 0, -1).Weekday()), nil
+	}
+	return false, nil
+}
+
+// parseClock parses the time of day in the form 'HH:MM' into the number of minutes since midnight
+func parseClock(text string, defaultValue int) (int, error) {
+	if text == "" {
+		return defaultValue, nil
+	}
+	if text == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", text)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid time of day %s, expected HH:MM", text)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// CreateWorkflowApproveStep creates a Workflow step which waits for approval once the given steps have completed
+func CreateWorkflowApproveStep(name string, message string, preconditionSteps ...v1.WorkflowStep) v1.WorkflowStep {
+	answer := v1.WorkflowStep{
+		Kind: v1.WorkflowStepKindTypeApprove,
+		Name: name,
+		Approve: &v1.ApproveWorkflowStep{
+			Message: message,
+		},
+	}
+	addPreconditions(&answer, preconditionSteps)
+	return answer
+}
+
+// CreateWorkflowParallelStep creates a Workflow step which promotes to the environments at the same time
+func CreateWorkflowParallelStep(envNames []string, preconditionSteps ...v1.WorkflowStep) v1.WorkflowStep {
+	answer := v1.WorkflowStep{
+		Kind: v1.WorkflowStepKindTypeParallel,
+		Parallel: &v1.ParallelWorkflowStep{
+			Environments: envNames,
+		},
+	}
+	addPreconditions(&answer, preconditionSteps)
+	return answer
+}
+
+// addPreconditions adds the environments of the promote steps and the names of the other steps as preconditions
+func addPreconditions(step *v1.WorkflowStep, preconditionSteps []v1.WorkflowStep) {
+	for _, preconditionStep := range preconditionSteps {
+		envNames := StepEnvironments(&preconditionStep)
+		if len(envNames) > 0 {
+			step.Preconditions.Environments = append(step.Preconditions.Environments, envNames...)
+		} else if preconditionStep.Name != "" {
+			step.Preconditions.Steps = append(step.Preconditions.Steps, preconditionStep.Name)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package workflow_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInTimeWindow(t *testing.T) {
+	t.Parallel()
+	// 2019-07-01 was a Monday
+	monday := func(hour int, minute int) time.Time {
+		return time.Date(2019, time.July, 1, hour, minute, 0, 0, time.UTC)
+	}
+	tuesday := func(hour int, minute int) time.Time {
+		return time.Date(2019, time.July, 2, hour, minute, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name     string
+		window   *v1.TimeWindow
+		time     time.Time
+		expected bool
+	}{
+		{"no window", nil, monday(3, 0), true},
+		{"inside", &v1.TimeWindow{Start: "09:00", End: "17:00"}, monday(9, 0), true},
+		{"before", &v1.TimeWindow{Start: "09:00", End: "17:00"}, monday(8, 59), false},
+		{"end is exclusive", &v1.TimeWindow{Start: "09:00", End: "17:00"}, monday(17, 0), false},
+		{"matching day", &v1.TimeWindow{Start: "09:00", End: "17:00", Days: []string{"Mon", "Wed"}}, monday(10, 0), true},
+		{"other day", &v1.TimeWindow{Start: "09:00", End: "17:00", Days: []string{"Mon", "Wed"}}, tuesday(10, 0), false},
+		{"full day names", &v1.TimeWindow{Days: []string{"tuesday"}}, tuesday(23, 59), true},
+		{"over midnight before", &v1.TimeWindow{Start: "22:00", End: "02:00", Days: []string{"Mon"}}, monday(23, 0), true},
+		{"over midnight after", &v1.TimeWindow{Start: "22:00", End: "02:00", Days: []string{"Mon"}}, tuesday(1, 0), true},
+		{"over midnight wrong day", &v1.TimeWindow{Start: "22:00", End: "02:00", Days: []string{"Mon"}}, tuesday(23, 0), false},
+		{"over midnight closed", &v1.TimeWindow{Start: "22:00", End: "02:00"}, monday(12, 0), false},
+		{"time zone", &v1.TimeWindow{Start: "09:00", End: "17:00", TimeZone: "America/New_York"}, monday(14, 0), true},
+		{"time zone closed", &v1.TimeWindow{Start: "09:00", End: "17:00", TimeZone: "America/New_York"}, monday(10, 0), false},
+	}
+	for _, tc := range testCases {
+		actual, err := workflow.InTimeWindow(tc.window, tc.time)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, actual, tc.name)
+	}
+}
+
+func TestInTimeWindowInvalid(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	for _, window := range []*v1.TimeWindow{
+		{Start: "9am"},
+		{End: "25:00"},
+		{Days: []string{"Funday"}},
+		{TimeZone: "Nowhere/Special"},
+	} {
+		_, err := workflow.InTimeWindow(window, now)
+		assert.Error(t, err, "window %#v", window)
+	}
+}
+
+func TestStepName(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "change-board", workflow.StepName(&v1.WorkflowStep{Name: "change-board", Approve: &v1.ApproveWorkflowStep{}}, 0))
+	assert.Equal(t, "approve-2", workflow.StepName(&v1.WorkflowStep{Approve: &v1.ApproveWorkflowStep{}}, 1))
+	assert.Equal(t, "promote-staging", workflow.StepName(&v1.WorkflowStep{Promote: &v1.PromoteWorkflowStep{Environment: "staging"}}, 0))
+	assert.Equal(t, "parallel-3", workflow.StepName(&v1.WorkflowStep{Kind: v1.WorkflowStepKindTypeParallel}, 2))
+}
+
+func TestApprove(t *testing.T) {
+	t.Parallel()
+	staging := workflow.CreateWorkflowPromoteStep("staging")
+	approve := workflow.CreateWorkflowApproveStep("change-board", "approve the release to production", staging)
+	approve.Approve.Approvers = []string{"alice", "bob"}
+	production := workflow.CreateWorkflowPromoteStep("production")
+	production.Preconditions.Steps = []string{approve.Name}
+	flow := workflow.CreateWorkflow("jx", "myflow", staging, approve, production)
+
+	assert.Equal(t, []string{"staging"}, approve.Preconditions.Environments)
+
+	newActivity := func() *v1.PipelineActivity {
+		activity := &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "myorg-myapp-master-1",
+			},
+		}
+		status := workflow.GetOrCreateStepStatus(activity, "change-board", v1.WorkflowStepKindTypeApprove)
+		status.Status = v1.WorkflowStatusTypeWaitingForApproval
+		activity.Spec.WorkflowStatus = v1.ActivityStatusTypeWaitingForApproval
+		return activity
+	}
+
+	activity := newActivity()
+	assert.Equal(t, "change-board", workflow.FindApprovalStep(activity))
+	err := workflow.Approve(activity, flow, "", "eve", true)
+	assert.Error(t, err, "eve is not an approver")
+	err = workflow.Approve(activity, flow, "promote-production", "alice", true)
+	assert.Error(t, err, "not an approval step")
+
+	err = workflow.Approve(activity, flow, "", "alice", true)
+	require.NoError(t, err)
+	status := workflow.GetStepStatus(activity, "change-board")
+	require.NotNil(t, status)
+	assert.Equal(t, v1.WorkflowStatusTypeSucceeded, status.Status)
+	assert.Equal(t, "alice", status.ApprovedBy)
+	assert.NotNil(t, status.CompletedTimestamp)
+	assert.Equal(t, v1.ActivityStatusTypeRunning, activity.Spec.WorkflowStatus)
+	assert.Equal(t, "", workflow.FindApprovalStep(activity))
+
+	err = workflow.Approve(activity, flow, "change-board", "bob", true)
+	assert.Error(t, err, "already approved")
+
+	activity = newActivity()
+	err = workflow.Approve(activity, flow, "change-board", "bob", false)
+	require.NoError(t, err)
+	assert.Equal(t, v1.WorkflowStatusTypeFailed, workflow.GetStepStatus(activity, "change-board").Status)
+	assert.Equal(t, v1.ActivityStatusTypeFailed, activity.Spec.WorkflowStatus)
+}
@@ -41,7 +41,7 @@ func CreateDefaultWorkflow(jxClient versioned.Interface, ns string) (*v1.Workflo
 	previousEnv := ""
 	for _, name := range names {
 		env := m[name]
-		if env != nil && env.Spec.PromotionStrategy == v1.PromotionStrategyTypeAutomatic && env.Spec.Kind == v1.EnvironmentKindTypePermanent {
+		if env != nil && env.Spec.PromotionStrategy.IsAutomatic() && env.Spec.Kind == v1.EnvironmentKindTypePermanent {
 			step := CreateWorkflowPromoteStep(name)
 			if previousEnv != "" {
 				step.Preconditions.Environments = []string{previousEnv}